@@ -339,13 +339,25 @@ func Generator(pass *analysis.Pass, pos token.Pos) (generated.Generator, bool) {
 // the purity argument is nil, this function implements a purely
 // syntactic check, meaning that any function call may have side
 // effects, regardless of the called function's body. Otherwise,
-// purity will be consulted to determine the purity of function calls.
-func MayHaveSideEffects(pass *analysis.Pass, expr ast.Expr, purity purity.Result) bool {
+// purity will be consulted to determine whether function calls are
+// free of side effects.
+func MayHaveSideEffects(pass *analysis.Pass, expr ast.Expr, purity *purity.Result) bool {
+	return mayHaveSideEffects(pass, expr, purity, false)
+}
+
+// MayBeImpure is like MayHaveSideEffects, but additionally reports
+// whether evaluating expr repeatedly may produce different values, for
+// example because it calls a function that returns a fresh allocation.
+func MayBeImpure(pass *analysis.Pass, expr ast.Expr, purity *purity.Result) bool {
+	return mayHaveSideEffects(pass, expr, purity, true)
+}
+
+func mayHaveSideEffects(pass *analysis.Pass, expr ast.Expr, purity *purity.Result, pure bool) bool {
 	switch expr := expr.(type) {
 	case *ast.BadExpr:
 		return true
 	case *ast.Ellipsis:
-		return MayHaveSideEffects(pass, expr.Elt, purity)
+		return mayHaveSideEffects(pass, expr.Elt, purity, pure)
 	case *ast.FuncLit:
 		// the literal itself cannot have side effects, only calling it
 		// might, which is handled by CallExpr.
@@ -356,14 +368,18 @@ func MayHaveSideEffects(pass *analysis.Pass, expr ast.Expr, purity purity.Result
 	case *ast.BasicLit:
 		return false
 	case *ast.BinaryExpr:
-		return MayHaveSideEffects(pass, expr.X, purity) || MayHaveSideEffects(pass, expr.Y, purity)
+		return mayHaveSideEffects(pass, expr.X, purity, pure) || mayHaveSideEffects(pass, expr.Y, purity, pure)
 	case *ast.CallExpr:
 		if purity == nil {
 			return true
 		}
 		switch obj := typeutil.Callee(pass.TypesInfo, expr).(type) {
 		case *types.Func:
-			if _, ok := purity[obj]; !ok {
+			if pure {
+				if _, ok := purity.Pure[obj]; !ok {
+					return true
+				}
+			} else if _, ok := purity.SideEffectFree[obj]; !ok {
 				return true
 			}
 		case *types.Builtin:
@@ -376,17 +392,17 @@ func MayHaveSideEffects(pass *analysis.Pass, expr ast.Expr, purity purity.Result
 			return true
 		}
 		for _, arg := range expr.Args {
-			if MayHaveSideEffects(pass, arg, purity) {
+			if mayHaveSideEffects(pass, arg, purity, pure) {
 				return true
 			}
 		}
 		return false
 	case *ast.CompositeLit:
-		if MayHaveSideEffects(pass, expr.Type, purity) {
+		if mayHaveSideEffects(pass, expr.Type, purity, pure) {
 			return true
 		}
 		for _, elt := range expr.Elts {
-			if MayHaveSideEffects(pass, elt, purity) {
+			if mayHaveSideEffects(pass, elt, purity, pure) {
 				return true
 			}
 		}
@@ -394,39 +410,39 @@ func MayHaveSideEffects(pass *analysis.Pass, expr ast.Expr, purity purity.Result
 	case *ast.Ident:
 		return false
 	case *ast.IndexExpr:
-		return MayHaveSideEffects(pass, expr.X, purity) || MayHaveSideEffects(pass, expr.Index, purity)
+		return mayHaveSideEffects(pass, expr.X, purity, pure) || mayHaveSideEffects(pass, expr.Index, purity, pure)
 	case *ast.IndexListExpr:
 		// In theory, none of the checks are necessary, as IndexListExpr only involves types. But there is no harm in
 		// being safe.
-		if MayHaveSideEffects(pass, expr.X, purity) {
+		if mayHaveSideEffects(pass, expr.X, purity, pure) {
 			return true
 		}
 		for _, idx := range expr.Indices {
-			if MayHaveSideEffects(pass, idx, purity) {
+			if mayHaveSideEffects(pass, idx, purity, pure) {
 				return true
 			}
 		}
 		return false
 	case *ast.KeyValueExpr:
-		return MayHaveSideEffects(pass, expr.Key, purity) || MayHaveSideEffects(pass, expr.Value, purity)
+		return mayHaveSideEffects(pass, expr.Key, purity, pure) || mayHaveSideEffects(pass, expr.Value, purity, pure)
 	case *ast.SelectorExpr:
-		return MayHaveSideEffects(pass, expr.X, purity)
+		return mayHaveSideEffects(pass, expr.X, purity, pure)
 	case *ast.SliceExpr:
-		return MayHaveSideEffects(pass, expr.X, purity) ||
-			MayHaveSideEffects(pass, expr.Low, purity) ||
-			MayHaveSideEffects(pass, expr.High, purity) ||
-			MayHaveSideEffects(pass, expr.Max, purity)
+		return mayHaveSideEffects(pass, expr.X, purity, pure) ||
+			mayHaveSideEffects(pass, expr.Low, purity, pure) ||
+			mayHaveSideEffects(pass, expr.High, purity, pure) ||
+			mayHaveSideEffects(pass, expr.Max, purity, pure)
 	case *ast.StarExpr:
-		return MayHaveSideEffects(pass, expr.X, purity)
+		return mayHaveSideEffects(pass, expr.X, purity, pure)
 	case *ast.TypeAssertExpr:
-		return MayHaveSideEffects(pass, expr.X, purity)
+		return mayHaveSideEffects(pass, expr.X, purity, pure)
 	case *ast.UnaryExpr:
-		if MayHaveSideEffects(pass, expr.X, purity) {
+		if mayHaveSideEffects(pass, expr.X, purity, pure) {
 			return true
 		}
 		return expr.Op == token.ARROW || expr.Op == token.AND
 	case *ast.ParenExpr:
-		return MayHaveSideEffects(pass, expr.X, purity)
+		return mayHaveSideEffects(pass, expr.X, purity, pure)
 	case nil:
 		return false
 	default:
@@ -5,6 +5,7 @@ import (
 	"go/ast"
 	"go/format"
 
+	"honnef.co/go/tools/analysis/edit"
 	"honnef.co/go/tools/pattern"
 
 	"golang.org/x/tools/go/analysis"
@@ -29,7 +30,7 @@ func Match(pass *analysis.Pass, q pattern.Pattern, node ast.Node) (*pattern.Matc
 	// Note that we ignore q.Relevant – callers of Match usually use
 	// AST inspectors that already filter on nodes we're interested
 	// in.
-	m := &pattern.Matcher{TypesInfo: pass.TypesInfo}
+	m := &pattern.Matcher{TypesInfo: pass.TypesInfo, Pkg: pass.Pkg}
 	ok := m.Match(q, node)
 	return m, ok
 }
@@ -42,10 +43,16 @@ func MatchAndEdit(pass *analysis.Pass, before, after pattern.Pattern, node ast.N
 	r := pattern.NodeToAST(after.Root, m.State)
 	buf := &bytes.Buffer{}
 	format.Node(buf, pass.Fset, r)
-	edit := []analysis.TextEdit{{
-		Pos:     node.Pos(),
-		End:     node.End(),
+	var rng edit.Ranger = node
+	if m.Stmts != nil {
+		// Only replace the statements matched by a Seq pattern, not
+		// the entire block.
+		rng = edit.StmtRange(m.Stmts)
+	}
+	edits := []analysis.TextEdit{{
+		Pos:     rng.Pos(),
+		End:     rng.End(),
 		NewText: buf.Bytes(),
 	}}
-	return m, edit, true
+	return m, edits, true
 }
@@ -4,6 +4,7 @@ package dfa
 import (
 	"cmp"
 	"fmt"
+	"go/token"
 	"log"
 	"math/bits"
 	"slices"
@@ -53,6 +54,9 @@ type Decision struct {
 	Inputs []ir.Value
 	// A human-readable description of the decision.
 	Description string
+	// The position the description refers to, if it isn't the position of the value. For example, a store into a
+	// variable describes the position of the store, not of the variable.
+	Pos token.Pos
 	// Whether this is the source of an abstract state. For example, in a taint analysis, the call to a function that
 	// produces a tainted value would be the source of the taint state, and any instructions that operate on
 	// and propagate tainted values would not be sources.
@@ -90,8 +94,16 @@ func Ms[S comparable](ms ...Mapping[S]) []Mapping[S] {
 //
 // Abstract states are associated with IR values. As such, the analysis is sparse and favours the partitioned variable
 // lattice (PVL) property.
+//
+// Widen, if set, implements a [widening operator] for semilattices of infinite height, which would otherwise not reach
+// a fixed point. It is applied to ϕ instructions whenever their state grows, and is called with their old and new
+// states, neither of which is ⊥ or ⊤. Its result must be greater than or equal to both, and repeated application must
+// stabilize after a finite number of steps.
+//
+// [widening operator]: https://en.wikipedia.org/wiki/Widening_(computer_science)
 type Framework[S comparable] struct {
 	Join     Join[S]
+	Widen    func(old, new S) S
 	Transfer func(*Instance[S], ir.Instruction) []Mapping[S]
 	Bottom   S
 	Top      S
@@ -215,18 +227,39 @@ func (ins *Instance[S]) Forward(fn *ir.Function) {
 		ins.Mapping = map[ir.Value]Mapping[S]{}
 	}
 
-	worklist := map[ir.Instruction]struct{}{}
+	// The worklist is processed in FIFO order, starting with all instructions in block order. This keeps the results of
+	// widening deterministic.
+	var worklist []ir.Instruction
+	queued := map[ir.Instruction]struct{}{}
 	for _, b := range fn.Blocks {
 		for _, instr := range b.Instrs {
-			worklist[instr] = struct{}{}
+			worklist = append(worklist, instr)
+			queued[instr] = struct{}{}
 		}
 	}
-	for len(worklist) > 0 {
-		var instr ir.Instruction
-		for instr = range worklist {
-			break
+	enqueue := func(instr ir.Instruction) {
+		if _, ok := queued[instr]; !ok {
+			worklist = append(worklist, instr)
+			queued[instr] = struct{}{}
+		}
+	}
+	enqueueSigmas := func(from *ir.BasicBlock) {
+		for _, succ := range from.Succs {
+			for _, instr := range succ.Instrs {
+				sigma, ok := instr.(*ir.Sigma)
+				if !ok {
+					break
+				}
+				if sigma.From == from {
+					enqueue(sigma)
+				}
+			}
 		}
-		delete(worklist, instr)
+	}
+	for len(worklist) > 0 {
+		instr := worklist[0]
+		worklist = worklist[1:]
+		delete(queued, instr)
 
 		var ds []Mapping[S]
 		if phi, ok := instr.(*ir.Phi); ok {
@@ -236,6 +269,15 @@ func (ins *Instance[S]) Forward(fn *ir.Function) {
 				d = join(ins.Framework.Join, a, b, ins.Framework.Bottom, ins.Framework.Top)
 				debugf("join(%v, %v) = %v", a, b, d)
 			}
+			if fw := ins.Framework; fw.Widen != nil {
+				old := ins.Value(phi)
+				if old != fw.Bottom && old != fw.Top && d != fw.Top {
+					if d = join(fw.Join, old, d, fw.Bottom, fw.Top); d != old && d != fw.Top {
+						d = fw.Widen(old, d)
+						debugf("widen(%v) = %v", old, d)
+					}
+				}
+			}
 			ds = []Mapping[S]{{Value: phi, State: d, Decision: Decision{Inputs: phi.Edges, Description: "this variable merges the results of multiple branches"}}}
 		} else {
 			ds = ins.Framework.Transfer(ins, instr)
@@ -256,8 +298,19 @@ func (ins *Instance[S]) Forward(fn *ir.Function) {
 				}
 				ins.Mapping[d.Value] = Mapping[S]{Value: d.Value, State: dd, Decision: d.Decision}
 
-				for _, ref := range *instr.Referrers() {
-					worklist[ref] = struct{}{}
+				if refs := d.Value.Referrers(); refs != nil {
+					for _, ref := range *refs {
+						enqueue(ref)
+						// Sigma nodes may depend on the operands of the branch condition, not just on the
+						// values they refine.
+						if cond, ok := ref.(ir.Value); ok && cond.Referrers() != nil {
+							for _, ref := range *cond.Referrers() {
+								if iff, ok := ref.(*ir.If); ok {
+									enqueueSigmas(iff.Block())
+								}
+							}
+						}
+					}
 				}
 			}
 		}
@@ -24,6 +24,12 @@ type Range [2]token.Pos
 func (r Range) Pos() token.Pos { return r[0] }
 func (r Range) End() token.Pos { return r[1] }
 
+// StmtRange returns the range spanned by a non-empty sequence of
+// statements, such as the statements matched by a Seq pattern.
+func StmtRange(stmts []ast.Stmt) Range {
+	return Range{stmts[0].Pos(), stmts[len(stmts)-1].End()}
+}
+
 // ReplaceWithString replaces a range with a string.
 func ReplaceWithString(old Ranger, new string) analysis.TextEdit {
 	return analysis.TextEdit{
@@ -47,6 +53,7 @@ func ReplaceWithNode(fset *token.FileSet, old Ranger, new ast.Node) analysis.Tex
 }
 
 // ReplaceWithPattern replaces a range with the result of executing a pattern.
+// Patterns whose root is a Seq node produce sequences of statements.
 func ReplaceWithPattern(fset *token.FileSet, old Ranger, new pattern.Pattern, state pattern.State) analysis.TextEdit {
 	r := pattern.NodeToAST(new.Root, state)
 	buf := &bytes.Buffer{}
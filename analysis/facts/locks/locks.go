@@ -0,0 +1,1239 @@
+// Package locks tracks the state of sync.Mutex and sync.RWMutex values along the paths through functions.
+//
+// Mutexes are identified by access paths, such as the field mu of the receiver, or a package-level variable. The
+// lock state of each mutex is computed by a forward dataflow analysis over the function's basic blocks, which takes
+// deferred calls into account when the function returns. Paths on which the state of a mutex differs are merged into
+// an unknown state, about which we report nothing.
+//
+// Functions are summarized by facts, which record how they change the state of mutexes that are reachable from their
+// parameters or that are package-level variables. This allows functions that return with a lock held, as well as
+// functions that release locks held by their callers, to be used without causing false positives. Functions that
+// return with a lock held and return a function that releases it, such as
+//
+//	func (c *Conn) grab() (release func(), err error)
+//
+// are marked by Held facts, which tell us that holding the lock is intentional.
+package locks
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/irutil"
+	"honnef.co/go/tools/go/types/typeutil"
+	"honnef.co/go/tools/internal/passes/buildir"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// State is the lock state of a mutex.
+type State uint8
+
+const (
+	// Unknown means that the function hasn't done anything to the mutex yet. As the From state of an effect, it means
+	// that the function doesn't require the mutex to be in any particular state.
+	Unknown State = iota
+	Unlocked
+	Locked
+	RLocked
+	// Conflict means that the state depends on the path taken.
+	Conflict
+)
+
+func (s State) String() string {
+	switch s {
+	case Unknown:
+		return "unknown"
+	case Unlocked:
+		return "unlocked"
+	case Locked:
+		return "locked"
+	case RLocked:
+		return "rlocked"
+	case Conflict:
+		return "conflict"
+	default:
+		return fmt.Sprintf("State(%d)", s)
+	}
+}
+
+// An Effect describes how a function changes the state of a mutex.
+type Effect struct {
+	// Mutex is the access path of the mutex. Paths rooted in parameters start with $ followed by the parameter's
+	// index, paths rooted in package-level variables start with the variable's package path and name, in the form
+	// g:path#name. Both may be followed by field selections, such as $0.mu.
+	Mutex string
+	// From is the state that the function expects the mutex to be in. It may be the same as To, for functions that
+	// lock and unlock a mutex, or that temporarily release a lock held by their caller.
+	From State
+	// To is the state that the function leaves the mutex in.
+	To State
+}
+
+// Summary summarizes the effects a function has on mutexes.
+type Summary struct {
+	Effects []Effect
+}
+
+func (*Summary) AFact() {}
+
+func (s *Summary) String() string {
+	parts := make([]string, len(s.Effects))
+	for i, eff := range s.Effects {
+		parts[i] = fmt.Sprintf("%s: %s -> %s", eff.Mutex, eff.From, eff.To)
+	}
+	return fmt.Sprintf("locks(%s)", strings.Join(parts, ", "))
+}
+
+func (s *Summary) equal(o *Summary) bool {
+	return reflect.DeepEqual(s.Effects, o.Effects)
+}
+
+// join returns the join of s and o. Mutexes missing from either summary keep their effects, as a missing effect only
+// means that we didn't know about it yet. Because effects are only ever added or made less precise, repeatedly joining
+// summaries reaches a fixed point.
+func (s *Summary) join(o *Summary) *Summary {
+	effects := map[string]Effect{}
+	for _, eff := range s.Effects {
+		effects[eff.Mutex] = eff
+	}
+	for _, eff := range o.Effects {
+		if old, ok := effects[eff.Mutex]; ok {
+			eff.From = joinState(old.From, eff.From)
+			eff.To = joinState(old.To, eff.To)
+		}
+		effects[eff.Mutex] = eff
+	}
+	out := &Summary{Effects: make([]Effect, 0, len(effects))}
+	for _, eff := range effects {
+		out.Effects = append(out.Effects, eff)
+	}
+	sort.Slice(out.Effects, func(i, j int) bool {
+		return out.Effects[i].Mutex < out.Effects[j].Mutex
+	})
+	return out
+}
+
+// Held is a fact that marks functions that intentionally return with mutexes held, leaving it to their callers to
+// release them by calling one of the functions they return.
+type Held struct {
+	Mutexes []HeldMutex
+}
+
+// A HeldMutex is a mutex that a function returns with held.
+type HeldMutex struct {
+	// Mutex is the access path of the mutex, in the same form as in Effect.
+	Mutex string
+	// State is the state the mutex is in, either Locked or RLocked.
+	State State
+	// Result is the index of the result that releases the mutex when called.
+	Result int
+}
+
+func (*Held) AFact() {}
+
+func (h *Held) String() string {
+	parts := make([]string, len(h.Mutexes))
+	for i, m := range h.Mutexes {
+		parts[i] = fmt.Sprintf("%s: %s, released by result %d", m.Mutex, m.State, m.Result)
+	}
+	return fmt.Sprintf("held(%s)", strings.Join(parts, ", "))
+}
+
+// IssueKind is the kind of a misuse of a mutex.
+type IssueKind uint8
+
+const (
+	// HeldAtReturn means that the function returns while holding a lock it acquired, and doesn't do so on all paths.
+	HeldAtReturn IssueKind = iota + 1
+	// DoubleLock means that a mutex is locked while already being locked.
+	DoubleLock
+	// NotLocked means that a mutex is unlocked while not being locked.
+	NotLocked
+	// WrongUnlock means that a read lock is released with Unlock, or a write lock with RUnlock.
+	WrongUnlock
+)
+
+// A Step is a position that helps explain an issue.
+type Step struct {
+	At          token.Pos
+	Description string
+}
+
+func (s Step) Pos() token.Pos { return s.At }
+
+// An Issue is a misuse of a mutex.
+type Issue struct {
+	Kind IssueKind
+	// At is the position of the offending lock or unlock, or of the return statement.
+	At token.Pos
+	// Mutex describes the mutex, in the form of the source expression that refers to it.
+	Mutex string
+	// State is the state the mutex was in.
+	State State
+	// Callee is the name of the function that locked or unlocked the mutex on our behalf, if any.
+	Callee string
+	// Related lists positions that explain the issue.
+	Related []Step
+}
+
+func (iss Issue) Pos() token.Pos { return iss.At }
+
+// Result lists the issues found in a package.
+type Result struct {
+	Issues []Issue
+}
+
+var Analyzer = &analysis.Analyzer{
+	Name:       "fact_locks",
+	Doc:        "Tracks the lock state of mutexes and summarizes functions' effects on them",
+	Run:        run,
+	Requires:   []*analysis.Analyzer{buildir.Analyzer},
+	FactTypes:  []analysis.Fact{(*Summary)(nil), (*Held)(nil)},
+	ResultType: reflect.TypeOf((*Result)(nil)),
+}
+
+// ops maps the methods of mutexes to the state transitions they cause.
+var ops = map[string][2]State{
+	"(*sync.Mutex).Lock":      {Unlocked, Locked},
+	"(*sync.Mutex).Unlock":    {Locked, Unlocked},
+	"(*sync.RWMutex).Lock":    {Unlocked, Locked},
+	"(*sync.RWMutex).Unlock":  {Locked, Unlocked},
+	"(*sync.RWMutex).RLock":   {Unlocked, RLocked},
+	"(*sync.RWMutex).RUnlock": {RLocked, Unlocked},
+	// Whether TryLock succeeded depends on its result, which we don't track.
+	"(*sync.Mutex).TryLock":    {Conflict, Conflict},
+	"(*sync.RWMutex).TryLock":  {Conflict, Conflict},
+	"(*sync.RWMutex).TryRLock": {Conflict, Conflict},
+}
+
+// mutexState is the state of a single mutex at a point in a function.
+type mutexState struct {
+	state State
+	// entry is the state that the mutex must have been in when the function was called, as implied by the first
+	// operation on it.
+	entry State
+	// at is the position of the operation that put the mutex in its state.
+	at token.Pos
+	// name describes the mutex.
+	name string
+}
+
+// deferred is an operation on a mutex that runs when the function returns.
+type deferred struct {
+	from, to State
+	at       token.Pos
+	name     string
+	callee   string
+	// conflict is true if the operation is only deferred on some paths.
+	conflict bool
+}
+
+type state struct {
+	mutexes map[string]mutexState
+	defers  map[string]deferred
+}
+
+func newState() state {
+	return state{
+		mutexes: map[string]mutexState{},
+		defers:  map[string]deferred{},
+	}
+}
+
+func (s state) copy() state {
+	out := newState()
+	for k, v := range s.mutexes {
+		out.mutexes[k] = v
+	}
+	for k, v := range s.defers {
+		out.defers[k] = v
+	}
+	return out
+}
+
+func joinState(a, b State) State {
+	if a == b {
+		return a
+	}
+	return Conflict
+}
+
+// join merges o into s and reports whether s changed.
+func (s state) join(o state) bool {
+	changed := false
+	for k, v := range o.mutexes {
+		old, ok := s.mutexes[k]
+		if !ok {
+			// The mutex wasn't touched on the other path, which means that it is still in its entry state.
+			if v.state != v.entry {
+				v.state = Conflict
+			}
+			s.mutexes[k] = v
+			changed = true
+			continue
+		}
+		nv := old
+		nv.state = joinState(old.state, v.state)
+		nv.entry = joinState(old.entry, v.entry)
+		if nv != old {
+			s.mutexes[k] = nv
+			changed = true
+		}
+	}
+	for k, v := range s.mutexes {
+		if _, ok := o.mutexes[k]; !ok && v.state != v.entry && v.state != Conflict {
+			v.state = Conflict
+			s.mutexes[k] = v
+			changed = true
+		}
+	}
+	for k, v := range o.defers {
+		old, ok := s.defers[k]
+		if !ok {
+			v.conflict = true
+			s.defers[k] = v
+			changed = true
+			continue
+		}
+		if !old.conflict && (v.conflict || old.from != v.from || old.to != v.to) {
+			old.conflict = true
+			s.defers[k] = old
+			changed = true
+		}
+	}
+	for k, v := range s.defers {
+		if _, ok := o.defers[k]; !ok && !v.conflict {
+			v.conflict = true
+			s.defers[k] = v
+			changed = true
+		}
+	}
+	return changed
+}
+
+type analyzer struct {
+	pass  *analysis.Pass
+	irpkg *ir.Package
+	// src holds the package's source functions.
+	src       map[*ir.Function]bool
+	summaries map[*ir.Function]*Summary
+	held      map[*ir.Function]*Held
+	// stores maps fields of function type to the stores to them, and is populated on demand.
+	stores map[*types.Var][]*ir.Store
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	irp := pass.ResultOf[buildir.Analyzer].(*buildir.IR)
+	a := &analyzer{
+		pass:      pass,
+		irpkg:     irp.Pkg,
+		src:       map[*ir.Function]bool{},
+		summaries: map[*ir.Function]*Summary{},
+		held:      map[*ir.Function]*Held{},
+	}
+	for _, fn := range irp.SrcFuncs {
+		a.src[fn] = true
+	}
+
+	// Compute summaries, reanalyzing the callers of functions whose summaries changed, until we reach a fixed
+	// point.
+	callers := map[*ir.Function][]*ir.Function{}
+	callees := map[*ir.Function][]*ir.Function{}
+	for _, fn := range irp.SrcFuncs {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				site, ok := instr.(ir.CallInstruction)
+				if !ok {
+					continue
+				}
+				if callee, _ := callee(site.Common()); callee != nil && callee.Pkg == irp.Pkg {
+					callers[callee] = append(callers[callee], fn)
+					callees[fn] = append(callees[fn], callee)
+				}
+			}
+		}
+	}
+	// Analyze callees before their callers, so that only recursive functions get analyzed without the summaries of
+	// their callees. Paths through calls to functions whose summaries we don't know yet are ignored until we do, so
+	// that the summaries of recursive functions start out with what their other paths do.
+	var worklist []*ir.Function
+	queued := map[*ir.Function]bool{}
+	var postorder func(fn *ir.Function)
+	postorder = func(fn *ir.Function) {
+		if queued[fn] || !a.src[fn] {
+			return
+		}
+		queued[fn] = true
+		for _, callee := range callees[fn] {
+			postorder(callee)
+		}
+		worklist = append(worklist, fn)
+	}
+	for _, fn := range irp.SrcFuncs {
+		postorder(fn)
+	}
+	order := append([]*ir.Function(nil), worklist...)
+	funcs := map[*ir.Function]*function{}
+	for len(worklist) > 0 {
+		fn := worklist[0]
+		worklist = worklist[1:]
+		queued[fn] = false
+
+		f := a.analyze(fn)
+		funcs[fn] = f
+		exits := f.exits()
+		if len(exits) == 0 {
+			// All paths go through functions whose summaries we don't know yet, or the function never returns.
+			continue
+		}
+		sum := f.summarize(exits)
+		if old, ok := a.summaries[fn]; ok {
+			// Joining with the previous summary keeps summaries from oscillating in the presence of mutual
+			// recursion.
+			sum = old.join(sum)
+			if sum.equal(old) {
+				continue
+			}
+		}
+		a.summaries[fn] = sum
+		for _, caller := range callers[fn] {
+			if !queued[caller] {
+				worklist = append(worklist, caller)
+				queued[caller] = true
+			}
+		}
+	}
+
+	// Callees come first, so that functions that return the release functions they got from other functions are
+	// marked, too.
+	for _, fn := range order {
+		if h := funcs[fn].held(); len(h.Mutexes) > 0 {
+			a.held[fn] = h
+		}
+	}
+
+	res := &Result{}
+	for _, fn := range irp.SrcFuncs {
+		f, ok := funcs[fn]
+		if !ok {
+			continue
+		}
+		res.Issues = append(res.Issues, f.issues()...)
+		if fn.Object() == nil {
+			continue
+		}
+		if sum := a.summaries[fn]; sum != nil && len(sum.Effects) > 0 {
+			pass.ExportObjectFact(fn.Object(), sum)
+		}
+		if h := a.held[fn]; h != nil {
+			pass.ExportObjectFact(fn.Object(), h)
+		}
+	}
+	sort.Slice(res.Issues, func(i, j int) bool {
+		return res.Issues[i].At < res.Issues[j].At
+	})
+	return res, nil
+}
+
+// callee returns the function called by common, and the values bound to its free variables, if it is a closure.
+func callee(common *ir.CallCommon) (*ir.Function, []ir.Value) {
+	switch v := common.Value.(type) {
+	case *ir.Function:
+		return v, nil
+	case *ir.MakeClosure:
+		return v.Fn.(*ir.Function), v.Bindings
+	default:
+		return nil, nil
+	}
+}
+
+// summary returns the summary of fn, or nil if we know nothing about fn.
+func (a *analyzer) summary(fn *ir.Function) *Summary {
+	if fn.Pkg == a.irpkg {
+		return a.summaries[fn]
+	}
+	obj, ok := fn.Object().(*types.Func)
+	if !ok || obj.Pkg() == nil {
+		return nil
+	}
+	sum := new(Summary)
+	if a.pass.ImportObjectFact(obj.Origin(), sum) {
+		return sum
+	}
+	return nil
+}
+
+// heldBy returns the Held fact of fn, or nil if fn doesn't return with mutexes held intentionally.
+func (a *analyzer) heldBy(fn *ir.Function) *Held {
+	if fn.Pkg == a.irpkg {
+		return a.held[fn]
+	}
+	obj, ok := fn.Object().(*types.Func)
+	if !ok || obj.Pkg() == nil {
+		return nil
+	}
+	h := new(Held)
+	if a.pass.ImportObjectFact(obj.Origin(), h) {
+		return h
+	}
+	return nil
+}
+
+// fieldStores returns the stores to the field, in all of the package's functions.
+func (a *analyzer) fieldStores(field *types.Var) []*ir.Store {
+	if a.stores == nil {
+		a.stores = map[*types.Var][]*ir.Store{}
+		for fn := range a.src {
+			for _, b := range fn.Blocks {
+				for _, instr := range b.Instrs {
+					st, ok := instr.(*ir.Store)
+					if !ok {
+						continue
+					}
+					if f := fieldOf(st.Addr); f != nil {
+						if _, ok := f.Type().Underlying().(*types.Signature); ok {
+							a.stores[f] = append(a.stores[f], st)
+						}
+					}
+				}
+			}
+		}
+	}
+	return a.stores[field]
+}
+
+// fieldOf returns the field that v is the address of, or nil.
+func fieldOf(v ir.Value) *types.Var {
+	fa, ok := v.(*ir.FieldAddr)
+	if !ok {
+		return nil
+	}
+	T, ok := typeutil.Dereference(fa.X.Type()).Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	return T.Field(fa.Field)
+}
+
+// function holds the analysis of a single function.
+type function struct {
+	a  *analyzer
+	fn *ir.Function
+	// in maps blocks to the states at their entry.
+	in map[*ir.BasicBlock]state
+	// found collects the issues found while computing a block's out state. It is nil while we compute the fixed
+	// point.
+	found *[]Issue
+}
+
+func (a *analyzer) analyze(fn *ir.Function) *function {
+	f := &function{
+		a:  a,
+		fn: fn,
+		in: map[*ir.BasicBlock]state{},
+	}
+	if len(fn.Blocks) == 0 {
+		return f
+	}
+	f.in[fn.Blocks[0]] = newState()
+	worklist := []*ir.BasicBlock{fn.Blocks[0]}
+	queued := map[*ir.BasicBlock]bool{fn.Blocks[0]: true}
+	for len(worklist) > 0 {
+		b := worklist[0]
+		worklist = worklist[1:]
+		queued[b] = false
+		out, ok := f.transfer(b)
+		if !ok {
+			continue
+		}
+		for _, succ := range b.Succs {
+			if succ == fn.Exit {
+				// Returns are handled by the callers of exitStates, which need the states of individual paths.
+				continue
+			}
+			in, ok := f.in[succ]
+			if !ok {
+				f.in[succ] = out.copy()
+			} else if !in.join(out) {
+				continue
+			}
+			if !queued[succ] {
+				worklist = append(worklist, succ)
+				queued[succ] = true
+			}
+		}
+	}
+	return f
+}
+
+// mutex returns the access path of the mutex that v points to, and a description of it.
+func (f *function) mutex(v ir.Value) (key string, name string, ok bool) {
+	key, name, ok = f.mutex1(v, map[ir.Value]bool{})
+	return key, name, ok && key != ""
+}
+
+// mutex1 implements mutex. seen holds the phis and allocations that we are currently resolving. Cycles through them
+// are reported with ok set to true and an empty key.
+func (f *function) mutex1(v ir.Value, seen map[ir.Value]bool) (key string, name string, ok bool) {
+	switch v := v.(type) {
+	case *ir.Parameter:
+		for i, p := range f.fn.Params {
+			if p == v {
+				return "$" + strconv.Itoa(i), v.Object().Name(), true
+			}
+		}
+	case *ir.FreeVar:
+		for i, fv := range f.fn.FreeVars {
+			if fv == v {
+				return "$f" + strconv.Itoa(i), v.Name(), true
+			}
+		}
+	case *ir.Global:
+		obj := v.Object()
+		if obj == nil || obj.Pkg() == nil {
+			return "", "", false
+		}
+		return "g:" + obj.Pkg().Path() + "#" + obj.Name(), obj.Name(), true
+	case *ir.Alloc:
+		// Variables captured by closures are stored in allocations. If all values stored in the variable refer to
+		// the same mutex, we use that mutex.
+		if seen[v] {
+			return "", "", true
+		}
+		seen[v] = true
+		defer delete(seen, v)
+		n := 0
+		for _, ref := range *v.Referrers() {
+			st, ok := ref.(*ir.Store)
+			if !ok || st.Addr != v {
+				continue
+			}
+			skey, sname, ok := f.mutex1(st.Val, seen)
+			if ok && skey == "" {
+				continue
+			}
+			if !ok || (n > 0 && skey != key) {
+				n = 0
+				break
+			}
+			key, name = skey, sname
+			n++
+		}
+		if n > 0 {
+			return key, name, true
+		}
+		name = v.Comment()
+		if name == "" {
+			name = v.Name()
+		}
+		return "l:" + v.Name(), name, true
+	case *ir.FieldAddr:
+		key, name, ok := f.mutex1(v.X, seen)
+		if !ok || key == "" || depth(key) >= maxDepth {
+			return "", "", false
+		}
+		T, ok := typeutil.Dereference(v.X.Type()).Underlying().(*types.Struct)
+		if !ok {
+			return "", "", false
+		}
+		field := T.Field(v.Field).Name()
+		return key + "." + field, name + "." + field, true
+	case *ir.Field:
+		key, name, ok := f.mutex1(v.X, seen)
+		if !ok || key == "" || depth(key) >= maxDepth {
+			return "", "", false
+		}
+		T, ok := v.X.Type().Underlying().(*types.Struct)
+		if !ok {
+			return "", "", false
+		}
+		field := T.Field(v.Field).Name()
+		return key + "." + field, name + "." + field, true
+	case *ir.Load:
+		return f.mutex1(v.X, seen)
+	case *ir.Sigma:
+		return f.mutex1(v.X, seen)
+	case *ir.Phi:
+		// Phis merge different versions of the same variable, which usually refer to the same mutex.
+		if seen[v] {
+			return "", "", true
+		}
+		seen[v] = true
+		defer delete(seen, v)
+		for _, edge := range v.Edges {
+			ekey, ename, ok := f.mutex1(edge, seen)
+			if !ok {
+				return "", "", false
+			}
+			if ekey == "" {
+				// A cycle through other phis.
+				continue
+			}
+			if key != "" && ekey != key {
+				return "", "", false
+			}
+			key, name = ekey, ename
+		}
+		// If all edges are cycles, key is empty, which our caller will ignore.
+		return key, name, true
+	case *ir.ChangeType:
+		return f.mutex1(v.X, seen)
+	}
+	return "", "", false
+}
+
+// maxDepth is the maximum number of field selections in an access path. Effects on mutexes that are nested more
+// deeply are dropped. Without a limit, recursive functions that pass fields of their arguments to themselves would
+// produce ever longer access paths.
+const maxDepth = 3
+
+// depth returns the number of field selections in the access path key.
+func depth(key string) int {
+	if i := strings.IndexByte(key, '#'); i != -1 {
+		// Package paths may contain dots.
+		key = key[i+1:]
+	}
+	return strings.Count(key, ".")
+}
+
+// local reports whether the mutex with the given access path belongs to the function.
+func local(key string) bool {
+	return strings.HasPrefix(key, "l:")
+}
+
+func (f *function) report(iss Issue) {
+	if f.found != nil {
+		*f.found = append(*f.found, iss)
+	}
+}
+
+// apply applies a transition of a mutex's state to s.
+func (f *function) apply(s state, key, name string, from, to State, at token.Pos, callee string) {
+	cur, ok := s.mutexes[key]
+	if !ok {
+		cur = mutexState{name: name}
+	}
+	if from == Unknown || from == Conflict || to == Conflict {
+		if cur.state == Unknown {
+			cur.entry = from
+		}
+		cur.state = to
+		cur.at = at
+		s.mutexes[key] = cur
+		return
+	}
+	switch cur.state {
+	case Unknown:
+		cur.entry = from
+	case Conflict, from:
+	default:
+		iss := Issue{
+			At:     at,
+			Mutex:  cur.name,
+			State:  cur.state,
+			Callee: callee,
+		}
+		switch {
+		case from == Unlocked && to == RLocked && cur.state == RLocked:
+			// Recursive read locks are allowed, but we can't track how many there are.
+			to = Conflict
+			iss.Kind = 0
+		case from == Unlocked:
+			iss.Kind = DoubleLock
+			desc := "locked here"
+			if cur.state == RLocked {
+				desc = "read-locked here"
+			}
+			iss.Related = []Step{{At: cur.at, Description: desc}}
+		case cur.state == Unlocked:
+			iss.Kind = NotLocked
+			iss.Related = []Step{{At: cur.at, Description: "unlocked here"}}
+		default:
+			iss.Kind = WrongUnlock
+			desc := "locked here"
+			if cur.state == RLocked {
+				desc = "read-locked here"
+			}
+			iss.Related = []Step{{At: cur.at, Description: desc}}
+		}
+		if iss.Kind != 0 && cur.at.IsValid() {
+			f.report(iss)
+		}
+	}
+	cur.state = to
+	cur.at = at
+	s.mutexes[key] = cur
+}
+
+// call returns the transitions that a call causes, keyed by access paths.
+func (f *function) call(common *ir.CallCommon) (effects []Effect, names []string, calleeName string) {
+	if common.IsInvoke() {
+		return nil, nil, ""
+	}
+	fn, bindings := callee(common)
+	if fn == nil {
+		return nil, nil, ""
+	}
+	if obj, ok := fn.Object().(*types.Func); ok {
+		if op, ok := ops[typeutil.FuncName(obj.Origin())]; ok {
+			if len(common.Args) == 0 {
+				return nil, nil, ""
+			}
+			key, name, ok := f.mutex(common.Args[0])
+			if !ok {
+				return nil, nil, ""
+			}
+			return []Effect{{Mutex: key, From: op[0], To: op[1]}}, []string{name}, ""
+		}
+		calleeName = typeutil.FuncName(obj)
+	} else {
+		calleeName = "function literal"
+	}
+	sum := f.a.summary(fn)
+	if sum == nil {
+		return nil, nil, ""
+	}
+	for _, eff := range sum.Effects {
+		key, name, ok := f.translate(eff.Mutex, common.Args, bindings)
+		if !ok {
+			continue
+		}
+		effects = append(effects, Effect{Mutex: key, From: eff.From, To: eff.To})
+		names = append(names, name)
+	}
+	return effects, names, calleeName
+}
+
+// translate maps the access path of a mutex in a callee to the corresponding access path in the caller.
+func (f *function) translate(key string, args []ir.Value, bindings []ir.Value) (string, string, bool) {
+	if strings.HasPrefix(key, "g:") {
+		return key, key[strings.IndexByte(key, '#')+1:], true
+	}
+	if !strings.HasPrefix(key, "$") {
+		return "", "", false
+	}
+	vals := args
+	rest := key[1:]
+	if strings.HasPrefix(rest, "f") {
+		vals = bindings
+		rest = rest[1:]
+	}
+	end := strings.IndexByte(rest, '.')
+	if end == -1 {
+		end = len(rest)
+	}
+	idx, err := strconv.Atoi(rest[:end])
+	if err != nil || idx >= len(vals) {
+		return "", "", false
+	}
+	base, name, ok := f.mutex(vals[idx])
+	if !ok || depth(base)+depth(rest) > maxDepth {
+		return "", "", false
+	}
+	return base + rest[end:], name + rest[end:], true
+}
+
+// pending reports whether common calls a function of the package whose summary we don't know yet. Either we haven't
+// analyzed it yet, because it is part of a cycle of calls, or none of its paths return.
+func (f *function) pending(common *ir.CallCommon) bool {
+	if common.IsInvoke() {
+		return false
+	}
+	fn, _ := callee(common)
+	if fn == nil || !f.a.src[fn] {
+		return false
+	}
+	_, ok := f.a.summaries[fn]
+	return !ok
+}
+
+// transfer computes the state at the end of b. It returns false if b calls a function whose summary we don't know
+// yet, in which case we know nothing about the paths through b.
+func (f *function) transfer(b *ir.BasicBlock) (state, bool) {
+	s := f.in[b].copy()
+	for _, instr := range b.Instrs {
+		switch instr := instr.(type) {
+		case *ir.Call:
+			if f.pending(instr.Common()) {
+				return s, false
+			}
+			effects, names, calleeName := f.call(instr.Common())
+			for i, eff := range effects {
+				f.apply(s, eff.Mutex, names[i], eff.From, eff.To, instr.Pos(), calleeName)
+			}
+		case *ir.Defer:
+			effects, names, calleeName := f.call(instr.Common())
+			for i, eff := range effects {
+				d := deferred{from: eff.From, to: eff.To, at: instr.Pos(), name: names[i], callee: calleeName}
+				if old, ok := s.defers[eff.Mutex]; ok && (old.from != d.from || old.to != d.to) {
+					d.conflict = true
+				}
+				s.defers[eff.Mutex] = d
+			}
+		}
+	}
+	return s, true
+}
+
+// exit is the state of the function when returning via a particular block.
+type exit struct {
+	block *ir.BasicBlock
+	state state
+}
+
+// exits returns the states in which the function returns, after running deferred calls.
+func (f *function) exits() []exit {
+	var out []exit
+	if f.fn.Exit == nil {
+		return nil
+	}
+	for _, pred := range f.fn.Exit.Preds {
+		if _, ok := f.in[pred]; !ok || irutil.Aborts(pred) {
+			continue
+		}
+		s, ok := f.transfer(pred)
+		if !ok {
+			continue
+		}
+		keys := make([]string, 0, len(s.defers))
+		for k := range s.defers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			d := s.defers[k]
+			if d.conflict {
+				cur := s.mutexes[k]
+				cur.state = Conflict
+				s.mutexes[k] = cur
+				continue
+			}
+			f.apply(s, k, d.name, d.from, d.to, d.at, d.callee)
+		}
+		out = append(out, exit{pred, s})
+	}
+	return out
+}
+
+// joinExits merges the states of mutexes over all returns.
+func joinExits(exits []exit) map[string]mutexState {
+	out := map[string]mutexState{}
+	n := map[string]int{}
+	for _, ex := range exits {
+		for k, v := range ex.state.mutexes {
+			n[k]++
+			if old, ok := out[k]; ok {
+				old.state = joinState(old.state, v.state)
+				old.entry = joinState(old.entry, v.entry)
+				out[k] = old
+			} else {
+				out[k] = v
+			}
+		}
+	}
+	for k, v := range out {
+		if n[k] < len(exits) {
+			// The mutex is untouched on some paths, so the function only requires it to be in a particular state on
+			// the others. If the function leaves the mutex in the state it found it in, it has no effect on it at all.
+			if v.state == v.entry {
+				v.state = Unknown
+			} else {
+				v.state = Conflict
+			}
+			v.entry = Unknown
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// summarize computes the summary of the function from the states it returns in.
+func (f *function) summarize(exits []exit) *Summary {
+	sum := &Summary{}
+	for k, v := range joinExits(exits) {
+		// Effects that leave the mutex in the state it was in still tell callers which state the mutex has to be in.
+		if local(k) || v.state == Unknown {
+			continue
+		}
+		if v.state == v.entry && strings.HasPrefix(k, "g:") && !strings.HasPrefix(k, "g:"+f.a.pass.Pkg.Path()+"#") {
+			// Code outside the package that owns a global mutex rarely holds it, and tracking all such mutexes
+			// would bloat the summaries of functions that transitively call into other packages.
+			continue
+		}
+		sum.Effects = append(sum.Effects, Effect{Mutex: k, From: v.entry, To: v.state})
+	}
+	sort.Slice(sum.Effects, func(i, j int) bool {
+		return sum.Effects[i].Mutex < sum.Effects[j].Mutex
+	})
+	return sum
+}
+
+// intentional reports whether the function's name suggests that it returns with locks held, such as lockState or
+// lockedFoo.
+func (f *function) intentional() bool {
+	fn := f.fn
+	for fn.Parent() != nil {
+		fn = fn.Parent()
+	}
+	name := strings.ToLower(fn.Name())
+	return strings.HasPrefix(name, "lock") || strings.HasPrefix(name, "rlock")
+}
+
+// issues returns the issues in the function.
+func (f *function) issues() []Issue {
+	var out []Issue
+	f.found = &out
+	defer func() { f.found = nil }()
+	for _, b := range f.fn.Blocks {
+		if _, ok := f.in[b]; ok && b != f.fn.Exit {
+			f.transfer(b)
+		}
+	}
+	exits := f.exits()
+
+	if f.intentional() {
+		return dedup(out)
+	}
+	// Mutexes that are held on all returns, and that callers can access, are held intentionally. So are mutexes
+	// that are released by functions we return.
+	held := joinExits(exits)
+	for _, ex := range exits {
+		released := f.handedOff(ex)
+		for k, v := range ex.state.mutexes {
+			if v.state != Locked && v.state != RLocked || v.entry != Unlocked {
+				continue
+			}
+			if !local(k) && held[k].state == v.state {
+				continue
+			}
+			if _, ok := released[k]; ok {
+				continue
+			}
+			desc := "locked here"
+			if v.state == RLocked {
+				desc = "read-locked here"
+			}
+			at := irutil.ReturnPos(f.fn, ex.block)
+			out = append(out, Issue{
+				Kind:    HeldAtReturn,
+				At:      at,
+				Mutex:   v.name,
+				State:   v.state,
+				Related: []Step{{At: v.at, Description: desc}},
+			})
+		}
+	}
+	return dedup(out)
+}
+
+// returned returns the values that the function returns when returning via the exit block b.
+func (f *function) returned(b *ir.BasicBlock) []ir.Value {
+	ret, ok := f.fn.Exit.Control().(*ir.Return)
+	if !ok {
+		return nil
+	}
+	idx := -1
+	for i, pred := range f.fn.Exit.Preds {
+		if pred == b {
+			idx = i
+			break
+		}
+	}
+	out := make([]ir.Value, len(ret.Results))
+	for i, v := range ret.Results {
+		if phi, ok := v.(*ir.Phi); ok && phi.Block() == f.fn.Exit && idx != -1 {
+			v = phi.Edges[idx]
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// handedOff returns the mutexes that are held when returning via ex, and that are released by functions that we
+// return, mapped to the index of the result that releases them.
+func (f *function) handedOff(ex exit) map[string]int {
+	var out map[string]int
+	for i, v := range f.returned(ex.block) {
+		if _, ok := v.Type().Underlying().(*types.Signature); !ok {
+			continue
+		}
+		for _, eff := range f.calling(v) {
+			cur, ok := ex.state.mutexes[eff.Mutex]
+			if !ok || cur.state != eff.From || eff.To != Unlocked {
+				continue
+			}
+			if out == nil {
+				out = map[string]int{}
+			}
+			if _, ok := out[eff.Mutex]; !ok {
+				out[eff.Mutex] = i
+			}
+		}
+	}
+	return out
+}
+
+// held computes the Held fact of the function, from the mutexes that it hands off to its callers on at least one of
+// its returns. Functions usually release the mutex themselves on the paths that return an error instead.
+func (f *function) held() *Held {
+	h := &Held{}
+	exits := f.exits()
+	seen := map[string]bool{}
+	for _, ex := range exits {
+		for k, i := range f.handedOff(ex) {
+			if seen[k] || local(k) {
+				continue
+			}
+			seen[k] = true
+			h.Mutexes = append(h.Mutexes, HeldMutex{Mutex: k, State: ex.state.mutexes[k].state, Result: i})
+		}
+	}
+	sort.Slice(h.Mutexes, func(i, j int) bool {
+		return h.Mutexes[i].Mutex < h.Mutexes[j].Mutex
+	})
+	return h
+}
+
+// calling returns the effects that calling the function value v has, in terms of the access paths of f.
+func (f *function) calling(v ir.Value) []Effect {
+	switch v := v.(type) {
+	case *ir.Function, *ir.MakeClosure, *ir.ChangeType:
+		return f.closure(v)
+	case *ir.Sigma:
+		return f.calling(v.X)
+	case *ir.Load:
+		// A function stored in a field, such as a cached method value. We look at all the functions stored in the
+		// field that refer to the same object the field belongs to.
+		field := fieldOf(v.X)
+		if field == nil {
+			return nil
+		}
+		base, _, ok := f.mutex(v.X.(*ir.FieldAddr).X)
+		if !ok {
+			return nil
+		}
+		var out []Effect
+		for _, st := range f.a.fieldStores(field) {
+			g := &function{a: f.a, fn: st.Parent()}
+			sbase, _, ok := g.mutex(st.Addr.(*ir.FieldAddr).X)
+			if !ok {
+				continue
+			}
+			for _, eff := range g.closure(st.Val) {
+				if rest, ok := strings.CutPrefix(eff.Mutex, sbase+"."); ok {
+					eff.Mutex = base + "." + rest
+					out = append(out, eff)
+				}
+			}
+		}
+		return out
+	case *ir.Extract:
+		if call, ok := v.Tuple.(*ir.Call); ok {
+			return f.releasedBy(call.Common(), v.Index)
+		}
+	case *ir.Call:
+		return f.releasedBy(v.Common(), 0)
+	}
+	return nil
+}
+
+// closure returns the effects that calling v has, where v is a function, a function literal or a method value.
+func (f *function) closure(v ir.Value) []Effect {
+	for {
+		// Look through conversions to named function types.
+		ct, ok := v.(*ir.ChangeType)
+		if !ok {
+			break
+		}
+		v = ct.X
+	}
+	var fn *ir.Function
+	var args, bindings []ir.Value
+	switch v := v.(type) {
+	case *ir.Function:
+		fn = v
+	case *ir.MakeClosure:
+		fn = v.Fn.(*ir.Function)
+		bindings = v.Bindings
+		if fn.Synthetic == ir.SyntheticBound {
+			// The receiver of a method value is bound to the closure.
+			obj, ok := fn.Object().(*types.Func)
+			if !ok {
+				return nil
+			}
+			fn = fn.Prog.FuncValue(obj)
+			args, bindings = bindings, nil
+		}
+	}
+	if fn == nil {
+		return nil
+	}
+	sum := f.a.summary(fn)
+	if sum == nil {
+		return nil
+	}
+	var out []Effect
+	for _, eff := range sum.Effects {
+		key, _, ok := f.translate(eff.Mutex, args, bindings)
+		if !ok {
+			continue
+		}
+		out = append(out, Effect{Mutex: key, From: eff.From, To: eff.To})
+	}
+	return out
+}
+
+// releasedBy returns the effects of calling the function returned as the result with the given index by the call.
+func (f *function) releasedBy(common *ir.CallCommon, result int) []Effect {
+	if common.IsInvoke() {
+		return nil
+	}
+	fn, bindings := callee(common)
+	if fn == nil {
+		return nil
+	}
+	h := f.a.heldBy(fn)
+	if h == nil {
+		return nil
+	}
+	var out []Effect
+	for _, m := range h.Mutexes {
+		if m.Result != result {
+			continue
+		}
+		key, _, ok := f.translate(m.Mutex, common.Args, bindings)
+		if !ok {
+			continue
+		}
+		out = append(out, Effect{Mutex: key, From: m.State, To: Unlocked})
+	}
+	return out
+}
+
+// dedup removes duplicate issues, which may be found more than once because we analyze the exit blocks for each
+// path separately.
+func dedup(issues []Issue) []Issue {
+	type key struct {
+		kind IssueKind
+		at   token.Pos
+		name string
+	}
+	seen := map[key]bool{}
+	out := issues[:0]
+	for _, iss := range issues {
+		k := key{iss.Kind, iss.At, iss.Mutex}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, iss)
+	}
+	return out
+}
@@ -0,0 +1,11 @@
+package locks
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestLocks(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "example.com/Locks")
+}
@@ -0,0 +1,140 @@
+package pkg
+
+import "sync"
+
+type T struct {
+	mu sync.Mutex
+	rw sync.RWMutex
+	x  int
+}
+
+func (t *T) balanced() { // want balanced:`locks\(\$0\.mu: unlocked -> unlocked\)`
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.x++
+}
+
+func (t *T) lock() { // want lock:`locks\(\$0\.mu: unlocked -> locked\)`
+	t.mu.Lock()
+}
+
+func (t *T) unlock() { // want unlock:`locks\(\$0\.mu: locked -> unlocked\)`
+	t.mu.Unlock()
+}
+
+func (t *T) rlock() { // want rlock:`locks\(\$0\.rw: unlocked -> rlocked\)`
+	t.rw.RLock()
+}
+
+func (t *T) updateLocked() { // want updateLocked:`locks\(\$0\.mu: locked -> locked\)`
+	// Temporarily releases a lock held by the caller.
+	t.mu.Unlock()
+	t.x++
+	t.mu.Lock()
+}
+
+func (t *T) viaHelper() { // want viaHelper:`locks\(\$0\.mu: unlocked -> locked\)`
+	t.lock()
+}
+
+func helperArg(t *T) { // want helperArg:`locks\(\$0\.mu: locked -> unlocked\)`
+	t.unlock()
+}
+
+// The mutex only has to be unlocked if b is true.
+func (t *T) maybe(b bool) { // want maybe:`locks\(\$0\.mu: unknown -> conflict\)`
+	if b {
+		t.mu.Lock()
+	}
+}
+
+var global sync.Mutex
+
+func lockGlobal() { // want lockGlobal:`locks\(g:example\.com/Locks#global: unlocked -> locked\)`
+	global.Lock()
+}
+
+func local() {
+	var mu sync.Mutex
+	mu.Lock()
+}
+
+func (t *T) closure() { // want closure:`locks\(\$0\.mu: unlocked -> unlocked\)`
+	t.mu.Lock()
+	defer func() {
+		t.mu.Unlock()
+	}()
+}
+
+type node struct {
+	mu   sync.Mutex
+	next *node
+}
+
+// Each call adds a level of nesting. Access paths are limited in depth, so that the analysis terminates.
+func (n *node) lockAll() { // want lockAll:`locks\(\$0\.mu: unlocked -> locked, \$0\.next\.mu: unknown -> conflict, \$0\.next\.next\.mu: unknown -> conflict\)`
+	n.mu.Lock()
+	if n.next != nil {
+		n.next.lockAll()
+	}
+}
+
+// The lock is only temporarily released on some paths, so callers needn't hold it.
+func (t *T) maybeUpdateLocked(b bool) {
+	if b {
+		t.updateLocked()
+	}
+}
+
+// Paths through the recursive call don't contribute to the summary until we know the summary of the call.
+func (t *T) recurse(n int) { // want recurse:`locks\(\$0\.mu: unlocked -> unlocked\)`
+	if n > 0 {
+		t.recurse(n - 1)
+	}
+	t.mu.Lock()
+	t.mu.Unlock()
+}
+
+func (t *T) runlock() { // want runlock:`locks\(\$0\.rw: rlocked -> unlocked\)`
+	t.rw.RUnlock()
+}
+
+func (t *T) grab(fail bool) (func(), error) { // want grab:`locks\(\$0\.rw: unlocked -> conflict\)` grab:`held\(\$0\.rw: rlocked, released by result 0\)`
+	t.rw.RLock()
+	if fail {
+		t.rw.RUnlock()
+		return nil, nil
+	}
+	return t.runlock, nil
+}
+
+func (t *T) grabClosure() func() { // want grabClosure:`locks\(\$0\.mu: unlocked -> locked\)` grabClosure:`held\(\$0\.mu: locked, released by result 0\)`
+	t.mu.Lock()
+	return func() { t.mu.Unlock() }
+}
+
+// Returns the release function of another function.
+func (t *T) grabWrapped() func() { // want grabWrapped:`locks\(\$0\.mu: unlocked -> locked\)` grabWrapped:`held\(\$0\.mu: locked, released by result 0\)`
+	return t.grabClosure()
+}
+
+type release func()
+
+type cached struct {
+	mu      sync.RWMutex
+	release release
+}
+
+func (c *cached) runlock() { // want runlock:`locks\(\$0\.mu: rlocked -> unlocked\)`
+	c.mu.RUnlock()
+}
+
+func (c *cached) init() {
+	c.release = c.runlock
+}
+
+// Returns a method value that was stored in a field.
+func (c *cached) grab() release { // want grab:`locks\(\$0\.mu: unlocked -> rlocked\)` grab:`held\(\$0\.mu: rlocked, released by result 0\)`
+	c.mu.RLock()
+	return c.release
+}
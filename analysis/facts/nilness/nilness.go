@@ -7,7 +7,10 @@ import (
 	"reflect"
 
 	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/callgraph"
+	"honnef.co/go/tools/go/ir/irutil"
 	"honnef.co/go/tools/go/types/typeutil"
+	"honnef.co/go/tools/internal/passes/buildcallgraph"
 	"honnef.co/go/tools/internal/passes/buildir"
 
 	"golang.org/x/tools/go/analysis"
@@ -25,16 +28,71 @@ func (fact *neverReturnsNilFact) String() string {
 	return fmt.Sprintf("never returns nil: %v", fact.Rets)
 }
 
+// paramNilnessFact records how a function treats its pointer-like
+// parameters. Parameter indices include the receiver, if any.
+type paramNilnessFact struct {
+	// Derefs lists the parameters that are dereferenced on every path
+	// through the function that returns normally.
+	Derefs []int
+	// Checks lists the parameters that are compared against nil.
+	Checks []int
+}
+
+func (*paramNilnessFact) AFact() {}
+func (fact *paramNilnessFact) String() string {
+	return fmt.Sprintf("dereferences %v, checks %v", fact.Derefs, fact.Checks)
+}
+
+// nilOnErrorFact denotes that some of a function's results are nil
+// whenever its last result, which is of type error, is non-nil.
+type nilOnErrorFact struct {
+	Rets []int
+}
+
+func (*nilOnErrorFact) AFact() {}
+func (fact *nilOnErrorFact) String() string {
+	return fmt.Sprintf("nil on error: %v", fact.Rets)
+}
+
+// infallibleFact denotes that a function's last result, which is of
+// type error, is always nil.
+type infallibleFact struct{}
+
+func (*infallibleFact) AFact()         {}
+func (*infallibleFact) String() string { return "never fails" }
+
+// nilOnErrorStdlib lists functions whose results are nil on error, but
+// for which we cannot infer this.
+var nilOnErrorStdlib = map[string][]int{
+	"net/http.Get":                   {0},
+	"net/http.Head":                  {0},
+	"net/http.Post":                  {0},
+	"net/http.PostForm":              {0},
+	"(*net/http.Client).Do":          {0},
+	"(*net/http.Client).Get":         {0},
+	"(*net/http.Client).Head":        {0},
+	"(*net/http.Client).Post":        {0},
+	"(*net/http.Client).PostForm":    {0},
+	"net/http.NewRequest":            {0},
+	"net/http.NewRequestWithContext": {0},
+	"os.Create":                      {0},
+	"os.Open":                        {0},
+	"os.OpenFile":                    {0},
+}
+
 type Result struct {
-	m map[*types.Func][]neverNilness
+	m          map[*types.Func][]neverNilness
+	params     map[*types.Func]*paramInfo
+	nilOnError map[*types.Func][]int
+	infallible map[*types.Func]struct{}
 }
 
 var Analysis = &analysis.Analyzer{
 	Name:       "nilness",
-	Doc:        "Annotates return values that will never be nil (typed or untyped)",
+	Doc:        "Annotates return values that will never be nil (typed or untyped), that are nil on error, or errors that are always nil, and parameters that get dereferenced",
 	Run:        run,
-	Requires:   []*analysis.Analyzer{buildir.Analyzer},
-	FactTypes:  []analysis.Fact{(*neverReturnsNilFact)(nil)},
+	Requires:   []*analysis.Analyzer{buildir.Analyzer, buildcallgraph.Analyzer},
+	FactTypes:  []analysis.Fact{(*neverReturnsNilFact)(nil), (*paramNilnessFact)(nil), (*nilOnErrorFact)(nil), (*infallibleFact)(nil)},
 	ResultType: reflect.TypeOf((*Result)(nil)),
 }
 
@@ -57,17 +115,103 @@ func (r *Result) MayReturnNil(fn *types.Func, ret int) (yes bool, globalOnly boo
 	return v != neverNil, v == onlyGlobal
 }
 
+// Dereferences reports whether fn dereferences its idx'th parameter
+// on every path that returns normally, and thus panics if the
+// argument is nil. The receiver, if any, is the first parameter. For
+// functions in the current package, it also returns the instruction
+// that dereferences the parameter; that instruction may be a call to
+// another function that dereferences it.
+//
+// The analysis errs on the side of false negatives.
+func (r *Result) Dereferences(fn *types.Func, idx int) (ir.Instruction, bool) {
+	info, ok := r.params[fn]
+	if !ok {
+		return nil, false
+	}
+	for _, i := range info.fact.Derefs {
+		if i == idx {
+			return info.derefs[idx], true
+		}
+	}
+	return nil, false
+}
+
+// ChecksNil reports whether fn compares its idx'th parameter against
+// nil. The receiver, if any, is the first parameter.
+func (r *Result) ChecksNil(fn *types.Func, idx int) bool {
+	info, ok := r.params[fn]
+	if !ok {
+		return false
+	}
+	for _, i := range info.fact.Checks {
+		if i == idx {
+			return true
+		}
+	}
+	return false
+}
+
+// NilOnError reports whether the ret's result of fn is nil whenever
+// fn's error result, which is its last result, is non-nil. The value
+// of ret is zero-based.
+//
+// The analysis errs on the side of false negatives.
+func (r *Result) NilOnError(fn *types.Func, ret int) bool {
+	rets, ok := nilOnErrorStdlib[fn.Origin().FullName()]
+	if !ok {
+		rets = r.nilOnError[fn]
+	}
+	for _, i := range rets {
+		if i == ret {
+			return true
+		}
+	}
+	return false
+}
+
+// Infallible reports whether fn's last result is of type error and is
+// always nil.
+//
+// The analysis errs on the side of false negatives.
+func (r *Result) Infallible(fn *types.Func) bool {
+	_, ok := r.infallible[fn]
+	return ok
+}
+
 func run(pass *analysis.Pass) (interface{}, error) {
 	seen := map[*ir.Function]struct{}{}
+	params := map[*ir.Function]*paramInfo{}
+	nilOnError := map[*ir.Function][]int{}
+	infallible := map[*ir.Function]bool{}
 	out := &Result{
-		m: map[*types.Func][]neverNilness{},
+		m:          map[*types.Func][]neverNilness{},
+		params:     map[*types.Func]*paramInfo{},
+		nilOnError: map[*types.Func][]int{},
+		infallible: map[*types.Func]struct{}{},
 	}
 	for _, fn := range pass.ResultOf[buildir.Analyzer].(*buildir.IR).SrcFuncs {
 		impl(pass, fn, seen)
+		paramImpl(pass, fn, params)
+		nilOnErrorImpl(pass, fn, nilOnError)
+		infallibleImpl(pass, fn, infallible)
 	}
 
 	for _, fact := range pass.AllObjectFacts() {
-		out.m[fact.Object.(*types.Func)] = fact.Fact.(*neverReturnsNilFact).Rets
+		switch f := fact.Fact.(type) {
+		case *neverReturnsNilFact:
+			out.m[fact.Object.(*types.Func)] = f.Rets
+		case *paramNilnessFact:
+			out.params[fact.Object.(*types.Func)] = &paramInfo{fact: *f}
+		case *nilOnErrorFact:
+			out.nilOnError[fact.Object.(*types.Func)] = f.Rets
+		case *infallibleFact:
+			out.infallible[fact.Object.(*types.Func)] = struct{}{}
+		}
+	}
+	for fn, info := range params {
+		if obj, ok := fn.Object().(*types.Func); ok && info != nil {
+			out.params[obj] = info
+		}
 	}
 
 	return out, nil
@@ -117,6 +261,27 @@ func impl(pass *analysis.Pass, fn *ir.Function, seenFns map[*ir.Function]struct{
 
 	seen := map[ir.Value]struct{}{}
 
+	// callNilness returns the nilness of the idx'th result of call,
+	// which may resolve to multiple functions if it is dynamic.
+	callNilness := func(call *ir.Call, idx int) neverNilness {
+		cg := pass.ResultOf[buildcallgraph.Analyzer].(*callgraph.Graph)
+		callees := cg.Callees(call)
+		if len(callees) == 0 || cg.Unresolved[call] {
+			return nilly
+		}
+		ret := neverNil
+		for _, callee := range callees {
+			rets := impl(pass, callee, seenFns)
+			if len(rets) == 0 {
+				return nilly
+			}
+			if rets[idx] > ret {
+				ret = rets[idx]
+			}
+		}
+		return ret
+	}
+
 	var mightReturnNil func(v ir.Value) neverNilness
 	mightReturnNil = func(v ir.Value) neverNilness {
 		if _, ok := seen[v]; ok {
@@ -152,15 +317,7 @@ func impl(pass *analysis.Pass, fn *ir.Function, seenFns map[*ir.Function]struct{
 		case *ir.Extract:
 			switch d := v.Tuple.(type) {
 			case *ir.Call:
-				if callee := d.Call.StaticCallee(); callee != nil {
-					ret := impl(pass, callee, seenFns)
-					if len(ret) == 0 {
-						return nilly
-					}
-					return ret[v.Index]
-				} else {
-					return nilly
-				}
+				return callNilness(d, v.Index)
 			case *ir.TypeAssert, *ir.Next, *ir.Select, *ir.MapLookup, *ir.TypeSwitch, *ir.Recv, *ir.Sigma:
 				// we don't need to look at the Extract's index
 				// because we've already checked its type.
@@ -169,15 +326,7 @@ func impl(pass *analysis.Pass, fn *ir.Function, seenFns map[*ir.Function]struct{
 				panic(fmt.Sprintf("internal error: unhandled type %T", d))
 			}
 		case *ir.Call:
-			if callee := v.Call.StaticCallee(); callee != nil {
-				ret := impl(pass, callee, seenFns)
-				if len(ret) == 0 {
-					return nilly
-				}
-				return ret[0]
-			} else {
-				return nilly
-			}
+			return callNilness(v, 0)
 		case *ir.BinOp, *ir.UnOp, *ir.Alloc, *ir.FieldAddr, *ir.IndexAddr, *ir.Global, *ir.MakeSlice, *ir.MakeClosure, *ir.Function, *ir.MakeMap, *ir.MakeChan:
 			return neverNil
 		case *ir.Sigma:
@@ -253,3 +402,418 @@ func impl(pass *analysis.Pass, fn *ir.Function, seenFns map[*ir.Function]struct{
 	}
 	return out
 }
+
+type paramInfo struct {
+	fact paramNilnessFact
+	// derefs maps parameters to instructions that dereference them.
+	// It is only populated for functions in the current package.
+	derefs map[int]ir.Instruction
+}
+
+// paramImpl computes the parameter nilness of fn, exporting a fact
+// if there is anything to record.
+func paramImpl(pass *analysis.Pass, fn *ir.Function, cache map[*ir.Function]*paramInfo) *paramInfo {
+	if fn.Object() == nil {
+		// TODO(dh): support closures
+		return nil
+	}
+	if fn.Pkg != pass.ResultOf[buildir.Analyzer].(*buildir.IR).Pkg {
+		if fact := new(paramNilnessFact); pass.ImportObjectFact(fn.Object(), fact) {
+			return &paramInfo{fact: *fact}
+		}
+		return nil
+	}
+	if info, ok := cache[fn]; ok {
+		// Either we've already processed the function, or we're
+		// breaking recursion, in which case info is nil.
+		return info
+	}
+	cache[fn] = nil
+	if fn.Blocks == nil {
+		return nil
+	}
+
+	// Map the parameters and values derived from them to parameter indices.
+	aliases := map[ir.Value]int{}
+	var add func(v ir.Value, idx int)
+	add = func(v ir.Value, idx int) {
+		if _, ok := aliases[v]; ok {
+			return
+		}
+		aliases[v] = idx
+		for _, ref := range *v.Referrers() {
+			switch ref := ref.(type) {
+			case *ir.Sigma:
+				add(ref, idx)
+			case *ir.ChangeType:
+				add(ref, idx)
+			}
+		}
+	}
+	for i, param := range fn.Params {
+		if _, ok := param.Type().(*types.TypeParam); ok {
+			continue
+		}
+		if typeutil.IsPointerLike(param.Type()) {
+			add(param, i)
+		}
+	}
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	isNil := func(v ir.Value) bool {
+		k, ok := v.(*ir.Const)
+		return ok && k.IsNil()
+	}
+	// A deferred function might recover from the panic caused by
+	// dereferencing a nil pointer.
+	mayRecover := false
+	derefs := map[int]map[*ir.BasicBlock]ir.Instruction{}
+	checks := map[int]struct{}{}
+	deref := func(instr ir.Instruction, v ir.Value) {
+		idx, ok := aliases[v]
+		if !ok {
+			return
+		}
+		if derefs[idx] == nil {
+			derefs[idx] = map[*ir.BasicBlock]ir.Instruction{}
+		}
+		if _, ok := derefs[idx][instr.Block()]; !ok {
+			derefs[idx][instr.Block()] = instr
+		}
+	}
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			switch instr := instr.(type) {
+			case *ir.Load:
+				deref(instr, instr.X)
+			case *ir.Store:
+				deref(instr, instr.Addr)
+			case *ir.FieldAddr:
+				deref(instr, instr.X)
+			case *ir.IndexAddr:
+				if _, ok := typeutil.CoreType(instr.X.Type()).(*types.Pointer); ok {
+					deref(instr, instr.X)
+				}
+			case *ir.MapUpdate:
+				deref(instr, instr.Map)
+			case *ir.TypeAssert:
+				if !instr.CommaOk {
+					deref(instr, instr.X)
+				}
+			case *ir.Defer:
+				mayRecover = true
+			case *ir.Call:
+				common := instr.Common()
+				if common.IsInvoke() {
+					deref(instr, common.Value)
+					continue
+				}
+				if _, ok := common.Value.(*ir.Builtin); ok {
+					continue
+				}
+				callee := common.StaticCallee()
+				if callee == nil {
+					deref(instr, common.Value)
+					continue
+				}
+				if info := paramImpl(pass, callee, cache); info != nil {
+					for _, idx := range info.fact.Derefs {
+						if idx < len(common.Args) {
+							deref(instr, common.Args[idx])
+						}
+					}
+				}
+			case *ir.If:
+				if cond, ok := instr.Cond.(*ir.BinOp); ok {
+					var v ir.Value
+					if isNil(cond.X) {
+						v = cond.Y
+					} else if isNil(cond.Y) {
+						v = cond.X
+					}
+					if idx, ok := aliases[v]; ok && v != nil {
+						checks[idx] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+
+	info := &paramInfo{derefs: map[int]ir.Instruction{}}
+	for i := range fn.Params {
+		if blocks, ok := derefs[i]; ok && !mayRecover && fn.Exit != nil && unconditional(fn, blocks) {
+			info.fact.Derefs = append(info.fact.Derefs, i)
+			// Point at the dereference that happens first.
+			for _, b := range fn.Blocks {
+				if instr, ok := blocks[b]; ok {
+					info.derefs[i] = instr
+					break
+				}
+			}
+		}
+		if _, ok := checks[i]; ok {
+			info.fact.Checks = append(info.fact.Checks, i)
+		}
+	}
+	if len(info.fact.Derefs) == 0 && len(info.fact.Checks) == 0 {
+		return nil
+	}
+	cache[fn] = info
+	pass.ExportObjectFact(fn.Object(), &info.fact)
+	return info
+}
+
+// unconditional reports whether every path from fn's entry to its
+// exit passes through one of blocks. Paths that panic or exit the
+// process don't need to.
+func unconditional(fn *ir.Function, blocks map[*ir.BasicBlock]ir.Instruction) bool {
+	seen := map[*ir.BasicBlock]struct{}{}
+	q := []*ir.BasicBlock{fn.Blocks[0]}
+	for len(q) > 0 {
+		b := q[len(q)-1]
+		q = q[:len(q)-1]
+		if _, ok := blocks[b]; ok {
+			continue
+		}
+		if b == fn.Exit {
+			return false
+		}
+		if _, ok := seen[b]; ok {
+			continue
+		}
+		seen[b] = struct{}{}
+		if irutil.Aborts(b) {
+			continue
+		}
+		q = append(q, b.Succs...)
+	}
+	return true
+}
+
+// nilOnErrorImpl computes which of fn's results are nil whenever its
+// error result is non-nil, exporting a fact if there are any.
+func nilOnErrorImpl(pass *analysis.Pass, fn *ir.Function, cache map[*ir.Function][]int) []int {
+	obj, ok := fn.Object().(*types.Func)
+	if !ok {
+		// TODO(dh): support closures
+		return nil
+	}
+	results := fn.Signature.Results()
+	if results.Len() < 2 || !returnsError(fn.Signature) {
+		return nil
+	}
+	if rets, ok := nilOnErrorStdlib[obj.Origin().FullName()]; ok {
+		return rets
+	}
+	if fn.Pkg != pass.ResultOf[buildir.Analyzer].(*buildir.IR).Pkg {
+		if fact := new(nilOnErrorFact); pass.ImportObjectFact(obj, fact) {
+			return fact.Rets
+		}
+		return nil
+	}
+	if rets, ok := cache[fn]; ok {
+		// Either we've already processed the function, or we're
+		// breaking recursion, in which case rets is nil.
+		return rets
+	}
+	cache[fn] = nil
+	if fn.Blocks == nil || fn.Exit == nil {
+		return nil
+	}
+	ret, ok := fn.Exit.Control().(*ir.Return)
+	if !ok {
+		return nil
+	}
+
+	// edge returns the value of v when control enters the exit block
+	// from its k'th predecessor.
+	edge := func(v ir.Value, k int) ir.Value {
+		if phi, ok := v.(*ir.Phi); ok && phi.Block() == fn.Exit {
+			return phi.Edges[k]
+		}
+		return v
+	}
+	// nilOnError reports whether res is nil if err is non-nil.
+	nilOnError := func(res, err ir.Value) bool {
+		res = stripSigmas(res)
+		if k, ok := res.(*ir.Const); ok {
+			return k.IsNil()
+		}
+		resEx, ok := res.(*ir.Extract)
+		if !ok {
+			return false
+		}
+		errEx, ok := stripSigmas(err).(*ir.Extract)
+		if !ok || errEx.Tuple != resEx.Tuple {
+			return false
+		}
+		call, ok := resEx.Tuple.(*ir.Call)
+		if !ok {
+			return false
+		}
+		callee := call.Common().StaticCallee()
+		if callee == nil || errEx.Index != callee.Signature.Results().Len()-1 {
+			return false
+		}
+		for _, i := range nilOnErrorImpl(pass, callee, cache) {
+			if i == resEx.Index {
+				return true
+			}
+		}
+		return false
+	}
+
+	errIdx := results.Len() - 1
+	var rets []int
+resultLoop:
+	for i := 0; i < errIdx; i++ {
+		if !typeutil.IsPointerLike(results.At(i).Type()) {
+			continue
+		}
+		for k, pred := range fn.Exit.Preds {
+			if irutil.Aborts(pred) {
+				continue
+			}
+			err := edge(ret.Results[errIdx], k)
+			if knownNil(err) {
+				continue
+			}
+			if !nilOnError(edge(ret.Results[i], k), err) {
+				continue resultLoop
+			}
+		}
+		rets = append(rets, i)
+	}
+	if len(rets) == 0 {
+		return nil
+	}
+	cache[fn] = rets
+	pass.ExportObjectFact(obj, &nilOnErrorFact{rets})
+	return rets
+}
+
+func stripSigmas(v ir.Value) ir.Value {
+	for {
+		sigma, ok := v.(*ir.Sigma)
+		if !ok {
+			return v
+		}
+		v = sigma.X
+	}
+}
+
+// knownNil reports whether v is known to be nil, either because it is
+// the nil constant or because it has been compared against nil.
+func knownNil(v ir.Value) bool {
+	for {
+		switch w := v.(type) {
+		case *ir.Const:
+			return w.IsNil()
+		case *ir.Sigma:
+			if iff, ok := w.From.Control().(*ir.If); ok {
+				if binop, ok := iff.Cond.(*ir.BinOp); ok {
+					isNil := func(v ir.Value) bool {
+						k, ok := v.(*ir.Const)
+						return ok && k.IsNil()
+					}
+					if binop.X == w.X && isNil(binop.Y) || binop.Y == w.X && isNil(binop.X) {
+						// The first successor is taken if the condition holds.
+						taken := w.From.Succs[0] == w.Block()
+						return (binop.Op == token.EQL) == taken
+					}
+				}
+			}
+			v = w.X
+		default:
+			return false
+		}
+	}
+}
+
+func returnsError(sig *types.Signature) bool {
+	results := sig.Results()
+	return results.Len() > 0 && types.Identical(results.At(results.Len()-1).Type(), types.Universe.Lookup("error").Type())
+}
+
+// infallibleImpl computes whether fn's error result is always nil,
+// exporting a fact if it is.
+func infallibleImpl(pass *analysis.Pass, fn *ir.Function, cache map[*ir.Function]bool) bool {
+	obj, ok := fn.Object().(*types.Func)
+	if !ok {
+		// TODO(dh): support closures
+		return false
+	}
+	if !returnsError(fn.Signature) {
+		return false
+	}
+	if fn.Pkg != pass.ResultOf[buildir.Analyzer].(*buildir.IR).Pkg {
+		return pass.ImportObjectFact(obj, new(infallibleFact))
+	}
+	if ok, seen := cache[fn]; seen {
+		// Either we've already processed the function, or we're
+		// breaking recursion, in which case ok is false.
+		return ok
+	}
+	cache[fn] = false
+	if fn.Blocks == nil || fn.Exit == nil {
+		return false
+	}
+	ret, ok := fn.Exit.Control().(*ir.Return)
+	if !ok {
+		return false
+	}
+
+	seen := map[ir.Value]struct{}{}
+	var alwaysNil func(v ir.Value) bool
+	alwaysNil = func(v ir.Value) bool {
+		if knownNil(v) {
+			return true
+		}
+		v = stripSigmas(v)
+		if _, ok := seen[v]; ok {
+			// We're in a cycle of phis; the other edges decide.
+			return true
+		}
+		seen[v] = struct{}{}
+		switch v := v.(type) {
+		case *ir.Phi:
+			for _, edge := range v.Edges {
+				if !alwaysNil(edge) {
+					return false
+				}
+			}
+			return true
+		case *ir.Extract:
+			call, ok := v.Tuple.(*ir.Call)
+			if !ok || v.Index != call.Call.Signature().Results().Len()-1 {
+				return false
+			}
+			callee := call.Common().StaticCallee()
+			return callee != nil && infallibleImpl(pass, callee, cache)
+		case *ir.Call:
+			callee := v.Common().StaticCallee()
+			return callee != nil && infallibleImpl(pass, callee, cache)
+		default:
+			return false
+		}
+	}
+
+	errIdx := len(ret.Results) - 1
+	for k, pred := range fn.Exit.Preds {
+		if irutil.Aborts(pred) {
+			continue
+		}
+		v := ret.Results[errIdx]
+		if phi, ok := v.(*ir.Phi); ok && phi.Block() == fn.Exit {
+			v = phi.Edges[k]
+		}
+		if !alwaysNil(v) {
+			return false
+		}
+	}
+	cache[fn] = true
+	pass.ExportObjectFact(obj, &infallibleFact{})
+	return true
+}
@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+)
+
+func infallible1() error { // want infallible1:`never fails`
+	return nil
+}
+
+func infallible2(buf *bytes.Buffer, s string) error { // want infallible2:`dereferences \[0\], checks \[\]` infallible2:`never fails`
+	_, err := buf.WriteString(s)
+	return err
+}
+
+func infallible3(sb *strings.Builder, b []byte) (int, error) { // want infallible3:`dereferences \[0\], checks \[\]` infallible3:`never fails`
+	return sb.Write(b)
+}
+
+func infallible4(buf *bytes.Buffer, s string) error { // want infallible4:`dereferences \[0\], checks \[\]` infallible4:`never fails`
+	if err := infallible2(buf, s); err != nil {
+		return err
+	}
+	return infallible1()
+}
+
+func infallible5(x bool) error { // want infallible5:`never fails`
+	var err error
+	if x {
+		log.Println("x")
+		err = infallible1()
+	}
+	return err
+}
+
+func infallible6(err error) error { // want infallible6:`dereferences \[\], checks \[0\]` infallible6:`never fails`
+	if err == nil {
+		log.Println("nil")
+		return err
+	}
+	panic(err)
+}
+
+func fallible1(x bool) error {
+	if x {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func fallible2(err error) error {
+	return err
+}
+
+func fallible3(x bool) error {
+	if err := fallible1(x); err != nil {
+		return err
+	}
+	return nil
+}
+
+func fallible4(b []byte, w interface{ Write([]byte) (int, error) }) error { // want fallible4:`dereferences \[1\], checks \[\]`
+	_, err := w.Write(b)
+	return err
+}
@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+)
+
+type R struct{ x int }
+
+func open1(fail bool) (*R, error) { // want open1:`nil on error: \[0\]`
+	if fail {
+		return nil, errors.New("failed")
+	}
+	return &R{}, nil
+}
+
+func open2(fail bool) (*R, error) { // want open2:`nil on error: \[0\]`
+	r, err := open1(fail)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func open3(fail bool) (*R, error) { // want open3:`nil on error: \[0\]`
+	return open1(fail)
+}
+
+func open4(fail bool) (*R, int, error) { // want open4:`nil on error: \[0\]`
+	if fail {
+		return nil, 1, errors.New("failed")
+	}
+	return &R{}, 0, nil
+}
+
+func open5(fail bool) (*R, error) { // want open5:`nil on error: \[0\]`
+	r, err := open1(fail)
+	if err == nil {
+		return r, nil
+	}
+	return nil, err
+}
+
+func open6(name string) (*os.File, error) { // want open6:`nil on error: \[0\]`
+	return os.Open(name)
+}
+
+func partial(fail bool) (*R, error) {
+	r := &R{}
+	if fail {
+		return r, errors.New("failed")
+	}
+	return r, nil
+}
+
+func unknown(fail bool) (*R, error) { // want unknown:`never returns nil: \[never nil\]`
+	_, err := open1(fail)
+	return &R{}, err
+}
+
+func swapped(fail bool) (*R, error) {
+	r1, _ := open1(fail)
+	_, err2 := open1(!fail)
+	return r1, err2
+}
+
+func noError() (*R, bool) {
+	return nil, false
+}
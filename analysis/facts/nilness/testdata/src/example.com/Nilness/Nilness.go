@@ -54,7 +54,7 @@ func fn11(x *T) *T {
 	return x
 }
 
-func fn12(x *T) *int {
+func fn12(x *T) *int { // want fn12:`dereferences \[0\], checks \[\]`
 	return x.f
 }
 
@@ -113,3 +113,28 @@ func fn28[T [8]int]() T {
 func fn29[T []int]() T { // want fn29:`never returns nil: \[never\]`
 	return T{}
 }
+
+type maker interface{ make() *T }
+
+type maker1 struct{}
+type maker2 struct{}
+
+func (maker1) make() *T  { return &T{} }   // want make:`never returns nil: \[never\]`
+func (*maker2) make() *T { return new(T) } // want make:`never returns nil: \[never\]`
+
+func fn40() *T { // want fn40:`never returns nil: \[never\]`
+	var m maker = maker1{}
+	if true {
+		m = &maker2{}
+	}
+	return m.make()
+}
+
+func fn41(m maker) *T { // want fn41:`dereferences \[0\], checks \[\]`
+	return m.make()
+}
+
+func fn42() *T { // want fn42:`never returns nil: \[never\]`
+	f := fn3
+	return f()
+}
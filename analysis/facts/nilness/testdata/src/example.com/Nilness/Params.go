@@ -0,0 +1,74 @@
+package pkg
+
+type P struct{ x int }
+
+func (p *P) get() int { return p.x } // want get:`dereferences \[0\], checks \[\]`
+
+func (p P) val() int { return p.x }
+
+func deref1(p *P) int { return p.x } // want deref1:`dereferences \[0\], checks \[\]`
+
+func deref2(a int, p *P) int { // want deref2:`dereferences \[1\], checks \[\]`
+	if a > 0 {
+		return p.x + a
+	}
+	return p.x
+}
+
+func deref3(p *P) int { // want deref3:`dereferences \[0\], checks \[\]`
+	return deref1(p)
+}
+
+func deref4(p *P) int { // want deref4:`dereferences \[0\], checks \[\]`
+	return p.get()
+}
+
+func deref5(m map[string]int) { // want deref5:`dereferences \[0\], checks \[\]`
+	m["k"] = 1
+}
+
+func deref6(x interface{ get() int }) int { // want deref6:`dereferences \[0\], checks \[\]`
+	return x.get()
+}
+
+func deref7(f func() int) int { // want deref7:`dereferences \[0\], checks \[\]`
+	return f()
+}
+
+func conditional1(a int, p *P) int {
+	if a > 0 {
+		return p.x
+	}
+	return 0
+}
+
+func checked1(p *P) int { // want checked1:`dereferences \[\], checks \[0\]`
+	if p == nil {
+		return 0
+	}
+	return p.x
+}
+
+func checked2(p *P) int { // want checked2:`dereferences \[0\], checks \[0\]`
+	x := p.x
+	if p != nil {
+		println("not nil")
+	}
+	return x
+}
+
+func deferred(p *P) int {
+	defer func() { recover() }()
+	return p.x
+}
+
+func notDeref(m map[string]int, s []int) int {
+	return m["k"] + len(s)
+}
+
+func panics(p *P) int { // want panics:`dereferences \[0\], checks \[0\]`
+	if p == nil {
+		panic("nil")
+	}
+	return p.x
+}
@@ -1,60 +1,111 @@
 package purity
 
-// TODO(dh): we should split this into two facts, one tracking actual purity, and one tracking side-effects. A function
-// that returns a heap allocation isn't pure, but it may be free of side effects.
-
 import (
 	"go/types"
 	"reflect"
 
+	"honnef.co/go/tools/config"
 	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/callgraph"
 	"honnef.co/go/tools/go/ir/irutil"
+	"honnef.co/go/tools/go/types/typeutil"
+	"honnef.co/go/tools/internal/passes/buildcallgraph"
 	"honnef.co/go/tools/internal/passes/buildir"
 
 	"golang.org/x/tools/go/analysis"
 )
 
+// IsPure marks functions that have no side effects, whose results depend only on their arguments, and that don't
+// return fresh allocations. Pure functions are also side-effect free.
 type IsPure struct{}
 
 func (*IsPure) AFact()           {}
 func (d *IsPure) String() string { return "is pure" }
 
-type Result map[*types.Func]*IsPure
+// IsSideEffectFree marks functions that have no observable side effects. Unlike pure functions, they may read global
+// state and return fresh allocations, such as fmt.Sprintf.
+type IsSideEffectFree struct{}
+
+func (*IsSideEffectFree) AFact()           {}
+func (d *IsSideEffectFree) String() string { return "is side-effect free" }
+
+type Result struct {
+	Pure           map[*types.Func]*IsPure
+	SideEffectFree map[*types.Func]*IsSideEffectFree
+}
 
 var Analyzer = &analysis.Analyzer{
 	Name:       "fact_purity",
-	Doc:        "Mark pure functions",
+	Doc:        "Mark pure and side-effect free functions",
 	Run:        purity,
-	Requires:   []*analysis.Analyzer{buildir.Analyzer},
-	FactTypes:  []analysis.Fact{(*IsPure)(nil)},
-	ResultType: reflect.TypeOf(Result{}),
+	Requires:   []*analysis.Analyzer{buildir.Analyzer, buildcallgraph.Analyzer, config.Analyzer},
+	FactTypes:  []analysis.Fact{(*IsPure)(nil), (*IsSideEffectFree)(nil)},
+	ResultType: reflect.TypeOf((*Result)(nil)),
 }
 
 var pureStdlib = map[string]struct{}{
+	"strings.Repeat":         {},
+	"strings.Replace":        {},
+	"strings.Title":          {},
+	"strings.ToLower":        {},
+	"strings.ToTitle":        {},
+	"strings.ToUpper":        {},
+	"strings.Trim":           {},
+	"strings.TrimLeft":       {},
+	"strings.TrimPrefix":     {},
+	"strings.TrimRight":      {},
+	"strings.TrimSpace":      {},
+	"strings.TrimSuffix":     {},
+	"(time.Time).Add":        {},
+	"(time.Time).AddDate":    {},
+	"(time.Time).After":      {},
+	"(time.Time).Before":     {},
+	"(time.Time).Clock":      {},
+	"(time.Time).Compare":    {},
+	"(time.Time).Date":       {},
+	"(time.Time).Day":        {},
+	"(time.Time).Equal":      {},
+	"(time.Time).Format":     {},
+	"(time.Time).GoString":   {},
+	"(time.Time).Hour":       {},
+	"(time.Time).ISOWeek":    {},
+	"(time.Time).In":         {},
+	"(time.Time).IsDST":      {},
+	"(time.Time).IsZero":     {},
+	"(time.Time).Location":   {},
+	"(time.Time).Minute":     {},
+	"(time.Time).Month":      {},
+	"(time.Time).Nanosecond": {},
+	"(time.Time).Round":      {},
+	"(time.Time).Second":     {},
+	"(time.Time).String":     {},
+	"(time.Time).Sub":        {},
+	"(time.Time).Truncate":   {},
+	"(time.Time).UTC":        {},
+	"(time.Time).Unix":       {},
+	"(time.Time).UnixMicro":  {},
+	"(time.Time).UnixMilli":  {},
+	"(time.Time).UnixNano":   {},
+	"(time.Time).Weekday":    {},
+	"(time.Time).Year":       {},
+	"(time.Time).YearDay":    {},
+	"(time.Time).Zone":       {},
+	"(time.Time).ZoneBounds": {},
+}
+
+var sideEffectFreeStdlib = map[string]struct{}{
 	"errors.New":                      {},
 	"fmt.Errorf":                      {},
 	"fmt.Sprintf":                     {},
 	"fmt.Sprint":                      {},
 	"sort.Reverse":                    {},
 	"strings.Map":                     {},
-	"strings.Repeat":                  {},
-	"strings.Replace":                 {},
-	"strings.Title":                   {},
-	"strings.ToLower":                 {},
 	"strings.ToLowerSpecial":          {},
-	"strings.ToTitle":                 {},
 	"strings.ToTitleSpecial":          {},
-	"strings.ToUpper":                 {},
 	"strings.ToUpperSpecial":          {},
-	"strings.Trim":                    {},
 	"strings.TrimFunc":                {},
-	"strings.TrimLeft":                {},
 	"strings.TrimLeftFunc":            {},
-	"strings.TrimPrefix":              {},
-	"strings.TrimRight":               {},
 	"strings.TrimRightFunc":           {},
-	"strings.TrimSpace":               {},
-	"strings.TrimSuffix":              {},
 	"(*net/http.Request).WithContext": {},
 	"time.Now":                        {},
 	"time.Parse":                      {},
@@ -62,203 +113,345 @@ var pureStdlib = map[string]struct{}{
 	"time.Unix":                       {},
 	"time.UnixMicro":                  {},
 	"time.UnixMilli":                  {},
-	"(time.Time).Add":                 {},
-	"(time.Time).AddDate":             {},
-	"(time.Time).After":               {},
-	"(time.Time).Before":              {},
-	"(time.Time).Clock":               {},
-	"(time.Time).Compare":             {},
-	"(time.Time).Date":                {},
-	"(time.Time).Day":                 {},
-	"(time.Time).Equal":               {},
-	"(time.Time).Format":              {},
-	"(time.Time).GoString":            {},
 	"(time.Time).GobEncode":           {},
-	"(time.Time).Hour":                {},
-	"(time.Time).ISOWeek":             {},
-	"(time.Time).In":                  {},
-	"(time.Time).IsDST":               {},
-	"(time.Time).IsZero":              {},
 	"(time.Time).Local":               {},
-	"(time.Time).Location":            {},
 	"(time.Time).MarshalBinary":       {},
 	"(time.Time).MarshalJSON":         {},
 	"(time.Time).MarshalText":         {},
-	"(time.Time).Minute":              {},
-	"(time.Time).Month":               {},
-	"(time.Time).Nanosecond":          {},
-	"(time.Time).Round":               {},
-	"(time.Time).Second":              {},
-	"(time.Time).String":              {},
-	"(time.Time).Sub":                 {},
-	"(time.Time).Truncate":            {},
-	"(time.Time).UTC":                 {},
-	"(time.Time).Unix":                {},
-	"(time.Time).UnixMicro":           {},
-	"(time.Time).UnixMilli":           {},
-	"(time.Time).UnixNano":            {},
-	"(time.Time).Weekday":             {},
-	"(time.Time).Year":                {},
-	"(time.Time).YearDay":             {},
-	"(time.Time).Zone":                {},
-	"(time.Time).ZoneBounds":          {},
 }
 
-func purity(pass *analysis.Pass) (interface{}, error) {
-	seen := map[*ir.Function]struct{}{}
-	irpkg := pass.ResultOf[buildir.Analyzer].(*buildir.IR).Pkg
-	var check func(fn *ir.Function) (ret bool)
-	check = func(fn *ir.Function) (ret bool) {
-		if fn.Object() == nil {
-			// TODO(dh): support closures
+type checker struct {
+	pass  *analysis.Pass
+	irpkg *ir.Package
+	cg    *callgraph.Graph
+	cfg   *config.Config
+	seen  map[*ir.Function]struct{}
+
+	// pure selects between checking for purity and checking for freedom of side effects.
+	pure bool
+}
+
+// declared reports whether the user declared fn to be pure.
+func (c *checker) declared(fn *types.Func) bool {
+	sem, ok := c.cfg.Function(typeutil.FuncName(fn.Origin()))
+	return ok && sem.Pure
+}
+
+func (c *checker) importFact(fn *ir.Function) bool {
+	if c.pure {
+		return c.pass.ImportObjectFact(fn.Object(), new(IsPure))
+	}
+	return c.pass.ImportObjectFact(fn.Object(), new(IsSideEffectFree))
+}
+
+func (c *checker) exportFact(fn *ir.Function) {
+	if c.pure {
+		c.pass.ExportObjectFact(fn.Object(), &IsPure{})
+	} else {
+		c.pass.ExportObjectFact(fn.Object(), &IsSideEffectFree{})
+	}
+}
+
+func (c *checker) inStdlib(name string) bool {
+	if _, ok := pureStdlib[name]; ok {
+		return true
+	}
+	if !c.pure {
+		_, ok := sideEffectFreeStdlib[name]
+		return ok
+	}
+	return false
+}
+
+func (c *checker) check(fn *ir.Function) (ret bool) {
+	if fn.Object() == nil {
+		// TODO(dh): support closures
+		return false
+	}
+	if c.importFact(fn) {
+		return true
+	}
+	if fn.Pkg != c.irpkg {
+		// Function is in another package but wasn't marked as
+		// pure, ergo it isn't pure, unless the user says so
+		return c.declared(fn.Object().(*types.Func))
+	}
+	// Break recursion
+	if _, ok := c.seen[fn]; ok {
+		return false
+	}
+
+	c.seen[fn] = struct{}{}
+	defer func() {
+		if ret {
+			c.exportFact(fn)
+		}
+	}()
+
+	if irutil.IsStub(fn) {
+		return false
+	}
+
+	if c.inStdlib(fn.Object().(*types.Func).FullName()) {
+		return true
+	}
+	if c.declared(fn.Object().(*types.Func)) {
+		return true
+	}
+
+	if fn.Signature.Results().Len() == 0 {
+		// A function with no return values is empty or is doing some
+		// work we cannot see (for example because of build tags);
+		// don't consider it pure.
+		return false
+	}
+
+	// Don't consider external functions pure.
+	if fn.Blocks == nil {
+		return false
+	}
+
+	if c.pure {
+		// Side-effect freedom is checked before purity, and pure
+		// functions must be free of side effects.
+		if !c.pass.ImportObjectFact(fn.Object(), new(IsSideEffectFree)) {
 			return false
 		}
-		if pass.ImportObjectFact(fn.Object(), new(IsPure)) {
+		return c.checkPure(fn)
+	}
+	return c.checkSideEffectFree(fn)
+}
+
+func (c *checker) checkCall(fn *ir.Function, site ir.CallInstruction) bool {
+	common := site.Common()
+	if builtin, ok := common.Value.(*ir.Builtin); ok {
+		switch builtin.Name() {
+		case "len", "cap":
 			return true
 		}
-		if fn.Pkg != irpkg {
-			// Function is in another package but wasn't marked as
-			// pure, ergo it isn't pure
+		if c.pure {
 			return false
 		}
-		// Break recursion
-		if _, ok := seen[fn]; ok {
+		switch builtin.Name() {
+		case "min", "max", "real", "imag", "complex":
+			return true
+		case "append", "copy", "delete":
+			// These builtins write to their first argument.
+			return fresh(common.Args[0])
+		default:
 			return false
 		}
+	}
 
-		seen[fn] = struct{}{}
-		defer func() {
-			if ret {
-				pass.ExportObjectFact(fn.Object(), &IsPure{})
-			}
-		}()
-
-		if irutil.IsStub(fn) {
+	if callee := c.concreteCallee(common); callee != nil {
+		return callee == fn || c.check(callee)
+	}
+	// Dynamic calls are pure if all of their possible
+	// callees are.
+	callees := c.cg.Callees(site)
+	if len(callees) == 0 || c.cg.Unresolved[site] {
+		return false
+	}
+	for _, callee := range callees {
+		if callee != fn && !c.check(callee) {
 			return false
 		}
+	}
+	return true
+}
 
-		if _, ok := pureStdlib[fn.Object().(*types.Func).FullName()]; ok {
-			return true
-		}
-
-		if fn.Signature.Results().Len() == 0 {
-			// A function with no return values is empty or is doing some
-			// work we cannot see (for example because of build tags);
-			// don't consider it pure.
-			return false
-		}
+// concreteCallee returns the function called by an invocation of an interface method on a value whose concrete type
+// is known, or nil.
+func (c *checker) concreteCallee(common *ir.CallCommon) *ir.Function {
+	if !common.IsInvoke() {
+		return nil
+	}
+	mi, ok := irutil.Flatten(common.Value).(*ir.MakeInterface)
+	if !ok {
+		return nil
+	}
+	obj, _, _ := types.LookupFieldOrMethod(mi.X.Type(), false, common.Method.Pkg(), common.Method.Name())
+	method, ok := obj.(*types.Func)
+	if !ok {
+		return nil
+	}
+	return c.irpkg.Prog.FuncValue(method)
+}
 
-		var isBasic func(typ types.Type) bool
-		isBasic = func(typ types.Type) bool {
-			switch u := typ.Underlying().(type) {
-			case *types.Basic:
-				return true
-			case *types.Struct:
-				for i := 0; i < u.NumFields(); i++ {
-					if !isBasic(u.Field(i).Type()) {
-						return false
-					}
+func (c *checker) checkSideEffectFree(fn *ir.Function) bool {
+	for _, b := range fn.Blocks {
+		for _, ins := range b.Instrs {
+			switch ins := ins.(type) {
+			case *ir.Call:
+				if !c.checkCall(fn, ins) {
+					return false
+				}
+			case *ir.Defer:
+				if !c.checkCall(fn, ins) {
+					return false
 				}
-				return true
-			default:
+			case *ir.Select, *ir.Send, *ir.Recv, *ir.Go, *ir.Panic:
 				return false
+			case *ir.Store:
+				if !fresh(ins.Addr) {
+					return false
+				}
+			case *ir.MapUpdate:
+				if !fresh(ins.Map) {
+					return false
+				}
 			}
 		}
+	}
+	return true
+}
 
-		for _, param := range fn.Params {
-			// TODO(dh): this may not be strictly correct. pure code can, to an extent, operate on non-basic types.
-			if !isBasic(param.Type()) {
-				return false
+func (c *checker) checkPure(fn *ir.Function) bool {
+	var isBasic func(typ types.Type) bool
+	isBasic = func(typ types.Type) bool {
+		switch u := typ.Underlying().(type) {
+		case *types.Basic:
+			return true
+		case *types.Struct:
+			for i := 0; i < u.NumFields(); i++ {
+				if !isBasic(u.Field(i).Type()) {
+					return false
+				}
 			}
+			return true
+		default:
+			return false
 		}
+	}
 
-		// Don't consider external functions pure.
-		if fn.Blocks == nil {
+	for _, param := range fn.Params {
+		// TODO(dh): this may not be strictly correct. pure code can, to an extent, operate on non-basic types.
+		if !isBasic(param.Type()) {
 			return false
 		}
-		checkCall := func(common *ir.CallCommon) bool {
-			if common.IsInvoke() {
-				return false
-			}
-			builtin, ok := common.Value.(*ir.Builtin)
-			if !ok {
-				if common.StaticCallee() != fn {
-					if common.StaticCallee() == nil {
-						return false
-					}
-					if !check(common.StaticCallee()) {
-						return false
-					}
+	}
+
+	var isStackAddr func(ir.Value) bool
+	isStackAddr = func(v ir.Value) bool {
+		switch v := v.(type) {
+		case *ir.Alloc:
+			return !v.Heap
+		case *ir.FieldAddr:
+			return isStackAddr(v.X)
+		default:
+			return false
+		}
+	}
+	for _, b := range fn.Blocks {
+		for _, ins := range b.Instrs {
+			switch ins := ins.(type) {
+			case *ir.Call:
+				if !c.checkCall(fn, ins) {
+					return false
 				}
-			} else {
-				switch builtin.Name() {
-				case "len", "cap":
-				default:
+			case *ir.Defer:
+				if !c.checkCall(fn, ins) {
+					return false
+				}
+			case *ir.Store:
+				if !isStackAddr(ins.Addr) {
 					return false
 				}
-			}
-			return true
-		}
-
-		var isStackAddr func(ir.Value) bool
-		isStackAddr = func(v ir.Value) bool {
-			switch v := v.(type) {
-			case *ir.Alloc:
-				return !v.Heap
 			case *ir.FieldAddr:
-				return isStackAddr(v.X)
-			default:
-				return false
-			}
-		}
-		for _, b := range fn.Blocks {
-			for _, ins := range b.Instrs {
-				switch ins := ins.(type) {
-				case *ir.Call:
-					if !checkCall(ins.Common()) {
-						return false
-					}
-				case *ir.Defer:
-					if !checkCall(&ins.Call) {
-						return false
-					}
-				case *ir.Select:
+				if !isStackAddr(ins.X) {
 					return false
-				case *ir.Send:
+				}
+			case *ir.Alloc:
+				// TODO(dh): make use of proper escape analysis
+				if ins.Heap {
 					return false
-				case *ir.Go:
+				}
+			case *ir.MakeMap, *ir.MakeSlice, *ir.MakeChan, *ir.MakeClosure:
+				return false
+			case *ir.Convert:
+				if _, ok := ins.Type().Underlying().(*types.Slice); ok {
+					// Converting a string to a slice allocates.
 					return false
-				case *ir.Panic:
+				}
+			case *ir.Load:
+				if !isStackAddr(ins.X) {
 					return false
-				case *ir.Store:
-					if !isStackAddr(ins.Addr) {
-						return false
-					}
-				case *ir.FieldAddr:
-					if !isStackAddr(ins.X) {
-						return false
-					}
-				case *ir.Alloc:
-					// TODO(dh): make use of proper escape analysis
-					if ins.Heap {
-						return false
-					}
-				case *ir.Load:
-					if !isStackAddr(ins.X) {
-						return false
-					}
 				}
 			}
 		}
+	}
+	return true
+}
+
+// fresh reports whether v refers to memory that was allocated by the
+// current invocation of the function, writes to which aren't
+// observable by the caller before the function returns.
+func fresh(v ir.Value) bool {
+	switch v := irutil.Flatten(v).(type) {
+	case *ir.Alloc, *ir.MakeSlice, *ir.MakeMap:
 		return true
+	case *ir.FieldAddr:
+		return fresh(v.X)
+	case *ir.IndexAddr:
+		return fresh(v.X)
+	case *ir.Slice:
+		return fresh(v.X)
+	case *ir.Const:
+		return v.Value == nil
+	default:
+		return false
 	}
-	for _, fn := range pass.ResultOf[buildir.Analyzer].(*buildir.IR).SrcFuncs {
-		check(fn)
+}
+
+func purity(pass *analysis.Pass) (interface{}, error) {
+	irpkg := pass.ResultOf[buildir.Analyzer].(*buildir.IR).Pkg
+	srcFuncs := pass.ResultOf[buildir.Analyzer].(*buildir.IR).SrcFuncs
+	c := &checker{
+		pass:  pass,
+		irpkg: irpkg,
+		cg:    pass.ResultOf[buildcallgraph.Analyzer].(*callgraph.Graph),
+		cfg:   config.For(pass),
+	}
+	// Purity depends on side-effect freedom, so we have to compute the latter first.
+	for _, pure := range []bool{false, true} {
+		c.pure = pure
+		c.seen = map[*ir.Function]struct{}{}
+		for _, fn := range srcFuncs {
+			c.check(fn)
+		}
 	}
 
-	out := Result{}
+	out := &Result{
+		Pure:           map[*types.Func]*IsPure{},
+		SideEffectFree: map[*types.Func]*IsSideEffectFree{},
+	}
 	for _, fact := range pass.AllObjectFacts() {
-		out[fact.Object.(*types.Func)] = fact.Fact.(*IsPure)
+		switch f := fact.Fact.(type) {
+		case *IsPure:
+			out.Pure[fact.Object.(*types.Func)] = f
+		case *IsSideEffectFree:
+			out.SideEffectFree[fact.Object.(*types.Func)] = f
+		}
+	}
+	// Functions in other packages that the user declared to be pure
+	// don't have facts.
+	for _, fn := range srcFuncs {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				call, ok := instr.(ir.CallInstruction)
+				if !ok {
+					continue
+				}
+				callee := call.Common().StaticCallee()
+				if callee == nil || callee.Pkg == irpkg {
+					continue
+				}
+				if obj, ok := callee.Object().(*types.Func); ok && c.declared(obj) {
+					out.Pure[obj] = &IsPure{}
+					out.SideEffectFree[obj] = &IsSideEffectFree{}
+				}
+			}
+		}
 	}
 	return out, nil
 }
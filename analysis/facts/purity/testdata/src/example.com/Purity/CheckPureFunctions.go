@@ -1,6 +1,11 @@
 package pkg
 
-func foo(a, b int) int { return a + b } // want foo:"is pure"
+import (
+	"fmt"
+	"strings"
+)
+
+func foo(a, b int) int { return a + b } // want foo:"is pure" foo:"is side-effect free"
 func bar(a, b int) int {
 	println(a + b)
 	return a + b
@@ -16,14 +21,14 @@ func fn3() {
 	stubInt()
 }
 
-func ptr1() *int { return new(int) }
-func ptr2() *int { var x int; return &x }
-func lit() []int { return []int{} }
+func ptr1() *int { return new(int) }      // want ptr1:"is side-effect free"
+func ptr2() *int { var x int; return &x } // want ptr2:"is side-effect free"
+func lit() []int { return []int{} }       // want lit:"is side-effect free"
 
 var X int
 
-func load() int        { _ = X; return 0 }
-func assign(x int) int { _ = x; return 0 } // want assign:"is pure"
+func load() int        { _ = X; return 0 } // want load:"is side-effect free"
+func assign(x int) int { _ = x; return 0 } // want assign:"is pure" assign:"is side-effect free"
 
 type pureStruct1 struct {
 	a int
@@ -35,6 +40,81 @@ type pureStruct2 struct {
 	c float64
 }
 
-func (arg pureStruct1) get() int { // want get:"is pure"
+func (arg pureStruct1) get() int { // want get:"is pure" get:"is side-effect free"
 	return arg.a
 }
+
+type adder interface{ add(a, b int) int }
+
+type pureAdder struct{}
+
+func (pureAdder) add(a, b int) int { return a + b } // want add:"is pure" add:"is side-effect free"
+
+func invoke(a, b int) int { // want invoke:"is pure" invoke:"is side-effect free"
+	var x adder = pureAdder{}
+	return x.add(a, b)
+}
+
+func dynamic(a, b int) int { // want dynamic:"is pure" dynamic:"is side-effect free"
+	f := foo
+	return f(a, b)
+}
+
+func store() int { X = 1; return 0 }
+
+func storeFresh() *[2]int { // want storeFresh:"is side-effect free"
+	x := new([2]int)
+	x[0] = 1
+	return x
+}
+
+func mapFresh(k string) map[string]int { // want mapFresh:"is side-effect free"
+	m := map[string]int{}
+	m[k] = 1
+	return m
+}
+
+func mapParam(m map[string]int) int {
+	m["k"] = 1
+	return 0
+}
+
+func appendParam(s []int) []int { return append(s, 1) }
+
+func appendFresh(n int) []int { // want appendFresh:"is side-effect free"
+	s := make([]int, 0, n)
+	return append(s, 1)
+}
+
+func callFree() int { // want callFree:"is side-effect free"
+	return *ptr1()
+}
+
+func sprintf(x int) string { // want sprintf:"is side-effect free"
+	return fmt.Sprintf("%d", x)
+}
+
+func toLower(s string) string { // want toLower:"is pure" toLower:"is side-effect free"
+	return strings.ToLower(s)
+}
+
+type counter struct{ n int }
+
+func (c *counter) inc() int { c.n++; return c.n }
+
+func (c *counter) get() int { return c.n } // want get:"is side-effect free"
+
+type getter interface{ get() int }
+type incrementer interface{ inc() int }
+
+func invokeFree(c *counter) int { // want invokeFree:"is side-effect free"
+	var x getter = c
+	return x.get()
+}
+
+func invokeEffect(c *counter) int {
+	var x incrementer = c
+	return x.inc()
+}
+
+func recv(ch chan int) int { return <-ch }
@@ -0,0 +1,1001 @@
+// Package resources finds resources, such as files, HTTP response bodies and the cancel functions of contexts, that
+// aren't closed on all paths.
+//
+// Resources are the results of well-known functions like os.Open, and of functions that return such resources
+// without closing them. A resource is tracked through the values that alias it, which are computed with package dfa.
+// The aliases include values that wrap the resource, such as a *bufio.Reader created from a file, or structs that store
+// it in a field.
+//
+// A resource is consumed when it, or one of its aliases, is closed, or when it escapes: when it is returned, stored in
+// memory that the function doesn't own, captured by a closure or passed to a function that consumes it. Functions are
+// summarized by facts, which record the parameters that they consume, return, or store in other parameters.
+//
+// A resource leaks if there is a path from where it is opened to a return statement that doesn't consume it. Paths on
+// which the resource is known to be nil, because of an error check or a nil check, are ignored, as are paths that end
+// in panics or calls that never return.
+package resources
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"math/bits"
+	"reflect"
+	"sort"
+
+	"honnef.co/go/tools/analysis/dfa"
+	"honnef.co/go/tools/config"
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/irutil"
+	"honnef.co/go/tools/go/types/typeutil"
+	"honnef.co/go/tools/internal/passes/buildir"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Summary summarizes how a function handles the resources passed to it, and whether it returns new resources.
+// Parameters are numbered like in ir.Function.Params, that is, receivers count as the first parameter.
+type Summary struct {
+	// Closes lists the parameters that the function closes, or that escape so that the caller doesn't have to close
+	// them anymore.
+	Closes []int
+	// Returns lists the parameters that the function returns, possibly wrapped in other values.
+	Returns []int
+	// Stores lists pairs of parameters, where the first parameter is stored in memory reachable from the second,
+	// such as by a method that assigns to a field of its receiver.
+	Stores [][2]int
+	// Opens lists the results that hold new resources, which the caller has to close.
+	Opens []int
+}
+
+func (*Summary) AFact() {}
+
+func (s *Summary) String() string {
+	return fmt.Sprintf("resources(closes: %v, returns: %v, stores: %v, opens: %v)", s.Closes, s.Returns, s.Stores, s.Opens)
+}
+
+func (s *Summary) empty() bool {
+	return len(s.Closes) == 0 && len(s.Returns) == 0 && len(s.Stores) == 0 && len(s.Opens) == 0
+}
+
+func (s *Summary) equal(o *Summary) bool {
+	return reflect.DeepEqual(s, o)
+}
+
+func contains(s []int, i int) bool {
+	for _, el := range s {
+		if el == i {
+			return true
+		}
+	}
+	return false
+}
+
+// A Step is a step on the path on which a resource leaks.
+type Step struct {
+	At          token.Pos
+	Description string
+}
+
+func (s Step) Pos() token.Pos { return s.At }
+
+// A Leak is a resource that isn't closed on at least one path.
+type Leak struct {
+	// Source is the call that opened the resource.
+	Source *ir.Call
+	// Type is the type of the resource.
+	Type types.Type
+	// Never is true if the resource isn't closed on any path.
+	Never bool
+	// Cancel is true if the resource is a context's cancel function, which is closed by calling it.
+	Cancel bool
+	// Return is the position of the return statement that the leaking path ends at.
+	Return token.Pos
+	// Path lists the steps from opening the resource to returning without closing it.
+	Path []Step
+}
+
+// Result lists the leaks in a package.
+type Result struct {
+	Leaks []Leak
+}
+
+var Analyzer = &analysis.Analyzer{
+	Name:       "fact_resources",
+	Doc:        "Find resources that aren't closed on all paths",
+	Run:        run,
+	Requires:   []*analysis.Analyzer{buildir.Analyzer, config.Analyzer},
+	FactTypes:  []analysis.Fact{(*Summary)(nil)},
+	ResultType: reflect.TypeOf((*Result)(nil)),
+}
+
+// openers lists well-known functions that return new resources, and the indices of those results.
+var openers = map[string]int{
+	"os.Create":     0,
+	"os.CreateTemp": 0,
+	"os.Open":       0,
+	"os.OpenFile":   0,
+
+	"net.Dial":        0,
+	"net.DialTimeout": 0,
+	"net.Listen":      0,
+
+	"net/http.Get":                        0,
+	"net/http.Head":                       0,
+	"net/http.Post":                       0,
+	"net/http.PostForm":                   0,
+	"(*net/http.Client).Do":               0,
+	"(*net/http.Client).Get":              0,
+	"(*net/http.Client).Head":             0,
+	"(*net/http.Client).Post":             0,
+	"(*net/http.Client).PostForm":         0,
+	"(*database/sql.DB).Prepare":          0,
+	"(*database/sql.DB).Query":            0,
+	"(*database/sql.Conn).Prepare":        0,
+	"(*database/sql.Stmt).Query":          0,
+	"(*database/sql.Tx).Prepare":          0,
+	"(*database/sql.Tx).Query":            0,
+	"(*database/sql.DB).PrepareContext":   0,
+	"(*database/sql.DB).QueryContext":     0,
+	"(*database/sql.Conn).PrepareContext": 0,
+	"(*database/sql.Conn).QueryContext":   0,
+	"(*database/sql.Stmt).QueryContext":   0,
+	"(*database/sql.Tx).PrepareContext":   0,
+	"(*database/sql.Tx).QueryContext":     0,
+
+	// The cancel functions of contexts are resources, too. They are closed by calling them.
+	"context.WithCancel":        1,
+	"context.WithCancelCause":   1,
+	"context.WithDeadline":      1,
+	"context.WithDeadlineCause": 1,
+	"context.WithTimeout":       1,
+	"context.WithTimeoutCause":  1,
+}
+
+// maxBits is the number of parameters and resources per function that we track.
+const maxBits = 64
+
+// A set is a set of parameters and resources. The first bits represent the function's parameters, the remaining bits
+// represent the resources opened in the function.
+type set uint64
+
+// closable reports whether the caller can close values of type T, either by calling their Close method or, for
+// cancel functions, by calling them.
+func closable(T types.Type) bool {
+	if _, ok := T.Underlying().(*types.Signature); ok {
+		return true
+	}
+	obj, _, _ := types.LookupFieldOrMethod(T, true, nil, "Close")
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// mayHold reports whether values of type T may hold resources.
+func mayHold(T types.Type) bool {
+	return mayHold1(T, map[types.Type]bool{})
+}
+
+func mayHold1(T types.Type, seen map[types.Type]bool) bool {
+	if seen[T] {
+		return false
+	}
+	seen[T] = true
+	if typeutil.IsTypeWithName(T, "error") || typeutil.IsTypeWithName(T, "context.Context") {
+		return false
+	}
+	if obj, _, _ := types.LookupFieldOrMethod(T, true, nil, "Close"); obj != nil {
+		if _, ok := obj.(*types.Func); ok {
+			return true
+		}
+	}
+	switch T := T.Underlying().(type) {
+	case *types.Basic:
+		return T.Kind() == types.UnsafePointer
+	case *types.Pointer:
+		return mayHold1(T.Elem(), seen)
+	case *types.Slice:
+		return mayHold1(T.Elem(), seen)
+	case *types.Array:
+		return mayHold1(T.Elem(), seen)
+	case *types.Chan:
+		return mayHold1(T.Elem(), seen)
+	case *types.Map:
+		return mayHold1(T.Key(), seen) || mayHold1(T.Elem(), seen)
+	case *types.Struct:
+		for i := 0; i < T.NumFields(); i++ {
+			if mayHold1(T.Field(i).Type(), seen) {
+				return true
+			}
+		}
+		return false
+	case *types.Tuple:
+		for i := 0; i < T.Len(); i++ {
+			if mayHold1(T.At(i).Type(), seen) {
+				return true
+			}
+		}
+		return false
+	default:
+		// Interfaces, functions and type parameters
+		return true
+	}
+}
+
+// root returns the value that the memory addr points into belongs to.
+func root(addr ir.Value) ir.Value {
+	for {
+		switch v := addr.(type) {
+		case *ir.FieldAddr:
+			addr = v.X
+		case *ir.IndexAddr:
+			addr = v.X
+		case *ir.Slice:
+			addr = v.X
+		case *ir.Load:
+			addr = v.X
+		case *ir.ChangeType:
+			addr = v.X
+		case *ir.Sigma:
+			addr = v.X
+		default:
+			return addr
+		}
+	}
+}
+
+type analyzer struct {
+	pass      *analysis.Pass
+	cfg       *config.Config
+	irpkg     *ir.Package
+	summaries map[*ir.Function]*Summary
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	irp := pass.ResultOf[buildir.Analyzer].(*buildir.IR)
+	a := &analyzer{
+		pass:      pass,
+		cfg:       config.For(pass),
+		irpkg:     irp.Pkg,
+		summaries: map[*ir.Function]*Summary{},
+	}
+
+	// Compute summaries, reanalyzing the callers of functions whose summaries changed, until we reach a fixed
+	// point.
+	callers := map[*ir.Function][]*ir.Function{}
+	for _, fn := range irp.SrcFuncs {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if site, ok := instr.(ir.CallInstruction); ok {
+					if callee := site.Common().StaticCallee(); callee != nil && callee.Pkg == irp.Pkg {
+						callers[callee] = append(callers[callee], fn)
+					}
+				}
+			}
+		}
+	}
+	funcs := map[*ir.Function]*function{}
+	worklist := make([]*ir.Function, len(irp.SrcFuncs))
+	copy(worklist, irp.SrcFuncs)
+	queued := map[*ir.Function]bool{}
+	for _, fn := range worklist {
+		queued[fn] = true
+	}
+	// Summaries only grow, but they aren't merged with earlier summaries, which means that mutual recursion could in
+	// theory cause oscillation. We bound the number of iterations to be safe.
+	for n := 0; len(worklist) > 0 && n < 10*len(irp.SrcFuncs); n++ {
+		fn := worklist[0]
+		worklist = worklist[1:]
+		queued[fn] = false
+
+		f := a.analyze(fn)
+		funcs[fn] = f
+		sum := f.summarize()
+		if old, ok := a.summaries[fn]; ok && sum.equal(old) {
+			continue
+		}
+		a.summaries[fn] = sum
+		for _, caller := range callers[fn] {
+			if !queued[caller] {
+				worklist = append(worklist, caller)
+				queued[caller] = true
+			}
+		}
+	}
+
+	res := &Result{}
+	for _, fn := range irp.SrcFuncs {
+		f, ok := funcs[fn]
+		if !ok {
+			continue
+		}
+		res.Leaks = append(res.Leaks, f.leaks()...)
+		if fn.Object() == nil {
+			continue
+		}
+		if sum := a.summaries[fn]; !sum.empty() {
+			pass.ExportObjectFact(fn.Object(), sum)
+		}
+	}
+	for _, m := range irp.Pkg.Members {
+		// Functions without bodies, such as those implemented in assembly, may do anything with their arguments.
+		fn, ok := m.(*ir.Function)
+		if !ok || fn.Blocks != nil || fn.Object() == nil {
+			continue
+		}
+		sum := &Summary{}
+		for i, p := range fn.Params {
+			if mayHold(p.Type()) {
+				sum.Closes = append(sum.Closes, i)
+			}
+		}
+		if !sum.empty() {
+			pass.ExportObjectFact(fn.Object(), sum)
+		}
+	}
+	return res, nil
+}
+
+// summary returns the summary of fn, or nil if we know nothing about fn.
+func (a *analyzer) summary(fn *ir.Function) *Summary {
+	if fn.Pkg == a.irpkg {
+		if sum, ok := a.summaries[fn]; ok {
+			return sum
+		}
+		if fn.Blocks != nil {
+			// Not analyzed yet. We will reanalyze the caller once we have.
+			return &Summary{}
+		}
+		return nil
+	}
+	obj, ok := fn.Object().(*types.Func)
+	if !ok || obj.Pkg() == nil {
+		return nil
+	}
+	sum := new(Summary)
+	if a.pass.ImportObjectFact(obj.Origin(), sum) {
+		return sum
+	}
+	// Functions in other packages have been analyzed, and those without facts don't do anything with resources.
+	return &Summary{}
+}
+
+// opens returns the indices of the results of the call that hold new resources.
+func (a *analyzer) opens(call *ir.Call) []int {
+	common := call.Common()
+	if common.IsInvoke() {
+		return nil
+	}
+	callee, ok := common.Value.(*ir.Function)
+	if !ok {
+		return nil
+	}
+	if obj, ok := callee.Object().(*types.Func); ok {
+		name := typeutil.FuncName(obj.Origin())
+		if idx, ok := openers[name]; ok {
+			return []int{idx}
+		}
+		if sem, ok := a.cfg.Function(name); ok && sem.ReturnsCloser {
+			return []int{closerResult(obj)}
+		}
+	}
+	if sum := a.summary(callee); sum != nil {
+		return sum.Opens
+	}
+	return nil
+}
+
+// closerResult returns the index of the first result of fn that has a Close method, or 0 if there is none.
+func closerResult(fn *types.Func) int {
+	results := fn.Type().(*types.Signature).Results()
+	for i := 0; i < results.Len(); i++ {
+		obj, _, _ := types.LookupFieldOrMethod(results.At(i).Type(), true, nil, "Close")
+		if _, ok := obj.(*types.Func); ok {
+			return i
+		}
+	}
+	return 0
+}
+
+// function holds the analysis of a single function.
+type function struct {
+	a   *analyzer
+	fn  *ir.Function
+	ins *dfa.Instance[set]
+	// sources maps resources to the calls that opened them. Resources are numbered starting at len(fn.Params).
+	sources []*ir.Call
+	// consumers maps instructions to the sets that they consume.
+	consumers map[ir.Instruction]set
+	// stores records parameters that are stored in memory reachable from other parameters.
+	stores map[[2]int]struct{}
+}
+
+func (f *function) bit(v ir.Value) set {
+	if f.ins == nil {
+		return 0
+	}
+	return f.ins.Value(v)
+}
+
+func (a *analyzer) analyze(fn *ir.Function) *function {
+	f := &function{
+		a:         a,
+		fn:        fn,
+		consumers: map[ir.Instruction]set{},
+		stores:    map[[2]int]struct{}{},
+	}
+	sourceBits := map[*ir.Call]set{}
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			call, ok := instr.(*ir.Call)
+			if !ok || len(a.opens(call)) == 0 {
+				continue
+			}
+			n := len(fn.Params) + len(f.sources)
+			if n >= maxBits {
+				continue
+			}
+			sourceBits[call] = 1 << n
+			f.sources = append(f.sources, call)
+		}
+	}
+
+	fw := &dfa.Framework[set]{
+		Join:   func(a, b set) set { return a | b },
+		Bottom: 0,
+		Top:    ^set(0),
+	}
+	fw.Transfer = func(ins *dfa.Instance[set], instr ir.Instruction) []dfa.Mapping[set] {
+		return f.transfer(ins, instr, sourceBits)
+	}
+	f.ins = fw.Forward(fn)
+
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			if s := f.consumes(instr); s != 0 {
+				f.consumers[instr] = s
+			}
+		}
+	}
+	return f
+}
+
+func (f *function) transfer(ins *dfa.Instance[set], instr ir.Instruction, sourceBits map[*ir.Call]set) []dfa.Mapping[set] {
+	// alias maps dst to the union of its current set, which may have been added to by stores, and the sets of srcs.
+	alias := func(dst ir.Value, srcs ...ir.Value) []dfa.Mapping[set] {
+		d := ins.Value(dst)
+		for _, src := range srcs {
+			d |= ins.Value(src)
+		}
+		if d == 0 {
+			return nil
+		}
+		return dfa.Ms(dfa.M(dst, d, dfa.Decision{Inputs: srcs}))
+	}
+	// store records that the values of srcs are stored in memory reachable from addr. If addr belongs to a local
+	// allocation, then the allocation aliases the stored values.
+	store := func(addr ir.Value, srcs ...ir.Value) []dfa.Mapping[set] {
+		if r, ok := root(addr).(*ir.Alloc); ok {
+			return alias(r, srcs...)
+		}
+		return nil
+	}
+
+	switch instr := instr.(type) {
+	case *ir.Store:
+		return store(instr.Addr, instr.Val)
+	case *ir.MapUpdate:
+		return store(instr.Map, instr.Key, instr.Value)
+	}
+
+	if site, ok := instr.(ir.CallInstruction); ok {
+		common := site.Common()
+		var ms []dfa.Mapping[set]
+		if b, ok := common.Value.(*ir.Builtin); ok {
+			switch b.Name() {
+			case "append":
+				if v, ok := instr.(*ir.Call); ok {
+					ms = alias(v, common.Args...)
+				}
+			case "copy":
+				ms = store(common.Args[0], common.Args[1])
+			}
+			return ms
+		}
+		var sum *Summary
+		if callee := common.StaticCallee(); callee != nil && !common.IsInvoke() {
+			sum = f.a.summary(callee)
+		}
+		if v, ok := instr.(*ir.Call); ok && mayHold(v.Type()) {
+			d := ins.Value(v) | sourceBits[v]
+			var inputs []ir.Value
+			if sum != nil {
+				for _, i := range sum.Returns {
+					if i < len(common.Args) {
+						d |= ins.Value(common.Args[i])
+						inputs = append(inputs, common.Args[i])
+					}
+				}
+			}
+			if d != 0 {
+				ms = append(ms, dfa.M[set](v, d, dfa.Decision{Inputs: inputs}))
+			}
+		}
+		if sum == nil {
+			return ms
+		}
+		for _, p := range sum.Stores {
+			if p[0] < len(common.Args) && p[1] < len(common.Args) {
+				for _, m := range store(common.Args[p[1]], common.Args[p[0]]) {
+					ms = mergeMapping(ms, m)
+				}
+			}
+		}
+		return ms
+	}
+
+	v, ok := instr.(ir.Value)
+	if !ok || !mayHold(v.Type()) {
+		return nil
+	}
+	switch v := v.(type) {
+	case *ir.Parameter:
+		for i, p := range f.fn.Params {
+			if p == v && i < maxBits {
+				return dfa.Ms(dfa.M(v, ins.Value(v)|1<<i, dfa.Decision{}))
+			}
+		}
+		return nil
+	case *ir.Extract:
+		if call, ok := v.Tuple.(*ir.Call); ok && sourceBits[call] != 0 && !contains(f.a.opens(call), v.Index) {
+			// Other results of calls that open resources don't alias the resource.
+			return alias(v)
+		}
+		return alias(v, v.Tuple)
+	case *ir.Load:
+		return alias(v, v.X)
+	case *ir.FieldAddr:
+		return alias(v, v.X)
+	case *ir.Field:
+		return alias(v, v.X)
+	case *ir.IndexAddr:
+		return alias(v, v.X)
+	case *ir.Index:
+		return alias(v, v.X)
+	case *ir.MapLookup:
+		return alias(v, v.X)
+	case *ir.Slice:
+		return alias(v, v.X)
+	case *ir.MakeInterface:
+		return alias(v, v.X)
+	case *ir.ChangeType:
+		return alias(v, v.X)
+	case *ir.ChangeInterface:
+		return alias(v, v.X)
+	case *ir.TypeAssert:
+		return alias(v, v.X)
+	case *ir.Range:
+		return alias(v, v.X)
+	case *ir.Next:
+		return alias(v, v.Iter)
+	case *ir.Recv:
+		return alias(v, v.Chan)
+	case *ir.Copy:
+		return alias(v, v.X)
+	case *ir.Sigma:
+		return alias(v, v.X)
+	case *ir.CompositeValue:
+		return alias(v, v.Values...)
+	default:
+		return nil
+	}
+}
+
+// mergeMapping adds m to ms, joining it with an existing mapping of the same value.
+func mergeMapping(ms []dfa.Mapping[set], m dfa.Mapping[set]) []dfa.Mapping[set] {
+	for i := range ms {
+		if ms[i].Value == m.Value {
+			ms[i].State |= m.State
+			return ms
+		}
+	}
+	return append(ms, m)
+}
+
+// consumes returns the parameters and resources that instr consumes.
+func (f *function) consumes(instr ir.Instruction) set {
+	params := set(1)<<len(f.fn.Params) - 1
+	// escape returns the values that escape by being stored in memory reachable from addr. Parameters stored in
+	// other parameters are recorded in f.stores instead.
+	escape := func(addr ir.Value, srcs ...ir.Value) set {
+		var s set
+		for _, src := range srcs {
+			s |= f.bit(src)
+		}
+		switch r := root(addr).(type) {
+		case *ir.Alloc:
+			// The allocation aliases the values.
+			return 0
+		case *ir.Parameter:
+			if j := f.param(r); j >= 0 {
+				for i := 0; i < len(f.fn.Params) && i < maxBits; i++ {
+					if s&(1<<i) != 0 && i != j {
+						f.stores[[2]int{i, j}] = struct{}{}
+					}
+				}
+				return s &^ params
+			}
+		}
+		return s
+	}
+
+	switch instr := instr.(type) {
+	case *ir.Store:
+		return escape(instr.Addr, instr.Val)
+	case *ir.MapUpdate:
+		return escape(instr.Map, instr.Key, instr.Value)
+	case *ir.Send:
+		return f.bit(instr.X)
+	case *ir.Return:
+		var s set
+		for _, r := range instr.Results {
+			s |= f.bit(r)
+		}
+		return s
+	case *ir.MakeClosure:
+		var s set
+		for _, b := range instr.Bindings {
+			s |= f.bit(b)
+		}
+		return s
+	case ir.CallInstruction:
+		common := instr.Common()
+		if b, ok := common.Value.(*ir.Builtin); ok {
+			if b.Name() == "append" && len(common.Args) > 1 {
+				// Resources collected in slices are usually closed in a loop, which we cannot track. We treat them
+				// as escaping.
+				return f.bit(common.Args[1]) &^ params
+			}
+			return 0
+		}
+		if common.IsInvoke() {
+			// Methods called via interfaces rarely take ownership of their arguments, and we assume that they
+			// don't.
+			if common.Method.Name() == "Close" {
+				return f.bit(common.Value)
+			}
+			return 0
+		}
+		callee := common.StaticCallee()
+		if callee == nil {
+			// The resources may be closed by the dynamically called function. Calling a function value that is
+			// itself a resource, such as a context's cancel function, closes it.
+			s := f.bit(common.Value)
+			for _, arg := range common.Args {
+				s |= f.bit(arg)
+			}
+			return s
+		}
+		if callee.Signature.Recv() != nil && callee.Name() == "Close" && len(common.Args) > 0 {
+			return f.bit(common.Args[0])
+		}
+		sum := f.a.summary(callee)
+		if sum == nil {
+			var s set
+			for _, arg := range common.Args {
+				s |= f.bit(arg)
+			}
+			return s
+		}
+		var s set
+		for _, i := range sum.Closes {
+			if i < len(common.Args) {
+				s |= f.bit(common.Args[i])
+			}
+		}
+		for _, p := range sum.Stores {
+			if p[0] < len(common.Args) && p[1] < len(common.Args) {
+				s |= escape(common.Args[p[1]], common.Args[p[0]])
+			}
+		}
+		return s
+	}
+	return 0
+}
+
+func (f *function) param(p *ir.Parameter) int {
+	for i, pp := range f.fn.Params {
+		if pp == p {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *function) summarize() *Summary {
+	sum := &Summary{}
+	var consumed, returned set
+	for instr, s := range f.consumers {
+		if _, ok := instr.(*ir.Return); ok {
+			returned |= s
+		} else {
+			consumed |= s
+		}
+	}
+	for i, p := range f.fn.Params {
+		if i >= maxBits || !mayHold(p.Type()) {
+			continue
+		}
+		if consumed&(1<<i) != 0 {
+			sum.Closes = append(sum.Closes, i)
+		}
+		if returned&(1<<i) != 0 {
+			sum.Returns = append(sum.Returns, i)
+		}
+	}
+	for p := range f.stores {
+		sum.Stores = append(sum.Stores, p)
+	}
+	sort.Slice(sum.Stores, func(i, j int) bool {
+		a, b := sum.Stores[i], sum.Stores[j]
+		return a[0] < b[0] || a[0] == b[0] && a[1] < b[1]
+	})
+
+	// A function opens a resource if it returns a resource that it doesn't otherwise consume, and that its caller can
+	// close. Values such as a *sql.Row wrap resources that they close themselves.
+	for _, b := range f.fn.Blocks {
+		ret, ok := b.Control().(*ir.Return)
+		if !ok {
+			continue
+		}
+		for i, r := range ret.Results {
+			if !closable(r.Type()) {
+				continue
+			}
+			s := f.bit(r) >> len(f.fn.Params)
+			for s != 0 {
+				n := bits.TrailingZeros64(uint64(s))
+				s &^= 1 << n
+				if consumed&(1<<(n+len(f.fn.Params))) == 0 && !contains(sum.Opens, i) {
+					sum.Opens = append(sum.Opens, i)
+				}
+			}
+		}
+	}
+	sort.Ints(sum.Opens)
+	return sum
+}
+
+// nilEdge returns the index of the successor of b in which the resource r is known to be nil, or -1.
+func (f *function) nilEdge(b *ir.BasicBlock, r int) int {
+	iff, ok := b.Control().(*ir.If)
+	if !ok {
+		return -1
+	}
+	cond, ok := iff.Cond.(*ir.BinOp)
+	if !ok || (cond.Op != token.EQL && cond.Op != token.NEQ) {
+		return -1
+	}
+	x, y := unsigma(cond.X), unsigma(cond.Y)
+	if isNil(x) {
+		x, y = y, x
+	}
+	if !isNil(y) {
+		return -1
+	}
+	// isNilEdge is the successor in which x == nil.
+	isNilEdge := 0
+	if cond.Op == token.NEQ {
+		isNilEdge = 1
+	}
+
+	if isErrorOf(x, f.sources[r], map[ir.Value]bool{}) {
+		// The resource is nil if the error isn't.
+		return 1 - isNilEdge
+	}
+	if f.bit(x)&(1<<(r+len(f.fn.Params))) != 0 {
+		return isNilEdge
+	}
+	return -1
+}
+
+// isErrorOf reports whether v is the error returned by src, possibly merged with other values by a phi, or stored
+// in and loaded from a local variable, as happens with named results.
+func isErrorOf(v ir.Value, src *ir.Call, seen map[ir.Value]bool) bool {
+	v = unsigma(v)
+	if seen[v] {
+		return false
+	}
+	seen[v] = true
+	switch v := v.(type) {
+	case *ir.Extract:
+		return v.Tuple == src && typeutil.IsTypeWithName(v.Type(), "error")
+	case *ir.Phi:
+		for _, edge := range v.Edges {
+			if isErrorOf(edge, src, seen) {
+				return true
+			}
+		}
+	case *ir.Load:
+		if _, ok := v.X.(*ir.Alloc); !ok {
+			return false
+		}
+		instrs := v.Block().Instrs
+		i := len(instrs) - 1
+		for ; i >= 0 && instrs[i] != v; i-- {
+		}
+		for i--; i >= 0; i-- {
+			if store, ok := instrs[i].(*ir.Store); ok && store.Addr == v.X {
+				return isErrorOf(store.Val, src, seen)
+			}
+		}
+	}
+	return false
+}
+
+func unsigma(v ir.Value) ir.Value {
+	for {
+		sigma, ok := v.(*ir.Sigma)
+		if !ok {
+			return v
+		}
+		v = sigma.X
+	}
+}
+
+func isNil(v ir.Value) bool {
+	c, ok := v.(*ir.Const)
+	return ok && c.Value == nil
+}
+
+// leaks finds the paths on which the resources opened in f leak.
+func (f *function) leaks() []Leak {
+	var out []Leak
+	for r, src := range f.sources {
+		if leak, ok := f.leak(r, src); ok {
+			out = append(out, leak)
+		}
+	}
+	return out
+}
+
+func (f *function) leak(r int, src *ir.Call) (Leak, bool) {
+	bit := set(1) << (r + len(f.fn.Params))
+	consumed := false
+	// first maps blocks to the index of their first instruction that consumes the resource.
+	first := map[*ir.BasicBlock]int{}
+	for instr, s := range f.consumers {
+		if s&bit != 0 {
+			consumed = true
+		}
+		if _, ok := instr.(*ir.Return); ok || s&bit == 0 {
+			// Returns are handled per path by returned.
+			continue
+		}
+		b := instr.Block()
+		idx := -1
+		for i, instr2 := range b.Instrs {
+			if instr2 == instr {
+				idx = i
+				break
+			}
+		}
+		if old, ok := first[b]; !ok || idx < old {
+			first[b] = idx
+		}
+	}
+
+	start := src.Block()
+	srcIdx := -1
+	for i, instr := range start.Instrs {
+		if instr == src {
+			srcIdx = i
+			break
+		}
+	}
+	for instr := range f.consumers {
+		if instr.Block() == start && f.consumers[instr]&bit != 0 {
+			for i := srcIdx + 1; i < len(start.Instrs); i++ {
+				if start.Instrs[i] == instr {
+					// Consumed in the same block, after being opened.
+					return Leak{}, false
+				}
+			}
+		}
+	}
+
+	// Breadth-first search for the shortest path to a return that doesn't consume the resource.
+	parent := map[*ir.BasicBlock]*ir.BasicBlock{}
+	seen := map[*ir.BasicBlock]bool{start: true}
+	queue := []*ir.BasicBlock{start}
+	var end *ir.BasicBlock
+	for len(queue) > 0 && end == nil {
+		b := queue[0]
+		queue = queue[1:]
+		if irutil.Aborts(b) {
+			continue
+		}
+		skip := f.nilEdge(b, r)
+		for i, succ := range b.Succs {
+			if i == skip || seen[succ] {
+				continue
+			}
+			seen[succ] = true
+			if _, ok := first[succ]; ok {
+				continue
+			}
+			parent[succ] = b
+			if succ == f.fn.Exit {
+				if f.returned(b, bit) {
+					continue
+				}
+				end = b
+				break
+			}
+			queue = append(queue, succ)
+		}
+	}
+	if end == nil {
+		return Leak{}, false
+	}
+
+	leak := Leak{
+		Source: src,
+		Type:   src.Type(),
+		Never:  !consumed,
+		Return: irutil.ReturnPos(f.fn, end),
+	}
+	if tuple, ok := leak.Type.(*types.Tuple); ok {
+		if idx := f.a.opens(src); len(idx) > 0 {
+			leak.Type = tuple.At(idx[0]).Type()
+		}
+	}
+	var blocks []*ir.BasicBlock
+	for b := end; b != start; b = parent[b] {
+		blocks = append(blocks, b)
+	}
+	blocks = append(blocks, start)
+	leak.Path = append(leak.Path, Step{At: src.Pos(), Description: "opened here"})
+	for i := len(blocks) - 1; i > 0; i-- {
+		b, next := blocks[i], blocks[i-1]
+		iff, ok := b.Control().(*ir.If)
+		if !ok || !iff.Cond.Pos().IsValid() {
+			continue
+		}
+		desc := "condition is true"
+		if b.Succs[1] == next {
+			desc = "condition is false"
+		}
+		leak.Path = append(leak.Path, Step{At: iff.Cond.Pos(), Description: desc})
+	}
+	leak.Cancel = typeutil.IsTypeWithName(leak.Type, "context.CancelFunc") ||
+		typeutil.IsTypeWithName(leak.Type, "context.CancelCauseFunc")
+	desc := "returns without closing"
+	if leak.Cancel {
+		desc = "returns without calling the cancel function"
+	}
+	leak.Path = append(leak.Path, Step{At: leak.Return, Description: desc})
+	return leak, true
+}
+
+// returned reports whether the function returns the resource when control flows from b to the exit block.
+func (f *function) returned(b *ir.BasicBlock, bit set) bool {
+	exit := f.fn.Exit
+	ret, ok := exit.Control().(*ir.Return)
+	if !ok {
+		return false
+	}
+	for _, r := range ret.Results {
+		if phi, ok := r.(*ir.Phi); ok && phi.Block() == exit {
+			for i, pred := range exit.Preds {
+				if pred == b && f.bit(phi.Edges[i])&bit != 0 {
+					return true
+				}
+			}
+			continue
+		}
+		if f.bit(r)&bit != 0 {
+			return true
+		}
+	}
+	return false
+}
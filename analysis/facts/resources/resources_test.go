@@ -0,0 +1,11 @@
+package resources
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestResources(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "example.com/Resources")
+}
@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+)
+
+func open(name string) (*os.File, error) { // want open:`resources\(closes: \[\], returns: \[\], stores: \[\], opens: \[0\]\)`
+	return os.Open(name)
+}
+
+func openChecked(name string) (*os.File, error) { // want openChecked:`resources\(closes: \[\], returns: \[\], stores: \[\], opens: \[0\]\)`
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func closeIt(c io.Closer) { // want closeIt:`resources\(closes: \[0\], returns: \[\], stores: \[\], opens: \[\]\)`
+	c.Close()
+}
+
+func closeFile(f *os.File) error { // want closeFile:`resources\(closes: \[0\], returns: \[\], stores: \[\], opens: \[\]\)`
+	return f.Close()
+}
+
+func indirectClose(f *os.File) { // want indirectClose:`resources\(closes: \[0\], returns: \[\], stores: \[\], opens: \[\]\)`
+	closeIt(f)
+}
+
+func read(r io.Reader) ([]byte, error) {
+	return io.ReadAll(r)
+}
+
+func wrap(r io.Reader) *bufio.Reader { // want wrap:`resources\(closes: \[\], returns: \[0\], stores: \[\], opens: \[\]\)`
+	return bufio.NewReader(r)
+}
+
+var global io.Closer
+
+func escape(c io.Closer) { // want escape:`resources\(closes: \[0\], returns: \[\], stores: \[\], opens: \[\]\)`
+	global = c
+}
+
+type T struct {
+	c io.Closer
+}
+
+func (t *T) set(c io.Closer) { // want set:`resources\(closes: \[\], returns: \[\], stores: \[\[1 0\]\], opens: \[\]\)`
+	t.c = c
+}
+
+func (t *T) Close() error { // want Close:`resources\(closes: \[0\], returns: \[\], stores: \[\], opens: \[\]\)`
+	return t.c.Close()
+}
+
+func newT(name string) (*T, error) { // want newT:`resources\(closes: \[\], returns: \[\], stores: \[\], opens: \[0\]\)`
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	t := &T{}
+	t.set(f)
+	return t, nil
+}
+
+func closure(c io.Closer) func() { // want closure:`resources\(closes: \[0\], returns: \[\], stores: \[\], opens: \[\]\)`
+	return func() { c.Close() }
+}
+
+func stop(cancel context.CancelFunc) { // want stop:`resources\(closes: \[0\], returns: \[\], stores: \[\], opens: \[\]\)`
+	cancel()
+}
+
+func withCancel(ctx context.Context) (context.Context, context.CancelFunc) { // want withCancel:`resources\(closes: \[\], returns: \[\], stores: \[\], opens: \[1\]\)`
+	return context.WithCancel(ctx)
+}
+
+// row closes its file itself, so its callers don't have to.
+type row struct{ f *os.File }
+
+func (r *row) scan() error { // want scan:`resources\(closes: \[0\], returns: \[\], stores: \[\], opens: \[\]\)`
+	return r.f.Close()
+}
+
+func openRow(name string) *row {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil
+	}
+	return &row{f}
+}
@@ -0,0 +1,937 @@
+// Package taint implements an interprocedural taint analysis.
+//
+// Untrusted data enters a program through sources, such as parameters of type *net/http.Request, and must not reach
+// sinks, such as the query strings of database/sql, without first passing through a sanitizer. Sources, sinks and
+// sanitizers are configured via the taint option of staticcheck.conf.
+//
+// The analysis tracks the flow of data between IR values using package dfa, and across functions using summaries. A
+// function's summary describes which of its parameters flow into its results, which parameters flow into memory
+// reachable from other parameters, and which parameters reach sinks. Summaries are exported as facts, so that flows
+// can be tracked across packages.
+//
+// Memory is modeled coarsely: storing a value anywhere in memory reachable from a variable taints the variable as a
+// whole. Global variables and variables captured by closures aren't tracked.
+//
+// Data is tracked per kind of sink, so that sanitizing data for use in file paths doesn't make it safe to use in SQL
+// queries. Values of boolean and numeric types are never tainted, and neither are contexts.
+package taint
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"honnef.co/go/tools/analysis/dfa"
+	"honnef.co/go/tools/config"
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/types/typeutil"
+	"honnef.co/go/tools/internal/passes/buildir"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A Kind is a set of kinds of sinks.
+type Kind uint8
+
+const (
+	SQL Kind = 1 << iota
+	Exec
+	HTML
+	Path
+
+	numKinds      = 4
+	allKinds Kind = 1<<numKinds - 1
+)
+
+var kindNames = [numKinds]string{"sql", "exec", "html", "path"}
+
+func (k Kind) String() string {
+	var names []string
+	for i, name := range kindNames {
+		if k&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+func parseKind(name string) (Kind, bool) {
+	for i, n := range kindNames {
+		if n == name {
+			return 1 << i, true
+		}
+	}
+	return 0, false
+}
+
+// maxParams is the number of parameters whose flows we track. Each parameter uses numKinds bits of State.Params.
+const maxParams = 64 / numKinds
+
+// State is the taint state of a value. Source is the set of kinds for which the value holds untrusted data. Params
+// records, for each of the function's parameters, the set of kinds for which the parameter's value flows into the
+// value.
+type State struct {
+	Source Kind
+	Params uint64
+}
+
+func join(a, b State) State {
+	return State{Source: a.Source | b.Source, Params: a.Params | b.Params}
+}
+
+// mask restricts s to the kinds in k.
+func (s State) mask(k Kind) State {
+	return State{Source: s.Source & k, Params: s.Params & (uint64(k) * 0x1111111111111111)}
+}
+
+// Param returns the kinds for which the i'th parameter flows into the value.
+func (s State) Param(i int) Kind {
+	if i >= maxParams {
+		return 0
+	}
+	return Kind(s.Params>>(numKinds*i)) & allKinds
+}
+
+func paramState(i int, k Kind) State {
+	if i >= maxParams {
+		return State{}
+	}
+	return State{Params: uint64(k) << (numKinds * i)}
+}
+
+func (s State) String() string {
+	var parts []string
+	if s.Source != 0 {
+		parts = append(parts, fmt.Sprintf("source(%s)", s.Source))
+	}
+	for i := 0; i < maxParams; i++ {
+		if k := s.Param(i); k != 0 {
+			parts = append(parts, fmt.Sprintf("param%d(%s)", i, k))
+		}
+	}
+	if len(parts) == 0 {
+		return "clean"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Summary summarizes the flows of data through a function. Parameters are numbered like in ir.Function.Params, that
+// is, receivers count as the first parameter.
+type Summary struct {
+	// Result is the state of the function's results. Its Source field is set if the function returns untrusted data
+	// regardless of its arguments.
+	Result State
+	// Writes records, for each parameter, the state of the data that the function stores in memory reachable from
+	// the parameter.
+	Writes []State
+	// Sinks records, for each parameter, the kinds of sinks that the parameter reaches.
+	Sinks []Kind
+}
+
+func (*Summary) AFact() {}
+
+func (s *Summary) String() string {
+	parts := []string{fmt.Sprintf("result: %s", s.Result)}
+	for i, w := range s.Writes {
+		if w != (State{}) {
+			parts = append(parts, fmt.Sprintf("param%d ← %s", i, w))
+		}
+	}
+	for i, k := range s.Sinks {
+		if k != 0 {
+			parts = append(parts, fmt.Sprintf("param%d → %s", i, k))
+		}
+	}
+	return fmt.Sprintf("taint(%s)", strings.Join(parts, "; "))
+}
+
+func newSummary(fn *ir.Function) *Summary {
+	return &Summary{
+		Writes: make([]State, len(fn.Params)),
+		Sinks:  make([]Kind, len(fn.Params)),
+	}
+}
+
+func (s *Summary) empty() bool {
+	if s.Result != (State{}) {
+		return false
+	}
+	for i := range s.Sinks {
+		if s.Writes[i] != (State{}) || s.Sinks[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Summary) equal(o *Summary) bool {
+	if s.Result != o.Result {
+		return false
+	}
+	for i := range s.Sinks {
+		if s.Writes[i] != o.Writes[i] || s.Sinks[i] != o.Sinks[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Summary) merge(o *Summary) *Summary {
+	out := &Summary{
+		Result: join(s.Result, o.Result),
+		Writes: make([]State, len(s.Writes)),
+		Sinks:  make([]Kind, len(s.Sinks)),
+	}
+	for i := range out.Sinks {
+		out.Writes[i] = join(s.Writes[i], o.Writes[i])
+		out.Sinks[i] = s.Sinks[i] | o.Sinks[i]
+	}
+	return out
+}
+
+// A Step is a step in the flow of untrusted data from a source to a sink.
+type Step struct {
+	At          token.Pos
+	Description string
+}
+
+func (s Step) Pos() token.Pos { return s.At }
+
+// A Flow is a flow of untrusted data into a sink.
+type Flow struct {
+	Kind Kind
+	// Sink is the call or conversion that passes the data to the sink.
+	Sink ir.Instruction
+	// Target describes the sink, for example "(*database/sql.DB).Query" or "conversion to html/template.HTML".
+	Target string
+	// Path lists the steps from the source to the sink, in order.
+	Path []Step
+}
+
+// Result is the result of the taint analysis of a package.
+type Result struct {
+	Flows []Flow
+}
+
+// FlowsOf returns the flows into sinks of kind k.
+func (r *Result) FlowsOf(k Kind) []Flow {
+	var out []Flow
+	for _, f := range r.Flows {
+		if f.Kind&k != 0 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+var Analyzer = &analysis.Analyzer{
+	Name:       "fact_taint",
+	Doc:        "Track flows of untrusted data",
+	Run:        run,
+	Requires:   []*analysis.Analyzer{buildir.Analyzer, config.Analyzer},
+	FactTypes:  []analysis.Fact{(*Summary)(nil)},
+	ResultType: reflect.TypeOf((*Result)(nil)),
+}
+
+// A sink is an argument of a function, or all of its arguments if arg is negative.
+type sink struct {
+	arg  int
+	kind Kind
+}
+
+type spec struct {
+	// Sources, and sinks without argument indices, may refer to functions or to types. The configuration doesn't
+	// distinguish the two, so we look them up as both.
+	sources    map[string]struct{}
+	sinkFuncs  map[string][]sink
+	sinkTypes  map[string]Kind
+	sanitizers map[string]Kind
+}
+
+func parseSpec(cfg config.TaintConfig) (*spec, error) {
+	s := &spec{
+		sources:    map[string]struct{}{},
+		sinkFuncs:  map[string][]sink{},
+		sinkTypes:  map[string]Kind{},
+		sanitizers: map[string]Kind{},
+	}
+	for _, name := range cfg.Sources {
+		s.sources[name] = struct{}{}
+	}
+	for kname, names := range cfg.Sinks {
+		k, ok := parseKind(kname)
+		if !ok {
+			return nil, fmt.Errorf("unknown kind of taint sink %q, must be one of %s", kname, strings.Join(kindNames[:], ", "))
+		}
+		for _, name := range names {
+			fn, idx, ok := strings.Cut(name, ":")
+			if !ok {
+				s.sinkTypes[fn] |= k
+				s.sinkFuncs[fn] = append(s.sinkFuncs[fn], sink{-1, k})
+				continue
+			}
+			n, err := strconv.Atoi(idx)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid argument index in taint sink %q", name)
+			}
+			s.sinkFuncs[fn] = append(s.sinkFuncs[fn], sink{n, k})
+		}
+	}
+	for kname, names := range cfg.Sanitizers {
+		k, ok := parseKind(kname)
+		if !ok {
+			return nil, fmt.Errorf("unknown kind of taint sanitizer %q, must be one of %s", kname, strings.Join(kindNames[:], ", "))
+		}
+		for _, name := range names {
+			s.sanitizers[name] |= k
+		}
+	}
+	return s, nil
+}
+
+// carries reports whether values of type T may carry untrusted data.
+func carries(T types.Type) bool {
+	if typeutil.IsTypeWithName(T, "context.Context") {
+		return false
+	}
+	switch T := T.Underlying().(type) {
+	case *types.Basic:
+		return T.Info()&(types.IsBoolean|types.IsNumeric) == 0
+	case *types.Tuple:
+		for i := 0; i < T.Len(); i++ {
+			if carries(T.At(i).Type()) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func typeName(T types.Type) string {
+	named, ok := types.Unalias(T).(*types.Named)
+	if !ok {
+		return ""
+	}
+	return types.TypeString(named.Origin(), nil)
+}
+
+func funcName(fn *ir.Function) string {
+	obj, ok := fn.Object().(*types.Func)
+	if !ok {
+		return ""
+	}
+	return typeutil.FuncName(obj.Origin())
+}
+
+// roots returns the variables whose memory addr may point into. It omits global variables and variables captured by
+// closures, which are shared with other functions whose accesses we don't track.
+func roots(addr ir.Value) []ir.Value {
+	var out []ir.Value
+	seen := map[ir.Value]bool{}
+	var walk func(addr ir.Value)
+	walk = func(addr ir.Value) {
+		if seen[addr] {
+			return
+		}
+		seen[addr] = true
+		switch v := addr.(type) {
+		case *ir.FieldAddr:
+			walk(v.X)
+		case *ir.IndexAddr:
+			walk(v.X)
+		case *ir.Slice:
+			walk(v.X)
+		case *ir.Load:
+			walk(v.X)
+		case *ir.ChangeType:
+			walk(v.X)
+		case *ir.Sigma:
+			walk(v.X)
+		case *ir.Phi:
+			// The state of ϕ nodes is computed by the framework, from the states of their edges.
+			for _, edge := range v.Edges {
+				walk(edge)
+			}
+		case ir.Instruction:
+			out = append(out, addr)
+		}
+	}
+	walk(addr)
+	return out
+}
+
+type analyzer struct {
+	pass      *analysis.Pass
+	spec      *spec
+	irpkg     *ir.Package
+	summaries map[*ir.Function]*Summary
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	spec, err := parseSpec(config.For(pass).Taint)
+	if err != nil {
+		return nil, err
+	}
+	irp := pass.ResultOf[buildir.Analyzer].(*buildir.IR)
+	a := &analyzer{
+		pass:      pass,
+		spec:      spec,
+		irpkg:     irp.Pkg,
+		summaries: map[*ir.Function]*Summary{},
+	}
+
+	// Compute summaries, reanalyzing the callers of functions whose summaries changed, until we reach a fixed
+	// point. Summaries only ever grow, which guarantees termination.
+	callers := map[*ir.Function][]*ir.Function{}
+	for _, fn := range irp.SrcFuncs {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if site, ok := instr.(ir.CallInstruction); ok {
+					if callee := site.Common().StaticCallee(); callee != nil && callee.Pkg == irp.Pkg {
+						callers[callee] = append(callers[callee], fn)
+					}
+				}
+			}
+		}
+	}
+	instances := map[*ir.Function]*dfa.Instance[State]{}
+	worklist := make([]*ir.Function, len(irp.SrcFuncs))
+	copy(worklist, irp.SrcFuncs)
+	queued := map[*ir.Function]bool{}
+	for _, fn := range worklist {
+		queued[fn] = true
+	}
+	for len(worklist) > 0 {
+		fn := worklist[0]
+		worklist = worklist[1:]
+		queued[fn] = false
+
+		ins := a.analyze(fn)
+		instances[fn] = ins
+		sum := a.summarize(fn, ins, nil)
+		if old, ok := a.summaries[fn]; ok {
+			sum = old.merge(sum)
+			if sum.equal(old) {
+				continue
+			}
+		}
+		a.summaries[fn] = sum
+		for _, caller := range callers[fn] {
+			if !queued[caller] {
+				worklist = append(worklist, caller)
+				queued[caller] = true
+			}
+		}
+	}
+
+	res := &Result{}
+	for _, fn := range irp.SrcFuncs {
+		a.summarize(fn, instances[fn], &res.Flows)
+		if fn.Object() == nil {
+			continue
+		}
+		if sum := a.summaries[fn]; !sum.empty() {
+			pass.ExportObjectFact(fn.Object(), sum)
+		}
+	}
+	for _, m := range irp.Pkg.Members {
+		// Functions without bodies, such as those implemented in assembly, may let any of their arguments flow
+		// into their results.
+		fn, ok := m.(*ir.Function)
+		if !ok || fn.Blocks != nil || fn.Object() == nil || !carries(fn.Signature.Results()) {
+			continue
+		}
+		sum := newSummary(fn)
+		for i := range fn.Params {
+			sum.Result = join(sum.Result, paramState(i, allKinds))
+		}
+		if !sum.empty() {
+			pass.ExportObjectFact(fn.Object(), sum)
+		}
+	}
+	return res, nil
+}
+
+// summary returns the summary of fn, or nil if we know nothing about fn.
+func (a *analyzer) summary(fn *ir.Function) *Summary {
+	if fn.Pkg == a.irpkg {
+		if sum, ok := a.summaries[fn]; ok {
+			return sum
+		}
+		if fn.Blocks != nil {
+			// Not analyzed yet. We will reanalyze the caller once we have.
+			return newSummary(fn)
+		}
+		return nil
+	}
+	obj, ok := fn.Object().(*types.Func)
+	if !ok || obj.Pkg() == nil {
+		return nil
+	}
+	sum := new(Summary)
+	if a.pass.ImportObjectFact(obj.Origin(), sum) {
+		return sum
+	}
+	// Functions in other packages have been analyzed, and those without facts don't let any data flow.
+	return newSummary(fn)
+}
+
+func (a *analyzer) isSource(T types.Type) bool {
+	if ptr, ok := types.Unalias(T).(*types.Pointer); ok {
+		T = ptr.Elem()
+	}
+	name := typeName(T)
+	if name == "" {
+		return false
+	}
+	_, ok := a.spec.sources[name]
+	return ok
+}
+
+// analyze computes the states of the values in fn.
+func (a *analyzer) analyze(fn *ir.Function) *dfa.Instance[State] {
+	params := map[*ir.Parameter]int{}
+	for i, p := range fn.Params {
+		params[p] = i
+	}
+	fw := &dfa.Framework[State]{
+		Join:   join,
+		Bottom: State{},
+		Top:    State{Source: ^Kind(0), Params: ^uint64(0)},
+		Transfer: func(ins *dfa.Instance[State], instr ir.Instruction) []dfa.Mapping[State] {
+			return a.transfer(ins, instr, params)
+		},
+	}
+	return fw.Forward(fn)
+}
+
+// propagate maps dst to the union of its current state, which may have been set by stores, and the states of srcs.
+func propagate(ins *dfa.Instance[State], dst ir.Value, desc string, srcs ...ir.Value) []dfa.Mapping[State] {
+	return propagateAt(ins, dst, desc, token.NoPos, srcs...)
+}
+
+// propagateAt is like propagate, but describes the decision at pos instead of at dst.
+func propagateAt(ins *dfa.Instance[State], dst ir.Value, desc string, pos token.Pos, srcs ...ir.Value) []dfa.Mapping[State] {
+	d := ins.Value(dst)
+	var inputs []ir.Value
+	for _, src := range srcs {
+		if s := ins.Value(src); s != (State{}) {
+			d = join(d, s)
+			inputs = append(inputs, src)
+		}
+	}
+	if d == (State{}) {
+		return nil
+	}
+	return dfa.Ms(dfa.M(dst, d, dfa.Decision{Inputs: inputs, Description: desc, Pos: pos}))
+}
+
+// combine merges mappings of the same value, which may arise when several arguments of a call share memory.
+func combine(ms []dfa.Mapping[State]) []dfa.Mapping[State] {
+	out := ms[:0]
+	idx := map[ir.Value]int{}
+	for _, m := range ms {
+		if i, ok := idx[m.Value]; ok {
+			out[i].State = join(out[i].State, m.State)
+			out[i].Decision.Inputs = append(out[i].Decision.Inputs, m.Decision.Inputs...)
+			continue
+		}
+		idx[m.Value] = len(out)
+		out = append(out, m)
+	}
+	return out
+}
+
+// store taints the variables that addr may point into with the states of srcs. The flow is described at the position
+// of instr, not at the positions of the variables.
+func store(ins *dfa.Instance[State], instr ir.Instruction, addr ir.Value, desc string, srcs ...ir.Value) []dfa.Mapping[State] {
+	var ms []dfa.Mapping[State]
+	for _, r := range roots(addr) {
+		ms = append(ms, propagateAt(ins, r, desc, instr.Pos(), srcs...)...)
+	}
+	return ms
+}
+
+func (a *analyzer) transfer(ins *dfa.Instance[State], instr ir.Instruction, params map[*ir.Parameter]int) []dfa.Mapping[State] {
+	switch instr := instr.(type) {
+	case *ir.Store:
+		return store(ins, instr, instr.Addr, "stored here", instr.Val)
+	case *ir.MapUpdate:
+		return store(ins, instr, instr.Map, "stored in map here", instr.Key, instr.Value)
+	case *ir.Send:
+		return store(ins, instr, instr.Chan, "sent here", instr.X)
+	case *ir.Go:
+		return a.call(ins, instr, nil)
+	case *ir.Defer:
+		return a.call(ins, instr, nil)
+	case *ir.Call:
+		if !carries(instr.Type()) {
+			// The call may still store data in memory reachable from its arguments.
+			return a.call(ins, instr, nil)
+		}
+		return a.call(ins, instr, instr)
+	}
+
+	v, ok := instr.(ir.Value)
+	if !ok || !carries(v.Type()) {
+		return nil
+	}
+	switch v := v.(type) {
+	case *ir.Parameter:
+		if a.isSource(v.Type()) {
+			return dfa.Ms(dfa.M(v, join(ins.Value(v), State{Source: allKinds}), dfa.Decision{
+				Description: fmt.Sprintf("untrusted value of type %s", v.Type()),
+				Source:      true,
+			}))
+		}
+		return dfa.Ms(dfa.M(v, join(ins.Value(v), paramState(params[v], allKinds)), dfa.Decision{}))
+	case *ir.BinOp:
+		return propagate(ins, v, "concatenated here", v.X, v.Y)
+	case *ir.Load:
+		return propagate(ins, v, "", v.X)
+	case *ir.FieldAddr:
+		return propagate(ins, v, "", v.X)
+	case *ir.Field:
+		return propagate(ins, v, "", v.X)
+	case *ir.IndexAddr:
+		return propagate(ins, v, "", v.X)
+	case *ir.Index:
+		return propagate(ins, v, "", v.X)
+	case *ir.MapLookup:
+		return propagate(ins, v, "", v.X)
+	case *ir.StringLookup:
+		return propagate(ins, v, "", v.X)
+	case *ir.Slice:
+		return propagate(ins, v, "", v.X)
+	case *ir.MakeInterface:
+		return propagate(ins, v, "", v.X)
+	case *ir.ChangeType:
+		return propagate(ins, v, "", v.X)
+	case *ir.Convert:
+		return propagate(ins, v, "", v.X)
+	case *ir.MultiConvert:
+		return propagate(ins, v, "", v.X)
+	case *ir.ChangeInterface:
+		return propagate(ins, v, "", v.X)
+	case *ir.SliceToArrayPointer:
+		return propagate(ins, v, "", v.X)
+	case *ir.SliceToArray:
+		return propagate(ins, v, "", v.X)
+	case *ir.TypeAssert:
+		return propagate(ins, v, "", v.X)
+	case *ir.Extract:
+		return propagate(ins, v, "", v.Tuple)
+	case *ir.Range:
+		return propagate(ins, v, "", v.X)
+	case *ir.Next:
+		return propagate(ins, v, "", v.Iter)
+	case *ir.Recv:
+		return propagate(ins, v, "", v.Chan)
+	case *ir.Copy:
+		return propagate(ins, v, "", v.X)
+	case *ir.Sigma:
+		return propagate(ins, v, "", v.X)
+	case *ir.CompositeValue:
+		return propagate(ins, v, "", v.Values...)
+	case *ir.MakeClosure:
+		return propagate(ins, v, "", v.Bindings...)
+	default:
+		// Allocations, and values produced by operations that we don't model, start out clean. Allocations may
+		// become tainted by stores.
+		return nil
+	}
+}
+
+// call computes the effects of a call. v is the value of the call, or nil for go and defer statements.
+func (a *analyzer) call(ins *dfa.Instance[State], site ir.CallInstruction, v *ir.Call) []dfa.Mapping[State] {
+	common := site.Common()
+	if b, ok := common.Value.(*ir.Builtin); ok {
+		switch b.Name() {
+		case "copy":
+			return store(ins, site, common.Args[0], "copied here", common.Args[1])
+		default:
+			if v == nil {
+				return nil
+			}
+			return propagate(ins, v, "", common.Args...)
+		}
+	}
+
+	var callee *ir.Function
+	var name string
+	if !common.IsInvoke() {
+		callee, _ = common.Value.(*ir.Function)
+	}
+	if callee != nil {
+		name = funcName(callee)
+	}
+	if v != nil && name != "" {
+		if _, ok := a.spec.sources[name]; ok {
+			return dfa.Ms(dfa.M(v, join(ins.Value(v), State{Source: allKinds}), dfa.Decision{
+				Description: fmt.Sprintf("untrusted data returned by %s", name),
+				Source:      true,
+			}))
+		}
+		if k, ok := a.spec.sanitizers[name]; ok {
+			ms := propagate(ins, v, fmt.Sprintf("sanitized by %s", name), common.Args...)
+			for i := range ms {
+				ms[i].State = join(ms[i].State.mask(allKinds&^k), ins.Value(v))
+			}
+			return ms
+		}
+	}
+
+	var sum *Summary
+	if callee != nil {
+		sum = a.summary(callee)
+	}
+	if sum == nil {
+		// We don't know what the callee does, so we assume that all data flows into the result. In addition, method
+		// calls on interfaces may store the receiver's data in their arguments, as is the case for io.Reader.Read.
+		desc := "passed through function call"
+		if common.IsInvoke() {
+			desc = fmt.Sprintf("passed through call to %s", common.Method.Name())
+		}
+		var ms []dfa.Mapping[State]
+		if v != nil {
+			ms = propagate(ins, v, desc, append([]ir.Value{common.Value}, common.Args...)...)
+		}
+		if common.IsInvoke() {
+			for _, arg := range common.Args {
+				if carries(arg.Type()) {
+					ms = append(ms, store(ins, site, arg, desc, common.Value)...)
+				}
+			}
+		}
+		return combine(ms)
+	}
+
+	// substitute maps dst to the state of s in the caller, by replacing parameters with the states of the
+	// arguments.
+	substitute := func(dst ir.Value, s State, desc string) []dfa.Mapping[State] {
+		d := join(ins.Value(dst), State{Source: s.Source})
+		var inputs []ir.Value
+		for i, arg := range common.Args {
+			if k := s.Param(i); k != 0 {
+				if as := ins.Value(arg).mask(k); as != (State{}) {
+					d = join(d, as)
+					inputs = append(inputs, arg)
+				}
+			}
+		}
+		if d == (State{}) {
+			return nil
+		}
+		return dfa.Ms(dfa.M(dst, d, dfa.Decision{Inputs: inputs, Description: desc, Source: s.Source != 0}))
+	}
+
+	var ms []dfa.Mapping[State]
+	if v != nil && sum.Result != (State{}) {
+		desc := fmt.Sprintf("passed through call to %s", callee.Name())
+		if sum.Result.Source != 0 {
+			desc = fmt.Sprintf("untrusted data returned by %s", callee.Name())
+		}
+		ms = substitute(v, sum.Result, desc)
+	}
+	for i, w := range sum.Writes {
+		if w == (State{}) || i >= len(common.Args) {
+			continue
+		}
+		for _, r := range roots(common.Args[i]) {
+			ms = append(ms, substitute(r, w, fmt.Sprintf("stored by call to %s", callee.Name()))...)
+		}
+	}
+	return combine(ms)
+}
+
+// summarize computes the summary of fn. If flows is not nil, it also records the flows of untrusted data into sinks.
+func (a *analyzer) summarize(fn *ir.Function, ins *dfa.Instance[State], flows *[]Flow) *Summary {
+	sum := newSummary(fn)
+	for i, p := range fn.Params {
+		// Parameters only get tainted by other data if it is stored in memory reachable from them.
+		w := ins.Value(p)
+		w.Params &^= paramState(i, allKinds).Params
+		if a.isSource(p.Type()) {
+			w.Source = 0
+		}
+		sum.Writes[i] = w
+	}
+
+	reported := map[ir.Instruction]Kind{}
+	reach := func(instr ir.Instruction, arg ir.Value, k Kind, target string) {
+		s := ins.Value(arg).mask(k)
+		for i := range sum.Sinks {
+			sum.Sinks[i] |= s.Param(i)
+		}
+		if flows == nil {
+			return
+		}
+		for k := s.Source &^ reported[instr]; k != 0; k &= k - 1 {
+			kind := k & -k
+			reported[instr] |= kind
+			*flows = append(*flows, Flow{
+				Kind:   kind,
+				Sink:   instr,
+				Target: target,
+				Path:   path(ins, arg, kind),
+			})
+		}
+	}
+	conversion := func(instr ir.Instruction, x ir.Value) {
+		name := typeName(instr.(ir.Value).Type())
+		if k := a.spec.sinkTypes[name]; k != 0 {
+			reach(instr, x, k, fmt.Sprintf("conversion to %s", name))
+		}
+	}
+
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			switch instr := instr.(type) {
+			case *ir.Return:
+				for _, r := range instr.Results {
+					sum.Result = join(sum.Result, ins.Value(r))
+				}
+			case *ir.ChangeType:
+				conversion(instr, instr.X)
+			case *ir.Convert:
+				conversion(instr, instr.X)
+			case *ir.MultiConvert:
+				conversion(instr, instr.X)
+			case ir.CallInstruction:
+				common := instr.Common()
+				if common.IsInvoke() {
+					continue
+				}
+				callee, ok := common.Value.(*ir.Function)
+				if !ok {
+					continue
+				}
+				name := funcName(callee)
+				offset := 0
+				if callee.Signature.Recv() != nil {
+					offset = 1
+				}
+				for _, s := range a.spec.sinkFuncs[name] {
+					if s.arg < 0 {
+						for _, arg := range common.Args {
+							reach(instr, arg, s.kind, name)
+						}
+					} else if i := s.arg + offset; i < len(common.Args) {
+						reach(instr, common.Args[i], s.kind, name)
+						if k := s.kind & Exec; k != 0 && i+1 < len(common.Args) {
+							// A shell runs its script like a command name.
+							if script := shellScript(common.Args[i], common.Args[i+1]); script != nil {
+								reach(instr, script, k, name)
+							}
+						}
+					}
+				}
+				if csum := a.summary(callee); csum != nil {
+					for i, k := range csum.Sinks {
+						if k != 0 && i < len(common.Args) {
+							reach(instr, common.Args[i], k, fmt.Sprintf("call to %s", callee.Name()))
+						}
+					}
+				}
+			}
+		}
+	}
+	return sum
+}
+
+// shellScript returns the script argument of a command that starts a shell with -c, or nil. name is the command's
+// name and args is the slice of its variadic arguments.
+func shellScript(name, args ir.Value) ir.Value {
+	k, ok := name.(*ir.Const)
+	if !ok || k.Value == nil || k.Value.Kind() != constant.String {
+		return nil
+	}
+	shell := constant.StringVal(k.Value)
+	switch shell[strings.LastIndexByte(shell, '/')+1:] {
+	case "sh", "bash", "dash", "ksh", "zsh":
+	default:
+		return nil
+	}
+
+	// Variadic arguments are stored in an array that is then sliced.
+	slice, ok := args.(*ir.Slice)
+	if !ok {
+		return nil
+	}
+	arr, ok := slice.X.(*ir.Alloc)
+	if !ok {
+		return nil
+	}
+	elems := map[int64]ir.Value{}
+	for _, ref := range *arr.Referrers() {
+		addr, ok := ref.(*ir.IndexAddr)
+		if !ok {
+			continue
+		}
+		idx, ok := addr.Index.(*ir.Const)
+		if !ok || idx.Value == nil {
+			continue
+		}
+		i, ok := constant.Int64Val(idx.Value)
+		if !ok {
+			continue
+		}
+		for _, ref := range *addr.Referrers() {
+			if store, ok := ref.(*ir.Store); ok && store.Addr == addr {
+				elems[i] = store.Val
+			}
+		}
+	}
+
+	// The script follows -c, which may be preceded by other options.
+	for i := int64(0); ; i++ {
+		opt, ok := elems[i].(*ir.Const)
+		if !ok || opt.Value == nil || opt.Value.Kind() != constant.String {
+			return nil
+		}
+		switch s := constant.StringVal(opt.Value); {
+		case s == "-c":
+			return elems[i+1]
+		case !strings.HasPrefix(s, "-"):
+			return nil
+		}
+	}
+}
+
+// path reconstructs the flow of untrusted data of kind k into v.
+func path(ins *dfa.Instance[State], v ir.Value, k Kind) []Step {
+	var steps []Step
+	seen := map[ir.Value]bool{}
+	for v != nil {
+		seen[v] = true
+		d := ins.Decision(v)
+		pos := d.Pos
+		if !pos.IsValid() {
+			pos = v.Pos()
+		}
+		if d.Description != "" && pos.IsValid() {
+			steps = append(steps, Step{At: pos, Description: d.Description})
+		}
+		v = nil
+		for _, in := range d.Inputs {
+			if !seen[in] && ins.Value(in).Source&k != 0 {
+				v = in
+				break
+			}
+		}
+	}
+	// We collected the steps from the sink to the source.
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps
+}
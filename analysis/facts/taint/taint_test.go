@@ -0,0 +1,11 @@
+package taint
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestTaint(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "example.com/Taint")
+}
@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"net/http"
+	"os/exec"
+	"strings"
+)
+
+func identity(s string) string { // want identity:`taint\(result: param0\(sql\|exec\|html\|path\)\)`
+	return s
+}
+
+func concat(a, b string) string { // want concat:`taint\(result: param0\(sql\|exec\|html\|path\) param1\(sql\|exec\|html\|path\)\)`
+	return a + "," + b
+}
+
+func length(s string) int {
+	return len(s)
+}
+
+func constant(s string) string {
+	return "constant"
+}
+
+func escape(s string) string { // want escape:`taint\(result: param0\(sql\|exec\|path\)\)`
+	return html.EscapeString(s)
+}
+
+func join(parts []string) string { // want join:`taint\(result: param0\(sql\|exec\|html\|path\)\)`
+	return strings.Join(parts, ",")
+}
+
+func build(s string) string { // want build:`taint\(result: param0\(sql\|exec\|html\|path\)\)`
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	sb.WriteString(s)
+	return sb.String()
+}
+
+type T struct{ s string }
+
+func (t *T) set(s string) { // want set:`taint\(result: clean; param0 ← param1\(sql\|exec\|html\|path\)\)`
+	t.s = s
+}
+
+func (t *T) get() string { // want get:`taint\(result: param0\(sql\|exec\|html\|path\)\)`
+	return t.s
+}
+
+func query(db *sql.DB, q string) { // want query:`taint\(result: clean; param1 → sql\)`
+	db.Query(q)
+}
+
+func indirectQuery(db *sql.DB, q string) { // want indirectQuery:`taint\(result: clean; param1 → sql\)`
+	query(db, "SELECT "+q)
+}
+
+func command(name, arg string) { // want command:`taint\(result: clean; param0 → exec\)`
+	exec.Command(name, arg).Run()
+}
+
+func shell(script, arg string) { // want shell:`taint\(result: clean; param0 → exec\)`
+	exec.Command("/bin/sh", "-e", "-c", script, "sh", arg).Run()
+}
+
+func notShell(script string) {
+	exec.Command("sh", script).Run()
+}
+
+func formValue(r *http.Request) string { // want formValue:`taint\(result: source\(sql\|exec\|html\|path\)\)`
+	return r.FormValue("name")
+}
+
+func queryValue(r *http.Request) string { // want queryValue:`taint\(result: source\(sql\|exec\|html\|path\)\)`
+	return r.URL.Query().Get("name")
+}
+
+func recursive(s string, n int) string { // want recursive:`taint\(result: param0\(sql\|exec\|html\|path\)\)`
+	if n == 0 {
+		return s
+	}
+	return recursive(s, n-1)
+}
+
+func sprintf(s string) string { // want sprintf:`taint\(result: param0\(sql\|exec\|html\|path\)\)`
+	return fmt.Sprintf("%s", s)
+}
+
+func setIndirectly(t *T, s string) { // want setIndirectly:`taint\(result: clean; param0 ← param1\(sql\|exec\|html\|path\)\)`
+	t.set(s)
+}
+
+func closure(r *http.Request) func() string { // want closure:`taint\(result: source\(sql\|exec\|html\|path\)\)`
+	// The closure carries the data that it captures
+	return func() string {
+		return r.FormValue("name")
+	}
+}
@@ -0,0 +1,970 @@
+// Package intervals implements a value-range analysis of integers, built on package dfa.
+//
+// For every integer value in a function, the analysis computes an interval [lo, hi] that contains all values it may
+// have at run time. Values of slice, string, array and pointer to array types are mapped to the interval of their
+// lengths. Conditional branches refine the intervals of variables via Sigma nodes. Loops are handled by widening ϕ nodes
+// to constants that occur in the function, or to the bounds of integer types.
+//
+// Bounds are stored as int64. Upper bounds of math.MaxInt64 are treated as unbounded, which allows representing the
+// ranges of uint64 values.
+//
+// The sizes of int, uint and uintptr depend on the platform. Functions are analyzed once with 32-bit and once with
+// 64-bit words, and the results hold on both kinds of platforms. Converting a uint32 to an int, for example, may
+// produce negative values, because they wrap around on 32-bit platforms.
+package intervals
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"honnef.co/go/tools/analysis/dfa"
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/internal/passes/buildir"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// An Interval is the set of integers between Lo and Hi, inclusive.
+type Interval struct {
+	Lo, Hi int64
+	// special is non-zero for ⊥ and ⊤
+	special uint8
+}
+
+var (
+	// Bottom is the interval of values that haven't been computed yet, or that are unreachable.
+	Bottom = Interval{special: 1}
+	// Top is the interval of values that we know nothing about.
+	Top = Interval{special: 2}
+)
+
+// New returns the interval [lo, hi], or Bottom if lo > hi.
+func New(lo, hi int64) Interval {
+	if lo > hi {
+		return Bottom
+	}
+	return Interval{Lo: lo, Hi: hi}
+}
+
+// Known reports whether iv is neither ⊥ nor ⊤.
+func (iv Interval) Known() bool { return iv.special == 0 }
+
+// Unbounded reports whether iv has no upper bound.
+func (iv Interval) Unbounded() bool { return iv.Hi == math.MaxInt64 }
+
+// Singleton reports whether iv contains exactly one value, and returns it.
+func (iv Interval) Singleton() (int64, bool) {
+	if iv.Known() && iv.Lo == iv.Hi && !iv.Unbounded() {
+		return iv.Lo, true
+	}
+	return 0, false
+}
+
+func (iv Interval) String() string {
+	switch iv {
+	case Bottom:
+		return "⊥"
+	case Top:
+		return "⊤"
+	}
+	lo := fmt.Sprint(iv.Lo)
+	if iv.Lo == math.MinInt64 {
+		lo = "-∞"
+	}
+	hi := fmt.Sprint(iv.Hi)
+	if iv.Unbounded() {
+		hi = "∞"
+	}
+	return fmt.Sprintf("[%s, %s]", lo, hi)
+}
+
+func join(a, b Interval) Interval {
+	return Interval{Lo: min(a.Lo, b.Lo), Hi: max(a.Hi, b.Hi)}
+}
+
+// words are the sizes of int, uint and uintptr that we analyze functions with.
+var words = [2]int{32, 64}
+
+// Function is the result of analyzing a single function.
+type Function struct {
+	// ins holds the analyses for each of the sizes in words.
+	ins  [len(words)]*dfa.Instance[Interval]
+	dead map[*ir.BasicBlock]bool
+}
+
+// Value returns the interval of v, which must be an integer, or the interval of the length of v, which must be a
+// slice, string, array or pointer to array. It returns Top for values of other types, and Bottom for values that are
+// never computed. The interval contains the values of v on all platforms.
+func (fn *Function) Value(v ir.Value) Interval {
+	out := Bottom
+	for i, word := range words {
+		a := analyzer{ins: fn.ins[i], word: word}
+		iv := a.get(v)
+		switch {
+		case iv == Bottom:
+		case out == Bottom:
+			out = iv
+		case !iv.Known() || !out.Known():
+			return Top
+		default:
+			out = join(out, iv)
+		}
+	}
+	return out
+}
+
+// Compare evaluates the comparison x op y, where x and y are integers of the same type. If the comparison has the same
+// outcome for all values in the intervals of x and y, on all platforms, it returns the outcome and true.
+func (fn *Function) Compare(op token.Token, x, y ir.Value) (result bool, ok bool) {
+	for i, word := range words {
+		a := analyzer{ins: fn.ins[i], word: word}
+		r, ok := a.compare(op, x, y)
+		if !ok || (i > 0 && r != result) {
+			return false, false
+		}
+		result = r
+	}
+	return result, true
+}
+
+func (a *analyzer) compare(op token.Token, x, y ir.Value) (result bool, ok bool) {
+	if !isInteger(x.Type()) {
+		return false, false
+	}
+	xi, yi := a.get(x), a.get(y)
+	if !xi.Known() || !yi.Known() {
+		return false, false
+	}
+	xlo, xhi := a.toBig(xi, x.Type())
+	ylo, yhi := a.toBig(yi, y.Type())
+	switch op {
+	case token.GTR, token.GEQ:
+		xlo, xhi, ylo, yhi = ylo, yhi, xlo, xhi
+		if op == token.GTR {
+			op = token.LSS
+		} else {
+			op = token.LEQ
+		}
+	}
+	switch op {
+	case token.LSS:
+		if xhi.Cmp(ylo) < 0 {
+			return true, true
+		}
+		if xlo.Cmp(yhi) >= 0 {
+			return false, true
+		}
+	case token.LEQ:
+		if xhi.Cmp(ylo) <= 0 {
+			return true, true
+		}
+		if xlo.Cmp(yhi) > 0 {
+			return false, true
+		}
+	case token.EQL, token.NEQ:
+		eq := op == token.EQL
+		if xlo.Cmp(xhi) == 0 && ylo.Cmp(yhi) == 0 && xlo.Cmp(ylo) == 0 {
+			return eq, true
+		}
+		if xhi.Cmp(ylo) < 0 || yhi.Cmp(xlo) < 0 {
+			return !eq, true
+		}
+	}
+	return false, false
+}
+
+// Dead reports whether b can never be executed, because the conditions leading to it are provably false on all
+// platforms.
+func (fn *Function) Dead(b *ir.BasicBlock) bool {
+	return fn.dead[b]
+}
+
+// Result maps functions to the results of their analyses.
+type Result map[*ir.Function]*Function
+
+var Analyzer = &analysis.Analyzer{
+	Name:       "intervals",
+	Doc:        "Computes the ranges of integer values",
+	Run:        run,
+	Requires:   []*analysis.Analyzer{buildir.Analyzer},
+	ResultType: reflect.TypeOf(Result{}),
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	out := Result{}
+	for _, fn := range pass.ResultOf[buildir.Analyzer].(*buildir.IR).SrcFuncs {
+		out[fn] = Analyze(fn)
+	}
+	return out, nil
+}
+
+// Analyze computes the intervals of values in fn.
+func Analyze(fn *ir.Function) *Function {
+	res := &Function{dead: map[*ir.BasicBlock]bool{}}
+	dead := map[*ir.BasicBlock]int{}
+	ts := thresholds(fn)
+	for i, word := range words {
+		a := &analyzer{word: word, thresholds: ts}
+		fw := &dfa.Framework[Interval]{
+			Join:     join,
+			Widen:    a.widen,
+			Transfer: a.transfer,
+			Bottom:   Bottom,
+			Top:      Top,
+		}
+		a.ins = fw.Start()
+		a.ins.Forward(fn)
+		res.ins[i] = a.ins
+
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				sigma, ok := instr.(*ir.Sigma)
+				if !ok {
+					break
+				}
+				if tracked(sigma.Type()) && a.get(sigma.X).Known() && a.get(sigma) == Bottom {
+					dead[b]++
+					break
+				}
+			}
+		}
+	}
+	for b, n := range dead {
+		if n == len(words) {
+			res.dead[b] = true
+		}
+	}
+	// Blocks dominated by dead blocks are dead, too.
+	var markDead func(b *ir.BasicBlock)
+	markDead = func(b *ir.BasicBlock) {
+		res.dead[b] = true
+		for _, c := range b.Dominees() {
+			if !res.dead[c] {
+				markDead(c)
+			}
+		}
+	}
+	for _, b := range fn.Blocks {
+		if res.dead[b] {
+			markDead(b)
+		}
+	}
+	return res
+}
+
+// thresholds returns the sorted bounds that ϕ nodes get widened to: the integer constants used in fn, and the bounds
+// of all integer types.
+func thresholds(fn *ir.Function) []int64 {
+	set := map[int64]struct{}{
+		math.MinInt8: {}, math.MaxInt8: {}, math.MaxUint8: {},
+		math.MinInt16: {}, math.MaxInt16: {}, math.MaxUint16: {},
+		math.MinInt32: {}, math.MaxInt32: {}, math.MaxUint32: {},
+		math.MinInt64: {}, math.MaxInt64: {},
+		0: {},
+	}
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			k, ok := instr.(*ir.Const)
+			if !ok || k.Value == nil || k.Value.Kind() != constant.Int {
+				continue
+			}
+			if c, ok := constant.Int64Val(k.Value); ok {
+				set[c] = struct{}{}
+				if c > math.MinInt64 {
+					set[c-1] = struct{}{}
+				}
+			}
+		}
+	}
+	out := make([]int64, 0, len(set))
+	for c := range set {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+type analyzer struct {
+	ins *dfa.Instance[Interval]
+	// word is the size of int, uint and uintptr, in bits.
+	word       int
+	thresholds []int64
+}
+
+func (a *analyzer) widen(old, new Interval) Interval {
+	out := new
+	if new.Lo < old.Lo {
+		i := sort.Search(len(a.thresholds), func(i int) bool { return a.thresholds[i] > new.Lo })
+		out.Lo = a.thresholds[i-1]
+	}
+	if new.Hi > old.Hi {
+		i := sort.Search(len(a.thresholds), func(i int) bool { return a.thresholds[i] >= new.Hi })
+		out.Hi = a.thresholds[i]
+	}
+	return out
+}
+
+// tracked reports whether the analysis computes intervals for values of type T.
+func tracked(T types.Type) bool {
+	if _, ok := T.(*types.TypeParam); ok {
+		// Type parameters may be instantiated with integers of any size, and with strings as well as slices.
+		return false
+	}
+	switch T := T.Underlying().(type) {
+	case *types.Basic:
+		return T.Info()&(types.IsInteger|types.IsString) != 0
+	case *types.Slice, *types.Array:
+		return true
+	case *types.Pointer:
+		_, ok := T.Elem().Underlying().(*types.Array)
+		return ok
+	default:
+		return false
+	}
+}
+
+func isInteger(T types.Type) bool {
+	basic, ok := T.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsInteger != 0
+}
+
+// WordSized reports whether T is int, uint or uintptr, whose sizes depend on the platform.
+func WordSized(T types.Type) bool {
+	basic, ok := T.Underlying().(*types.Basic)
+	if !ok {
+		return false
+	}
+	switch basic.Kind() {
+	case types.Int, types.Uint, types.Uintptr:
+		return true
+	default:
+		return false
+	}
+}
+
+// bits returns the size of the integer type T in bits.
+func (a *analyzer) bits(T types.Type) int {
+	basic := T.Underlying().(*types.Basic)
+	switch basic.Kind() {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32:
+		return 32
+	case types.Int, types.Uint, types.Uintptr:
+		return a.word
+	default:
+		return 64
+	}
+}
+
+// bounds returns the smallest and largest values of the integer type T.
+func (a *analyzer) bounds(T types.Type) (lo, hi *big.Int) {
+	size := a.bits(T)
+	basic := T.Underlying().(*types.Basic)
+	if basic.Info()&types.IsUnsigned != 0 {
+		hi := new(big.Int).Lsh(big.NewInt(1), uint(size))
+		return big.NewInt(0), hi.Sub(hi, big.NewInt(1))
+	}
+	lo = new(big.Int).Lsh(big.NewInt(1), uint(size-1))
+	hi = new(big.Int).Sub(lo, big.NewInt(1))
+	return lo.Neg(lo), hi
+}
+
+// fits reports whether all values of the integer type T fit in an int64.
+func (a *analyzer) fits(T types.Type) bool {
+	_, hi := a.bounds(T)
+	return hi.IsInt64()
+}
+
+// Range returns the interval of all values of the integer type T. For int, uint and uintptr, this is the range of
+// their 64-bit variants, which includes that of the 32-bit ones.
+func Range(T types.Type) Interval {
+	a := analyzer{word: 64}
+	lo, hi := a.bounds(T)
+	return a.fromBig(lo, hi, T)
+}
+
+// unknown returns the interval of a value of type T that we know nothing about.
+func (a *analyzer) unknown(T types.Type) Interval {
+	if isInteger(T) {
+		lo, hi := a.bounds(T)
+		return a.fromBig(lo, hi, T)
+	}
+	if n, ok := arrayLen(T); ok {
+		return New(n, n)
+	}
+	return New(0, math.MaxInt64)
+}
+
+func arrayLen(T types.Type) (int64, bool) {
+	T = T.Underlying()
+	if ptr, ok := T.(*types.Pointer); ok {
+		T = ptr.Elem().Underlying()
+	}
+	if arr, ok := T.(*types.Array); ok {
+		return arr.Len(), true
+	}
+	return 0, false
+}
+
+// toBig returns the bounds of iv, which is an interval of values of type T, as big integers. The bounds are limited
+// to the range of T, which gives unbounded intervals the largest value of T as their upper bound. Widening may
+// produce bounds outside the range of int when it is 32 bits wide.
+func (a *analyzer) toBig(iv Interval, T types.Type) (lo, hi *big.Int) {
+	lo, hi = big.NewInt(iv.Lo), big.NewInt(iv.Hi)
+	if isInteger(T) {
+		tlo, thi := a.bounds(T)
+		lo = maxBig(lo, tlo)
+		if iv.Unbounded() {
+			hi = thi
+		} else {
+			hi = minBig(hi, thi)
+		}
+	}
+	return lo, hi
+}
+
+// fromBig returns the interval [lo, hi] of values of type T. Integer values that don't fit the type wrap around, in
+// which case the result is the whole range of T.
+func (a *analyzer) fromBig(lo, hi *big.Int, T types.Type) Interval {
+	if lo.Cmp(hi) > 0 {
+		return Bottom
+	}
+	if isInteger(T) {
+		tlo, thi := a.bounds(T)
+		if lo.Cmp(tlo) < 0 || hi.Cmp(thi) > 0 {
+			lo, hi = tlo, thi
+		}
+	}
+	iv := Interval{Lo: math.MinInt64, Hi: math.MaxInt64}
+	if lo.IsInt64() {
+		iv.Lo = lo.Int64()
+	} else if lo.Sign() > 0 {
+		iv.Lo = math.MaxInt64
+	}
+	if hi.IsInt64() {
+		iv.Hi = hi.Int64()
+	} else if hi.Sign() < 0 {
+		iv.Hi = math.MinInt64
+	}
+	return iv
+}
+
+// get returns the interval of v.
+func (a *analyzer) get(v ir.Value) Interval {
+	if !tracked(v.Type()) {
+		return Top
+	}
+	if n, ok := arrayLen(v.Type()); ok {
+		return New(n, n)
+	}
+	if k, ok := v.(*ir.Const); ok {
+		return a.constant(k)
+	}
+	if _, ok := v.(ir.Instruction); ok {
+		return a.ins.Value(v)
+	}
+	// Free variables and globals
+	return a.unknown(v.Type())
+}
+
+func (a *analyzer) constant(k *ir.Const) Interval {
+	if k.Value == nil {
+		// The zero value of a slice
+		return New(0, 0)
+	}
+	switch k.Value.Kind() {
+	case constant.Int:
+		lo := constant.Val(constant.ToInt(k.Value))
+		var n *big.Int
+		switch lo := lo.(type) {
+		case int64:
+			n = big.NewInt(lo)
+		case *big.Int:
+			n = lo
+		default:
+			return a.unknown(k.Type())
+		}
+		return a.fromBig(n, n, k.Type())
+	case constant.String:
+		n := int64(len(constant.StringVal(k.Value)))
+		return New(n, n)
+	default:
+		return a.unknown(k.Type())
+	}
+}
+
+func (a *analyzer) transfer(ins *dfa.Instance[Interval], instr ir.Instruction) []dfa.Mapping[Interval] {
+	v, ok := instr.(ir.Value)
+	if !ok || !tracked(v.Type()) {
+		return nil
+	}
+	if _, ok := arrayLen(v.Type()); ok {
+		return nil
+	}
+	iv, desc := a.compute(v)
+	if iv == Bottom {
+		return nil
+	}
+	return dfa.Ms(dfa.M(v, iv, dfa.Decision{Description: desc}))
+}
+
+func (a *analyzer) compute(v ir.Value) (Interval, string) {
+	T := v.Type()
+	switch v := v.(type) {
+	case *ir.Const:
+		return a.constant(v), "constant"
+	case *ir.Sigma:
+		return a.sigma(v), "refined by branch condition"
+	case *ir.BinOp:
+		return a.binop(v), "arithmetic"
+	case *ir.UnOp:
+		return a.unop(v), "arithmetic"
+	case *ir.Convert:
+		x := a.get(v.X)
+		if x == Bottom {
+			return Bottom, ""
+		}
+		if isInteger(T) && isInteger(v.X.Type()) && x.Known() {
+			lo, hi := a.toBig(x, v.X.Type())
+			return a.fromBig(lo, hi, T), "conversion"
+		}
+		if !isInteger(T) && !isInteger(v.X.Type()) && x.Known() {
+			// Conversions between strings and byte slices preserve the length. Conversions involving runes
+			// may change it.
+			if isBytes(T) || isBytes(v.X.Type()) {
+				return x, "conversion"
+			}
+		}
+		return a.unknown(T), "conversion"
+	case *ir.ChangeType:
+		return a.get(v.X), "conversion"
+	case *ir.MakeSlice:
+		n := a.get(v.Len)
+		if n == Bottom {
+			return Bottom, ""
+		}
+		if !n.Known() {
+			return a.unknown(T), "make"
+		}
+		return a.nonNegative(n), "make"
+	case *ir.Slice:
+		return a.slice(v), "slicing"
+	case *ir.Call:
+		return a.call(v)
+	default:
+		return a.unknown(T), "unknown value"
+	}
+}
+
+func isBytes(T types.Type) bool {
+	s, ok := T.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	basic, ok := s.Elem().Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.Byte
+}
+
+func (a *analyzer) nonNegative(iv Interval) Interval {
+	return New(max(iv.Lo, 0), iv.Hi)
+}
+
+func (a *analyzer) slice(v *ir.Slice) Interval {
+	x := a.get(v.X)
+	if x == Bottom {
+		return Bottom
+	}
+	lo := New(0, 0)
+	if v.Low != nil {
+		lo = a.get(v.Low)
+	}
+	hi := x
+	if v.High != nil {
+		hi = a.get(v.High)
+	}
+	if lo == Bottom || hi == Bottom {
+		return Bottom
+	}
+	if !lo.Known() || !hi.Known() || hi.Unbounded() {
+		return a.unknown(v.Type())
+	}
+	// len = hi - lo, and slicing panics for negative lengths.
+	out := New(max(sub(hi.Lo, lo.Hi), 0), max(sub(hi.Hi, lo.Lo), 0))
+	if out.Hi < 0 || out.Lo > out.Hi {
+		return a.unknown(v.Type())
+	}
+	return out
+}
+
+// sub returns x-y, saturating at the bounds of int64.
+func sub(x, y int64) int64 {
+	r := new(big.Int).Sub(big.NewInt(x), big.NewInt(y))
+	switch {
+	case r.IsInt64():
+		return r.Int64()
+	case r.Sign() > 0:
+		return math.MaxInt64
+	default:
+		return math.MinInt64
+	}
+}
+
+func (a *analyzer) call(v *ir.Call) (Interval, string) {
+	T := v.Type()
+	builtin, ok := v.Call.Value.(*ir.Builtin)
+	if !ok {
+		return a.unknown(T), "function call"
+	}
+	args := make([]Interval, len(v.Call.Args))
+	for i, arg := range v.Call.Args {
+		args[i] = a.get(arg)
+		if args[i] == Bottom {
+			return Bottom, ""
+		}
+	}
+	switch builtin.Name() {
+	case "len":
+		if tracked(v.Call.Args[0].Type()) && args[0].Known() {
+			return args[0], "length"
+		}
+	case "cap":
+		if tracked(v.Call.Args[0].Type()) && args[0].Known() {
+			return New(args[0].Lo, math.MaxInt64), "capacity"
+		}
+	case "append":
+		if args[0].Known() {
+			return New(args[0].Lo, math.MaxInt64), "append"
+		}
+	case "copy":
+		if args[0].Known() && args[1].Known() {
+			return New(0, min(args[0].Hi, args[1].Hi)), "copy"
+		}
+	case "min", "max":
+		if !isInteger(T) {
+			break
+		}
+		out := args[0]
+		for _, arg := range args[1:] {
+			if !arg.Known() || !out.Known() {
+				return a.unknown(T), "min/max"
+			}
+			if builtin.Name() == "min" {
+				out = New(min(out.Lo, arg.Lo), min(out.Hi, arg.Hi))
+			} else {
+				out = New(max(out.Lo, arg.Lo), max(out.Hi, arg.Hi))
+			}
+		}
+		if out.Known() {
+			return out, builtin.Name()
+		}
+	}
+	return a.unknown(T), "builtin call"
+}
+
+func (a *analyzer) unop(v *ir.UnOp) Interval {
+	T := v.Type()
+	if v.Op != token.SUB && v.Op != token.XOR {
+		return a.unknown(T)
+	}
+	x := a.get(v.X)
+	if x == Bottom {
+		return Bottom
+	}
+	if !x.Known() || !isInteger(T) {
+		return a.unknown(T)
+	}
+	lo, hi := a.toBig(x, T)
+	switch v.Op {
+	case token.SUB:
+		return a.fromBig(new(big.Int).Neg(hi), new(big.Int).Neg(lo), T)
+	default:
+		// ^x
+		if T.Underlying().(*types.Basic).Info()&types.IsUnsigned != 0 {
+			_, max := a.bounds(T)
+			return a.fromBig(new(big.Int).Sub(max, hi), new(big.Int).Sub(max, lo), T)
+		}
+		one := big.NewInt(1)
+		nlo := new(big.Int).Neg(hi)
+		nhi := new(big.Int).Neg(lo)
+		return a.fromBig(nlo.Sub(nlo, one), nhi.Sub(nhi, one), T)
+	}
+}
+
+// maxShift is the largest shift amount we compute with. Shifting values of at most 64 bits by more than that has the
+// same effect.
+const maxShift = 128
+
+func (a *analyzer) binop(v *ir.BinOp) Interval {
+	T := v.Type()
+	x, y := a.get(v.X), a.get(v.Y)
+	if x == Bottom || y == Bottom {
+		return Bottom
+	}
+	if !x.Known() || !y.Known() {
+		return a.unknown(T)
+	}
+	if !isInteger(T) {
+		// String concatenation
+		if v.Op == token.ADD {
+			xlo, xhi := a.toBig(x, T)
+			ylo, yhi := a.toBig(y, T)
+			return a.fromBig(xlo.Add(xlo, ylo), xhi.Add(xhi, yhi), T)
+		}
+		return a.unknown(T)
+	}
+
+	xlo, xhi := a.toBig(x, v.X.Type())
+	ylo, yhi := a.toBig(y, v.Y.Type())
+	zero := big.NewInt(0)
+	switch v.Op {
+	case token.ADD:
+		return a.fromBig(new(big.Int).Add(xlo, ylo), new(big.Int).Add(xhi, yhi), T)
+	case token.SUB:
+		return a.fromBig(new(big.Int).Sub(xlo, yhi), new(big.Int).Sub(xhi, ylo), T)
+	case token.MUL:
+		return a.corners(xlo, xhi, ylo, yhi, T, (*big.Int).Mul)
+	case token.QUO:
+		// Compute the quotient separately for negative and positive divisors.
+		var out Interval = Bottom
+		if ylo.Sign() < 0 {
+			out = a.corners(xlo, xhi, ylo, minBig(yhi, big.NewInt(-1)), T, (*big.Int).Quo)
+		}
+		if yhi.Sign() > 0 {
+			q := a.corners(xlo, xhi, maxBig(ylo, big.NewInt(1)), yhi, T, (*big.Int).Quo)
+			if out == Bottom {
+				out = q
+			} else if q != Bottom {
+				out = join(out, q)
+			}
+		}
+		// If the divisor is always zero, the division always panics and out is Bottom. Returning Bottom, instead of
+		// an unknown value, keeps the transfer function monotonic when the divisor's interval grows.
+		return out
+	case token.REM:
+		// The result has the sign of x and a magnitude smaller than that of both x and y.
+		m := maxBig(new(big.Int).Abs(ylo), new(big.Int).Abs(yhi))
+		if m.Sign() == 0 {
+			// Division by zero always panics. See the comment for QUO.
+			return Bottom
+		}
+		m.Sub(m, big.NewInt(1))
+		lo, hi := maxBig(xlo, new(big.Int).Neg(m)), minBig(xhi, m)
+		if xlo.Sign() >= 0 {
+			lo = zero
+		}
+		if xhi.Sign() <= 0 {
+			hi = zero
+		}
+		return a.fromBig(lo, hi, T)
+	case token.AND:
+		switch {
+		case xlo.Sign() >= 0 && ylo.Sign() >= 0:
+			return a.fromBig(zero, minBig(xhi, yhi), T)
+		case xlo.Sign() >= 0:
+			return a.fromBig(zero, xhi, T)
+		case ylo.Sign() >= 0:
+			return a.fromBig(zero, yhi, T)
+		}
+	case token.AND_NOT:
+		if xlo.Sign() >= 0 {
+			return a.fromBig(zero, xhi, T)
+		}
+	case token.OR, token.XOR:
+		if xlo.Sign() >= 0 && ylo.Sign() >= 0 {
+			m := maxBig(xhi, yhi)
+			hi := new(big.Int).Lsh(big.NewInt(1), uint(m.BitLen()))
+			hi.Sub(hi, big.NewInt(1))
+			lo := zero
+			if v.Op == token.OR {
+				lo = maxBig(xlo, ylo)
+			}
+			return a.fromBig(lo, hi, T)
+		}
+	case token.SHL:
+		if xlo.Sign() >= 0 && ylo.Sign() >= 0 {
+			if yhi.Cmp(big.NewInt(maxShift)) > 0 {
+				return a.unknown(T)
+			}
+			return a.fromBig(new(big.Int).Lsh(xlo, uint(ylo.Int64())), new(big.Int).Lsh(xhi, uint(yhi.Int64())), T)
+		}
+	case token.SHR:
+		if ylo.Sign() >= 0 {
+			slo := uint(minBig(ylo, big.NewInt(maxShift)).Int64())
+			shi := uint(minBig(yhi, big.NewInt(maxShift)).Int64())
+			var lo, hi *big.Int
+			if xlo.Sign() >= 0 {
+				lo = new(big.Int).Rsh(xlo, shi)
+			} else {
+				lo = new(big.Int).Rsh(xlo, slo)
+			}
+			if xhi.Sign() >= 0 {
+				hi = new(big.Int).Rsh(xhi, slo)
+			} else {
+				hi = new(big.Int).Rsh(xhi, shi)
+			}
+			return a.fromBig(lo, hi, T)
+		}
+	}
+	return a.unknown(T)
+}
+
+// corners computes the interval of op applied to all combinations of the bounds of x and y. This is correct for
+// operations that are monotonic in each argument.
+func (a *analyzer) corners(xlo, xhi, ylo, yhi *big.Int, T types.Type, op func(z, x, y *big.Int) *big.Int) Interval {
+	var lo, hi *big.Int
+	for _, x := range []*big.Int{xlo, xhi} {
+		for _, y := range []*big.Int{ylo, yhi} {
+			r := op(new(big.Int), x, y)
+			if lo == nil || r.Cmp(lo) < 0 {
+				lo = r
+			}
+			if hi == nil || r.Cmp(hi) > 0 {
+				hi = r
+			}
+		}
+	}
+	return a.fromBig(lo, hi, T)
+}
+
+func minBig(x, y *big.Int) *big.Int {
+	if x.Cmp(y) < 0 {
+		return x
+	}
+	return y
+}
+
+func maxBig(x, y *big.Int) *big.Int {
+	if x.Cmp(y) > 0 {
+		return x
+	}
+	return y
+}
+
+// sigma refines the interval of a Sigma's value using the branch condition that leads to it.
+func (a *analyzer) sigma(v *ir.Sigma) Interval {
+	x := a.get(v.X)
+	if x == Bottom {
+		return Bottom
+	}
+	iff, ok := v.From.Control().(*ir.If)
+	if !ok || len(v.From.Succs) != 2 || v.From.Succs[0] == v.From.Succs[1] {
+		return x
+	}
+	cond, ok := iff.Cond.(*ir.BinOp)
+	if !ok {
+		return x
+	}
+	op := cond.Op
+	// Normalize to "v.X op other"
+	var other ir.Value
+	switch {
+	case a.refers(cond.X, v.X):
+		other = cond.Y
+	case a.refers(cond.Y, v.X):
+		other = cond.X
+		switch op {
+		case token.LSS:
+			op = token.GTR
+		case token.LEQ:
+			op = token.GEQ
+		case token.GTR:
+			op = token.LSS
+		case token.GEQ:
+			op = token.LEQ
+		}
+	default:
+		return x
+	}
+	if v.Block() == v.From.Succs[1] {
+		// False branch
+		switch op {
+		case token.LSS:
+			op = token.GEQ
+		case token.LEQ:
+			op = token.GTR
+		case token.GTR:
+			op = token.LEQ
+		case token.GEQ:
+			op = token.LSS
+		case token.EQL:
+			op = token.NEQ
+		case token.NEQ:
+			op = token.EQL
+		default:
+			return x
+		}
+	}
+	y := a.get(other)
+	if y == Bottom {
+		return Bottom
+	}
+	if !x.Known() || !y.Known() {
+		return x
+	}
+	// For unsigned 64-bit integers, an upper bound of math.MaxInt64 stands for values that don't fit in an int64.
+	// Subtracting from it would lose those values.
+	wide := isInteger(v.X.Type()) && !a.fits(v.X.Type())
+	switch op {
+	case token.LSS:
+		if y.Hi == math.MinInt64 {
+			return Bottom
+		}
+		if wide && y.Unbounded() {
+			return x
+		}
+		return New(x.Lo, min(x.Hi, y.Hi-1))
+	case token.LEQ:
+		return New(x.Lo, min(x.Hi, y.Hi))
+	case token.GTR:
+		if y.Lo == math.MaxInt64 {
+			if wide {
+				return New(y.Lo, x.Hi)
+			}
+			return Bottom
+		}
+		return New(max(x.Lo, y.Lo+1), x.Hi)
+	case token.GEQ:
+		return New(max(x.Lo, y.Lo), x.Hi)
+	case token.EQL:
+		return New(max(x.Lo, y.Lo), min(x.Hi, y.Hi))
+	case token.NEQ:
+		if c, ok := y.Singleton(); ok {
+			switch {
+			case x.Lo == c && x.Hi == c:
+				return Bottom
+			case x.Lo == c:
+				return New(c+1, x.Hi)
+			case x.Hi == c:
+				return New(x.Lo, c-1)
+			}
+		}
+		return x
+	default:
+		return x
+	}
+}
+
+// refers reports whether operand is x or, if x is a sequence, the length of x.
+func (a *analyzer) refers(operand, x ir.Value) bool {
+	if operand == x {
+		return isInteger(x.Type())
+	}
+	if isInteger(x.Type()) {
+		return false
+	}
+	call, ok := operand.(*ir.Call)
+	if !ok {
+		return false
+	}
+	builtin, ok := call.Call.Value.(*ir.Builtin)
+	return ok && builtin.Name() == "len" && call.Call.Args[0] == x
+}
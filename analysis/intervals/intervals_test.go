@@ -0,0 +1,76 @@
+package intervals_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"honnef.co/go/tools/analysis/intervals"
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/irutil"
+)
+
+var annotation = regexp.MustCompile(`// (\[.*\])$`)
+
+// TestIntervals checks the intervals of the arguments to calls of use in testdata/intervals.go against the
+// annotations on the same lines.
+func TestIntervals(t *testing.T) {
+	fset := token.NewFileSet()
+	filename := filepath.Join("testdata", "intervals.go")
+	f, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &types.Config{Importer: importer.Default()}
+	pkg, _, err := irutil.BuildPackage(conf, fset, types.NewPackage("example.com/pkg", ""), []*ast.File{f}, ir.SanityCheckFunctions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]string{}
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			if m := annotation.FindStringSubmatch(c.Text); m != nil {
+				want[fset.Position(c.Pos()).Line] = m[1]
+			}
+		}
+	}
+
+	seen := map[int]bool{}
+	for fn := range irutil.AllFunctions(pkg.Prog) {
+		if fn.Pkg != pkg {
+			continue
+		}
+		res := intervals.Analyze(fn)
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				call, ok := instr.(*ir.Call)
+				if !ok {
+					continue
+				}
+				if callee := call.Call.StaticCallee(); callee == nil || callee.Name() != "use" {
+					continue
+				}
+				line := fset.Position(call.Pos()).Line
+				exp, ok := want[line]
+				if !ok {
+					continue
+				}
+				seen[line] = true
+				if got := res.Value(call.Call.Args[0]).String(); got != exp {
+					t.Errorf("line %d: got %s, want %s", line, got, exp)
+				}
+			}
+		}
+	}
+	for line := range want {
+		if !seen[line] {
+			t.Errorf("line %d: no call of use", line)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package pkg
+
+func use(x int64) {}
+
+func fn1(x int, s []int, str string) {
+	use(int64(x)) // [-∞, ∞]
+	if x > 10 && x < 20 {
+		use(int64(x))     // [11, 19]
+		use(int64(x * 2)) // [22, 38]
+		use(int64(x / 3)) // [3, 6]
+		use(int64(x % 7)) // [0, 6]
+		use(int64(-x))    // [-19, -11]
+	}
+	if x >= 0 {
+		use(int64(x & 0xFF)) // [0, 255]
+		use(int64(x >> 60))  // [0, 7]
+		use(int64(uint8(x))) // [0, 255]
+		use(int64(x | 1))    // [1, ∞]
+	}
+	if x == 5 {
+		use(int64(x)) // [5, 5]
+	}
+	if x != 5 {
+		use(int64(x)) // [-∞, ∞]
+	}
+
+	use(int64(len(s))) // [0, ∞]
+	if len(s) < 4 {
+		use(int64(len(s))) // [0, 3]
+	}
+	s2 := make([]int, 3, 10)
+	use(int64(len(s2)))         // [3, 3]
+	use(int64(len(s2[1:])))     // [2, 2]
+	use(int64(len(str + "ab"))) // [2, ∞]
+	var arr [8]int
+	use(int64(len(arr))) // [8, 8]
+}
+
+func fn2() {
+	for i := 0; i < 10; i++ {
+		use(int64(i)) // [0, 9]
+	}
+	var j uint8
+	for j < 200 {
+		j++
+	}
+	use(int64(j)) // [200, 200]
+	k := 0
+	for {
+		use(int64(k)) // [0, 99]
+		k++
+		if k == 100 {
+			k = 0
+		}
+	}
+}
+
+func fn3(x uint64, y uint) {
+	use(int64(x)) // [-∞, ∞]
+	if x < 3 {
+		use(int64(x)) // [0, 2]
+	}
+	use(int64(y >> 62))   // [0, 3]
+	use(int64(min(y, 7))) // [0, 7]
+}
+
+func fn4(n int) {
+	// The divisor is zero in the first iteration, and grows afterwards.
+	d := 0
+	for i := 0; i < n; i++ {
+		use(int64(7 % d)) // [0, 7]
+		d++
+	}
+}
+
+func fn5(x uint32, y int64, z uint) {
+	// Values that don't fit in 32 bits wrap around on 32-bit platforms.
+	use(int64(int(x))) // [-2147483648, 4294967295]
+	if y >= 0 {
+		use(int64(int(y))) // [-2147483648, ∞]
+	}
+	if y >= 0 && y < 1<<20 {
+		use(int64(int(y))) // [0, 1048575]
+	}
+	if z < 10 {
+		use(int64(^z)) // [-∞, ∞]
+	}
+}
@@ -0,0 +1,138 @@
+// Package rules turns user-defined rules, declared in rule files, into
+// analyzers.
+package rules
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"regexp"
+
+	"honnef.co/go/tools/analysis/code"
+	"honnef.co/go/tools/analysis/edit"
+	"honnef.co/go/tools/analysis/facts/generated"
+	"honnef.co/go/tools/analysis/facts/tokenfile"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+	"honnef.co/go/tools/config"
+	"honnef.co/go/tools/pattern"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+)
+
+var severities = map[string]lint.Severity{
+	"":        lint.SeverityWarning,
+	"error":   lint.SeverityError,
+	"warning": lint.SeverityWarning,
+	"info":    lint.SeverityInfo,
+	"hint":    lint.SeverityHint,
+}
+
+// Analyzers returns an analyzer for each rule. The rules' patterns are
+// compiled once, here.
+//
+// An analyzer only checks packages whose configuration declares its
+// rule, which allows disabling rules for parts of a module by not
+// inheriting rule files.
+func Analyzers(rules []config.Rule) ([]*lint.Analyzer, error) {
+	out := make([]*lint.Analyzer, 0, len(rules))
+	for _, r := range rules {
+		cr, err := r.Compile()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, newAnalyzer(cr))
+	}
+	return out, nil
+}
+
+func newAnalyzer(r *config.CompiledRule) *lint.Analyzer {
+	doc := &lint.RawDocumentation{
+		Title:    r.Message,
+		Text:     fmt.Sprintf("This check is declared in the rule file %s.", r.File),
+		Severity: severities[r.Severity],
+		MergeIf:  lint.MergeIfAny,
+	}
+
+	var nodes []ast.Node
+	for T := range r.Match.Relevant {
+		nodes = append(nodes, reflect.Zero(T).Interface().(ast.Node))
+	}
+
+	a := &analysis.Analyzer{
+		Name:     r.ID,
+		Doc:      doc.Compile().String(),
+		Requires: []*analysis.Analyzer{inspect.Analyzer, generated.Analyzer, config.Analyzer, tokenfile.Analyzer},
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			if !declares(config.For(pass), r.ID) {
+				return nil, nil
+			}
+			// Reusing matchers lets them cache the types that patterns
+			// refer to.
+			m := &pattern.Matcher{TypesInfo: pass.TypesInfo, Pkg: pass.Pkg}
+			wm := &pattern.Matcher{TypesInfo: pass.TypesInfo, Pkg: pass.Pkg}
+			fn := func(node ast.Node) {
+				if !m.Match(r.Match, node) {
+					return
+				}
+				for name, q := range r.Where {
+					bound, ok := m.State[name].(ast.Node)
+					if !ok {
+						return
+					}
+					if !wm.Match(q, bound) {
+						return
+					}
+				}
+
+				var rng edit.Ranger = node
+				if m.Stmts != nil {
+					rng = edit.StmtRange(m.Stmts)
+				}
+				opts := []report.Option{report.FilterGenerated()}
+				if r.Replace != nil {
+					e := edit.ReplaceWithPattern(pass.Fset, rng, *r.Replace, m.State)
+					opts = append(opts, report.Fixes(edit.Fix(fmt.Sprintf("Replace with %s", e.NewText), e)))
+				}
+				report.Report(pass, rng, expand(pass, r.Message, m.State), opts...)
+			}
+			code.Preorder(pass, fn, nodes...)
+			return nil, nil
+		},
+	}
+	return &lint.Analyzer{Doc: doc, Analyzer: a}
+}
+
+func declares(cfg *config.Config, id string) bool {
+	for _, r := range cfg.Rules {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+var placeholderRe = regexp.MustCompile(`\$[a-zA-Z_][a-zA-Z0-9_]*`)
+
+// expand replaces references to bindings in a rule's message with the
+// bound values.
+func expand(pass *analysis.Pass, msg string, state pattern.State) string {
+	return placeholderRe.ReplaceAllStringFunc(msg, func(s string) string {
+		switch v := state[s[1:]].(type) {
+		case ast.Node:
+			return report.Render(pass, v)
+		case []ast.Expr:
+			return report.RenderArgs(pass, v)
+		case []ast.Stmt:
+			return report.Render(pass, v)
+		case types.Object:
+			return v.Name()
+		case string:
+			return v
+		default:
+			return s
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package rules
+
+import (
+	"testing"
+
+	"honnef.co/go/tools/analysis/lint/testutil"
+	"honnef.co/go/tools/config"
+)
+
+func TestTestdata(t *testing.T) {
+	cfg, err := config.Load("testdata/go1.0/Rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	as, err := Analyzers(cfg.Rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(as) != 1 {
+		t.Fatalf("got %d analyzers, want 1", len(as))
+	}
+	testutil.Run(t, as[0])
+}
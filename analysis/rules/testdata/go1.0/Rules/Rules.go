@@ -0,0 +1,15 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+func fn(buf *bytes.Buffer, s string) {
+	fmt.Fprint(buf, s)     //@ diag(`use buf.WriteString instead of fmt.Fprint`)
+	fmt.Fprint(buf, "x"+s) //@ diag(`use buf.WriteString instead of fmt.Fprint`)
+	fmt.Fprint(buf, 1)
+	fmt.Fprint(os.Stdout, s)
+	fmt.Fprint(buf, s, s)
+}
@@ -0,0 +1,387 @@
+// gogrep searches Go packages for code matching a pattern and
+// optionally rewrites the matches.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"honnef.co/go/tools/analysis/edit"
+	"honnef.co/go/tools/lintcmd/version"
+	"honnef.co/go/tools/pattern"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	fRewrite string
+	fWrite   bool
+	fList    bool
+	fJSON    bool
+	fTests   bool
+	fTags    string
+	fVersion bool
+)
+
+func init() {
+	flag.StringVar(&fRewrite, "rewrite", "", "Replace matches with `pattern`")
+	flag.BoolVar(&fWrite, "w", false, "Write rewritten files instead of printing them")
+	flag.BoolVar(&fList, "l", false, "Only list the files containing matches")
+	flag.BoolVar(&fJSON, "json", false, "Print matches as JSON")
+	flag.BoolVar(&fTests, "tests", true, "Include tests")
+	flag.StringVar(&fTags, "tags", "", "List of `build tags`")
+	flag.BoolVar(&fVersion, "version", false, "Print version and exit")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: gogrep [flags] pattern [packages]\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
+}
+
+// A match is a node that matched the pattern, together with the
+// bindings of the match.
+type match struct {
+	node ast.Node
+	// stmts are the statements matched by a Seq pattern, which are
+	// part of node.
+	stmts []ast.Stmt
+	state pattern.State
+	// edit is the replacement of the match, if rewriting.
+	edit analysis.TextEdit
+}
+
+// rng returns the range of source code that was matched.
+func (m match) rng() edit.Ranger {
+	if m.stmts != nil {
+		return edit.StmtRange(m.stmts)
+	}
+	return m.node
+}
+
+// syntax returns the matched syntax, for printing.
+func (m match) syntax() interface{} {
+	if m.stmts != nil {
+		return m.stmts
+	}
+	return m.node
+}
+
+type jsonPosition struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+type jsonMatch struct {
+	Location    jsonPosition `json:"location"`
+	End         jsonPosition `json:"end"`
+	Text        string       `json:"text"`
+	Replacement *string      `json:"replacement,omitempty"`
+}
+
+// options configures a run of gogrep.
+type options struct {
+	rewrite string
+	write   bool
+	list    bool
+	json    bool
+	tests   bool
+	tags    string
+}
+
+func main() {
+	log.SetFlags(0)
+	flag.Parse()
+
+	if fVersion {
+		version.Print(version.Version, version.MachineVersion)
+		os.Exit(0)
+	}
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	opts := options{
+		rewrite: fRewrite,
+		write:   fWrite,
+		list:    fList,
+		json:    fJSON,
+		tests:   fTests,
+		tags:    fTags,
+	}
+	if err := run(os.Stdout, opts, flag.Arg(0), flag.Args()[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run searches the packages matched by patterns for the pattern
+// query, writing its results to w.
+func run(w io.Writer, opts options, query string, patterns []string) error {
+	if opts.list && opts.json {
+		return errors.New("-l and -json are mutually exclusive")
+	}
+	if opts.list && opts.rewrite != "" {
+		return errors.New("-l and -rewrite are mutually exclusive")
+	}
+	if opts.write && opts.rewrite == "" {
+		return errors.New("-w requires -rewrite")
+	}
+
+	p := &pattern.Parser{AllowTypeInfo: true}
+	q, err := p.Parse(query)
+	if err != nil {
+		return err
+	}
+	var replace *pattern.Pattern
+	if opts.rewrite != "" {
+		p := &pattern.Parser{}
+		r, err := p.Parse(opts.rewrite)
+		if err != nil {
+			return fmt.Errorf("-rewrite: %s", err)
+		}
+		if err := pattern.CheckReplacement(q, r); err != nil {
+			return fmt.Errorf("-rewrite: %s", err)
+		}
+		replace = &r
+	}
+
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax,
+		Tests: opts.tests,
+	}
+	if opts.tags != "" {
+		cfg.BuildFlags = []string{"-tags", opts.tags}
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return err
+	}
+	if n := packages.PrintErrors(pkgs); n > 0 {
+		return fmt.Errorf("%d errors while loading packages", n)
+	}
+
+	enc := json.NewEncoder(w)
+	// With tests enabled, most files belong to more than one package.
+	// We only search each file once.
+	seen := map[string]bool{}
+	for _, pkg := range pkgs {
+		goFiles := map[string]bool{}
+		for _, name := range pkg.GoFiles {
+			goFiles[name] = true
+		}
+		for _, f := range pkg.Syntax {
+			name := pkg.Fset.File(f.Pos()).Name()
+			// Skip files generated by cgo and the go tool.
+			if !goFiles[name] || seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			matches := find(pkg, q, f, replace != nil)
+			if len(matches) == 0 {
+				continue
+			}
+			if replace != nil {
+				for i := range matches {
+					matches[i].edit = edit.ReplaceWithPattern(pkg.Fset, matches[i].rng(), *replace, matches[i].state)
+				}
+			}
+
+			switch {
+			case opts.list:
+				fmt.Fprintln(w, shortPath(name))
+			case opts.json:
+				for _, m := range matches {
+					if err := enc.Encode(toJSON(pkg.Fset, m, replace != nil)); err != nil {
+						return err
+					}
+				}
+			case replace != nil:
+				if err := rewriteFile(w, pkg.Fset, name, matches, opts.write); err != nil {
+					return err
+				}
+			default:
+				for _, m := range matches {
+					buf := &bytes.Buffer{}
+					format.Node(buf, pkg.Fset, m.syntax())
+					fmt.Fprintf(w, "%s: %s\n", relativePositionString(pkg.Fset.Position(m.rng().Pos())), buf)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// find returns the matches of q in f, in source order. When
+// rewriting, matches don't overlap: we don't look for matches inside
+// of other matches.
+func find(pkg *packages.Package, q pattern.Pattern, f *ast.File, rewriting bool) []match {
+	var out []match
+	inside := func(node ast.Node) bool {
+		for _, m := range out {
+			if r := m.rng(); r.Pos() <= node.Pos() && node.End() <= r.End() {
+				return true
+			}
+		}
+		return false
+	}
+	m := &pattern.Matcher{TypesInfo: pkg.TypesInfo, Pkg: pkg.Types}
+	ast.Inspect(f, func(node ast.Node) bool {
+		if node == nil {
+			return true
+		}
+		if rewriting && inside(node) {
+			return false
+		}
+		if _, ok := q.Relevant[reflect.TypeOf(node)]; ok {
+			if m.Match(q, node) {
+				out = append(out, match{node: node, stmts: m.Stmts, state: m.State})
+			}
+		}
+		return mayContain(node, q.Relevant)
+	})
+	// Seq patterns match statements in the middle of blocks, which
+	// may come after matches inside of earlier statements.
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].rng().Pos() < out[j].rng().Pos()
+	})
+	return out
+}
+
+var (
+	fileType       = reflect.TypeOf((*ast.File)(nil))
+	funcDeclType   = reflect.TypeOf((*ast.FuncDecl)(nil))
+	importSpecType = reflect.TypeOf((*ast.ImportSpec)(nil))
+	identType      = reflect.TypeOf((*ast.Ident)(nil))
+	basicLitType   = reflect.TypeOf((*ast.BasicLit)(nil))
+)
+
+// mayContain reports whether the children of node may contain nodes
+// of the relevant types. It allows skipping subtrees that cannot
+// possibly contain matches.
+func mayContain(node ast.Node, relevant map[reflect.Type]struct{}) bool {
+	has := func(Ts ...reflect.Type) bool {
+		for _, T := range Ts {
+			if _, ok := relevant[T]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch node := node.(type) {
+	case *ast.CommentGroup:
+		// Patterns cannot match comments.
+		return false
+	case *ast.ImportSpec:
+		return has(identType, basicLitType)
+	case *ast.GenDecl:
+		if node.Tok == token.IMPORT {
+			return has(importSpecType, identType, basicLitType)
+		}
+	}
+
+	// Files, function declarations and import specs only occur at the
+	// top level of files. When looking only for those, we needn't look
+	// inside of declarations. For example, we'll never find an
+	// ImportSpec beneath a FuncLit.
+	for T := range relevant {
+		if T != fileType && T != funcDeclType && T != importSpecType {
+			return true
+		}
+	}
+	_, ok := node.(*ast.File)
+	return ok
+}
+
+// rewriteFile applies the matches' edits to the file and either writes
+// the result back or prints it to w.
+func rewriteFile(w io.Writer, fset *token.FileSet, name string, matches []match, write bool) error {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	tf := fset.File(matches[0].node.Pos())
+	buf := &bytes.Buffer{}
+	last := 0
+	for _, m := range matches {
+		start, end := tf.Offset(m.edit.Pos), tf.Offset(m.edit.End)
+		buf.Write(src[last:start])
+		buf.Write(m.edit.NewText)
+		last = end
+	}
+	buf.Write(src[last:])
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("%s: rewritten code is invalid: %s", shortPath(name), err)
+	}
+	if !write {
+		_, err := w.Write(out)
+		return err
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, out, info.Mode().Perm())
+}
+
+func toJSON(fset *token.FileSet, m match, rewriting bool) jsonMatch {
+	pos := func(p token.Pos) jsonPosition {
+		position := fset.Position(p)
+		return jsonPosition{
+			File:   position.Filename,
+			Line:   position.Line,
+			Column: position.Column,
+		}
+	}
+	buf := &bytes.Buffer{}
+	format.Node(buf, fset, m.syntax())
+	out := jsonMatch{
+		Location: pos(m.rng().Pos()),
+		End:      pos(m.rng().End()),
+		Text:     buf.String(),
+	}
+	if rewriting {
+		s := string(m.edit.NewText)
+		out.Replacement = &s
+	}
+	return out
+}
+
+func shortPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(cwd, path); err == nil && len(rel) < len(path) {
+		return rel
+	}
+	return path
+}
+
+func relativePositionString(pos token.Position) string {
+	s := shortPath(pos.Filename)
+	if pos.IsValid() {
+		s += fmt.Sprintf(":%d:%d", pos.Line, pos.Column)
+	}
+	return s
+}
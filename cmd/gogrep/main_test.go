@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const src = `package pkg
+
+import "fmt"
+
+func fn(s string) {
+	fmt.Println(fmt.Sprint(fmt.Sprint(s)))
+}
+
+func seq() {
+	x := 1
+	x = 2
+	x = 3
+	println(x)
+}
+`
+
+// setup creates a module containing src and changes into its
+// directory.
+func setup(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/pkg\n\ngo 1.18\n",
+		"pkg.go": src,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func runTest(t *testing.T, opts options, query string) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	if err := run(buf, opts, query, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func readSource(t *testing.T) string {
+	t.Helper()
+	b, err := os.ReadFile("pkg.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+const (
+	sprint   = `(CallExpr (Symbol "fmt.Sprint") [x])`
+	sprintln = `(CallExpr (SelectorExpr (Ident "fmt") (Ident "Sprintln")) [x])`
+	assigns  = `(Seq [(AssignStmt x "=" _) last@(AssignStmt x "=" _)])`
+)
+
+func TestMatch(t *testing.T) {
+	setup(t)
+
+	got := runTest(t, options{}, sprint)
+	want := "pkg.go:6:14: fmt.Sprint(fmt.Sprint(s))\npkg.go:6:25: fmt.Sprint(s)\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = runTest(t, options{}, assigns)
+	want = "pkg.go:11:2: x = 2\nx = 3\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestList(t *testing.T) {
+	setup(t)
+
+	got := runTest(t, options{list: true}, sprint)
+	if want := "pkg.go\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	setup(t)
+
+	out := runTest(t, options{json: true, rewrite: sprintln}, sprint)
+	var matches []jsonMatch
+	dec := json.NewDecoder(strings.NewReader(out))
+	for dec.More() {
+		var m jsonMatch
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		matches = append(matches, m)
+	}
+	// When rewriting, we don't report matches inside of other
+	// matches.
+	if len(matches) != 1 {
+		t.Fatalf("got %d matches, want 1", len(matches))
+	}
+	m := matches[0]
+	if m.Location.Line != 6 || m.Location.Column != 14 || m.End.Line != 6 || m.End.Column != 39 {
+		t.Errorf("got range %d:%d-%d:%d, want 6:14-6:39", m.Location.Line, m.Location.Column, m.End.Line, m.End.Column)
+	}
+	if want := "fmt.Sprint(fmt.Sprint(s))"; m.Text != want {
+		t.Errorf("got text %q, want %q", m.Text, want)
+	}
+	if want := "fmt.Sprintln(fmt.Sprint(s))"; m.Replacement == nil || *m.Replacement != want {
+		t.Errorf("got replacement %v, want %q", m.Replacement, want)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	setup(t)
+
+	got := runTest(t, options{rewrite: sprintln}, sprint)
+	want := strings.Replace(src, "fmt.Sprint(fmt.Sprint(s))", "fmt.Sprintln(fmt.Sprint(s))", 1)
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if readSource(t) != src {
+		t.Error("file was modified without -w")
+	}
+
+	got = runTest(t, options{rewrite: `(Seq last)`}, assigns)
+	want = strings.Replace(src, "\tx = 2\n", "", 1)
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRewriteWrite(t *testing.T) {
+	setup(t)
+
+	if out := runTest(t, options{rewrite: sprintln, write: true}, sprint); out != "" {
+		t.Errorf("unexpected output %q", out)
+	}
+	want := strings.Replace(src, "fmt.Sprint(fmt.Sprint(s))", "fmt.Sprintln(fmt.Sprint(s))", 1)
+	if got := readSource(t); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestInvalidFlags(t *testing.T) {
+	tests := []options{
+		{list: true, json: true},
+		{list: true, rewrite: sprintln},
+		{list: true, rewrite: sprintln, write: true},
+		{write: true},
+	}
+	for _, opts := range tests {
+		if err := run(&bytes.Buffer{}, opts, sprint, nil); err == nil {
+			t.Errorf("expected error for %+v", opts)
+		}
+	}
+}
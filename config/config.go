@@ -2,14 +2,19 @@ package config
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/token"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 
+	"honnef.co/go/tools/knowledge"
+
 	"github.com/BurntSushi/toml"
 	"golang.org/x/tools/go/analysis"
 )
@@ -61,10 +66,6 @@ var Analyzer = &analysis.Analyzer{
 	Doc:  "loads configuration for the current package tree",
 	Run: func(pass *analysis.Pass) (interface{}, error) {
 		dir := dirAST(pass.Files, pass.Fset)
-		if dir == "" {
-			cfg := DefaultConfig
-			return &cfg, nil
-		}
 		cfg, err := Load(dir)
 		if err != nil {
 			return nil, fmt.Errorf("error loading staticcheck.conf: %s", err)
@@ -115,6 +116,16 @@ func normalizeList(list []string) []string {
 	return list
 }
 
+// normalizeListMap returns a copy of m with normalized lists. The
+// copy is necessary because m may belong to DefaultConfig.
+func normalizeListMap(m map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(m))
+	for k, v := range m {
+		out[k] = normalizeList(v)
+	}
+	return out
+}
+
 func (cfg Config) Merge(ocfg Config) Config {
 	if ocfg.Checks != nil {
 		cfg.Checks = mergeLists(cfg.Checks, ocfg.Checks)
@@ -128,9 +139,199 @@ func (cfg Config) Merge(ocfg Config) Config {
 	if ocfg.HTTPStatusCodeWhitelist != nil {
 		cfg.HTTPStatusCodeWhitelist = mergeLists(cfg.HTTPStatusCodeWhitelist, ocfg.HTTPStatusCodeWhitelist)
 	}
+	if ocfg.IgnoredErrors != nil {
+		cfg.IgnoredErrors = mergeLists(cfg.IgnoredErrors, ocfg.IgnoredErrors)
+	}
+	if ocfg.RuleFiles != nil {
+		cfg.RuleFiles = mergeLists(cfg.RuleFiles, ocfg.RuleFiles)
+	}
+	cfg.Unused = cfg.Unused.Merge(ocfg.Unused)
+	cfg.Taint = cfg.Taint.Merge(ocfg.Taint)
+	cfg.Functions = mergeListMaps(cfg.Functions, ocfg.Functions)
+	return cfg
+}
+
+// UnusedConfig configures U1000. Each option corresponds to a field in
+// unused.Options. Options that are nil haven't been set and inherit
+// their values.
+type UnusedConfig struct {
+	FieldWritesAreUses     *bool `toml:"field_writes_are_uses" json:"field_writes_are_uses,omitempty"`
+	PostStatementsAreReads *bool `toml:"post_statements_are_reads" json:"post_statements_are_reads,omitempty"`
+	ExportedIsUsed         *bool `toml:"exported_is_used" json:"exported_is_used,omitempty"`
+	ExportedFieldsAreUsed  *bool `toml:"exported_fields_are_used" json:"exported_fields_are_used,omitempty"`
+	ParametersAreUsed      *bool `toml:"parameters_are_used" json:"parameters_are_used,omitempty"`
+	LocalVariablesAreUsed  *bool `toml:"local_variables_are_used" json:"local_variables_are_used,omitempty"`
+	GeneratedIsUsed        *bool `toml:"generated_is_used" json:"generated_is_used,omitempty"`
+
+	// WholeProgram enables U1000's whole-program mode, which flags
+	// exported identifiers that no analyzed package uses. In this
+	// mode, only the exported identifiers of packages matching
+	// APIRoots are considered used, and ExportedIsUsed is ignored.
+	WholeProgram *bool    `toml:"whole_program" json:"whole_program,omitempty"`
+	APIRoots     []string `toml:"api_roots" json:"api_roots,omitempty"`
+}
+
+// TaintConfig configures the taint analysis and the checks that are
+// based on it.
+//
+// Sources lists functions, whose results are untrusted, and named
+// types, whose values are untrusted when they are received as
+// function parameters. Sinks and Sanitizers map kinds of sinks, such
+// as "sql" or "path", to lists of functions and types. Untrusted data
+// must not be passed to a sink, which is either an argument of a
+// function, written as "pkg.Func:N" with N counting from zero and not
+// including receivers, or a conversion to a named type. Functions
+// without an argument index take untrusted data in all of their
+// arguments. Calling a
+// sanitizer on untrusted data makes its result safe for sinks of the
+// same kind.
+//
+// Functions are written like in Staticcheck's messages, for example
+// "(*database/sql.DB).Query:0", and types are written as
+// "html/template.HTML".
+type TaintConfig struct {
+	Sources    []string            `toml:"sources" json:"sources,omitempty"`
+	Sinks      map[string][]string `toml:"sinks" json:"sinks,omitempty"`
+	Sanitizers map[string][]string `toml:"sanitizers" json:"sanitizers,omitempty"`
+}
+
+func mergeListMaps(a, b map[string][]string) map[string][]string {
+	if b == nil {
+		return a
+	}
+	out := make(map[string][]string, len(a)+len(b))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		out[k] = mergeLists(a[k], v)
+	}
+	return out
+}
+
+func (cfg TaintConfig) Merge(ocfg TaintConfig) TaintConfig {
+	if ocfg.Sources != nil {
+		cfg.Sources = mergeLists(cfg.Sources, ocfg.Sources)
+	}
+	cfg.Sinks = mergeListMaps(cfg.Sinks, ocfg.Sinks)
+	cfg.Sanitizers = mergeListMaps(cfg.Sanitizers, ocfg.Sanitizers)
 	return cfg
 }
 
+func (cfg TaintConfig) String() string {
+	return fmt.Sprintf("{Sources: %#v, Sinks: %v, Sanitizers: %v}", cfg.Sources, cfg.Sinks, cfg.Sanitizers)
+}
+
+// FunctionSemantics describes the behavior of a function, as declared
+// in the [functions] table. The table maps functions, written like in
+// Staticcheck's messages, to lists of semantics:
+//
+//   - "noreturn": the function never returns, for example because it
+//     exits the process.
+//   - "pure": the function has no side effects and its result only
+//     depends on its arguments.
+//   - "printf:N": the function formats its arguments like fmt.Printf,
+//     using argument N as the format string. Arguments are counted from
+//     zero and don't include receivers. The arguments to format follow
+//     the format string.
+//   - "returns-closer": the function returns a new resource that has
+//     to be closed.
+type FunctionSemantics struct {
+	NoReturn      bool
+	Pure          bool
+	ReturnsCloser bool
+	// Printf is the index of the format string of a printf-like
+	// function, or -1.
+	Printf int
+}
+
+func parseFunctionSemantics(list []string) (FunctionSemantics, error) {
+	sem := FunctionSemantics{Printf: -1}
+	for _, el := range list {
+		switch {
+		case el == "noreturn":
+			sem.NoReturn = true
+		case el == "pure":
+			sem.Pure = true
+		case el == "returns-closer":
+			sem.ReturnsCloser = true
+		case strings.HasPrefix(el, "printf:"):
+			n, err := strconv.Atoi(strings.TrimPrefix(el, "printf:"))
+			if err != nil || n < 0 {
+				return FunctionSemantics{}, fmt.Errorf("invalid argument index in %q", el)
+			}
+			sem.Printf = n
+		default:
+			return FunctionSemantics{}, fmt.Errorf("unknown function semantics %q", el)
+		}
+	}
+	return sem, nil
+}
+
+// Function returns the semantics declared for the function with the
+// given name.
+func (cfg Config) Function(name string) (FunctionSemantics, bool) {
+	list, ok := cfg.Functions[name]
+	if !ok {
+		return FunctionSemantics{Printf: -1}, false
+	}
+	// Load has already validated the list.
+	sem, _ := parseFunctionSemantics(list)
+	return sem, true
+}
+
+func mergeBool(a, b *bool) *bool {
+	if b != nil {
+		return b
+	}
+	return a
+}
+
+func (cfg UnusedConfig) Merge(ocfg UnusedConfig) UnusedConfig {
+	cfg.FieldWritesAreUses = mergeBool(cfg.FieldWritesAreUses, ocfg.FieldWritesAreUses)
+	cfg.PostStatementsAreReads = mergeBool(cfg.PostStatementsAreReads, ocfg.PostStatementsAreReads)
+	cfg.ExportedIsUsed = mergeBool(cfg.ExportedIsUsed, ocfg.ExportedIsUsed)
+	cfg.ExportedFieldsAreUsed = mergeBool(cfg.ExportedFieldsAreUsed, ocfg.ExportedFieldsAreUsed)
+	cfg.ParametersAreUsed = mergeBool(cfg.ParametersAreUsed, ocfg.ParametersAreUsed)
+	cfg.LocalVariablesAreUsed = mergeBool(cfg.LocalVariablesAreUsed, ocfg.LocalVariablesAreUsed)
+	cfg.GeneratedIsUsed = mergeBool(cfg.GeneratedIsUsed, ocfg.GeneratedIsUsed)
+	cfg.WholeProgram = mergeBool(cfg.WholeProgram, ocfg.WholeProgram)
+	if ocfg.APIRoots != nil {
+		cfg.APIRoots = mergeLists(cfg.APIRoots, ocfg.APIRoots)
+	}
+	return cfg
+}
+
+func (cfg UnusedConfig) String() string {
+	buf := &bytes.Buffer{}
+	printBool := func(name string, b *bool) {
+		if b == nil {
+			fmt.Fprintf(buf, "%s: <unset>", name)
+		} else {
+			fmt.Fprintf(buf, "%s: %t", name, *b)
+		}
+	}
+	buf.WriteString("{")
+	printBool("FieldWritesAreUses", cfg.FieldWritesAreUses)
+	buf.WriteString(", ")
+	printBool("PostStatementsAreReads", cfg.PostStatementsAreReads)
+	buf.WriteString(", ")
+	printBool("ExportedIsUsed", cfg.ExportedIsUsed)
+	buf.WriteString(", ")
+	printBool("ExportedFieldsAreUsed", cfg.ExportedFieldsAreUsed)
+	buf.WriteString(", ")
+	printBool("ParametersAreUsed", cfg.ParametersAreUsed)
+	buf.WriteString(", ")
+	printBool("LocalVariablesAreUsed", cfg.LocalVariablesAreUsed)
+	buf.WriteString(", ")
+	printBool("GeneratedIsUsed", cfg.GeneratedIsUsed)
+	buf.WriteString(", ")
+	printBool("WholeProgram", cfg.WholeProgram)
+	fmt.Fprintf(buf, ", APIRoots: %#v", cfg.APIRoots)
+	buf.WriteString("}")
+	return buf.String()
+}
+
 type Config struct {
 	// TODO(dh): this implementation makes it impossible for external
 	// clients to add their own checkers with configuration. At the
@@ -139,10 +340,31 @@ type Config struct {
 	// obvious solution would be using map[string]interface{}, but
 	// that's obviously subpar.
 
-	Checks                  []string `toml:"checks"`
-	Initialisms             []string `toml:"initialisms"`
-	DotImportWhitelist      []string `toml:"dot_import_whitelist"`
-	HTTPStatusCodeWhitelist []string `toml:"http_status_code_whitelist"`
+	Checks                  []string `toml:"checks" json:"checks,omitempty"`
+	Initialisms             []string `toml:"initialisms" json:"initialisms,omitempty"`
+	DotImportWhitelist      []string `toml:"dot_import_whitelist" json:"dot_import_whitelist,omitempty"`
+	HTTPStatusCodeWhitelist []string `toml:"http_status_code_whitelist" json:"http_status_code_whitelist,omitempty"`
+
+	// IgnoredErrors lists functions whose error results may be
+	// discarded without being flagged by SA5017.
+	IgnoredErrors []string `toml:"ignored_errors" json:"ignored_errors,omitempty"`
+
+	// RuleFiles lists rule files declaring user-defined checks. Paths
+	// are relative to the configuration file that lists them. Load
+	// parses the files and stores their rules in Rules.
+	RuleFiles []string `toml:"rules" json:"rules,omitempty"`
+	Rules     []Rule   `toml:"-" json:"-"`
+
+	Unused UnusedConfig `toml:"unused" json:"unused"`
+	Taint  TaintConfig  `toml:"taint" json:"taint"`
+
+	// Functions declares the semantics of functions, for when
+	// Staticcheck cannot infer them. See FunctionSemantics.
+	Functions map[string][]string `toml:"functions" json:"functions,omitempty"`
+
+	// Root stops the discovery of configuration files. Files in
+	// parent directories will not be inherited from.
+	Root bool `toml:"root,omitempty" json:"root,omitempty"`
 }
 
 func (c Config) String() string {
@@ -151,7 +373,14 @@ func (c Config) String() string {
 	fmt.Fprintf(buf, "Checks: %#v\n", c.Checks)
 	fmt.Fprintf(buf, "Initialisms: %#v\n", c.Initialisms)
 	fmt.Fprintf(buf, "DotImportWhitelist: %#v\n", c.DotImportWhitelist)
-	fmt.Fprintf(buf, "HTTPStatusCodeWhitelist: %#v", c.HTTPStatusCodeWhitelist)
+	fmt.Fprintf(buf, "HTTPStatusCodeWhitelist: %#v\n", c.HTTPStatusCodeWhitelist)
+	fmt.Fprintf(buf, "IgnoredErrors: %#v\n", c.IgnoredErrors)
+	fmt.Fprintf(buf, "RuleFiles: %#v\n", c.RuleFiles)
+	fmt.Fprintf(buf, "Rules: %v\n", c.Rules)
+	fmt.Fprintf(buf, "Unused: %s\n", c.Unused)
+	fmt.Fprintf(buf, "Taint: %s\n", c.Taint)
+	fmt.Fprintf(buf, "Functions: %v\n", c.Functions)
+	fmt.Fprintf(buf, "Root: %t", c.Root)
 
 	return buf.String()
 }
@@ -179,45 +408,294 @@ var DefaultConfig = Config{
 		"github.com/mmcloughlin/avo/reg",
 	},
 	HTTPStatusCodeWhitelist: []string{"200", "400", "404", "500"},
+	IgnoredErrors:           knowledge.IgnoredErrors,
+	Unused: UnusedConfig{
+		FieldWritesAreUses:     ptr(true),
+		PostStatementsAreReads: ptr(false),
+		ExportedIsUsed:         ptr(true),
+		ExportedFieldsAreUsed:  ptr(true),
+		ParametersAreUsed:      ptr(true),
+		LocalVariablesAreUsed:  ptr(true),
+		GeneratedIsUsed:        ptr(true),
+		WholeProgram:           ptr(false),
+		APIRoots:               []string{},
+	},
+	Taint: TaintConfig{
+		Sources: []string{"net/http.Request"},
+		Sinks: map[string][]string{
+			"sql": {
+				"(*database/sql.DB).Exec:0",
+				"(*database/sql.DB).ExecContext:1",
+				"(*database/sql.DB).Prepare:0",
+				"(*database/sql.DB).PrepareContext:1",
+				"(*database/sql.DB).Query:0",
+				"(*database/sql.DB).QueryContext:1",
+				"(*database/sql.DB).QueryRow:0",
+				"(*database/sql.DB).QueryRowContext:1",
+				"(*database/sql.Tx).Exec:0",
+				"(*database/sql.Tx).ExecContext:1",
+				"(*database/sql.Tx).Prepare:0",
+				"(*database/sql.Tx).PrepareContext:1",
+				"(*database/sql.Tx).Query:0",
+				"(*database/sql.Tx).QueryContext:1",
+				"(*database/sql.Tx).QueryRow:0",
+				"(*database/sql.Tx).QueryRowContext:1",
+				"(*database/sql.Conn).ExecContext:1",
+				"(*database/sql.Conn).PrepareContext:1",
+				"(*database/sql.Conn).QueryContext:1",
+				"(*database/sql.Conn).QueryRowContext:1",
+			},
+			"exec": {
+				"os/exec.Command:0",
+				"os/exec.CommandContext:1",
+			},
+			"html": {
+				"html/template.CSS",
+				"html/template.HTML",
+				"html/template.HTMLAttr",
+				"html/template.JS",
+				"html/template.JSStr",
+				"html/template.Srcset",
+				"html/template.URL",
+			},
+			"path": {
+				"net/http.ServeFile:2",
+				"os.Create:0",
+				"os.Mkdir:0",
+				"os.MkdirAll:0",
+				"os.Open:0",
+				"os.OpenFile:0",
+				"os.ReadDir:0",
+				"os.ReadFile:0",
+				"os.Remove:0",
+				"os.RemoveAll:0",
+				"os.WriteFile:0",
+			},
+		},
+		Sanitizers: map[string][]string{
+			"html": {
+				"html.EscapeString",
+				"html/template.HTMLEscapeString",
+				"html/template.JSEscapeString",
+				"net/url.PathEscape",
+				"net/url.QueryEscape",
+			},
+			"path": {
+				"path.Base",
+				"path/filepath.Base",
+			},
+		},
+	},
 }
 
+func ptr[T any](v T) *T { return &v }
+
+// Explicit is a configuration that was explicitly requested by the
+// user, for example via the -config flag of cmd/staticcheck. If it is
+// non-nil, it gets merged on top of the configurations found by Load.
+// If its Root field is set, it replaces them instead.
+//
+// Like DefaultConfig, Explicit shouldn't be modified while analyzers
+// are executing.
+var Explicit *Config
+
 const ConfigName = "staticcheck.conf"
 
+// JSONConfigName is the name of configuration files that use JSON
+// instead of TOML. They use the same schema and merge semantics as
+// files named ConfigName. A directory may not contain both.
+const JSONConfigName = "staticcheck.json"
+
+// moduleRootFiles are the files that mark the root of a module or
+// workspace. Configuration discovery doesn't go past such roots.
+var moduleRootFiles = []string{"go.mod", "go.work"}
+
+func isModuleRoot(dir string) bool {
+	for _, name := range moduleRootFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 type ParseError struct {
 	Filename string
 	toml.ParseError
 }
 
-func parseConfigs(dir string) ([]Config, error) {
-	var out []Config
+func parseConfig(path string) (Config, error) {
+	var cfg Config
+	var err error
+	if filepath.Ext(path) == ".json" {
+		cfg, err = parseJSONConfig(path)
+	} else {
+		cfg, err = parseTOMLConfig(path)
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	if err := resolveRuleFiles(&cfg, path); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
 
-	// TODO(dh): consider stopping at the GOPATH/module boundary
-	for dir != "" {
-		f, err := os.Open(filepath.Join(dir, ConfigName))
-		if os.IsNotExist(err) {
-			ndir := filepath.Dir(dir)
-			if ndir == dir {
-				break
+func parseTOMLConfig(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+	var cfg Config
+	if _, err := toml.NewDecoder(f).Decode(&cfg); err != nil {
+		if err, ok := err.(toml.ParseError); ok {
+			return Config{}, ParseError{
+				Filename:   path,
+				ParseError: err,
 			}
-			dir = ndir
-			continue
 		}
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func parseJSONConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		var offset int64 = -1
+		switch err := err.(type) {
+		case *json.SyntaxError:
+			offset = err.Offset
+		case *json.UnmarshalTypeError:
+			offset = err.Offset
+		}
+		if offset > 0 {
+			// Offset is the number of bytes read before the error
+			// was detected. Point at the last byte read.
+			offset--
+			line := 1 + bytes.Count(data[:offset], []byte("\n"))
+			col := int(offset) - bytes.LastIndexByte(data[:offset], '\n')
+			return Config{}, fmt.Errorf("%s:%d:%d: %s", path, line, col, err)
+		}
+		return Config{}, fmt.Errorf("%s: %s", path, err)
+	}
+	return cfg, nil
+}
+
+// parseDir parses the configuration file in dir, if there is one.
+func parseDir(dir string) (Config, bool, error) {
+	var found string
+	for _, name := range []string{ConfigName, JSONConfigName} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return Config{}, false, err
+		}
+		if found != "" {
+			return Config{}, false, fmt.Errorf("found both %s and %s in %s", found, name, dir)
+		}
+		found = name
+	}
+	if found == "" {
+		return Config{}, false, nil
+	}
+	cfg, err := parseConfig(filepath.Join(dir, found))
+	return cfg, err == nil, err
+}
+
+// DeclaresRules reports whether Explicit, or any configuration file
+// that may apply to packages in dir or its subdirectories, sets rules.
+// These are the files in the module or workspace that contains dir. It
+// is cheaper than loading the configurations of all packages, which
+// makes it useful for skipping that work when no rules are declared.
+func DeclaresRules(dir string) (bool, error) {
+	if Explicit != nil {
+		if len(Explicit.RuleFiles) > 0 {
+			return true, nil
+		}
+		if Explicit.Root {
+			return false, nil
+		}
+	}
+
+	// Check the parents of dir, up to the root of the module.
+	root := dir
+	for !isModuleRoot(root) {
+		parent := filepath.Dir(root)
+		if parent == root {
+			// dir isn't in a module, and we've checked all of its
+			// parents.
+			root = dir
+			break
+		}
+		root = parent
+		cfg, ok, err := parseDir(root)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
-		var cfg Config
-		_, err = toml.NewDecoder(f).Decode(&cfg)
-		f.Close()
+		if ok && len(cfg.RuleFiles) > 0 {
+			return true, nil
+		}
+	}
+
+	found := false
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			if err, ok := err.(toml.ParseError); ok {
-				return nil, ParseError{
-					Filename:   filepath.Join(dir, ConfigName),
-					ParseError: err,
-				}
+			if path == root {
+				return err
 			}
+			// Directories we cannot read don't contain packages we
+			// can lint, either.
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if name := d.Name(); path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata") {
+			// The go command ignores these directories.
+			return filepath.SkipDir
+		}
+		cfg, ok, err := parseDir(path)
+		if err != nil {
+			return err
+		}
+		if ok && len(cfg.RuleFiles) > 0 {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found, err
+}
+
+// parseConfigs returns the configurations that apply to dir, ordered
+// from least to most specific, starting with DefaultConfig. Discovery
+// stops at the root of the module or workspace, and at configurations
+// that set root = true.
+func parseConfigs(dir string) ([]Config, error) {
+	var out []Config
+
+	for dir != "" {
+		cfg, ok, err := parseDir(dir)
+		if err != nil {
 			return nil, err
 		}
-		out = append(out, cfg)
+		if ok {
+			out = append(out, cfg)
+			if cfg.Root {
+				break
+			}
+		}
+		if isModuleRoot(dir) {
+			break
+		}
 		ndir := filepath.Dir(dir)
 		if ndir == dir {
 			break
@@ -250,17 +728,55 @@ func mergeConfigs(confs []Config) Config {
 	return conf
 }
 
+// LoadFile parses a single configuration file, without merging it
+// with any other configuration. Files with the .json extension are
+// parsed as JSON, all other files as TOML.
+func LoadFile(path string) (Config, error) {
+	return parseConfig(path)
+}
+
+// Load returns the configuration for the packages in dir, merging all
+// configuration files found in dir and its parents, up to the root of
+// the module, as well as Explicit. If dir is empty, no discovery takes
+// place.
 func Load(dir string) (Config, error) {
-	confs, err := parseConfigs(dir)
-	if err != nil {
-		return Config{}, err
+	var confs []Config
+	if Explicit != nil && Explicit.Root {
+		confs = []Config{DefaultConfig, *Explicit}
+	} else {
+		var err error
+		confs, err = parseConfigs(dir)
+		if err != nil {
+			return Config{}, err
+		}
+		if Explicit != nil {
+			confs = append(confs, *Explicit)
+		}
 	}
 	conf := mergeConfigs(confs)
+	conf.Root = false
 
 	conf.Checks = normalizeList(conf.Checks)
 	conf.Initialisms = normalizeList(conf.Initialisms)
 	conf.DotImportWhitelist = normalizeList(conf.DotImportWhitelist)
 	conf.HTTPStatusCodeWhitelist = normalizeList(conf.HTTPStatusCodeWhitelist)
+	conf.IgnoredErrors = normalizeList(conf.IgnoredErrors)
+	conf.RuleFiles = normalizeList(conf.RuleFiles)
+	conf.Unused.APIRoots = normalizeList(conf.Unused.APIRoots)
+	conf.Taint.Sources = normalizeList(conf.Taint.Sources)
+	conf.Taint.Sinks = normalizeListMap(conf.Taint.Sinks)
+	conf.Taint.Sanitizers = normalizeListMap(conf.Taint.Sanitizers)
+	conf.Functions = normalizeListMap(conf.Functions)
+	for name, list := range conf.Functions {
+		if _, err := parseFunctionSemantics(list); err != nil {
+			return Config{}, fmt.Errorf("functions: %s: %s", name, err)
+		}
+	}
+	rules, err := loadRules(conf.RuleFiles)
+	if err != nil {
+		return Config{}, fmt.Errorf("rules: %w", err)
+	}
+	conf.Rules = rules
 
 	return conf, nil
 }
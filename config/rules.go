@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"honnef.co/go/tools/pattern"
+
+	"github.com/BurntSushi/toml"
+)
+
+// A Rule is a user-defined check, declared in a rule file listed by
+// the rules option. Rule files are TOML files containing an array of
+// [[rule]] tables:
+//
+//	[[rule]]
+//	id = "ACME1000"
+//	message = "use strings.Contains to check for substrings"
+//	match = '(BinaryExpr (CallExpr (Symbol "strings.Index") [s sub]) "!=" (IntegerLiteral "-1"))'
+//	replace = '(CallExpr (SelectorExpr (Ident "strings") (Ident "Contains")) [s sub])'
+//
+//	[rule.where]
+//	s = '(TypeOf _ "string")'
+//
+// Match and Replace are written in the language of the pattern
+// package. Where maps bindings of Match to patterns that the bound
+// nodes have to match, which is useful for constraining the types of
+// expressions. Message may refer to bindings as $name, which expands to
+// the source code of the bound node.
+type Rule struct {
+	ID       string            `toml:"id"`
+	Message  string            `toml:"message"`
+	Match    string            `toml:"match"`
+	Replace  string            `toml:"replace"`
+	Where    map[string]string `toml:"where"`
+	Severity string            `toml:"severity"`
+
+	// File is the rule file that declared the rule.
+	File string `toml:"-"`
+}
+
+// A CompiledRule is a Rule whose patterns have been parsed.
+type CompiledRule struct {
+	Rule
+	Match pattern.Pattern
+	// Replace is nil if the rule doesn't have a replacement.
+	Replace *pattern.Pattern
+	Where   map[string]pattern.Pattern
+}
+
+var (
+	ruleIDRe       = regexp.MustCompile(`^[A-Z]+[0-9]+$`)
+	ruleSeverities = map[string]bool{"": true, "error": true, "warning": true, "info": true, "hint": true}
+)
+
+func (r Rule) String() string {
+	var where []string
+	for name, q := range r.Where {
+		where = append(where, fmt.Sprintf("%s=%s", name, q))
+	}
+	sort.Strings(where)
+	return fmt.Sprintf("{%s %q %s %s %v %s}", r.ID, r.Message, r.Match, r.Replace, where, r.Severity)
+}
+
+// Compile parses the rule's patterns and verifies that they are
+// consistent with each other.
+func (r Rule) Compile() (*CompiledRule, error) {
+	if !ruleIDRe.MatchString(r.ID) {
+		return nil, fmt.Errorf("invalid rule ID %q, IDs must be upper-case letters followed by digits, such as ACME1000", r.ID)
+	}
+	if r.Message == "" {
+		return nil, fmt.Errorf("rule %s: missing message", r.ID)
+	}
+	if !ruleSeverities[r.Severity] {
+		return nil, fmt.Errorf("rule %s: invalid severity %q", r.ID, r.Severity)
+	}
+
+	p := &pattern.Parser{AllowTypeInfo: true}
+	match, err := p.Parse(r.Match)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: match: %s", r.ID, err)
+	}
+	bound := map[string]bool{}
+	for _, name := range match.Bindings {
+		bound[name] = true
+	}
+	out := &CompiledRule{
+		Rule:  r,
+		Match: match,
+		Where: map[string]pattern.Pattern{},
+	}
+
+	if r.Replace != "" {
+		p := &pattern.Parser{}
+		replace, err := p.Parse(r.Replace)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: replace: %s", r.ID, err)
+		}
+		if err := pattern.CheckReplacement(match, replace); err != nil {
+			return nil, fmt.Errorf("rule %s: replace: %s", r.ID, err)
+		}
+		out.Replace = &replace
+	}
+
+	for name, q := range r.Where {
+		if !bound[name] {
+			return nil, fmt.Errorf("rule %s: where: %s isn't bound by the match pattern", r.ID, name)
+		}
+		p := &pattern.Parser{AllowTypeInfo: true}
+		where, err := p.Parse(q)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: where: %s: %s", r.ID, name, err)
+		}
+		out.Where[name] = where
+	}
+	return out, nil
+}
+
+type ruleFile struct {
+	Rules []Rule `toml:"rule"`
+}
+
+var ruleFiles struct {
+	mu    sync.Mutex
+	cache map[string]ruleFileResult
+}
+
+type ruleFileResult struct {
+	rules []Rule
+	err   error
+}
+
+// parseRuleFile parses and validates a rule file. Rule files are
+// cached, as every package's configuration refers to the same files.
+func parseRuleFile(path string) ([]Rule, error) {
+	ruleFiles.mu.Lock()
+	defer ruleFiles.mu.Unlock()
+	if res, ok := ruleFiles.cache[path]; ok {
+		return res.rules, res.err
+	}
+	if ruleFiles.cache == nil {
+		ruleFiles.cache = map[string]ruleFileResult{}
+	}
+	rules, err := parseRuleFileUncached(path)
+	ruleFiles.cache[path] = ruleFileResult{rules, err}
+	return rules, err
+}
+
+func parseRuleFileUncached(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f ruleFile
+	if _, err := toml.Decode(string(data), &f); err != nil {
+		if err, ok := err.(toml.ParseError); ok {
+			return nil, ParseError{
+				Filename:   path,
+				ParseError: err,
+			}
+		}
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	for i := range f.Rules {
+		f.Rules[i].File = path
+		if _, err := f.Rules[i].Compile(); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+	return f.Rules, nil
+}
+
+// loadRules loads the rules declared in the rule files at paths.
+func loadRules(paths []string) ([]Rule, error) {
+	var out []Rule
+	seen := map[string]string{}
+	for _, path := range paths {
+		rules, err := parseRuleFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rules {
+			if prev, ok := seen[r.ID]; ok {
+				return nil, fmt.Errorf("rule %s declared in both %s and %s", r.ID, prev, path)
+			}
+			seen[r.ID] = path
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// resolveRuleFiles makes the paths of rule files, which are relative
+// to the configuration file that lists them, absolute.
+func resolveRuleFiles(cfg *Config, path string) error {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	for i, file := range cfg.RuleFiles {
+		if file == "inherit" || filepath.IsAbs(file) {
+			continue
+		}
+		cfg.RuleFiles[i] = filepath.Join(dir, filepath.FromSlash(file))
+	}
+	return nil
+}
@@ -1230,7 +1230,11 @@ func (b *builder) compLit(fn *Function, addr Value, e *ast.CompositeLit, isZero
 					t:    t.Field(fieldIndex).Type(),
 					expr: e,
 				}
-				b.assign(fn, ce, e, isZero, sb, e)
+				// Elements of a CompositeValue aren't memory locations and
+				// don't need to go through the storebuf. Storing them right
+				// away makes sure that conversions are emitted before the
+				// CompositeValue.
+				b.assign(fn, ce, e, isZero, nil, e)
 				v.Bitmap.SetBit(&v.Bitmap, fieldIndex, 1)
 				v.NumSet++
 			}
@@ -1288,7 +1292,8 @@ func (b *builder) compLit(fn *Function, addr Value, e *ast.CompositeLit, isZero
 						expr: e,
 					}
 
-					b.assign(fn, iaddr, e, true, sb, e)
+					// See the comment in the struct case.
+					b.assign(fn, iaddr, e, true, nil, e)
 					v.Bitmap.SetBit(&v.Bitmap, int(idx.Int64()), 1)
 					v.NumSet++
 				}
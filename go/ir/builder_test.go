@@ -669,3 +669,44 @@ func TestUnreachableExit(t *testing.T) {
 		pkg.Build()
 	}
 }
+
+// Tests that the conversions of composite literal elements are emitted
+// before the CompositeValue that uses them, even when the literal is
+// assigned to an existing variable.
+func TestCompositeValueConversions(t *testing.T) {
+	input := `
+package pkg
+
+type T struct{ X interface{} }
+type A [1]interface{}
+
+func fn1(x *T, v int) { *x = T{X: v} }
+func fn2(x *A, v int) { *x = A{v} }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "input.go", input, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, _, err := irutil.BuildPackage(&types.Config{Importer: importer.Default()}, fset,
+		types.NewPackage("pkg", ""), []*ast.File{f}, ir.SanityCheckFunctions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"fn1", "fn2"} {
+		fn := pkg.Func(name)
+		for _, b := range fn.Blocks {
+			defined := map[ir.Value]bool{}
+			for _, instr := range b.Instrs {
+				for _, op := range instr.Operands(nil) {
+					if v, ok := (*op).(ir.Instruction); ok && v.Block() == b && !defined[v.(ir.Value)] {
+						t.Errorf("%s: %s uses %s before its definition", name, instr, v.(ir.Value).Name())
+					}
+				}
+				if v, ok := instr.(ir.Value); ok {
+					defined[v] = true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package callgraph defines the call graph of a program built from IR, as well as
+// operations on it. The algorithms for constructing call graphs live in the
+// subpackages cha (class hierarchy analysis) and vta (variable type analysis).
+//
+// A call graph is a directed graph whose nodes represent functions and whose
+// edges represent call sites. Each edge connects a call site in its caller to
+// one of the functions that may be called at that site. A site may have any
+// number of outgoing edges: static calls have exactly one, while dynamic calls
+// through interfaces and function values may have many, or none at all.
+//
+// Call graphs are usually built from only part of a program, such as a single
+// package and the method sets of its types. Dynamic calls in such a graph may
+// reach functions that the graph knows nothing about. These sites are recorded
+// in Graph.Unresolved, and clients that need to know all callees of a site
+// must consult it.
+package callgraph
+
+import (
+	"fmt"
+	"go/token"
+
+	"honnef.co/go/tools/go/ir"
+)
+
+// A Graph represents a call graph.
+type Graph struct {
+	// Root is the node of the distinguished root function, if any.
+	Root *Node
+	// Nodes maps functions to their nodes.
+	Nodes map[*ir.Function]*Node
+	// Unresolved contains the call sites whose callees may include
+	// functions that aren't represented by edges in the graph, for
+	// example because the called value may originate in code that
+	// wasn't analyzed.
+	Unresolved map[ir.CallInstruction]bool
+}
+
+// New returns a new, empty graph with a node for root, which may be nil.
+func New(root *ir.Function) *Graph {
+	g := &Graph{
+		Nodes:      make(map[*ir.Function]*Node),
+		Unresolved: make(map[ir.CallInstruction]bool),
+	}
+	if root != nil {
+		g.Root = g.CreateNode(root)
+	}
+	return g
+}
+
+// CreateNode returns the node for fn, creating it if it doesn't exist yet.
+func (g *Graph) CreateNode(fn *ir.Function) *Node {
+	n, ok := g.Nodes[fn]
+	if !ok {
+		n = &Node{Func: fn, ID: len(g.Nodes)}
+		g.Nodes[fn] = n
+	}
+	return n
+}
+
+// Callees returns the functions that may be called at site, in the order
+// in which the graph's edges were added. It returns nil if the function
+// containing site isn't part of the graph. Use g.Unresolved to determine
+// whether the list is complete.
+func (g *Graph) Callees(site ir.CallInstruction) []*ir.Function {
+	n := g.Nodes[site.Parent()]
+	if n == nil {
+		return nil
+	}
+	var out []*ir.Function
+	seen := map[*ir.Function]struct{}{}
+	for _, e := range n.Out {
+		if e.Site != site {
+			continue
+		}
+		if _, ok := seen[e.Callee.Func]; ok {
+			continue
+		}
+		seen[e.Callee.Func] = struct{}{}
+		out = append(out, e.Callee.Func)
+	}
+	return out
+}
+
+// A Node represents a function in the call graph.
+type Node struct {
+	Func *ir.Function
+	// ID is unique within a graph and assigned in order of creation.
+	ID int
+	// In contains the edges of calls to this function.
+	In []*Edge
+	// Out contains the edges of calls made by this function.
+	Out []*Edge
+}
+
+func (n *Node) String() string {
+	return fmt.Sprintf("n%d:%s", n.ID, n.Func)
+}
+
+// An Edge represents a call from Caller to Callee at Site. Site is
+// nil for edges that don't correspond to a call instruction, such as
+// those from a synthetic root node.
+type Edge struct {
+	Caller *Node
+	Site   ir.CallInstruction
+	Callee *Node
+}
+
+func (e *Edge) String() string {
+	return fmt.Sprintf("%s --> %s", e.Caller, e.Callee)
+}
+
+// Description returns a human-readable description of the kind of call.
+func (e *Edge) Description() string {
+	if e.Site == nil {
+		return "synthetic call"
+	}
+	var prefix string
+	switch e.Site.(type) {
+	case *ir.Defer:
+		prefix = "deferred "
+	case *ir.Go:
+		prefix = "concurrent "
+	}
+	common := e.Site.Common()
+	switch {
+	case common.IsInvoke():
+		return prefix + "dynamic method call"
+	case common.StaticCallee() != nil:
+		return prefix + "static call"
+	default:
+		return prefix + "dynamic function call"
+	}
+}
+
+// Pos returns the position of the edge's call site.
+func (e *Edge) Pos() token.Pos {
+	if e.Site == nil {
+		return token.NoPos
+	}
+	return e.Site.Pos()
+}
+
+// AddEdge adds the edge (caller, site, callee) to the graph.
+func AddEdge(caller *Node, site ir.CallInstruction, callee *Node) {
+	e := &Edge{caller, site, callee}
+	callee.In = append(callee.In, e)
+	caller.Out = append(caller.Out, e)
+}
+
+// VisitEdges calls fn for each edge in the graph, in unspecified order.
+// It stops at the first non-nil error returned by fn and returns it.
+func (g *Graph) VisitEdges(fn func(*Edge) error) error {
+	for _, n := range g.Nodes {
+		for _, e := range n.Out {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package callgraph_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/callgraph"
+	"honnef.co/go/tools/go/ir/callgraph/cha"
+	"honnef.co/go/tools/go/ir/callgraph/vta"
+	"honnef.co/go/tools/go/ir/irutil"
+)
+
+var annotation = regexp.MustCompile(`// cha:(.*)\| vta:(.*)$`)
+
+// TestCallGraphs checks the callees of annotated call sites in testdata/calls.go.
+// An annotation lists the expected callees for CHA and VTA; a question mark
+// denotes an unresolved site.
+func TestCallGraphs(t *testing.T) {
+	fset := token.NewFileSet()
+	filename := filepath.Join("testdata", "calls.go")
+	f, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &types.Config{Importer: importer.Default()}
+	pkg, _, err := irutil.BuildPackage(conf, fset, types.NewPackage("example.com/pkg", ""), []*ast.File{f}, ir.SanityCheckFunctions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int][2]string{}
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			if m := annotation.FindStringSubmatch(c.Text); m != nil {
+				want[fset.Position(c.Pos()).Line] = [2]string{normalize(m[1]), normalize(m[2])}
+			}
+		}
+	}
+
+	funcs := irutil.AllFunctions(pkg.Prog)
+	chaGraph := cha.FromFunctions(funcs)
+	vtaGraph := vta.CallGraph(funcs, chaGraph)
+	describe := func(g *callgraph.Graph, site ir.CallInstruction) string {
+		var names []string
+		for _, callee := range g.Callees(site) {
+			names = append(names, callee.RelString(pkg.Pkg))
+		}
+		if g.Unresolved[site] {
+			names = append(names, "?")
+		}
+		return normalize(strings.Join(names, " "))
+	}
+
+	seen := map[int]bool{}
+	for fn := range funcs {
+		if fn.Pkg != pkg {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				site, ok := instr.(ir.CallInstruction)
+				if !ok {
+					continue
+				}
+				if _, ok := site.Common().Value.(*ir.Builtin); ok {
+					continue
+				}
+				line := fset.Position(site.Pos()).Line
+				exp, ok := want[line]
+				if !ok {
+					continue
+				}
+				seen[line] = true
+				if got := describe(chaGraph, site); got != exp[0] {
+					t.Errorf("line %d: CHA callees are %q, want %q", line, got, exp[0])
+				}
+				if got := describe(vtaGraph, site); got != exp[1] {
+					t.Errorf("line %d: VTA callees are %q, want %q", line, got, exp[1])
+				}
+			}
+		}
+	}
+	for line := range want {
+		if !seen[line] {
+			t.Errorf("line %d: no call site", line)
+		}
+	}
+}
+
+func normalize(s string) string {
+	fields := strings.Fields(s)
+	sort.Strings(fields)
+	return strings.Join(fields, " ")
+}
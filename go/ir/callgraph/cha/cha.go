@@ -0,0 +1,166 @@
+// Package cha computes call graphs using class hierarchy analysis.
+//
+// Class hierarchy analysis (CHA) is a simple and fast way of resolving dynamic
+// calls. An interface method call may reach every concrete method of the same
+// name whose receiver type implements the interface, and a call of a function
+// value may reach every function of the same signature whose address is taken.
+// The result is sound with respect to the functions the analysis is given, but
+// can be quite imprecise. See package vta for a refinement.
+package cha
+
+import (
+	"go/types"
+	"sort"
+
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/callgraph"
+	"honnef.co/go/tools/go/ir/irutil"
+	"honnef.co/go/tools/go/types/typeutil"
+)
+
+// CallGraph computes the call graph of prog, which must have been built.
+// The graph has no root node.
+func CallGraph(prog *ir.Program) *callgraph.Graph {
+	return FromFunctions(irutil.AllFunctions(prog))
+}
+
+// FromFunctions computes the call graph of the functions in funcs. Only
+// functions in funcs are considered as the targets of dynamic calls. Because
+// funcs may not contain all functions that exist at run time, every dynamic
+// call site is recorded in the graph's Unresolved set.
+func FromFunctions(funcs map[*ir.Function]bool) *callgraph.Graph {
+	cg := callgraph.New(nil)
+	sorted := Sorted(funcs)
+
+	// Concrete methods, indexed by name, and functions whose address
+	// is taken, indexed by signature.
+	methodsByName := map[string][]*ir.Function{}
+	var funcsBySig typeutil.Map[[]*ir.Function]
+	addressTaken := map[*ir.Function]bool{}
+	addFunc := func(fn *ir.Function) {
+		if addressTaken[fn] || isGeneric(fn) {
+			return
+		}
+		addressTaken[fn] = true
+		fns, _ := funcsBySig.At(fn.Signature)
+		funcsBySig.Set(fn.Signature, append(fns, fn))
+	}
+	for _, fn := range sorted {
+		if recv := fn.Signature.Recv(); recv != nil && !types.IsInterface(recv.Type()) && !isGeneric(fn) {
+			methodsByName[fn.Name()] = append(methodsByName[fn.Name()], fn)
+		}
+		var buf [10]*ir.Value
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				var callee *ir.Value
+				if site, ok := instr.(ir.CallInstruction); ok {
+					callee = &site.Common().Value
+				}
+				for _, op := range instr.Operands(buf[:0]) {
+					if op == callee {
+						continue
+					}
+					if g, ok := (*op).(*ir.Function); ok {
+						addFunc(g)
+					}
+				}
+			}
+		}
+	}
+
+	// The implementations of a method depend on the interface it is
+	// called through, not just on the method: an interface embedding
+	// another shares the embedded interface's method objects, but may
+	// have fewer implementations.
+	var implementations typeutil.Map[map[*types.Func][]*ir.Function]
+	lookupMethods := func(iface *types.Interface, m *types.Func) []*ir.Function {
+		byMethod, _ := implementations.At(iface)
+		if byMethod == nil {
+			byMethod = map[*types.Func][]*ir.Function{}
+			implementations.Set(iface, byMethod)
+		}
+		fns, ok := byMethod[m]
+		if ok {
+			return fns
+		}
+		for _, fn := range methodsByName[m.Name()] {
+			if types.Implements(fn.Signature.Recv().Type(), iface) {
+				fns = append(fns, fn)
+			}
+		}
+		byMethod[m] = fns
+		return fns
+	}
+
+	for _, fn := range sorted {
+		caller := cg.CreateNode(fn)
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				site, ok := instr.(ir.CallInstruction)
+				if !ok {
+					continue
+				}
+				common := site.Common()
+				var callees []*ir.Function
+				switch {
+				case common.StaticCallee() != nil:
+					callees = []*ir.Function{common.StaticCallee()}
+				case common.IsInvoke():
+					cg.Unresolved[site] = true
+					iface, ok := common.Value.Type().Underlying().(*types.Interface)
+					if !ok {
+						continue
+					}
+					callees = lookupMethods(iface, common.Method)
+				default:
+					if _, ok := common.Value.(*ir.Builtin); ok {
+						continue
+					}
+					cg.Unresolved[site] = true
+					sig, ok := typeutil.CoreType(common.Value.Type()).(*types.Signature)
+					if !ok {
+						continue
+					}
+					callees, _ = funcsBySig.At(sig)
+				}
+				for _, callee := range callees {
+					callgraph.AddEdge(caller, site, cg.CreateNode(callee))
+				}
+			}
+		}
+	}
+	return cg
+}
+
+// isGeneric reports whether fn is a generic function or a method of a generic
+// type. Such functions are only ever called through their instantiations.
+func isGeneric(fn *ir.Function) bool {
+	return fn.Signature.TypeParams().Len() > 0 || fn.Signature.RecvTypeParams().Len() > 0
+}
+
+// Sorted returns the functions in funcs in a deterministic order, sorted by
+// position and then by name.
+func Sorted(funcs map[*ir.Function]bool) []*ir.Function {
+	out := make([]*ir.Function, 0, len(funcs))
+	for fn := range funcs {
+		out = append(out, fn)
+	}
+	// Computing names is expensive, only do it for functions that share
+	// a position, such as synthetic ones.
+	names := map[*ir.Function]string{}
+	name := func(fn *ir.Function) string {
+		s, ok := names[fn]
+		if !ok {
+			s = fn.String()
+			names[fn] = s
+		}
+		return s
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Pos() != out[j].Pos() {
+			return out[i].Pos() < out[j].Pos()
+		}
+		return name(out[i]) < name(out[j])
+	})
+	return out
+}
@@ -0,0 +1,102 @@
+package pkg
+
+import "fmt"
+
+type I interface{ M() int }
+
+type A struct{}
+type B struct{}
+type J interface{ M() int }
+
+type C struct{ next J }
+
+func (A) M() int  { return 1 }
+func (*B) M() int { return 2 }
+func (c *C) M() int {
+	return c.next.M() // cha: (A).M (*A).M (*B).M (*C).M ? | vta: (A).M (*A).M (*B).M (*C).M ?
+}
+
+func staticCall() int {
+	return helper() // cha: helper | vta: helper
+}
+
+func helper() int { return 0 }
+
+func local() int {
+	var x I = A{}
+	return x.M() // cha: (A).M (*A).M (*B).M (*C).M ? | vta: (A).M
+}
+
+func phi(b bool) int {
+	var x I
+	if b {
+		x = A{}
+	} else {
+		x = &B{}
+	}
+	return x.M() // cha: (A).M (*A).M (*B).M (*C).M ? | vta: (A).M (*B).M
+}
+
+func Param(x I) int {
+	return x.M() // cha: (A).M (*A).M (*B).M (*C).M ? | vta: (A).M (*A).M (*B).M (*C).M ?
+}
+
+func apply(f func() int) int {
+	return f() // cha: helper one two closure$1 ? | vta: one two
+}
+
+func one() int { return 1 }
+func two() int { return 2 }
+
+func applyAll() int {
+	return apply(one) + apply(two)
+}
+
+func closure() int {
+	n := 0
+	f := func() int { return n }
+	return f() // cha: closure$1 | vta: closure$1
+}
+
+var global = []I{&B{}}
+
+func viaGlobal() int {
+	return global[0].M() // cha: (A).M (*A).M (*B).M (*C).M ? | vta: (*B).M
+}
+
+func external() int {
+	var s fmt.Stringer
+	fmt.Sscan("", &s)
+	return len(s.String()) // cha: ? | vta: ?
+}
+
+func newC() *C {
+	return &C{next: A{}}
+}
+
+func useHelper() func() int {
+	return helper
+}
+
+// RC embeds R and thus shares the Read method object with it. Calls
+// through R must still find implementations that don't implement RC.
+type R interface{ Read() int }
+type RC interface {
+	R
+	Close()
+}
+
+type D struct{}
+type E struct{}
+
+func (D) Read() int { return 3 }
+func (E) Read() int { return 4 }
+func (E) Close()    {}
+
+func ReadClose(x RC) int {
+	return x.Read() // cha: (E).Read (*E).Read ? | vta: (E).Read (*E).Read ?
+}
+
+func Read(x R) int {
+	return x.Read() // cha: (D).Read (*D).Read (E).Read (*E).Read ? | vta: (D).Read (*D).Read (E).Read (*E).Read ?
+}
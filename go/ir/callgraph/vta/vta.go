@@ -0,0 +1,736 @@
+// Package vta computes call graphs using variable type analysis.
+//
+// Variable type analysis (VTA) refines an initial call graph, usually one
+// computed by package cha, by tracking which concrete types and which
+// functions may flow into each value of interface or function type. Dynamic
+// calls are then resolved to just the callees that match the types and
+// functions that may reach the called value.
+//
+// The analysis is flow-insensitive. Memory is modeled by type: all locations
+// holding values of the same type, be they variables, fields, elements of
+// slices and maps, or channel buffers, are treated as a single location.
+//
+// Values may originate in code that the analysis cannot see: parameters of
+// functions that may be called from other packages, results of calls to
+// functions without bodies, and memory that is shared with such code. These
+// values are marked as unknown. A call site whose called value may be unknown
+// keeps all of its initial callees and remains unresolved.
+package vta
+
+import (
+	"go/types"
+	"math/bits"
+	"sort"
+
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/callgraph"
+	"honnef.co/go/tools/go/ir/callgraph/cha"
+	"honnef.co/go/tools/go/types/typeutil"
+)
+
+// CallGraph computes the call graph of the functions in funcs, refining
+// initial. The callees of every site in the result are a subset of its
+// callees in initial, except for function values flowing from outside of
+// funcs, such as functions of other packages.
+func CallGraph(funcs map[*ir.Function]bool, initial *callgraph.Graph) *callgraph.Graph {
+	a := &analysis{
+		funcs:        funcs,
+		sorted:       cha.Sorted(funcs),
+		callees:      map[ir.CallInstruction][]*ir.Function{},
+		unresolved:   map[ir.CallInstruction]bool{},
+		addressTaken: map[*ir.Function]bool{},
+		callers:      map[*ir.Function]int{},
+	}
+	for _, fn := range a.sorted {
+		var buf [10]*ir.Value
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				var callee *ir.Value
+				if site, ok := instr.(ir.CallInstruction); ok {
+					callee = &site.Common().Value
+					a.sites = append(a.sites, site)
+					a.callees[site] = initial.Callees(site)
+					if initial.Unresolved[site] {
+						a.unresolved[site] = true
+					}
+					for _, g := range a.callees[site] {
+						a.callers[g]++
+					}
+				}
+				for _, op := range instr.Operands(buf[:0]) {
+					if op == callee {
+						continue
+					}
+					if g, ok := (*op).(*ir.Function); ok {
+						a.addressTaken[g] = true
+					}
+				}
+			}
+		}
+	}
+
+	// Each round treats the call sites that are still unresolved as
+	// calls of unknown functions. Resolving a site can only remove
+	// unknown values from the analysis, which may allow resolving
+	// further sites in the next round.
+	for {
+		a.types = typeutil.Map[int]{}
+		a.typeList = nil
+		a.nodes = map[interface{}]*node{}
+		a.escaped = map[int]bool{}
+		a.exposed = nil
+		a.asserts = nil
+		a.elems = []interface{}{topElem{}}
+		a.elemIDs = map[interface{}]int{topElem{}: top}
+		for _, fn := range a.sorted {
+			a.function(fn)
+		}
+		a.solve()
+		if !a.resolve() {
+			break
+		}
+	}
+
+	var root *ir.Function
+	if initial.Root != nil {
+		root = initial.Root.Func
+	}
+	cg := callgraph.New(root)
+	if initial.Root != nil {
+		for _, e := range initial.Root.Out {
+			if e.Site == nil {
+				callgraph.AddEdge(cg.Root, nil, cg.CreateNode(e.Callee.Func))
+			}
+		}
+	}
+	for _, fn := range a.sorted {
+		cg.CreateNode(fn)
+	}
+	for _, site := range a.sites {
+		caller := cg.CreateNode(site.Parent())
+		for _, callee := range a.callees[site] {
+			callgraph.AddEdge(caller, site, cg.CreateNode(callee))
+		}
+		if a.unresolved[site] {
+			cg.Unresolved[site] = true
+		}
+	}
+	return cg
+}
+
+// Elements of the sets propagated through the graph are typeElem for
+// concrete types stored in interfaces, *ir.Function for function values,
+// and topElem for unknown values. Sets store the indices of elements in
+// analysis.elems, with topElem always at index 0.
+type (
+	typeElem int
+	topElem  struct{}
+)
+
+const top = 0
+
+// Keys of nodes other than ir.Values.
+type (
+	// locKey is the memory location of all values of a type.
+	locKey int
+	// tupleKey is a component of a tuple-typed value.
+	tupleKey struct {
+		v ir.Value
+		i int
+	}
+	// retKey is a result of a function.
+	retKey struct {
+		fn *ir.Function
+		i  int
+	}
+)
+
+type node struct {
+	typ types.Type
+	// dynamic is set for nodes of interface and function types, the
+	// only ones that hold elements.
+	dynamic bool
+	elems   bitset
+	// delta contains the elements that haven't been propagated to
+	// succs yet.
+	delta    []int
+	succs    []*node
+	exposed  bool
+	incoming bool
+}
+
+func (n *node) unknown() bool {
+	return n.elems.has(top)
+}
+
+type bitset []uint64
+
+func (s bitset) has(i int) bool {
+	return i/64 < len(s) && s[i/64]&(1<<(i%64)) != 0
+}
+
+// each calls fn for each element of the set, in ascending order.
+func (s bitset) each(fn func(int)) {
+	for i, w := range s {
+		for w != 0 {
+			fn(i*64 + bits.TrailingZeros64(w))
+			w &= w - 1
+		}
+	}
+}
+
+type analysis struct {
+	funcs        map[*ir.Function]bool
+	sorted       []*ir.Function
+	sites        []ir.CallInstruction
+	callees      map[ir.CallInstruction][]*ir.Function
+	unresolved   map[ir.CallInstruction]bool
+	addressTaken map[*ir.Function]bool
+	callers      map[*ir.Function]int
+
+	// State of the current round
+	types    typeutil.Map[int]
+	typeList []types.Type
+	elems    []interface{}
+	elemIDs  map[interface{}]int
+	nodes    map[interface{}]*node
+	queue    []*node
+	escaped  map[int]bool
+	exposed  []*node
+	// Pairs of nodes (interface value, asserted value). Unknown
+	// interfaces produce unknown concrete values, which expose their
+	// memory.
+	asserts [][2]*node
+}
+
+func (a *analysis) typeID(T types.Type) int {
+	if id, ok := a.types.At(T); ok {
+		return id
+	}
+	id := len(a.typeList)
+	a.types.Set(T, id)
+	a.typeList = append(a.typeList, T)
+	return id
+}
+
+func (a *analysis) node(k interface{}, T types.Type) *node {
+	n, ok := a.nodes[k]
+	if !ok {
+		n = &node{typ: T, dynamic: isDynamic(T)}
+		a.nodes[k] = n
+	}
+	return n
+}
+
+func (a *analysis) value(v ir.Value) *node {
+	n := a.node(v, v.Type())
+	if fn, ok := v.(*ir.Function); ok {
+		a.add(n, a.elem(fn))
+	}
+	return n
+}
+
+// elem returns the index of an element.
+func (a *analysis) elem(e interface{}) int {
+	id, ok := a.elemIDs[e]
+	if !ok {
+		id = len(a.elems)
+		a.elemIDs[e] = id
+		a.elems = append(a.elems, e)
+	}
+	return id
+}
+
+func (a *analysis) loc(T types.Type) *node { return a.node(locKey(a.typeID(T)), T) }
+func (a *analysis) tuple(v ir.Value, i int) *node {
+	return a.node(tupleKey{v, i}, v.Type().(*types.Tuple).At(i).Type())
+}
+func (a *analysis) ret(fn *ir.Function, i int) *node {
+	return a.node(retKey{fn, i}, fn.Signature.Results().At(i).Type())
+}
+
+func (a *analysis) add(n *node, e int) {
+	if !n.dynamic || n.elems.has(e) {
+		return
+	}
+	for e/64 >= len(n.elems) {
+		n.elems = append(n.elems, 0)
+	}
+	n.elems[e/64] |= 1 << (e % 64)
+	if len(n.delta) == 0 {
+		a.queue = append(a.queue, n)
+	}
+	n.delta = append(n.delta, e)
+}
+
+func (a *analysis) flow(from, to *node) {
+	if !to.dynamic {
+		return
+	}
+	from.succs = append(from.succs, to)
+	from.elems.each(func(e int) { a.add(to, e) })
+}
+
+// unknown marks n as holding values that originate in code we cannot see.
+func (a *analysis) unknown(n *node) {
+	a.add(n, top)
+	if !n.incoming {
+		n.incoming = true
+		a.incoming(n.typ)
+	}
+	a.expose(n)
+}
+
+// expose marks n as holding values that are shared with code we cannot see.
+// That code may store arbitrary values in the memory reachable from them.
+func (a *analysis) expose(n *node) {
+	if n.exposed {
+		return
+	}
+	n.exposed = true
+	a.exposed = append(a.exposed, n)
+	a.escape(n.typ)
+}
+
+// escape marks the memory reachable from values of type T as exposed.
+func (a *analysis) escape(T types.Type) {
+	id := a.typeID(T)
+	if a.escaped[id] {
+		return
+	}
+	a.escaped[id] = true
+	switch u := T.Underlying().(type) {
+	case *types.Pointer:
+		a.unknown(a.loc(u.Elem()))
+	case *types.Slice:
+		a.unknown(a.loc(u.Elem()))
+	case *types.Chan:
+		a.unknown(a.loc(u.Elem()))
+	case *types.Map:
+		a.unknown(a.loc(u.Key()))
+		a.unknown(a.loc(u.Elem()))
+	case *types.Array:
+		a.escapeElem(u.Elem())
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			a.escapeElem(u.Field(i).Type())
+		}
+	case *types.Tuple:
+		for i := 0; i < u.Len(); i++ {
+			a.escape(u.At(i).Type())
+		}
+	}
+}
+
+// escapeElem marks an element of an aggregate of type T as exposed.
+// We don't track the contents of aggregates, only the locations of their
+// elements.
+func (a *analysis) escapeElem(T types.Type) {
+	if isDynamic(T) {
+		a.expose(a.loc(T))
+	} else {
+		a.escape(T)
+	}
+}
+
+// incoming marks the contents of an aggregate of type T as unknown.
+func (a *analysis) incoming(T types.Type) {
+	switch u := T.Underlying().(type) {
+	case *types.Array:
+		a.incomingElem(u.Elem())
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			a.incomingElem(u.Field(i).Type())
+		}
+	case *types.Tuple:
+		for i := 0; i < u.Len(); i++ {
+			a.incoming(u.At(i).Type())
+		}
+	}
+}
+
+func (a *analysis) incomingElem(T types.Type) {
+	if isDynamic(T) {
+		a.unknown(a.loc(T))
+	} else {
+		a.incoming(T)
+	}
+}
+
+func (a *analysis) solve() {
+	for {
+		for len(a.queue) > 0 {
+			n := a.queue[len(a.queue)-1]
+			a.queue = a.queue[:len(a.queue)-1]
+			delta := n.delta
+			n.delta = nil
+			for _, succ := range n.succs {
+				for _, e := range delta {
+					a.add(succ, e)
+				}
+			}
+		}
+
+		// The dynamic types of exposed interfaces are exposed, too.
+		for i := 0; i < len(a.exposed); i++ {
+			a.exposed[i].elems.each(func(e int) {
+				if id, ok := a.elems[e].(typeElem); ok {
+					a.escape(a.typeList[id])
+				}
+			})
+		}
+		for _, pair := range a.asserts {
+			if pair[0].unknown() {
+				a.expose(pair[1])
+			}
+		}
+		if len(a.queue) == 0 {
+			break
+		}
+	}
+}
+
+// callable reports whether fn may be called by code we cannot see.
+func (a *analysis) callable(fn *ir.Function) bool {
+	if a.addressTaken[fn] || a.callers[fn] == 0 || fn.Signature.Recv() != nil || fn.Parent() != nil || fn.Synthetic != 0 {
+		return true
+	}
+	obj := fn.Object()
+	return obj == nil || obj.Exported()
+}
+
+func (a *analysis) function(fn *ir.Function) {
+	if a.callable(fn) {
+		for _, p := range fn.Params {
+			a.unknown(a.value(p))
+		}
+	}
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			a.instr(fn, instr)
+		}
+	}
+}
+
+func (a *analysis) instr(fn *ir.Function, instr ir.Instruction) {
+	if v, ok := instr.(ir.Value); ok && !isDynamic(v.Type()) {
+		switch instr.(type) {
+		case *ir.TypeAssert, *ir.TypeSwitch, *ir.CompositeValue, *ir.MapLookup, *ir.Recv, *ir.Next, *ir.Select, *ir.Call:
+			// These may produce or consume values of interest even
+			// if they don't produce a dynamic value.
+		default:
+			return
+		}
+	}
+	switch instr := instr.(type) {
+	case *ir.Sigma:
+		a.flow(a.value(instr.X), a.value(instr))
+	case *ir.Phi:
+		for _, e := range instr.Edges {
+			a.flow(a.value(e), a.value(instr))
+		}
+	case *ir.Copy:
+		a.flow(a.value(instr.X), a.value(instr))
+	case *ir.ChangeInterface:
+		a.flow(a.value(instr.X), a.value(instr))
+	case *ir.Convert:
+		a.flow(a.value(instr.X), a.value(instr))
+	case *ir.MultiConvert:
+		a.flow(a.value(instr.X), a.value(instr))
+	case *ir.ChangeType:
+		a.flow(a.value(instr.X), a.value(instr))
+		if isInterface(instr.Type()) && !isInterface(instr.X.Type()) {
+			// Converting a type parameter to an interface. We don't
+			// know the concrete type.
+			a.add(a.value(instr), top)
+		}
+	case *ir.MakeInterface:
+		n := a.value(instr)
+		if hasTypeParams(instr.X.Type()) {
+			a.add(n, top)
+		} else {
+			a.add(n, a.elem(typeElem(a.typeID(instr.X.Type()))))
+		}
+		if isDynamic(instr.X.Type()) {
+			// Function values stored in interfaces can be asserted
+			// and called.
+			a.flow(a.value(instr.X), n)
+		}
+	case *ir.MakeClosure:
+		a.add(a.value(instr), a.elem(instr.Fn.(*ir.Function)))
+		for i, b := range instr.Bindings {
+			a.flow(a.value(b), a.value(instr.Fn.(*ir.Function).FreeVars[i]))
+		}
+	case *ir.TypeAssert:
+		x := a.value(instr.X)
+		var n *node
+		if instr.CommaOk {
+			n = a.tuple(instr, 0)
+		} else {
+			n = a.value(instr)
+		}
+		a.flow(x, n)
+		a.asserts = append(a.asserts, [2]*node{x, n})
+	case *ir.TypeSwitch:
+		x := a.value(instr.Tag)
+		for i := 1; i < instr.Type().(*types.Tuple).Len(); i++ {
+			n := a.tuple(instr, i)
+			a.flow(x, n)
+			a.asserts = append(a.asserts, [2]*node{x, n})
+		}
+	case *ir.Extract:
+		a.flow(a.tuple(instr.Tuple, instr.Index), a.value(instr))
+	case *ir.Load:
+		a.flow(a.loc(instr.Type()), a.value(instr))
+	case *ir.Field:
+		a.flow(a.loc(instr.Type()), a.value(instr))
+	case *ir.Index:
+		a.flow(a.loc(instr.Type()), a.value(instr))
+	case *ir.Store:
+		a.store(instr.Val)
+	case *ir.CompositeValue:
+		for _, v := range instr.Values {
+			a.store(v)
+		}
+	case *ir.MapUpdate:
+		a.store(instr.Key)
+		a.store(instr.Value)
+	case *ir.MapLookup:
+		if instr.CommaOk {
+			n := a.tuple(instr, 0)
+			a.flow(a.loc(n.typ), n)
+		} else {
+			a.flow(a.loc(instr.Type()), a.value(instr))
+		}
+	case *ir.Recv:
+		if instr.CommaOk {
+			n := a.tuple(instr, 0)
+			a.flow(a.loc(n.typ), n)
+		} else {
+			a.flow(a.loc(instr.Type()), a.value(instr))
+		}
+	case *ir.Send:
+		a.store(instr.X)
+	case *ir.Next:
+		if !instr.IsString {
+			for i := 1; i <= 2; i++ {
+				n := a.tuple(instr, i)
+				a.flow(a.loc(n.typ), n)
+			}
+		}
+	case *ir.Select:
+		for _, st := range instr.States {
+			if st.Dir == types.SendOnly {
+				a.store(st.Send)
+			}
+		}
+		for i := 2; i < instr.Type().(*types.Tuple).Len(); i++ {
+			n := a.tuple(instr, i)
+			a.flow(a.loc(n.typ), n)
+		}
+	case *ir.Panic:
+		a.expose(a.value(instr.X))
+	case *ir.Return:
+		for i, r := range instr.Results {
+			n := a.value(r)
+			a.flow(n, a.ret(fn, i))
+			if a.callable(fn) {
+				a.expose(n)
+			}
+		}
+	case ir.CallInstruction:
+		a.call(instr)
+	}
+}
+
+// store records that v is stored in memory.
+func (a *analysis) store(v ir.Value) {
+	if isDynamic(v.Type()) {
+		a.flow(a.value(v), a.loc(v.Type()))
+	}
+}
+
+func (a *analysis) call(site ir.CallInstruction) {
+	common := site.Common()
+	v := site.Value()
+	if b, ok := common.Value.(*ir.Builtin); ok {
+		if b.Name() == "recover" && v != nil {
+			a.unknown(a.value(v))
+		}
+		return
+	}
+
+	args := common.Args
+	if common.IsInvoke() {
+		args = append([]ir.Value{common.Value}, args...)
+	}
+	unknown := a.unresolved[site]
+	for _, callee := range a.callees[site] {
+		if callee.Blocks == nil || !a.funcs[callee] || len(callee.Params) != len(args) {
+			unknown = true
+			continue
+		}
+		for i, arg := range args {
+			a.flow(a.value(arg), a.value(callee.Params[i]))
+		}
+		if v != nil {
+			if n := callee.Signature.Results().Len(); n == 1 {
+				a.flow(a.ret(callee, 0), a.value(v))
+			} else {
+				for i := 0; i < n; i++ {
+					a.flow(a.ret(callee, i), a.tuple(v, i))
+				}
+			}
+		}
+	}
+	if !unknown {
+		return
+	}
+	for _, arg := range args {
+		a.expose(a.value(arg))
+	}
+	if v != nil {
+		if tuple, ok := v.Type().(*types.Tuple); ok {
+			for i := 0; i < tuple.Len(); i++ {
+				a.unknown(a.tuple(v, i))
+			}
+		} else {
+			a.unknown(a.value(v))
+		}
+	}
+}
+
+// resolve computes the callees of unresolved dynamic call sites from the
+// values that flow into them. It reports whether any site was resolved.
+func (a *analysis) resolve() bool {
+	changed := false
+	for _, site := range a.sites {
+		common := site.Common()
+		if !a.unresolved[site] || common.StaticCallee() != nil {
+			continue
+		}
+		n := a.nodes[common.Value]
+		if n == nil || n.unknown() {
+			continue
+		}
+		var callees []*ir.Function
+		if common.IsInvoke() {
+			if _, ok := types.Unalias(common.Value.Type()).(*types.TypeParam); ok {
+				// We only see concrete types stored in interfaces,
+				// not those that type parameters are instantiated with.
+				continue
+			}
+			iface := common.Value.Type().Underlying().(*types.Interface)
+			ok := true
+			for _, id := range a.sortedTypes(n) {
+				T := a.typeList[id]
+				if !types.Implements(T, iface) {
+					continue
+				}
+				found := false
+				for _, callee := range a.callees[site] {
+					if types.Identical(callee.Signature.Recv().Type(), T) {
+						callees = append(callees, callee)
+						found = true
+					}
+				}
+				if !found {
+					// The method isn't part of the analyzed functions.
+					ok = false
+					break
+				}
+			}
+			if !ok {
+				continue
+			}
+		} else {
+			sig, ok := typeutil.CoreType(common.Value.Type()).(*types.Signature)
+			if !ok {
+				continue
+			}
+			fns := map[*ir.Function]bool{}
+			n.elems.each(func(e int) {
+				if fn, ok := a.elems[e].(*ir.Function); ok && types.Identical(fn.Signature, sig) {
+					fns[fn] = true
+				}
+			})
+			callees = cha.Sorted(fns)
+		}
+		a.callees[site] = callees
+		delete(a.unresolved, site)
+		changed = true
+	}
+	return changed
+}
+
+func (a *analysis) sortedTypes(n *node) []int {
+	var out []int
+	n.elems.each(func(e int) {
+		if id, ok := a.elems[e].(typeElem); ok {
+			out = append(out, int(id))
+		}
+	})
+	sort.Ints(out)
+	return out
+}
+
+// isDynamic reports whether values of type T are tracked by the analysis.
+func isDynamic(T types.Type) bool {
+	switch T.Underlying().(type) {
+	case *types.Interface, *types.Signature:
+		return true
+	default:
+		return false
+	}
+}
+
+// isInterface reports whether T is an interface type, not counting type
+// parameters.
+func isInterface(T types.Type) bool {
+	if _, ok := types.Unalias(T).(*types.TypeParam); ok {
+		return false
+	}
+	return types.IsInterface(T)
+}
+
+func hasTypeParams(T types.Type) bool {
+	switch T := types.Unalias(T).(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Named:
+		for i := 0; i < T.TypeArgs().Len(); i++ {
+			if hasTypeParams(T.TypeArgs().At(i)) {
+				return true
+			}
+		}
+		return T.TypeParams().Len() > 0 && T.TypeArgs().Len() == 0
+	case *types.Pointer:
+		return hasTypeParams(T.Elem())
+	case *types.Slice:
+		return hasTypeParams(T.Elem())
+	case *types.Array:
+		return hasTypeParams(T.Elem())
+	case *types.Chan:
+		return hasTypeParams(T.Elem())
+	case *types.Map:
+		return hasTypeParams(T.Key()) || hasTypeParams(T.Elem())
+	case *types.Struct:
+		for i := 0; i < T.NumFields(); i++ {
+			if hasTypeParams(T.Field(i).Type()) {
+				return true
+			}
+		}
+		return false
+	case *types.Signature:
+		for _, tup := range []*types.Tuple{T.Params(), T.Results()} {
+			for i := 0; i < tup.Len(); i++ {
+				if hasTypeParams(tup.At(i).Type()) {
+					return true
+				}
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
@@ -1,13 +1,31 @@
 package ir
 
 import (
+	"go/constant"
+	"go/token"
 	"go/types"
 )
 
+// buildExits computes fn.NoReturn and fn.ExitsIf by looking for calls
+// to functions that don't return. Information about functions in other
+// packages is provided by the caller, in the form of facts.
+//
+// Some functions cannot be analyzed, either because they are
+// implemented in assembly or because whether they return depends on
+// dynamic calls. We hard-code information about the most important of
+// these. Users can declare others, which sets NoReturn before the
+// function is built.
 func (b *builder) buildExits(fn *Function) {
+	if fn.NoReturn != Returns {
+		// The user has declared the function as not returning.
+		return
+	}
 	if obj := fn.Object(); obj != nil {
 		switch obj.Pkg().Path() {
 		case "runtime":
+			// exit is implemented in assembly. throw and Goexit reach
+			// exit and the scheduler via systemstack and mcall, which
+			// are implemented in assembly and call function values.
 			switch obj.Name() {
 			case "exit":
 				fn.NoReturn = AlwaysExits
@@ -25,19 +43,16 @@ func (b *builder) buildExits(fn *Function) {
 				"(*go.uber.org/zap.SugaredLogger).Fatal",
 				"(*go.uber.org/zap.SugaredLogger).Fatalw",
 				"(*go.uber.org/zap.SugaredLogger).Fatalf":
-				// Technically, this method does not unconditionally exit
-				// the process. It dynamically calls a function stored in
-				// the logger. If the function is nil, it defaults to
-				// os.Exit.
-				//
-				// The main intent of this method is to terminate the
-				// process, and that's what the vast majority of people
-				// will use it for. We'll happily accept some false
-				// negatives to avoid a lot of false positives.
+				// These call a function stored in the logger, which
+				// defaults to os.Exit. Users rarely change it, and
+				// we accept the false negatives when they do.
 				fn.NoReturn = AlwaysExits
+				return
 			case "(*go.uber.org/zap.Logger).Panic",
 				"(*go.uber.org/zap.SugaredLogger).Panicw",
 				"(*go.uber.org/zap.SugaredLogger).Panicf":
+				// The panic happens in a hook that is called via an
+				// interface.
 				fn.NoReturn = AlwaysUnwinds
 				return
 			case "(*go.uber.org/zap.Logger).DPanic",
@@ -48,41 +63,22 @@ func (b *builder) buildExits(fn *Function) {
 		case "github.com/sirupsen/logrus":
 			switch obj.(*types.Func).FullName() {
 			case "(*github.com/sirupsen/logrus.Logger).Exit":
-				// Technically, this method does not unconditionally exit
-				// the process. It dynamically calls a function stored in
-				// the logger. If the function is nil, it defaults to
-				// os.Exit.
-				//
-				// The main intent of this method is to terminate the
-				// process, and that's what the vast majority of people
-				// will use it for. We'll happily accept some false
-				// negatives to avoid a lot of false positives.
+				// Calls a function stored in the logger, which
+				// defaults to os.Exit, like zap's Fatal.
 				fn.NoReturn = AlwaysExits
 				return
-			case "(*github.com/sirupsen/logrus.Logger).Panic",
-				"(*github.com/sirupsen/logrus.Logger).Panicf",
-				"(*github.com/sirupsen/logrus.Logger).Panicln":
-
-				// These methods will always panic, but that's not
-				// statically known from the code alone, because they
-				// take a detour through the generic Log methods.
-				fn.NoReturn = AlwaysUnwinds
-				return
-			case "(*github.com/sirupsen/logrus.Entry).Panicf",
-				"(*github.com/sirupsen/logrus.Entry).Panicln":
-
-				// Entry.Panic has an explicit panic, but Panicf and
-				// Panicln do not, relying fully on the generic Log
-				// method.
-				fn.NoReturn = AlwaysUnwinds
-				return
 			case "(*github.com/sirupsen/logrus.Logger).Log",
 				"(*github.com/sirupsen/logrus.Logger).Logf",
-				"(*github.com/sirupsen/logrus.Logger).Logln":
-				// TODO(dh): we cannot handle these cases. Whether they
-				// exit or unwind depends on the level, which is set
-				// via the first argument. We don't currently support
-				// call-site-specific exit information.
+				"(*github.com/sirupsen/logrus.Logger).Logln",
+				"(*github.com/sirupsen/logrus.Entry).Log",
+				"(*github.com/sirupsen/logrus.Entry).Logf",
+				"(*github.com/sirupsen/logrus.Entry).Logln":
+				// These panic when logging at PanicLevel, after
+				// calling hooks and formatters via interfaces. Fatal
+				// and the Panic methods are inferred from these and
+				// Exit.
+				fn.ExitsIf = []ConditionalExit{{Arg: 1, Value: constant.MakeUint64(0), Kind: AlwaysUnwinds}}
+				return
 			}
 		case "github.com/golang/glog":
 			switch obj.(*types.Func).FullName() {
@@ -94,8 +90,9 @@ func (b *builder) buildExits(fn *Function) {
 				"github.com/golang/glog.FatalDepth",
 				"github.com/golang/glog.Fatalf",
 				"github.com/golang/glog.Fatalln":
-				// all of these call os.Exit after logging
+				// These write the log via interfaces before exiting.
 				fn.NoReturn = AlwaysExits
+				return
 			}
 		case "k8s.io/klog":
 			switch obj.(*types.Func).FullName() {
@@ -107,8 +104,9 @@ func (b *builder) buildExits(fn *Function) {
 				"k8s.io/klog.FatalDepth",
 				"k8s.io/klog.Fatalf",
 				"k8s.io/klog.Fatalln":
-				// all of these call os.Exit after logging
+				// These write the log via interfaces before exiting.
 				fn.NoReturn = AlwaysExits
+				return
 			}
 		case "k8s.io/klog/v2":
 			switch obj.(*types.Func).FullName() {
@@ -120,8 +118,9 @@ func (b *builder) buildExits(fn *Function) {
 				"k8s.io/klog/v2.FatalDepth",
 				"k8s.io/klog/v2.Fatalf",
 				"k8s.io/klog/v2.Fatalln":
-				// all of these call os.Exit after logging
+				// These write the log via interfaces before exiting.
 				fn.NoReturn = AlwaysExits
+				return
 			}
 		}
 	}
@@ -137,29 +136,37 @@ func (b *builder) buildExits(fn *Function) {
 		return false
 	}
 
-	both := NewBlockSet(len(fn.Blocks))
-	exits := NewBlockSet(len(fn.Blocks))
-	unwinds := NewBlockSet(len(fn.Blocks))
 	recovers := false
 	for _, u := range fn.Blocks {
 		for _, instr := range u.Instrs {
 		instrSwitch:
 			switch instr := instr.(type) {
 			case *Defer:
+				if instr.Common().IsInvoke() {
+					// give up
+					return
+				}
+				call := instr.Call.StaticCallee()
+				if call != nil && call.Package() == fn.Package() {
+					// exitKind needs to know whether deferred
+					// calls return, too.
+					b.buildFunction(call)
+				}
 				if recovers {
 					// avoid doing extra work, we already know that this function calls recover
 					continue
 				}
-				call := instr.Call.StaticCallee()
+				if call != nil && call.NoReturn != Returns {
+					// even if the deferred call recovers, it doesn't
+					// let us return normally
+					break
+				}
 				if call == nil {
 					// not a static call, so we can't be sure the
 					// deferred call isn't calling recover
 					recovers = true
 					break
 				}
-				if call.Package() == fn.Package() {
-					b.buildFunction(call)
-				}
 				if len(call.Blocks) == 0 {
 					// external function, we don't know what's
 					// happening inside it
@@ -180,40 +187,66 @@ func (b *builder) buildExits(fn *Function) {
 					}
 				}
 
-			case *Panic:
-				both.Add(u)
-				unwinds.Add(u)
-
-			case CallInstruction:
-				switch instr.(type) {
-				case *Defer, *Call:
-				default:
-					continue
-				}
+			case *Call:
 				if instr.Common().IsInvoke() {
 					// give up
 					return
 				}
-				var call *Function
 				switch instr.Common().Value.(type) {
 				case *Function, *MakeClosure:
-					call = instr.Common().StaticCallee()
+					// buildFunction is idempotent. if we're part of a
+					// (mutually) recursive call chain, then buildFunction
+					// will immediately return, and fn.WillExit will be false.
+					if call := instr.Common().StaticCallee(); call.Package() == fn.Package() {
+						b.buildFunction(call)
+					}
 				case *Builtin:
 					// the only builtins that affect control flow are
 					// panic and recover, and we've already handled
 					// those
-					continue
 				default:
 					// dynamic dispatch
 					return
 				}
-				// buildFunction is idempotent. if we're part of a
-				// (mutually) recursive call chain, then buildFunction
-				// will immediately return, and fn.WillExit will be false.
-				if call.Package() == fn.Package() {
-					b.buildFunction(call)
-				}
-				switch call.NoReturn {
+			}
+		}
+	}
+
+	params := paramValues(fn)
+	fn.NoReturn = exitKind(fn, params, nil, recovers)
+	if fn.NoReturn != Returns {
+		return
+	}
+	for _, cond := range exitCandidates(fn, params) {
+		if kind := exitKind(fn, params, &cond, recovers); kind != Returns {
+			cond.Kind = kind
+			fn.ExitsIf = append(fn.ExitsIf, cond)
+		}
+	}
+}
+
+// maxExitCandidates limits the number of conditions per function that
+// buildExits tries.
+const maxExitCandidates = 16
+
+// exitKind computes how fn terminates control flow, under the
+// assumption that the argument assume.Arg is equal to assume.Value. If
+// assume is nil, nothing is assumed about the arguments.
+func exitKind(fn *Function, params map[Value]int, assume *ConditionalExit, recovers bool) NoReturn {
+	both := NewBlockSet(len(fn.Blocks))
+	exits := NewBlockSet(len(fn.Blocks))
+	unwinds := NewBlockSet(len(fn.Blocks))
+	for _, u := range fn.Blocks {
+		for _, instr := range u.Instrs {
+			switch instr := instr.(type) {
+			case *Panic:
+				both.Add(u)
+				unwinds.Add(u)
+			case *Call, *Defer:
+				// A deferred call runs when the function returns, so
+				// returning via its block terminates control flow,
+				// too.
+				switch callNoReturn(instr.(CallInstruction).Common(), params, assume) {
 				case AlwaysExits:
 					both.Add(u)
 					exits.Add(u)
@@ -242,6 +275,10 @@ func (b *builder) buildExits(fn *Function) {
 			return false
 		}
 		seen.Add(root)
+		if succ := knownSucc(root, params, assume); succ != nil {
+			// Don't follow branches that cannot be taken under our assumption.
+			return findPath(succ, bl)
+		}
 		for _, succ := range root.Succs {
 			if findPath(succ, bl) {
 				return true
@@ -258,7 +295,7 @@ func (b *builder) buildExits(fn *Function) {
 	}
 
 	if !findPathEntry(fn.Blocks[0], exits) {
-		fn.NoReturn = AlwaysExits
+		return AlwaysExits
 	} else if !recovers {
 		// Only consider unwinding and "never returns" if we don't
 		// call recover. If we do call recover, then panics don't
@@ -269,11 +306,218 @@ func (b *builder) buildExits(fn *Function) {
 		// the recover is ineffective.
 
 		if !findPathEntry(fn.Blocks[0], unwinds) {
-			fn.NoReturn = AlwaysUnwinds
+			return AlwaysUnwinds
 		} else if !findPathEntry(fn.Blocks[0], both) {
-			fn.NoReturn = NeverReturns
+			return NeverReturns
+		}
+	}
+	return Returns
+}
+
+// callNoReturn is like CallCommon.NoReturn, but additionally takes
+// into account calls that forward the argument we make an assumption
+// about.
+func callNoReturn(c *CallCommon, params map[Value]int, assume *ConditionalExit) NoReturn {
+	if kind := c.NoReturn(); kind != Returns || assume == nil {
+		return kind
+	}
+	callee := c.StaticCallee()
+	if callee == nil {
+		return Returns
+	}
+	for _, cond := range callee.ExitsIf {
+		if cond.Arg >= len(c.Args) {
+			continue
+		}
+		if arg, ok := params[c.Args[cond.Arg]]; ok && arg == assume.Arg && constEqual(assume.Value, cond.Value) {
+			return cond.Kind
 		}
 	}
+	return Returns
+}
+
+// exitCandidates returns the conditions under which fn might terminate
+// control flow. These are comparisons of parameters against constants,
+// and arguments that fn forwards to functions that exit conditionally.
+func exitCandidates(fn *Function, params map[Value]int) []ConditionalExit {
+	var out []ConditionalExit
+	add := func(param Value, v constant.Value) {
+		arg, ok := params[param]
+		if !ok || v == nil || len(out) >= maxExitCandidates {
+			return
+		}
+		switch v.Kind() {
+		case constant.Bool, constant.String, constant.Int:
+		default:
+			return
+		}
+		for _, cond := range out {
+			if cond.Arg == arg && constEqual(cond.Value, v) {
+				return
+			}
+		}
+		out = append(out, ConditionalExit{Arg: arg, Value: v})
+	}
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			switch instr := instr.(type) {
+			case *If:
+				if _, ok := params[instr.Cond]; ok {
+					add(instr.Cond, constant.MakeBool(true))
+					add(instr.Cond, constant.MakeBool(false))
+				} else if bin, ok := instr.Cond.(*BinOp); ok {
+					if k, ok := bin.Y.(*Const); ok {
+						add(bin.X, k.Value)
+					} else if k, ok := bin.X.(*Const); ok {
+						add(bin.Y, k.Value)
+					}
+				}
+			case *ConstantSwitch:
+				for _, cond := range instr.Conds {
+					if k, ok := cond.(*Const); ok {
+						add(instr.Tag, k.Value)
+					}
+				}
+			case *Call, *Defer:
+				common := instr.(CallInstruction).Common()
+				if callee := common.StaticCallee(); callee != nil {
+					for _, cond := range callee.ExitsIf {
+						if cond.Arg < len(common.Args) {
+							add(common.Args[cond.Arg], cond.Value)
+						}
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// paramValues maps the values in fn that are known to be equal to one
+// of fn's parameters to the parameter's index. Because buildExits runs
+// before lifting, parameters are usually loaded from the local
+// variables they have been spilled to, and referrers haven't been
+// computed yet.
+func paramValues(fn *Function) map[Value]int {
+	out := map[Value]int{}
+	for i, p := range fn.Params {
+		out[p] = i
+	}
+
+	spills := map[*Alloc]int{}
+	invalid := map[*Alloc]bool{}
+	var rands []*Value
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			switch instr := instr.(type) {
+			case *Store:
+				if alloc, ok := instr.Addr.(*Alloc); ok {
+					if i, ok := out[instr.Val]; ok && instr.Val == fn.Params[i] {
+						if _, ok := spills[alloc]; !ok {
+							spills[alloc] = i
+							break
+						}
+					}
+					invalid[alloc] = true
+				}
+				if alloc, ok := instr.Val.(*Alloc); ok {
+					invalid[alloc] = true
+				}
+			case *Load, *DebugRef:
+			default:
+				// Any other use of the variable's address means that it
+				// may get modified.
+				rands = instr.Operands(rands[:0])
+				for _, rand := range rands {
+					if alloc, ok := (*rand).(*Alloc); ok {
+						invalid[alloc] = true
+					}
+				}
+			}
+		}
+	}
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			if load, ok := instr.(*Load); ok {
+				if alloc, ok := load.X.(*Alloc); ok && !invalid[alloc] {
+					if i, ok := spills[alloc]; ok {
+						out[load] = i
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// knownSucc returns the successor of b that control flow will take
+// under the assumption assume, or nil if it isn't known.
+func knownSucc(b *BasicBlock, params map[Value]int, assume *ConditionalExit) *BasicBlock {
+	operand := func(v Value) constant.Value {
+		if k, ok := v.(*Const); ok {
+			return k.Value
+		}
+		if arg, ok := params[v]; ok && assume != nil && arg == assume.Arg {
+			return assume.Value
+		}
+		return nil
+	}
+
+	switch ctrl := b.Control().(type) {
+	case *If:
+		if v := operand(ctrl.Cond); v != nil && v.Kind() == constant.Bool {
+			if constant.BoolVal(v) {
+				return b.Succs[0]
+			}
+			return b.Succs[1]
+		}
+		bin, ok := ctrl.Cond.(*BinOp)
+		if !ok {
+			return nil
+		}
+		x, y := operand(bin.X), operand(bin.Y)
+		if x == nil || y == nil || x.Kind() != y.Kind() {
+			return nil
+		}
+		switch bin.Op {
+		case token.EQL, token.NEQ:
+		case token.LSS, token.LEQ, token.GTR, token.GEQ:
+			if x.Kind() != constant.Int && x.Kind() != constant.String {
+				return nil
+			}
+		default:
+			return nil
+		}
+		if constant.Compare(x, bin.Op, y) {
+			return b.Succs[0]
+		}
+		return b.Succs[1]
+
+	case *ConstantSwitch:
+		tag := operand(ctrl.Tag)
+		if tag == nil {
+			return nil
+		}
+		var dflt *BasicBlock
+		for i, cond := range ctrl.Conds {
+			if cond == nil {
+				dflt = b.Succs[i]
+			} else if k, ok := cond.(*Const); !ok {
+				return nil
+			} else if constEqual(tag, k.Value) {
+				return b.Succs[i]
+			}
+		}
+		return dflt
+	}
+	return nil
+}
+
+func constEqual(x, y constant.Value) bool {
+	if x == nil || y == nil || x.Kind() != y.Kind() || x.Kind() == constant.Unknown {
+		return false
+	}
+	return constant.Compare(x, token.EQL, y)
 }
 
 func (b *builder) addUnreachables(fn *Function) {
@@ -283,21 +527,11 @@ func (b *builder) addUnreachables(fn *Function) {
 	instrLoop:
 		for i, instr := range bb.Instrs {
 			if instr, ok := instr.(*Call); ok {
-				var call *Function
-				switch v := instr.Common().Value.(type) {
-				case *Function:
-					call = v
-				case *MakeClosure:
-					call = v.Fn.(*Function)
-				}
-				if call == nil {
-					continue
-				}
-				if call.Package() == fn.Package() {
+				if call := instr.Common().StaticCallee(); call != nil && call.Package() == fn.Package() {
 					// make sure we have information on all functions in this package
 					b.buildFunction(call)
 				}
-				switch call.NoReturn {
+				switch instr.Common().NoReturn() {
 				case AlwaysExits:
 					// This call will cause the process to terminate.
 					// Remove remaining instructions in the block and
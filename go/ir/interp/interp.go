@@ -0,0 +1,968 @@
+// Package interp implements an interpreter for IR functions.
+//
+// The interpreter executes the IR as built by package ir, including Sigma and Copy nodes, phi nodes and the control
+// flow that the builder synthesizes for calls to functions that never return. This makes it useful for validating
+// changes to the IR builder by running test programs, as well as for evaluating pure functions on constant
+// arguments.
+//
+// The interpreter isn't a complete implementation of Go. It doesn't support goroutines, channels, unsafe, or calls
+// to functions without bodies, such as functions loaded from export data or implemented in assembly. Encountering
+// an unsupported feature aborts execution with an error.
+//
+// Run-time errors, such as out of bounds accesses or nil pointer dereferences, cause panics in the interpreted
+// program, with string values describing the error. They can be recovered from like any other panic.
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"go/constant"
+	"go/types"
+	"io"
+	"runtime"
+	"unicode/utf8"
+
+	"honnef.co/go/tools/go/ir"
+)
+
+// ErrStepLimit is returned when the execution of a function exceeds the interpreter's step limit.
+var ErrStepLimit = errors.New("step limit exceeded")
+
+// maxDepth limits the depth of the interpreted call stack, so that infinite recursion fails with an error instead of
+// overflowing the interpreter's own stack.
+const maxDepth = 10000
+
+// A PanicError is returned when the interpreted program panics and doesn't recover.
+type PanicError struct {
+	// Value is the formatted value that was passed to panic.
+	Value string
+}
+
+func (err *PanicError) Error() string {
+	return "panic: " + err.Value
+}
+
+// stopError aborts the execution of the interpreted program. Unlike panics of the interpreted program, it doesn't run
+// deferred calls and can't be recovered from.
+type stopError struct {
+	err error
+}
+
+func stop(err error) stopError { return stopError{err} }
+
+// targetPanic is a panic of the interpreted program.
+type targetPanic struct {
+	v value
+}
+
+// runtimeError returns a run-time panic of the interpreted program.
+func runtimeError(msg string) targetPanic {
+	return targetPanic{iface{t: types.Typ[types.String], v: "runtime error: " + msg}}
+}
+
+// An Interpreter executes IR functions. The zero value is ready to use. An Interpreter retains the values of global
+// variables across calls. It is not safe for concurrent use.
+type Interpreter struct {
+	// MaxSteps limits the number of instructions executed by a single call to Run, Init or Eval. Zero means no
+	// limit.
+	MaxSteps int
+	// Output receives the output of the print and println builtins. If nil, the output is discarded.
+	Output io.Writer
+
+	globals map[*ir.Global]*value
+	steps   int
+	depth   int
+}
+
+type closure struct {
+	fn  *ir.Function
+	env []value
+	// targs holds the type arguments of the generic function the closure was created in.
+	targs map[*types.TypeParam]types.Type
+}
+
+type deferred struct {
+	fn    value
+	args  []value
+	targs map[*types.TypeParam]types.Type
+}
+
+type frame struct {
+	in     *Interpreter
+	fn     *ir.Function
+	caller *frame
+	env    map[ir.Value]value
+	targs  map[*types.TypeParam]types.Type
+	defers []deferred
+	// isDeferred records that the frame belongs to a deferred call, in which recover may stop a panic.
+	isDeferred bool
+
+	block, prev *ir.BasicBlock
+	result      value
+
+	panicking bool
+	panic     value
+}
+
+// Init runs the initializer of pkg, which initializes the package's global variables and runs its init functions.
+// Initializers of imported packages whose code isn't available are skipped.
+func (in *Interpreter) Init(pkg *ir.Package) error {
+	init := pkg.Func("init")
+	if init == nil {
+		return nil
+	}
+	_, err := in.top(init, nil)
+	return err
+}
+
+// Run runs the initializer of pkg, followed by its main function.
+func (in *Interpreter) Run(pkg *ir.Package) error {
+	if err := in.Init(pkg); err != nil {
+		return err
+	}
+	main := pkg.Func("main")
+	if main == nil {
+		return fmt.Errorf("package %s has no main function", pkg.Pkg.Path())
+	}
+	_, err := in.top(main, nil)
+	return err
+}
+
+// Eval calls fn with the constant arguments args and returns its results as constants. For methods, the first
+// argument is the receiver. It returns an error if a result isn't of a basic type, or isn't a finite number.
+//
+// Global variables start out with their zero values; use Init to initialize them first.
+func (in *Interpreter) Eval(fn *ir.Function, args ...constant.Value) ([]constant.Value, error) {
+	if len(args) != len(fn.Params) {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", fn, len(fn.Params), len(args))
+	}
+	if fn.Signature.TypeParams().Len() != 0 || fn.Signature.RecvTypeParams().Len() != 0 {
+		return nil, fmt.Errorf("can't call generic function %s without type arguments", fn)
+	}
+	vargs := make([]value, len(args))
+	for i, arg := range args {
+		if err := catch(func() { vargs[i] = constValue(arg, fn.Params[i].Type()) }); err != nil {
+			return nil, err
+		}
+	}
+	ret, err := in.top(fn, vargs)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []value
+	switch n := fn.Signature.Results().Len(); n {
+	case 0:
+	case 1:
+		results = []value{ret}
+	default:
+		results = ret.(tuple)
+	}
+	out := make([]constant.Value, len(results))
+	for i, r := range results {
+		c, ok := toConstant(r)
+		if !ok {
+			return nil, fmt.Errorf("result %d of %s isn't representable as a constant", i, fn)
+		}
+		out[i] = c
+	}
+	return out, nil
+}
+
+// catch runs f and converts panics of the interpreter and the interpreted program into errors.
+func catch(f func()) (err error) {
+	defer func() {
+		switch p := recover().(type) {
+		case nil:
+		case stopError:
+			err = p.err
+		case targetPanic:
+			err = &PanicError{Value: formatValue(p.v)}
+		default:
+			panic(p)
+		}
+	}()
+	f()
+	return nil
+}
+
+func (in *Interpreter) top(fn *ir.Function, args []value) (ret value, err error) {
+	in.steps = 0
+	in.depth = 0
+	err = catch(func() { ret = in.call(nil, fn, args, nil, nil, false) })
+	return ret, err
+}
+
+func (in *Interpreter) global(g *ir.Global) *value {
+	if in.globals == nil {
+		in.globals = map[*ir.Global]*value{}
+	}
+	addr, ok := in.globals[g]
+	if !ok {
+		addr = new(value)
+		*addr = zero(g.Type().(*types.Pointer).Elem())
+		in.globals[g] = addr
+	}
+	return addr
+}
+
+// call calls fn with the arguments args and the values env of its free variables. The type arguments of generic
+// functions are provided by targs.
+func (in *Interpreter) call(caller *frame, fn *ir.Function, args []value, env []value, targs map[*types.TypeParam]types.Type, isDeferred bool) value {
+	if fn.Blocks == nil {
+		if fn.Synthetic == ir.SyntheticPackageInitializer {
+			// The initializer of a package whose code isn't available
+			return nil
+		}
+		panic(stop(fmt.Errorf("call to function without body: %s", fn)))
+	}
+	if in.depth >= maxDepth {
+		panic(stop(fmt.Errorf("maximum call depth of %d exceeded in %s", maxDepth, fn)))
+	}
+	in.depth++
+	defer func() { in.depth-- }()
+
+	fr := &frame{
+		in:         in,
+		fn:         fn,
+		caller:     caller,
+		env:        make(map[ir.Value]value, len(fn.Params)+len(fn.FreeVars)),
+		targs:      targs,
+		isDeferred: isDeferred,
+		block:      fn.Blocks[0],
+	}
+	for i, p := range fn.Params {
+		fr.env[p] = args[i]
+	}
+	for i, fv := range fn.FreeVars {
+		fr.env[fv] = env[i]
+	}
+	fr.run()
+	return fr.result
+}
+
+// callValue calls the function value f.
+func (in *Interpreter) callValue(caller *frame, f value, args []value, targs map[*types.TypeParam]types.Type, isDeferred bool) value {
+	switch f := f.(type) {
+	case *ir.Function:
+		return in.call(caller, f, args, nil, targs, isDeferred)
+	case *closure:
+		if targs == nil {
+			targs = f.targs
+		}
+		return in.call(caller, f.fn, args, f.env, targs, isDeferred)
+	case *ir.Builtin:
+		return caller.builtin(f.Name(), args, nil)
+	case nil:
+		panic(runtimeError("invalid memory address or nil pointer dereference"))
+	default:
+		panic(stop(fmt.Errorf("can't call value of type %T", f)))
+	}
+}
+
+// run executes the frame until it returns. If the interpreted code panics, run executes deferred calls and, if one
+// of them recovers, resumes execution in the function's exit block.
+func (fr *frame) run() {
+	defer func() {
+		if fr.block == nil {
+			// The function returned normally.
+			return
+		}
+		switch p := recover().(type) {
+		case targetPanic:
+			fr.panicking = true
+			fr.panic = p.v
+		case runtime.Error:
+			// Go run-time errors caused by the interpreted code, such as out of bounds slice accesses or integer
+			// division by zero, are panics of the interpreted program.
+			fr.panicking = true
+			fr.panic = runtimeError(trimRuntimeError(p.Error())).v
+		default:
+			panic(p)
+		}
+		fr.runDefers()
+		if fr.panicking {
+			panic(targetPanic{fr.panic})
+		}
+
+		// A deferred call recovered from the panic. Results have to be stored in memory in functions that defer
+		// calls, which lets the exit block return them.
+		if fr.fn.Exit == nil {
+			fr.block = nil
+			fr.result = zero(resultType(fr.fn.Signature))
+			return
+		}
+		fr.block = fr.fn.Exit
+		fr.prev = nil
+		fr.run()
+	}()
+	fr.exec()
+}
+
+func trimRuntimeError(s string) string {
+	const prefix = "runtime error: "
+	if len(s) >= len(prefix) && s[:len(prefix)] == prefix {
+		return s[len(prefix):]
+	}
+	return s
+}
+
+func resultType(sig *types.Signature) types.Type {
+	switch sig.Results().Len() {
+	case 0:
+		return types.NewTuple()
+	case 1:
+		return sig.Results().At(0).Type()
+	default:
+		return sig.Results()
+	}
+}
+
+// runDefers runs the frame's deferred calls in reverse order. A deferred call that panics replaces the frame's current
+// panic, if any.
+func (fr *frame) runDefers() {
+	for len(fr.defers) > 0 {
+		d := fr.defers[len(fr.defers)-1]
+		fr.defers = fr.defers[:len(fr.defers)-1]
+		fr.runDefer(d)
+	}
+}
+
+func (fr *frame) runDefer(d deferred) {
+	ok := false
+	defer func() {
+		if ok {
+			return
+		}
+		switch p := recover().(type) {
+		case targetPanic:
+			fr.panicking = true
+			fr.panic = p.v
+		case runtime.Error:
+			fr.panicking = true
+			fr.panic = runtimeError(trimRuntimeError(p.Error())).v
+		default:
+			panic(p)
+		}
+	}()
+	fr.in.callValue(fr, d.fn, d.args, d.targs, true)
+	ok = true
+}
+
+func (fr *frame) step() {
+	fr.in.steps++
+	if fr.in.MaxSteps > 0 && fr.in.steps > fr.in.MaxSteps {
+		panic(stop(ErrStepLimit))
+	}
+}
+
+func (fr *frame) fail(format string, args ...interface{}) {
+	panic(stop(fmt.Errorf("%s: %s", fr.fn, fmt.Sprintf(format, args...))))
+}
+
+// jump transfers control to the successor succ of the current block.
+func (fr *frame) jump(succ *ir.BasicBlock) {
+	fr.prev = fr.block
+	fr.block = succ
+}
+
+// exec executes instructions until the function returns.
+func (fr *frame) exec() {
+	for {
+		b := fr.block
+		instrs := b.Instrs
+
+		// Blocks begin with sigma nodes, which belong to the edge from their From block, followed by phi nodes, which
+		// have to be evaluated simultaneously, as they may refer to each other.
+		n := 0
+		var phis []*ir.Phi
+	headLoop:
+		for ; n < len(instrs); n++ {
+			switch instr := instrs[n].(type) {
+			case *ir.Sigma:
+				if instr.From == fr.prev {
+					fr.step()
+					fr.env[instr] = fr.get(instr.X)
+				}
+			case *ir.Phi:
+				phis = append(phis, instr)
+			default:
+				break headLoop
+			}
+		}
+		if len(phis) > 0 {
+			pred := -1
+			for i, p := range b.Preds {
+				if p == fr.prev {
+					pred = i
+					break
+				}
+			}
+			if pred == -1 {
+				fr.fail("entered block %d, which has phi nodes, without a predecessor", b.Index)
+			}
+			vals := make([]value, len(phis))
+			for i, phi := range phis {
+				fr.step()
+				vals[i] = fr.get(phi.Edges[pred])
+			}
+			for i, phi := range phis {
+				fr.env[phi] = vals[i]
+			}
+		}
+
+	instrLoop:
+		for _, instr := range instrs[n:] {
+			fr.step()
+			switch instr := instr.(type) {
+			case *ir.Jump:
+				fr.jump(b.Succs[0])
+				break instrLoop
+			case *ir.If:
+				if fr.get(instr.Cond).(bool) {
+					fr.jump(b.Succs[0])
+				} else {
+					fr.jump(b.Succs[1])
+				}
+				break instrLoop
+			case *ir.ConstantSwitch:
+				tag := fr.get(instr.Tag)
+				target := -1
+				for i, cond := range instr.Conds {
+					if cond == nil {
+						if target == -1 {
+							target = i
+						}
+						continue
+					}
+					if equals(tag, fr.get(cond)) {
+						target = i
+						break
+					}
+				}
+				if target == -1 {
+					fr.fail("no branch of %s matched", instr)
+				}
+				fr.jump(b.Succs[target])
+				break instrLoop
+			case *ir.Return:
+				switch len(instr.Results) {
+				case 0:
+				case 1:
+					fr.result = fr.get(instr.Results[0])
+				default:
+					res := make(tuple, len(instr.Results))
+					for i, r := range instr.Results {
+						res[i] = fr.get(r)
+					}
+					fr.result = res
+				}
+				fr.block = nil
+				return
+			case *ir.Unreachable:
+				fr.fail("reached Unreachable in block %d", b.Index)
+			case *ir.Panic:
+				panic(targetPanic{fr.get(instr.X)})
+			default:
+				fr.instr(instr)
+			}
+		}
+	}
+}
+
+// instr executes instr, which must not be a control flow instruction.
+func (fr *frame) instr(instr ir.Instruction) {
+	switch instr := instr.(type) {
+	case ir.Constant:
+		// Constants are evaluated when they're used.
+	case *ir.Parameter:
+		// Parameters are bound when the frame is created.
+	case *ir.DebugRef, *ir.BlankStore:
+		// No dynamic effect
+	case *ir.Copy:
+		fr.env[instr] = fr.get(instr.X)
+	case *ir.Alloc:
+		addr := new(value)
+		*addr = fr.zero(instr.Type().(*types.Pointer).Elem())
+		fr.env[instr] = addr
+	case *ir.Load:
+		fr.env[instr] = copyVal(*fr.get(instr.X).(*value))
+	case *ir.Store:
+		store(fr.get(instr.Addr).(*value), fr.get(instr.Val))
+	case *ir.Call:
+		fr.env[instr] = fr.callInstr(instr)
+	case *ir.Defer:
+		fn, args, targs := fr.prepareCall(&instr.Call)
+		fr.defers = append(fr.defers, deferred{fn: fn, args: args, targs: targs})
+	case *ir.RunDefers:
+		fr.runDefers()
+		if fr.panicking {
+			panic(targetPanic{fr.panic})
+		}
+	case *ir.BinOp:
+		fr.env[instr] = binop(instr.Op, fr.get(instr.X), fr.get(instr.Y))
+	case *ir.UnOp:
+		fr.env[instr] = unop(instr.Op, fr.get(instr.X))
+	case *ir.ChangeType:
+		x := fr.get(instr.X)
+		if from, to := fr.subst(instr.X.Type()), fr.subst(instr.Type()); isInterface(to) && !types.IsInterface(from) {
+			// Converting a value whose type is a type parameter to an interface
+			x = iface{t: from, v: copyVal(x)}
+		}
+		fr.env[instr] = x
+	case *ir.ChangeInterface:
+		fr.env[instr] = fr.get(instr.X)
+	case *ir.Convert:
+		fr.env[instr] = fr.convert(fr.get(instr.X), fr.subst(instr.X.Type()), fr.subst(instr.Type()))
+	case *ir.MultiConvert:
+		fr.env[instr] = fr.convert(fr.get(instr.X), fr.subst(instr.X.Type()), fr.subst(instr.Type()))
+	case *ir.SliceToArrayPointer:
+		s := fr.get(instr.X).([]value)
+		n := int(fr.subst(instr.Type()).Underlying().(*types.Pointer).Elem().Underlying().(*types.Array).Len())
+		if len(s) < n {
+			panic(runtimeError(fmt.Sprintf("cannot convert slice with length %d to array or pointer to array with length %d", len(s), n)))
+		}
+		if s == nil {
+			fr.env[instr] = (*value)(nil)
+			break
+		}
+		addr := new(value)
+		*addr = array(s[:n:n])
+		fr.env[instr] = addr
+	case *ir.SliceToArray:
+		s := fr.get(instr.X).([]value)
+		n := int(fr.subst(instr.Type()).Underlying().(*types.Array).Len())
+		if len(s) < n {
+			panic(runtimeError(fmt.Sprintf("cannot convert slice with length %d to array or pointer to array with length %d", len(s), n)))
+		}
+		fr.env[instr] = copyVal(array(s[:n]))
+	case *ir.MakeInterface:
+		x := fr.get(instr.X)
+		t := fr.subst(instr.X.Type())
+		if types.IsInterface(t) {
+			// A type parameter instantiated with an interface type
+			fr.env[instr] = x
+		} else {
+			fr.env[instr] = iface{t: t, v: copyVal(x)}
+		}
+	case *ir.MakeClosure:
+		env := make([]value, len(instr.Bindings))
+		for i, b := range instr.Bindings {
+			env[i] = fr.get(b)
+		}
+		fr.env[instr] = &closure{fn: instr.Fn.(*ir.Function), env: env, targs: fr.targs}
+	case *ir.MakeMap:
+		fr.env[instr] = &hashMap{index: map[string]int{}}
+	case *ir.MakeSlice:
+		length := toInt(fr.get(instr.Len))
+		capacity := toInt(fr.get(instr.Cap))
+		if length < 0 || length > capacity {
+			panic(runtimeError("makeslice: len out of range"))
+		}
+		elem := fr.subst(instr.Type()).Underlying().(*types.Slice).Elem()
+		s := make([]value, capacity)
+		for i := range s {
+			s[i] = zero(elem)
+		}
+		fr.env[instr] = s[:length]
+	case *ir.CompositeValue:
+		vals := make([]value, len(instr.Values))
+		for i, v := range instr.Values {
+			vals[i] = copyVal(fr.get(v))
+		}
+		if _, ok := fr.subst(instr.Type()).Underlying().(*types.Struct); ok {
+			fr.env[instr] = structure(vals)
+		} else {
+			fr.env[instr] = array(vals)
+		}
+	case *ir.Slice:
+		fr.env[instr] = fr.slice(instr)
+	case *ir.FieldAddr:
+		fr.env[instr] = &(*fr.get(instr.X).(*value)).(structure)[instr.Field]
+	case *ir.Field:
+		fr.env[instr] = fr.get(instr.X).(structure)[instr.Field]
+	case *ir.IndexAddr:
+		idx := toInt(fr.get(instr.Index))
+		switch x := fr.get(instr.X).(type) {
+		case []value:
+			fr.env[instr] = &x[idx]
+		case *value:
+			fr.env[instr] = &(*x).(array)[idx]
+		default:
+			fr.fail("unexpected operand of %s: %T", instr, x)
+		}
+	case *ir.Index:
+		idx := toInt(fr.get(instr.Index))
+		switch x := fr.get(instr.X).(type) {
+		case array:
+			fr.env[instr] = x[idx]
+		case string:
+			fr.env[instr] = x[idx]
+		case []value:
+			fr.env[instr] = x[idx]
+		default:
+			fr.fail("unexpected operand of %s: %T", instr, x)
+		}
+	case *ir.StringLookup:
+		fr.env[instr] = fr.get(instr.X).(string)[toInt(fr.get(instr.Index))]
+	case *ir.MapLookup:
+		v, ok := fr.get(instr.X).(*hashMap).lookup(fr.get(instr.Index))
+		if !ok {
+			v = fr.zero(fr.subst(instr.X.Type()).Underlying().(*types.Map).Elem())
+		}
+		if instr.CommaOk {
+			fr.env[instr] = tuple{v, ok}
+		} else {
+			fr.env[instr] = v
+		}
+	case *ir.MapUpdate:
+		m := fr.get(instr.Map).(*hashMap)
+		if m == nil {
+			panic(targetPanic{iface{t: types.Typ[types.String], v: "assignment to entry in nil map"}})
+		}
+		m.update(fr.get(instr.Key), copyVal(fr.get(instr.Value)))
+	case *ir.Range:
+		switch x := fr.get(instr.X).(type) {
+		case string:
+			fr.env[instr] = &stringIter{s: x}
+		case *hashMap:
+			fr.env[instr] = &mapIter{m: x}
+		default:
+			fr.fail("unexpected operand of %s: %T", instr, x)
+		}
+	case *ir.Next:
+		fr.env[instr] = fr.next(instr)
+	case *ir.TypeAssert:
+		fr.env[instr] = fr.typeAssert(instr)
+	case *ir.TypeSwitch:
+		fr.env[instr] = fr.typeSwitch(instr)
+	case *ir.Extract:
+		fr.env[instr] = fr.get(instr.Tuple).(tuple)[instr.Index]
+	case *ir.Go:
+		fr.fail("goroutines aren't supported")
+	case *ir.MakeChan, *ir.Send, *ir.Recv, *ir.Select:
+		fr.fail("channels aren't supported")
+	default:
+		fr.fail("unexpected instruction %T", instr)
+	}
+}
+
+// get returns the value of v in the frame.
+func (fr *frame) get(v ir.Value) value {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case *ir.Function:
+		if v.Parent() != nil && fr.targs != nil {
+			// An anonymous function without free variables in a generic function still depends on the type arguments.
+			return &closure{fn: v, targs: fr.targs}
+		}
+		return v
+	case *ir.Builtin:
+		return v
+	case *ir.Global:
+		return fr.in.global(v)
+	case *ir.Const:
+		return constValue(v.Value, fr.subst(v.Type()))
+	case *ir.AggregateConst:
+		vals := make([]value, len(v.Values))
+		for i, e := range v.Values {
+			vals[i] = fr.get(e)
+		}
+		switch fr.subst(v.Type()).Underlying().(type) {
+		case *types.Struct:
+			return structure(vals)
+		case *types.Tuple:
+			return tuple(vals)
+		default:
+			return array(vals)
+		}
+	case *ir.ArrayConst, *ir.GenericConst:
+		return fr.zero(v.Type())
+	}
+	val, ok := fr.env[v]
+	if !ok {
+		fr.fail("no value for %s", v.Name())
+	}
+	return val
+}
+
+// zero returns the zero value of t, after substituting type arguments.
+func (fr *frame) zero(t types.Type) value {
+	return zero(fr.subst(t))
+}
+
+// subst substitutes the frame's type arguments in t.
+func (fr *frame) subst(t types.Type) types.Type {
+	if len(fr.targs) == 0 {
+		return t
+	}
+	return subst(t, fr.targs)
+}
+
+func subst(t types.Type, targs map[*types.TypeParam]types.Type) types.Type {
+	switch t := t.(type) {
+	case *types.TypeParam:
+		if r, ok := targs[t]; ok {
+			return r
+		}
+		return t
+	case *types.Alias:
+		return subst(types.Unalias(t), targs)
+	case *types.Pointer:
+		return types.NewPointer(subst(t.Elem(), targs))
+	case *types.Slice:
+		return types.NewSlice(subst(t.Elem(), targs))
+	case *types.Array:
+		return types.NewArray(subst(t.Elem(), targs), t.Len())
+	case *types.Map:
+		return types.NewMap(subst(t.Key(), targs), subst(t.Elem(), targs))
+	case *types.Chan:
+		return types.NewChan(t.Dir(), subst(t.Elem(), targs))
+	case *types.Tuple:
+		if t == nil {
+			return t
+		}
+		vars := make([]*types.Var, t.Len())
+		for i := range vars {
+			v := t.At(i)
+			vars[i] = types.NewVar(v.Pos(), v.Pkg(), v.Name(), subst(v.Type(), targs))
+		}
+		return types.NewTuple(vars...)
+	case *types.Struct:
+		fields := make([]*types.Var, t.NumFields())
+		tags := make([]string, t.NumFields())
+		for i := range fields {
+			f := t.Field(i)
+			fields[i] = types.NewField(f.Pos(), f.Pkg(), f.Name(), subst(f.Type(), targs), f.Embedded())
+			tags[i] = t.Tag(i)
+		}
+		return types.NewStruct(fields, tags)
+	case *types.Named:
+		if t.TypeArgs().Len() == 0 {
+			return t
+		}
+		args := make([]types.Type, t.TypeArgs().Len())
+		for i := range args {
+			args[i] = subst(t.TypeArgs().At(i), targs)
+		}
+		inst, err := types.Instantiate(nil, t.Origin(), args, false)
+		if err != nil {
+			panic(stop(err))
+		}
+		return inst
+	default:
+		return t
+	}
+}
+
+// prepareCall evaluates the callee and arguments of call, resolving interface method calls to concrete functions.
+func (fr *frame) prepareCall(call *ir.CallCommon) (fn value, args []value, targs map[*types.TypeParam]types.Type) {
+	args = make([]value, 0, len(call.Args)+1)
+	if call.IsInvoke() {
+		recv := fr.get(call.Value)
+		var t types.Type
+		if itf, ok := recv.(iface); ok {
+			if itf.t == nil {
+				panic(runtimeError("invalid memory address or nil pointer dereference"))
+			}
+			t, recv = itf.t, itf.v
+		} else {
+			// A method call on a value whose type is a type parameter
+			t = fr.subst(call.Value.Type())
+		}
+		fn = fr.fn.Prog.LookupMethod(t, call.Method.Pkg(), call.Method.Name())
+		args = append(args, recv)
+	} else {
+		fn = fr.get(call.Value)
+	}
+	for _, arg := range call.Args {
+		args = append(args, fr.get(arg))
+	}
+
+	if len(call.TypeArgs) != 0 {
+		callee, ok := fn.(*ir.Function)
+		if !ok {
+			fr.fail("type arguments for call of %T", fn)
+		}
+		tparams := callee.Signature.TypeParams()
+		if tparams.Len() == 0 {
+			tparams = callee.Signature.RecvTypeParams()
+		}
+		targs = make(map[*types.TypeParam]types.Type, tparams.Len())
+		for i := 0; i < tparams.Len(); i++ {
+			targs[tparams.At(i)] = fr.subst(call.TypeArgs[i])
+		}
+	}
+	return fn, args, targs
+}
+
+func (fr *frame) callInstr(instr *ir.Call) value {
+	if b, ok := instr.Call.Value.(*ir.Builtin); ok {
+		args := make([]value, len(instr.Call.Args))
+		for i, arg := range instr.Call.Args {
+			args[i] = fr.get(arg)
+		}
+		return fr.builtin(b.Name(), args, &instr.Call)
+	}
+
+	fn, args, targs := fr.prepareCall(&instr.Call)
+	ret := fr.in.callValue(fr, fn, args, targs, false)
+
+	// The builder relies on NoReturn to terminate blocks after calls to functions that don't return.
+	var callee *ir.Function
+	switch fn := fn.(type) {
+	case *ir.Function:
+		callee = fn
+	case *closure:
+		callee = fn.fn
+	}
+	if callee != nil && callee.NoReturn != ir.Returns {
+		fr.fail("call to %s returned, but the function is marked as never returning", callee)
+	}
+	return ret
+}
+
+func (fr *frame) slice(instr *ir.Slice) value {
+	x := fr.get(instr.X)
+	var lo, hi, max int
+	if instr.Low != nil {
+		lo = toInt(fr.get(instr.Low))
+	}
+	if instr.High != nil {
+		hi = toInt(fr.get(instr.High))
+	}
+	if instr.Max != nil {
+		max = toInt(fr.get(instr.Max))
+	}
+	switch x := x.(type) {
+	case string:
+		if instr.High == nil {
+			hi = len(x)
+		}
+		return x[lo:hi]
+	case []value:
+		if instr.High == nil {
+			hi = len(x)
+		}
+		if instr.Max == nil {
+			max = cap(x)
+		}
+		return x[lo:hi:max]
+	case *value:
+		a := []value((*x).(array))
+		if instr.High == nil {
+			hi = len(a)
+		}
+		if instr.Max == nil {
+			max = cap(a)
+		}
+		return a[lo:hi:max]
+	default:
+		fr.fail("unexpected operand of %s: %T", instr, x)
+		panic("unreachable")
+	}
+}
+
+func (fr *frame) next(instr *ir.Next) value {
+	elems := instr.Type().(*types.Tuple)
+	switch it := fr.get(instr.Iter).(type) {
+	case *stringIter:
+		if it.i >= len(it.s) {
+			return tuple{false, 0, int32(0)}
+		}
+		r, size := utf8.DecodeRuneInString(it.s[it.i:])
+		res := tuple{true, it.i, r}
+		it.i += size
+		return res
+	case *mapIter:
+		if it.m != nil {
+			for it.i < len(it.m.entries) {
+				e := it.m.entries[it.i]
+				it.i++
+				if !e.deleted {
+					return tuple{true, e.k, e.v}
+				}
+			}
+		}
+		return tuple{false, fr.zero(elems.At(1).Type()), fr.zero(elems.At(2).Type())}
+	default:
+		fr.fail("unexpected iterator %T", it)
+		panic("unreachable")
+	}
+}
+
+// matches reports whether the interface value itf has type t, for type assertions and type switches.
+func matches(itf iface, t types.Type) bool {
+	if b, ok := t.(*types.Basic); ok && b.Kind() == types.UntypedNil {
+		return itf.t == nil
+	}
+	if itf.t == nil {
+		return false
+	}
+	if tset, ok := t.Underlying().(*types.Interface); ok {
+		return types.Implements(itf.t, tset)
+	}
+	return types.Identical(itf.t, t)
+}
+
+func (fr *frame) typeAssert(instr *ir.TypeAssert) value {
+	itf := fr.get(instr.X).(iface)
+	t := fr.subst(instr.AssertedType)
+	ok := matches(itf, t)
+	var v value
+	switch {
+	case !ok:
+		if !instr.CommaOk {
+			dyn := "nil"
+			if itf.t != nil {
+				dyn = itf.t.String()
+			}
+			panic(targetPanic{iface{t: types.Typ[types.String], v: fmt.Sprintf("interface conversion: interface is %s, not %s", dyn, t)}})
+		}
+		v = zero(t)
+	case isInterface(t):
+		v = itf
+	default:
+		v = copyVal(itf.v)
+	}
+	if instr.CommaOk {
+		return tuple{v, ok}
+	}
+	return v
+}
+
+// typeSwitch evaluates a type switch. Its result is a tuple consisting of the index of the first matching case, or -1,
+// followed by the value converted to each case's type, and finally the value for the default case.
+func (fr *frame) typeSwitch(instr *ir.TypeSwitch) value {
+	itf := fr.get(instr.Tag).(iface)
+	res := make(tuple, len(instr.Conds)+2)
+	res[0] = -1
+	slots := instr.Type().(*types.Tuple)
+	for i := range instr.Conds {
+		res[i+1] = fr.zero(slots.At(i + 1).Type())
+	}
+	for i, cond := range instr.Conds {
+		if !matches(itf, fr.subst(cond)) {
+			continue
+		}
+		res[0] = i
+		if isInterface(fr.subst(slots.At(i + 1).Type())) {
+			res[i+1] = itf
+		} else {
+			res[i+1] = copyVal(itf.v)
+		}
+		break
+	}
+	res[len(res)-1] = itf
+	return res
+}
+
+// isInterface reports whether t is an interface type. Unlike types.IsInterface, it returns false for type
+// parameters, which aren't represented as interface values.
+func isInterface(t types.Type) bool {
+	if _, ok := types.Unalias(t).(*types.TypeParam); ok {
+		return false
+	}
+	return types.IsInterface(t)
+}
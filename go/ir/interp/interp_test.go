@@ -0,0 +1,177 @@
+package interp
+
+import (
+	"errors"
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/irutil"
+)
+
+func build(t *testing.T, filename string, src interface{}) *ir.Package {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &types.Config{Importer: importer.Default(), GoVersion: "go1.22"}
+	pkg, _, err := irutil.BuildPackage(conf, fset, types.NewPackage("main", ""), []*ast.File{f}, ir.SanityCheckFunctions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+// TestPrograms runs the programs in testdata, which panic if they observe unexpected behavior.
+func TestPrograms(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			pkg := build(t, file, nil)
+			in := &Interpreter{MaxSteps: 10_000_000}
+			if err := in.Run(pkg); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+const evalSrc = `
+package main
+
+var primes = []int{2, 3, 5, 7}
+
+func isPrime(n int) bool {
+	for _, p := range primes {
+		if n == p {
+			return true
+		}
+	}
+	return false
+}
+
+func clamp(x, lo, hi float64) float64 {
+	return max(lo, min(x, hi))
+}
+
+func split(s string) (string, string) {
+	for i := range s {
+		if s[i] == '=' {
+			return s[:i], s[i+1:]
+		}
+	}
+	return s, ""
+}
+
+func loop() {
+	for {
+	}
+}
+
+func ptr() *int { return new(int) }
+
+func ext() int { return len(errorString()) }
+
+func errorString() string {
+	return errNotFound.Error()
+}
+
+var errNotFound = errorsNew("not found")
+
+type errString string
+
+func (e errString) Error() string { return string(e) }
+
+func errorsNew(s string) error { return errString(s) }
+
+func main() {}
+`
+
+func TestEval(t *testing.T) {
+	pkg := build(t, "eval.go", evalSrc)
+	in := &Interpreter{MaxSteps: 10000}
+
+	tests := []struct {
+		fn   string
+		args []constant.Value
+		want []constant.Value
+		init bool
+	}{
+		{"clamp", []constant.Value{constant.MakeFloat64(5), constant.MakeInt64(0), constant.MakeInt64(1)}, []constant.Value{constant.MakeInt64(1)}, false},
+		{"split", []constant.Value{constant.MakeString("a=b")}, []constant.Value{constant.MakeString("a"), constant.MakeString("b")}, false},
+		{"isPrime", []constant.Value{constant.MakeInt64(5)}, []constant.Value{constant.MakeBool(false)}, false},
+		{"isPrime", []constant.Value{constant.MakeInt64(5)}, []constant.Value{constant.MakeBool(true)}, true},
+		{"ext", nil, []constant.Value{constant.MakeInt64(9)}, false},
+	}
+	for _, tt := range tests {
+		if tt.init {
+			if err := in.Init(pkg); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := in.Eval(pkg.Func(tt.fn), tt.args...)
+		if err != nil {
+			t.Errorf("%s%v: unexpected error: %s", tt.fn, tt.args, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s%v = %v, want %v", tt.fn, tt.args, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !constant.Compare(got[i], token.EQL, tt.want[i]) {
+				t.Errorf("%s%v = %v, want %v", tt.fn, tt.args, got, tt.want)
+			}
+		}
+	}
+
+	if _, err := in.Eval(pkg.Func("loop")); !errors.Is(err, ErrStepLimit) {
+		t.Errorf("got error %v, want %v", err, ErrStepLimit)
+	}
+	if _, err := in.Eval(pkg.Func("ptr")); err == nil || !strings.Contains(err.Error(), "constant") {
+		t.Errorf("got error %v, want error about non-constant result", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	const src = `
+package main
+
+import "os"
+
+var fail bool
+
+func main() {
+	if fail {
+		os.Exit(1)
+	}
+	var s []int
+	_ = s[3]
+}
+`
+	pkg := build(t, "errors.go", src)
+	var in Interpreter
+	err := in.Run(pkg)
+	var perr *PanicError
+	if !errors.As(err, &perr) || !strings.Contains(perr.Value, "index out of range") {
+		t.Errorf("got error %v, want panic about index out of range", err)
+	}
+
+	*in.global(pkg.Var("fail")) = true
+	_, err = in.Eval(pkg.Func("main"))
+	if err == nil || !strings.Contains(err.Error(), "os.Exit") {
+		t.Errorf("got error %v, want error about calling os.Exit", err)
+	}
+}
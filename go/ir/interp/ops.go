@@ -0,0 +1,643 @@
+package interp
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"honnef.co/go/tools/go/ir"
+)
+
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+type float interface {
+	~float32 | ~float64
+}
+
+type complexNumber interface {
+	~complex64 | ~complex128
+}
+
+func invalidOp(op token.Token, x value) stopError {
+	return stop(fmt.Errorf("invalid operation %s on %T", op, x))
+}
+
+func binop(op token.Token, x, y value) value {
+	switch op {
+	case token.EQL:
+		return equals(x, y)
+	case token.NEQ:
+		return !equals(x, y)
+	case token.SHL, token.SHR:
+		return shift(op, x, shiftCount(y))
+	}
+
+	switch x := x.(type) {
+	case int:
+		return intOp(op, x, y.(int))
+	case int8:
+		return intOp(op, x, y.(int8))
+	case int16:
+		return intOp(op, x, y.(int16))
+	case int32:
+		return intOp(op, x, y.(int32))
+	case int64:
+		return intOp(op, x, y.(int64))
+	case uint:
+		return intOp(op, x, y.(uint))
+	case uint8:
+		return intOp(op, x, y.(uint8))
+	case uint16:
+		return intOp(op, x, y.(uint16))
+	case uint32:
+		return intOp(op, x, y.(uint32))
+	case uint64:
+		return intOp(op, x, y.(uint64))
+	case uintptr:
+		return intOp(op, x, y.(uintptr))
+	case float32:
+		return floatOp(op, x, y.(float32))
+	case float64:
+		return floatOp(op, x, y.(float64))
+	case complex64:
+		return complexOp(op, x, y.(complex64))
+	case complex128:
+		return complexOp(op, x, y.(complex128))
+	case string:
+		return stringOp(op, x, y.(string))
+	default:
+		panic(invalidOp(op, x))
+	}
+}
+
+func intOp[T integer](op token.Token, x, y T) value {
+	switch op {
+	case token.ADD:
+		return x + y
+	case token.SUB:
+		return x - y
+	case token.MUL:
+		return x * y
+	case token.QUO:
+		return x / y
+	case token.REM:
+		return x % y
+	case token.AND:
+		return x & y
+	case token.OR:
+		return x | y
+	case token.XOR:
+		return x ^ y
+	case token.AND_NOT:
+		return x &^ y
+	case token.LSS:
+		return x < y
+	case token.LEQ:
+		return x <= y
+	case token.GTR:
+		return x > y
+	case token.GEQ:
+		return x >= y
+	default:
+		panic(invalidOp(op, x))
+	}
+}
+
+func floatOp[T float](op token.Token, x, y T) value {
+	switch op {
+	case token.ADD:
+		return x + y
+	case token.SUB:
+		return x - y
+	case token.MUL:
+		return x * y
+	case token.QUO:
+		return x / y
+	case token.LSS:
+		return x < y
+	case token.LEQ:
+		return x <= y
+	case token.GTR:
+		return x > y
+	case token.GEQ:
+		return x >= y
+	default:
+		panic(invalidOp(op, x))
+	}
+}
+
+func complexOp[T complexNumber](op token.Token, x, y T) value {
+	switch op {
+	case token.ADD:
+		return x + y
+	case token.SUB:
+		return x - y
+	case token.MUL:
+		return x * y
+	case token.QUO:
+		return x / y
+	default:
+		panic(invalidOp(op, x))
+	}
+}
+
+func stringOp(op token.Token, x, y string) value {
+	switch op {
+	case token.ADD:
+		return x + y
+	case token.LSS:
+		return x < y
+	case token.LEQ:
+		return x <= y
+	case token.GTR:
+		return x > y
+	case token.GEQ:
+		return x >= y
+	default:
+		panic(invalidOp(op, x))
+	}
+}
+
+// shiftCount returns the shift count y as an unsigned integer, panicking if it is negative.
+func shiftCount(y value) uint64 {
+	switch y := y.(type) {
+	case uint:
+		return uint64(y)
+	case uint8:
+		return uint64(y)
+	case uint16:
+		return uint64(y)
+	case uint32:
+		return uint64(y)
+	case uint64:
+		return y
+	case uintptr:
+		return uint64(y)
+	}
+	n := toInt64(y)
+	if n < 0 {
+		panic(runtimeError("negative shift amount"))
+	}
+	return uint64(n)
+}
+
+func shift(op token.Token, x value, n uint64) value {
+	switch x := x.(type) {
+	case int:
+		return shiftOp(op, x, n)
+	case int8:
+		return shiftOp(op, x, n)
+	case int16:
+		return shiftOp(op, x, n)
+	case int32:
+		return shiftOp(op, x, n)
+	case int64:
+		return shiftOp(op, x, n)
+	case uint:
+		return shiftOp(op, x, n)
+	case uint8:
+		return shiftOp(op, x, n)
+	case uint16:
+		return shiftOp(op, x, n)
+	case uint32:
+		return shiftOp(op, x, n)
+	case uint64:
+		return shiftOp(op, x, n)
+	case uintptr:
+		return shiftOp(op, x, n)
+	default:
+		panic(invalidOp(op, x))
+	}
+}
+
+func shiftOp[T integer](op token.Token, x T, n uint64) value {
+	if op == token.SHL {
+		return x << n
+	}
+	return x >> n
+}
+
+func unop(op token.Token, x value) value {
+	switch op {
+	case token.NOT:
+		return !x.(bool)
+	case token.SUB:
+		switch x := x.(type) {
+		case int:
+			return -x
+		case int8:
+			return -x
+		case int16:
+			return -x
+		case int32:
+			return -x
+		case int64:
+			return -x
+		case uint:
+			return -x
+		case uint8:
+			return -x
+		case uint16:
+			return -x
+		case uint32:
+			return -x
+		case uint64:
+			return -x
+		case uintptr:
+			return -x
+		case float32:
+			return -x
+		case float64:
+			return -x
+		case complex64:
+			return -x
+		case complex128:
+			return -x
+		}
+	case token.XOR:
+		switch x := x.(type) {
+		case int:
+			return ^x
+		case int8:
+			return ^x
+		case int16:
+			return ^x
+		case int32:
+			return ^x
+		case int64:
+			return ^x
+		case uint:
+			return ^x
+		case uint8:
+			return ^x
+		case uint16:
+			return ^x
+		case uint32:
+			return ^x
+		case uint64:
+			return ^x
+		case uintptr:
+			return ^x
+		}
+	}
+	panic(invalidOp(op, x))
+}
+
+// toInt64 returns the value of the integer x as an int64.
+func toInt64(x value) int64 {
+	switch x := x.(type) {
+	case int:
+		return int64(x)
+	case int8:
+		return int64(x)
+	case int16:
+		return int64(x)
+	case int32:
+		return int64(x)
+	case int64:
+		return x
+	case uint:
+		return int64(x)
+	case uint8:
+		return int64(x)
+	case uint16:
+		return int64(x)
+	case uint32:
+		return int64(x)
+	case uint64:
+		return int64(x)
+	case uintptr:
+		return int64(x)
+	default:
+		panic(stop(fmt.Errorf("%T isn't an integer", x)))
+	}
+}
+
+// toInt returns the value of the integer x, which is used as an index or length, as an int.
+func toInt(x value) int {
+	n := toInt64(x)
+	if u, ok := x.(uint64); ok && u > math.MaxInt64 {
+		// Make sure huge unsigned indices are out of range.
+		n = -1
+	}
+	return int(n)
+}
+
+// convertNumber converts the number x to the basic kind kind.
+func convertNumber(x value, kind types.BasicKind) value {
+	switch x := x.(type) {
+	case int:
+		return fromReal(x, kind)
+	case int8:
+		return fromReal(x, kind)
+	case int16:
+		return fromReal(x, kind)
+	case int32:
+		return fromReal(x, kind)
+	case int64:
+		return fromReal(x, kind)
+	case uint:
+		return fromReal(x, kind)
+	case uint8:
+		return fromReal(x, kind)
+	case uint16:
+		return fromReal(x, kind)
+	case uint32:
+		return fromReal(x, kind)
+	case uint64:
+		return fromReal(x, kind)
+	case uintptr:
+		return fromReal(x, kind)
+	case float32:
+		return fromReal(x, kind)
+	case float64:
+		return fromReal(x, kind)
+	case complex64:
+		return fromComplex(x, kind)
+	case complex128:
+		return fromComplex(x, kind)
+	default:
+		panic(stop(fmt.Errorf("can't convert %T to a number", x)))
+	}
+}
+
+func fromReal[T integer | float](x T, kind types.BasicKind) value {
+	switch kind {
+	case types.Int:
+		return int(x)
+	case types.Int8:
+		return int8(x)
+	case types.Int16:
+		return int16(x)
+	case types.Int32:
+		return int32(x)
+	case types.Int64:
+		return int64(x)
+	case types.Uint:
+		return uint(x)
+	case types.Uint8:
+		return uint8(x)
+	case types.Uint16:
+		return uint16(x)
+	case types.Uint32:
+		return uint32(x)
+	case types.Uint64:
+		return uint64(x)
+	case types.Uintptr:
+		return uintptr(x)
+	case types.Float32:
+		return float32(x)
+	case types.Float64:
+		return float64(x)
+	case types.Complex64:
+		return complex(float32(x), 0)
+	case types.Complex128:
+		return complex(float64(x), 0)
+	default:
+		panic(stop(fmt.Errorf("can't convert %T to %s", x, types.Typ[kind])))
+	}
+}
+
+func fromComplex[T complexNumber](x T, kind types.BasicKind) value {
+	switch kind {
+	case types.Complex64:
+		return complex64(x)
+	case types.Complex128:
+		return complex128(x)
+	default:
+		panic(stop(fmt.Errorf("can't convert %T to %s", x, types.Typ[kind])))
+	}
+}
+
+// convert implements the conversion of x from type from to type to, neither of which may contain type parameters.
+func (fr *frame) convert(x value, from, to types.Type) value {
+	switch ut := to.Underlying().(type) {
+	case *types.Basic:
+		if ut.Kind() == types.UnsafePointer {
+			fr.fail("unsafe.Pointer isn't supported")
+		}
+		if ut.Info()&types.IsString != 0 {
+			switch uf := from.Underlying().(type) {
+			case *types.Basic:
+				if uf.Info()&types.IsString != 0 {
+					return x
+				}
+				n := toInt64(x)
+				if n < 0 || n > utf8.MaxRune {
+					return string(utf8.RuneError)
+				}
+				return string(rune(n))
+			case *types.Slice:
+				var sb strings.Builder
+				for _, e := range x.([]value) {
+					switch e := e.(type) {
+					case uint8:
+						sb.WriteByte(e)
+					case int32:
+						sb.WriteRune(e)
+					}
+				}
+				return sb.String()
+			}
+		}
+		if uf, ok := from.Underlying().(*types.Basic); ok && uf.Kind() == types.UnsafePointer {
+			fr.fail("unsafe.Pointer isn't supported")
+		}
+		return convertNumber(x, ut.Kind())
+	case *types.Slice:
+		s := x.(string)
+		var out []value
+		if elem, ok := ut.Elem().Underlying().(*types.Basic); ok && elem.Kind() == types.Int32 {
+			for _, r := range s {
+				out = append(out, r)
+			}
+		} else {
+			for i := 0; i < len(s); i++ {
+				out = append(out, s[i])
+			}
+		}
+		if out == nil {
+			// Converting a string always produces a non-nil slice.
+			out = []value{}
+		}
+		return out
+	case *types.Pointer:
+		if uf, ok := from.Underlying().(*types.Basic); ok && uf.Kind() == types.UnsafePointer {
+			fr.fail("unsafe.Pointer isn't supported")
+		}
+		return x
+	default:
+		return x
+	}
+}
+
+// builtin calls the built-in function name. call is nil for deferred calls of built-in functions.
+func (fr *frame) builtin(name string, args []value, call *ir.CallCommon) value {
+	switch name {
+	case "append":
+		if len(args) == 1 {
+			return args[0]
+		}
+		s := args[0].([]value)
+		switch rest := args[1].(type) {
+		case string:
+			for i := 0; i < len(rest); i++ {
+				s = append(s, rest[i])
+			}
+		case []value:
+			for _, e := range rest {
+				s = append(s, copyVal(e))
+			}
+		}
+		return s
+	case "copy":
+		dst := args[0].([]value)
+		var n int
+		switch src := args[1].(type) {
+		case string:
+			n = min(len(dst), len(src))
+			for i := 0; i < n; i++ {
+				dst[i] = src[i]
+			}
+		case []value:
+			n = copy(dst, src)
+			for i := range dst[:n] {
+				dst[i] = copyVal(dst[i])
+			}
+		}
+		return n
+	case "len":
+		switch x := args[0].(type) {
+		case string:
+			return len(x)
+		case []value:
+			return len(x)
+		case array:
+			return len(x)
+		case *value:
+			return len((*x).(array))
+		case *hashMap:
+			return x.length()
+		}
+	case "cap":
+		switch x := args[0].(type) {
+		case []value:
+			return cap(x)
+		case array:
+			return len(x)
+		case *value:
+			return len((*x).(array))
+		}
+	case "delete":
+		args[0].(*hashMap).delete(args[1])
+		return nil
+	case "clear":
+		switch x := args[0].(type) {
+		case *hashMap:
+			x.clear()
+		case []value:
+			if call == nil {
+				fr.fail("deferred call of clear isn't supported")
+			}
+			elem := fr.subst(call.Args[0].Type()).Underlying().(*types.Slice).Elem()
+			for i := range x {
+				x[i] = zero(elem)
+			}
+		}
+		return nil
+	case "min", "max":
+		res := args[0]
+		for _, arg := range args[1:] {
+			res = minMax(name, res, arg)
+		}
+		return res
+	case "real":
+		switch x := args[0].(type) {
+		case complex64:
+			return real(x)
+		case complex128:
+			return real(x)
+		}
+	case "imag":
+		switch x := args[0].(type) {
+		case complex64:
+			return imag(x)
+		case complex128:
+			return imag(x)
+		}
+	case "complex":
+		switch re := args[0].(type) {
+		case float32:
+			return complex(re, args[1].(float32))
+		case float64:
+			return complex(re, args[1].(float64))
+		}
+	case "print", "println":
+		if fr.in.Output == nil {
+			return nil
+		}
+		strs := make([]string, len(args))
+		for i, arg := range args {
+			strs[i] = formatValue(arg)
+		}
+		if name == "println" {
+			io.WriteString(fr.in.Output, strings.Join(strs, " ")+"\n")
+		} else {
+			io.WriteString(fr.in.Output, strings.Join(strs, ""))
+		}
+		return nil
+	case "panic":
+		panic(targetPanic{args[0]})
+	case "recover":
+		// recover stops a panic only when called directly by a deferred function.
+		if c := fr.caller; fr.isDeferred && c != nil && c.panicking {
+			p := c.panic
+			c.panicking = false
+			c.panic = nil
+			return p
+		}
+		return iface{}
+	case "ssa:deferstack":
+		return nil
+	case "ir:wrapnilchk":
+		if args[0].(*value) == nil {
+			panic(runtimeError(fmt.Sprintf("value method %s.%s called using nil *%s pointer", args[1], args[2], args[1])))
+		}
+		return args[0]
+	case "ir:noreturnWasPanic":
+		fr.fail("call to a function that never returns returned")
+	case "close":
+		fr.fail("channels aren't supported")
+	}
+	fr.fail("unsupported call of built-in function %s", name)
+	panic("unreachable")
+}
+
+func minMax(name string, x, y value) value {
+	switch x := x.(type) {
+	case float32:
+		if name == "min" {
+			return float32(math.Min(float64(x), float64(y.(float32))))
+		}
+		return float32(math.Max(float64(x), float64(y.(float32))))
+	case float64:
+		if name == "min" {
+			return math.Min(x, y.(float64))
+		}
+		return math.Max(x, y.(float64))
+	}
+	op := token.LSS
+	if name == "max" {
+		op = token.GTR
+	}
+	if binop(op, y, x).(bool) {
+		return y
+	}
+	return x
+}
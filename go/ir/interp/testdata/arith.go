@@ -0,0 +1,61 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// Integer, floating-point and string operations, and conversions between basic types.
+
+func assert(b bool, msg string) {
+	if !b {
+		panic(msg)
+	}
+}
+
+type celsius float64
+
+func main() {
+	var i8 int8 = 127
+	i8++
+	assert(i8 == -128, "int8 overflow")
+
+	var u8 uint8 = 0
+	u8--
+	assert(u8 == 255, "uint8 underflow")
+
+	x := 7
+	assert(x/2 == 3, "division")
+	assert(-x/2 == -3, "negative division")
+	assert(x%3 == 1, "remainder")
+	assert(x<<3 == 56, "shift left")
+	assert(x>>1 == 3, "shift right")
+	assert(x&^5 == 2, "and not")
+	assert(^x == -8, "complement")
+
+	var u uint32 = 1 << 31
+	assert(u<<1 == 0, "uint32 shift overflow")
+	assert(int64(u)<<1 == 1<<32, "int64 conversion")
+
+	f := 1.5
+	assert(int(f) == 1, "float to int")
+	assert(float32(f)*2 == 3, "float32")
+	c := celsius(f) + 0.5
+	assert(c == 2, "named float")
+
+	z := complex(1, 2)
+	assert(real(z*z) == -3 && imag(z*z) == 4, "complex multiplication")
+
+	s := "hello"
+	assert(s+", world" == "hello, world", "concatenation")
+	assert(s[1] == 'e', "string index")
+	assert(s[1:3] == "el", "substring")
+	assert(s < "world", "string comparison")
+	assert(len(s) == 5, "string length")
+	assert(string(rune(0x263a)) == "☺", "rune to string")
+	assert(string([]byte{'h', 'i'}) == "hi", "bytes to string")
+	assert(len([]rune("héllo")) == 5, "string to runes")
+	assert(string(rune(-1)) == "�", "invalid rune")
+
+	assert(min(3, 1, 2) == 1, "min")
+	assert(max(1.5, 2.5) == 2.5, "max")
+	assert(max("a", "b") == "b", "max of strings")
+}
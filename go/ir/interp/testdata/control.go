@@ -0,0 +1,132 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// Loops, switches and labeled statements, which exercise phi and sigma nodes.
+
+func assert(b bool, msg string) {
+	if !b {
+		panic(msg)
+	}
+}
+
+func fib(n int) int {
+	a, b := 0, 1
+	for i := 0; i < n; i++ {
+		a, b = b, a+b
+	}
+	return a
+}
+
+func fibRec(n int) int {
+	if n < 2 {
+		return n
+	}
+	return fibRec(n-1) + fibRec(n-2)
+}
+
+func classify(x int) string {
+	switch {
+	case x < 0:
+		return "negative"
+	case x == 0:
+		return "zero"
+	case x < 10:
+		return "small"
+	default:
+		return "large"
+	}
+}
+
+func day(n int) string {
+	switch n {
+	case 0, 6:
+		return "weekend"
+	case 1, 2, 3, 4, 5:
+		return "weekday"
+	}
+	return "invalid"
+}
+
+func describe(x interface{}) string {
+	switch x := x.(type) {
+	case nil:
+		return "nil"
+	case int:
+		return classify(x)
+	case string, []byte:
+		return "text"
+	case error:
+		return "error: " + x.Error()
+	default:
+		return "unknown"
+	}
+}
+
+type myErr struct{}
+
+func (myErr) Error() string { return "oops" }
+
+func primes(n int) []int {
+	var out []int
+outer:
+	for i := 2; len(out) < n; i++ {
+		for _, p := range out {
+			if i%p == 0 {
+				continue outer
+			}
+		}
+		out = append(out, i)
+	}
+	return out
+}
+
+func firstNonNil(ptrs ...*int) int {
+	for _, p := range ptrs {
+		if p != nil {
+			return *p
+		}
+	}
+	return -1
+}
+
+func main() {
+	assert(fib(10) == 55, "fib")
+	assert(fibRec(15) == 610, "fibRec")
+	assert(classify(-3) == "negative", "classify negative")
+	assert(classify(0) == "zero", "classify zero")
+	assert(classify(100) == "large", "classify large")
+	assert(day(6) == "weekend", "day 6")
+	assert(day(3) == "weekday", "day 3")
+	assert(day(9) == "invalid", "day 9")
+	assert(describe(nil) == "nil", "describe nil")
+	assert(describe(5) == "small", "describe int")
+	assert(describe([]byte("x")) == "text", "describe bytes")
+	assert(describe(myErr{}) == "error: oops", "describe error")
+	assert(describe(1.5) == "unknown", "describe float")
+
+	ps := primes(6)
+	assert(len(ps) == 6 && ps[5] == 13, "primes")
+
+	x := 42
+	assert(firstNonNil(nil, &x) == 42, "firstNonNil")
+	assert(firstNonNil() == -1, "firstNonNil empty")
+
+	n := 0
+	for i := range 10 {
+		if i%2 == 0 {
+			continue
+		}
+		n += i
+	}
+	assert(n == 25, "range over int")
+
+	var runes []rune
+	var idx []int
+	for i, r := range "aé☺" {
+		idx = append(idx, i)
+		runes = append(runes, r)
+	}
+	assert(len(runes) == 3 && runes[2] == '☺' && idx[2] == 3, "range over string")
+}
@@ -0,0 +1,113 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// Structs, arrays, slices and maps, including aliasing between them.
+
+func assert(b bool, msg string) {
+	if !b {
+		panic(msg)
+	}
+}
+
+type point struct {
+	x, y int
+}
+
+type rect struct {
+	min, max point
+	tags     [2]string
+}
+
+func area(r rect) int {
+	return (r.max.x - r.min.x) * (r.max.y - r.min.y)
+}
+
+func grow(r *rect, n int) {
+	r.max.x += n
+	r.max.y += n
+}
+
+var origin = point{}
+
+var table = map[string]int{
+	"one": 1,
+	"two": 2,
+}
+
+func main() {
+	r := rect{max: point{2, 3}}
+	assert(area(r) == 6, "area")
+	r2 := r
+	grow(&r, 1)
+	assert(area(r) == 12, "grow")
+	assert(area(r2) == 6, "struct copy")
+	assert(r.min == origin, "struct equality")
+
+	p := &r.max
+	p.x = 10
+	assert(r.max.x == 10, "pointer to field")
+
+	var a [3]int
+	s := a[:]
+	s[1] = 5
+	assert(a[1] == 5, "slice of array aliases array")
+	a = [3]int{7, 8, 9}
+	assert(s[0] == 7, "array assignment updates aliased slice")
+	b := a
+	b[0] = 0
+	assert(a[0] == 7, "array copy")
+
+	s = make([]int, 2, 10)
+	t := append(s, 1)
+	u := append(s, 2)
+	assert(t[2] == 2 && u[2] == 2, "append shares backing array")
+	assert(len(s[1:]) == 1 && cap(s[1:5:6]) == 5, "slice expressions")
+	n := copy(s, []int{4, 5, 6})
+	assert(n == 2 && s[0] == 4 && s[1] == 5, "copy")
+
+	ap := (*[2]int)(s)
+	ap[0] = 99
+	assert(s[0] == 99, "slice to array pointer aliases slice")
+	arr := [2]int(s)
+	arr[1] = 0
+	assert(s[1] == 5, "slice to array copies")
+
+	bs := append([]byte("ab"), "cd"...)
+	assert(string(bs) == "abcd", "append string to bytes")
+
+	assert(table["two"] == 2, "map initializer")
+	m := map[point]string{}
+	m[point{1, 2}] = "a"
+	m[point{1, 2}] += "b"
+	v, ok := m[point{1, 2}]
+	assert(ok && v == "ab", "map with struct keys")
+	_, ok = m[point{2, 1}]
+	assert(!ok, "missing map key")
+	delete(m, point{1, 2})
+	assert(len(m) == 0, "delete")
+
+	im := map[interface{}]int{1: 1, "1": 2, 1.0: 3}
+	assert(len(im) == 3 && im[1] == 1 && im["1"] == 2, "map with interface keys")
+
+	sum := 0
+	for k, v := range table {
+		sum += len(k) * v
+	}
+	assert(sum == 9, "range over map")
+
+	var nilMap map[string]int
+	assert(nilMap["x"] == 0 && len(nilMap) == 0, "nil map")
+
+	clear(table)
+	assert(len(table) == 0, "clear map")
+	ints := []int{1, 2, 3}
+	clear(ints)
+	assert(ints[2] == 0, "clear slice")
+
+	grid := [2][2]int{{1, 2}, {3, 4}}
+	row := &grid[1]
+	row[0] = 30
+	assert(grid[1][0] == 30, "nested arrays")
+}
@@ -0,0 +1,118 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// Closures, methods, method values, interfaces and embedding.
+
+func assert(b bool, msg string) {
+	if !b {
+		panic(msg)
+	}
+}
+
+type counter struct {
+	n int
+}
+
+func (c *counter) inc() int {
+	c.n++
+	return c.n
+}
+
+func (c counter) get() int { return c.n }
+
+type getter interface {
+	get() int
+}
+
+type named struct {
+	*counter
+	name string
+}
+
+type shape interface {
+	area() int
+}
+
+type square int
+
+func (s square) area() int { return int(s * s) }
+
+type twice struct{ shape }
+
+func (t twice) area() int { return 2 * t.shape.area() }
+
+func makeAdder(n int) func(int) int {
+	return func(x int) int { return x + n }
+}
+
+func apply(fs []func(int) int, x int) int {
+	for _, f := range fs {
+		x = f(x)
+	}
+	return x
+}
+
+func divmod(a, b int) (q, r int) {
+	q = a / b
+	r = a % b
+	return
+}
+
+func sum(xs ...int) int {
+	s := 0
+	for _, x := range xs {
+		s += x
+	}
+	return s
+}
+
+func main() {
+	total := 0
+	add := func(n int) { total += n }
+	add(3)
+	add(4)
+	assert(total == 7, "closure over local variable")
+
+	assert(apply([]func(int) int{makeAdder(1), makeAdder(10)}, 0) == 11, "closures")
+
+	var fs []func() int
+	for i := 0; i < 3; i++ {
+		fs = append(fs, func() int { return i })
+	}
+	assert(fs[0]() == 0 && fs[2]() == 2, "per-iteration loop variables")
+
+	c := &counter{}
+	c.inc()
+	inc := c.inc
+	inc()
+	assert(c.n == 2, "method value")
+	get := (*counter).get
+	assert(get(c) == 2, "method expression")
+
+	var g getter = c
+	assert(g.get() == 2, "interface call through pointer")
+	g = *c
+	c.inc()
+	assert(g.get() == 2, "interface holds copy")
+
+	nc := named{counter: c, name: "x"}
+	nc.inc()
+	assert(nc.get() == 4, "promoted methods")
+
+	var s shape = twice{square(3)}
+	assert(s.area() == 18, "embedded interface")
+	if sq, ok := s.(twice).shape.(square); !ok || sq != 3 {
+		panic("type assertion")
+	}
+	_, ok := s.(square)
+	assert(!ok, "failed type assertion")
+
+	q, r := divmod(17, 5)
+	assert(q == 3 && r == 2, "multiple results")
+	assert(sum() == 0 && sum(1, 2, 3) == 6 && sum([]int{4, 5}...) == 9, "variadic")
+
+	var f func()
+	assert(f == nil, "nil func")
+}
@@ -0,0 +1,103 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// Generic functions and types.
+
+func assert(b bool, msg string) {
+	if !b {
+		panic(msg)
+	}
+}
+
+type number interface {
+	~int | ~int64 | ~float64
+}
+
+func sum[T number](xs []T) T {
+	var s T
+	for _, x := range xs {
+		s += x
+	}
+	return s
+}
+
+func mapSlice[T, U any](xs []T, f func(T) U) []U {
+	out := make([]U, 0, len(xs))
+	for _, x := range xs {
+		out = append(out, f(x))
+	}
+	return out
+}
+
+func index[T comparable](xs []T, x T) int {
+	for i, y := range xs {
+		if y == x {
+			return i
+		}
+	}
+	return -1
+}
+
+type stack[T any] struct {
+	elems []T
+}
+
+func (s *stack[T]) push(x T) { s.elems = append(s.elems, x) }
+
+func (s *stack[T]) pop() (T, bool) {
+	var zero T
+	if len(s.elems) == 0 {
+		return zero, false
+	}
+	x := s.elems[len(s.elems)-1]
+	s.elems = s.elems[:len(s.elems)-1]
+	return x, true
+}
+
+type stringer interface {
+	String() string
+}
+
+type id int
+
+func (i id) String() string { return "id" + string(rune('0'+i)) }
+
+func join[T stringer](xs []T) string {
+	s := ""
+	for _, x := range xs {
+		s += x.String()
+	}
+	return s
+}
+
+func toAny[T any](x T) interface{} { return x }
+
+func identity[T any](x T) T { return x }
+
+func convert[T, U number](x T) U { return U(x) }
+
+func main() {
+	assert(sum([]int{1, 2, 3}) == 6, "sum of ints")
+	assert(sum([]float64{0.5, 0.25}) == 0.75, "sum of floats")
+	strs := mapSlice([]int{1, 2}, func(i int) string { return string(rune('a' + i)) })
+	assert(len(strs) == 2 && strs[1] == "c", "mapSlice")
+	assert(index([]string{"a", "b"}, "b") == 1, "index")
+
+	var s stack[string]
+	s.push("x")
+	s.push("y")
+	x, ok := s.pop()
+	assert(ok && x == "y", "pop")
+	s.pop()
+	_, ok = s.pop()
+	assert(!ok, "pop on empty stack")
+
+	assert(join([]id{1, 2}) == "id1id2", "method calls on type parameters")
+	_, isInt := toAny(1).(int)
+	assert(isInt, "dynamic type of type parameter")
+	assert(convert[float64, int](2.5) == 2, "conversion between type parameters")
+	assert(identity[interface{}](3) == 3, "type parameter instantiated with interface type")
+	assert(toAny[interface{}]("x") == "x", "interface conversion of interface-typed type parameter")
+}
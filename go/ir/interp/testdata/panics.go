@@ -0,0 +1,98 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// Deferred calls, panics, recovery and functions that never return.
+
+func assert(b bool, msg string) {
+	if !b {
+		panic(msg)
+	}
+}
+
+var log []string
+
+func deferOrder() {
+	for i := 0; i < 3; i++ {
+		defer func() { log = append(log, string(rune('a'+i))) }()
+	}
+}
+
+func recovered() (err string) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(string)
+		}
+	}()
+	panic("boom")
+}
+
+func fail(msg string) {
+	panic("fail: " + msg)
+}
+
+// mustPositive calls fail, which never returns. The builder ends the block after the call.
+func mustPositive(x int) int {
+	if x <= 0 {
+		fail("not positive")
+	}
+	return x
+}
+
+func catch(f func()) (msg interface{}) {
+	defer func() {
+		msg = recover()
+	}()
+	f()
+	return nil
+}
+
+func runtimeErrors() {
+	var s []int
+	assert(catch(func() { _ = s[1] }) != nil, "index out of range")
+	var p *int
+	assert(catch(func() { _ = *p }) != nil, "nil dereference")
+	zero := 0
+	assert(catch(func() { _ = 1 / zero }) != nil, "division by zero")
+	var m map[string]int
+	assert(catch(func() { m["x"] = 1 }) != nil, "assignment to nil map")
+	var x interface{} = 1
+	assert(catch(func() { _ = x.(string) }) != nil, "failed type assertion")
+}
+
+func rethrow() (n int) {
+	defer func() {
+		recover()
+		n = 2
+	}()
+	defer func() {
+		panic("second")
+	}()
+	n = 1
+	panic("first")
+}
+
+func noRecoverInNestedCall() (ok bool) {
+	defer func() {
+		ok = recover() == "outer"
+	}()
+	defer func() {
+		helper := func() interface{} { return recover() }
+		if helper() != nil {
+			panic("recover in nested call stopped the panic")
+		}
+	}()
+	panic("outer")
+}
+
+func main() {
+	deferOrder()
+	assert(len(log) == 3 && log[0] == "c" && log[2] == "a", "defer order")
+	assert(recovered() == "boom", "recover")
+	assert(mustPositive(3) == 3, "mustPositive")
+	assert(catch(func() { mustPositive(-1) }) == "fail: not positive", "noreturn")
+	runtimeErrors()
+	assert(rethrow() == 2, "panic in deferred call")
+	assert(noRecoverInNestedCall(), "recover only in deferred calls")
+}
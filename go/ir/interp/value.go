@@ -0,0 +1,530 @@
+package interp
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A value is a value of the interpreted program. Values use the following representations:
+//
+//	bool                     bool
+//	numeric types            the corresponding Go type, e.g. int8 or complex64
+//	string                   string
+//	pointers                 *value
+//	structs                  structure
+//	arrays                   array
+//	slices                   []value
+//	maps                     *hashMap
+//	interfaces               iface
+//	functions                *ir.Function, *closure, or nil
+//	tuples                   tuple
+//	range iterators          *stringIter or *mapIter
+//
+// Values of named types use the representation of their underlying types. Structures and arrays are mutable and must
+// be copied when they are loaded from or stored to memory.
+type value interface{}
+
+type tuple []value
+
+type array []value
+
+type structure []value
+
+// An iface is a non-nil interface value with dynamic type t, or a nil interface value if t is nil.
+type iface struct {
+	t types.Type
+	v value
+}
+
+type hashMap struct {
+	index   map[string]int
+	entries []mapEntry
+	len     int
+}
+
+type mapEntry struct {
+	k, v    value
+	deleted bool
+}
+
+type stringIter struct {
+	s string
+	i int
+}
+
+type mapIter struct {
+	m *hashMap
+	i int
+}
+
+// mapKey returns a string that is equal for two values iff the values are equal, for use as the key of a hashMap. It
+// panics with a run-time error if v isn't comparable.
+func mapKey(v value) string {
+	var sb strings.Builder
+	writeMapKey(&sb, v)
+	return sb.String()
+}
+
+func writeMapKey(sb *strings.Builder, v value) {
+	switch v := v.(type) {
+	case string:
+		sb.WriteString(strconv.Quote(v))
+	case float32:
+		writeFloatKey(sb, float64(v))
+	case float64:
+		writeFloatKey(sb, v)
+	case complex64:
+		writeFloatKey(sb, float64(real(v)))
+		sb.WriteString("+")
+		writeFloatKey(sb, float64(imag(v)))
+	case complex128:
+		writeFloatKey(sb, real(v))
+		sb.WriteString("+")
+		writeFloatKey(sb, imag(v))
+	case *value:
+		fmt.Fprintf(sb, "%p", v)
+	case *hashMap, *closure:
+		// Maps and functions aren't comparable; the type checker only lets them be used as keys via interfaces.
+		panic(runtimeError(fmt.Sprintf("hash of unhashable type %T", v)))
+	case structure:
+		sb.WriteString("{")
+		for _, e := range v {
+			writeMapKey(sb, e)
+			sb.WriteString(",")
+		}
+		sb.WriteString("}")
+	case array:
+		sb.WriteString("[")
+		for _, e := range v {
+			writeMapKey(sb, e)
+			sb.WriteString(",")
+		}
+		sb.WriteString("]")
+	case iface:
+		if v.t == nil {
+			sb.WriteString("nil")
+			return
+		}
+		switch v.v.(type) {
+		case []value, *hashMap, *closure:
+			panic(runtimeError("hash of unhashable type " + v.t.String()))
+		}
+		sb.WriteString(types.TypeString(v.t, nil))
+		sb.WriteString(":")
+		writeMapKey(sb, v.v)
+	default:
+		fmt.Fprintf(sb, "%T:%v", v, v)
+	}
+}
+
+func writeFloatKey(sb *strings.Builder, f float64) {
+	switch {
+	case f == 0:
+		// +0 and -0 are equal
+		sb.WriteString("0")
+	case math.IsNaN(f):
+		// NaN is never equal to itself; make every NaN key unique.
+		fmt.Fprintf(sb, "NaN%p", new(byte))
+	default:
+		sb.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+}
+
+func (m *hashMap) lookup(k value) (value, bool) {
+	if m == nil {
+		return nil, false
+	}
+	if idx, ok := m.index[mapKey(k)]; ok {
+		return m.entries[idx].v, true
+	}
+	return nil, false
+}
+
+func (m *hashMap) update(k, v value) {
+	key := mapKey(k)
+	if idx, ok := m.index[key]; ok {
+		m.entries[idx].v = v
+		return
+	}
+	m.index[key] = len(m.entries)
+	m.entries = append(m.entries, mapEntry{k: k, v: v})
+	m.len++
+}
+
+func (m *hashMap) delete(k value) {
+	if m == nil {
+		return
+	}
+	key := mapKey(k)
+	if idx, ok := m.index[key]; ok {
+		m.entries[idx] = mapEntry{deleted: true}
+		delete(m.index, key)
+		m.len--
+	}
+}
+
+func (m *hashMap) clear() {
+	if m == nil {
+		return
+	}
+	m.index = map[string]int{}
+	m.entries = nil
+	m.len = 0
+}
+
+func (m *hashMap) length() int {
+	if m == nil {
+		return 0
+	}
+	return m.len
+}
+
+// zero returns the zero value of type t.
+func zero(t types.Type) value {
+	switch t := types.Unalias(t).(type) {
+	case *types.Basic:
+		switch t.Kind() {
+		case types.Bool, types.UntypedBool:
+			return false
+		case types.Int, types.UntypedInt:
+			return int(0)
+		case types.Int8:
+			return int8(0)
+		case types.Int16:
+			return int16(0)
+		case types.Int32, types.UntypedRune:
+			return int32(0)
+		case types.Int64:
+			return int64(0)
+		case types.Uint:
+			return uint(0)
+		case types.Uint8:
+			return uint8(0)
+		case types.Uint16:
+			return uint16(0)
+		case types.Uint32:
+			return uint32(0)
+		case types.Uint64:
+			return uint64(0)
+		case types.Uintptr:
+			return uintptr(0)
+		case types.Float32:
+			return float32(0)
+		case types.Float64, types.UntypedFloat:
+			return float64(0)
+		case types.Complex64:
+			return complex64(0)
+		case types.Complex128, types.UntypedComplex:
+			return complex128(0)
+		case types.String, types.UntypedString:
+			return ""
+		case types.UntypedNil:
+			return nil
+		case types.UnsafePointer:
+			return (*value)(nil)
+		}
+	case *types.Named:
+		return zero(t.Underlying())
+	case *types.Pointer:
+		return (*value)(nil)
+	case *types.Slice:
+		return []value(nil)
+	case *types.Array:
+		a := make(array, t.Len())
+		for i := range a {
+			a[i] = zero(t.Elem())
+		}
+		return a
+	case *types.Struct:
+		s := make(structure, t.NumFields())
+		for i := range s {
+			s[i] = zero(t.Field(i).Type())
+		}
+		return s
+	case *types.Tuple:
+		tup := make(tuple, t.Len())
+		for i := range tup {
+			tup[i] = zero(t.At(i).Type())
+		}
+		return tup
+	case *types.Map:
+		return (*hashMap)(nil)
+	case *types.Interface:
+		return iface{}
+	case *types.Signature:
+		return nil
+	case *types.Chan:
+		return nil
+	}
+	panic(stop(fmt.Errorf("no zero value for type %s", t)))
+}
+
+// copyVal returns a copy of v that doesn't share mutable state with v.
+func copyVal(v value) value {
+	switch v := v.(type) {
+	case structure:
+		c := make(structure, len(v))
+		for i, e := range v {
+			c[i] = copyVal(e)
+		}
+		return c
+	case array:
+		c := make(array, len(v))
+		for i, e := range v {
+			c[i] = copyVal(e)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// store stores v at addr. Structures and arrays are updated in place, so that pointers to their elements remain
+// valid.
+func store(addr *value, v value) {
+	switch dst := (*addr).(type) {
+	case structure:
+		if src, ok := v.(structure); ok {
+			for i := range dst {
+				store(&dst[i], src[i])
+			}
+			return
+		}
+	case array:
+		if src, ok := v.(array); ok {
+			for i := range dst {
+				store(&dst[i], src[i])
+			}
+			return
+		}
+	}
+	*addr = copyVal(v)
+}
+
+// equals reports whether x and y, which are of identical types, are equal.
+func equals(x, y value) bool {
+	switch x := x.(type) {
+	case []value:
+		// Slices can only be compared to nil.
+		return (x == nil) == (y.([]value) == nil)
+	case *hashMap:
+		return x == y.(*hashMap)
+	case *closure, *value:
+		return x == y
+	case structure:
+		y := y.(structure)
+		for i := range x {
+			if !equals(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case array:
+		y := y.(array)
+		for i := range x {
+			if !equals(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case iface:
+		y := y.(iface)
+		if x.t == nil || y.t == nil {
+			return x.t == nil && y.t == nil
+		}
+		if !types.Identical(x.t, y.t) {
+			return false
+		}
+		switch x.v.(type) {
+		case []value, *hashMap, *closure:
+			panic(runtimeError("comparing uncomparable type " + x.t.String()))
+		}
+		return equals(x.v, y.v)
+	default:
+		return x == y
+	}
+}
+
+// constValue returns the value of the constant c of type t. A nil c denotes the zero value of t.
+func constValue(c constant.Value, t types.Type) value {
+	if c == nil {
+		return zero(t)
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		// Constants of interface type, e.g. the result of converting a constant to an interface.
+		if _, ok := t.Underlying().(*types.Interface); ok {
+			dt := types.Default(constantType(c))
+			return iface{t: dt, v: constValue(c, dt)}
+		}
+		panic(stop(fmt.Errorf("unexpected constant %s of type %s", c, t)))
+	}
+	switch c.Kind() {
+	case constant.Bool:
+		return constant.BoolVal(c)
+	case constant.String:
+		return constant.StringVal(c)
+	case constant.Int, constant.Float, constant.Complex:
+	default:
+		panic(stop(fmt.Errorf("unexpected constant %s of type %s", c, t)))
+	}
+
+	kind := basic.Kind()
+	switch kind {
+	case types.UntypedInt:
+		kind = types.Int
+	case types.UntypedRune:
+		kind = types.Int32
+	case types.UntypedFloat:
+		kind = types.Float64
+	case types.UntypedComplex:
+		kind = types.Complex128
+	}
+	switch {
+	case basic.Info()&types.IsInteger != 0 || kind == types.Int || kind == types.Int32:
+		if i, ok := constant.Int64Val(constant.ToInt(c)); ok {
+			return convertNumber(i, kind)
+		}
+		u, _ := constant.Uint64Val(constant.ToInt(c))
+		return convertNumber(u, kind)
+	case kind == types.Float32 || kind == types.Float64:
+		f, _ := constant.Float64Val(constant.ToFloat(c))
+		return convertNumber(f, kind)
+	case kind == types.Complex64 || kind == types.Complex128:
+		re, _ := constant.Float64Val(constant.Real(c))
+		im, _ := constant.Float64Val(constant.Imag(c))
+		return convertNumber(complex(re, im), kind)
+	}
+	panic(stop(fmt.Errorf("unexpected constant %s of type %s", c, t)))
+}
+
+func constantType(c constant.Value) types.Type {
+	switch c.Kind() {
+	case constant.Bool:
+		return types.Typ[types.UntypedBool]
+	case constant.String:
+		return types.Typ[types.UntypedString]
+	case constant.Int:
+		return types.Typ[types.UntypedInt]
+	case constant.Float:
+		return types.Typ[types.UntypedFloat]
+	default:
+		return types.Typ[types.UntypedComplex]
+	}
+}
+
+// toConstant converts v, a value of a basic type, to a constant.
+func toConstant(v value) (constant.Value, bool) {
+	switch v := v.(type) {
+	case bool:
+		return constant.MakeBool(v), true
+	case string:
+		return constant.MakeString(v), true
+	case int:
+		return constant.MakeInt64(int64(v)), true
+	case int8:
+		return constant.MakeInt64(int64(v)), true
+	case int16:
+		return constant.MakeInt64(int64(v)), true
+	case int32:
+		return constant.MakeInt64(int64(v)), true
+	case int64:
+		return constant.MakeInt64(v), true
+	case uint:
+		return constant.MakeUint64(uint64(v)), true
+	case uint8:
+		return constant.MakeUint64(uint64(v)), true
+	case uint16:
+		return constant.MakeUint64(uint64(v)), true
+	case uint32:
+		return constant.MakeUint64(uint64(v)), true
+	case uint64:
+		return constant.MakeUint64(v), true
+	case uintptr:
+		return constant.MakeUint64(uint64(v)), true
+	case float32:
+		return floatConstant(float64(v))
+	case float64:
+		return floatConstant(v)
+	case complex64:
+		return complexConstant(complex128(v))
+	case complex128:
+		return complexConstant(v)
+	default:
+		return nil, false
+	}
+}
+
+func floatConstant(f float64) (constant.Value, bool) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, false
+	}
+	return constant.MakeFloat64(f), true
+}
+
+func complexConstant(c complex128) (constant.Value, bool) {
+	re, ok1 := floatConstant(real(c))
+	im, ok2 := floatConstant(imag(c))
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	return constant.BinaryOp(re, token.ADD, constant.MakeImag(im)), true
+}
+
+// formatValue formats v for the print builtins and for panic messages.
+func formatValue(v value) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case float32:
+		return formatFloat(float64(v))
+	case float64:
+		return formatFloat(v)
+	case complex64:
+		return "(" + formatFloat(float64(real(v))) + formatFloat(float64(imag(v))) + "i)"
+	case complex128:
+		return "(" + formatFloat(real(v)) + formatFloat(imag(v)) + "i)"
+	case iface:
+		if v.t == nil {
+			return "(nil)"
+		}
+		switch v.v.(type) {
+		case bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr,
+			float32, float64, complex64, complex128:
+			return formatValue(v.v)
+		}
+		return fmt.Sprintf("(%s) %p", v.t, &v.v)
+	case []value:
+		return fmt.Sprintf("[%d/%d]%p", len(v), cap(v), v)
+	case *value, *hashMap, *closure:
+		return fmt.Sprintf("%p", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatFloat formats f the way the runtime's print builtin does.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	s := strconv.FormatFloat(f, 'e', 6, 64)
+	if s[0] != '-' {
+		s = "+" + s
+	}
+	// The runtime always prints three exponent digits.
+	if i := strings.LastIndexAny(s, "+-"); len(s)-i-1 < 3 {
+		s = s[:i+1] + strings.Repeat("0", 3-(len(s)-i-1)) + s[i+1:]
+	}
+	return s
+}
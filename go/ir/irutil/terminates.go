@@ -68,3 +68,20 @@ func Terminates(fn *ir.Function) bool {
 	}
 	return false
 }
+
+// Aborts reports whether b ends in a panic, an unreachable
+// instruction or a call to a function that doesn't return. Unlike
+// Terminates, it only looks at a single block.
+func Aborts(b *ir.BasicBlock) bool {
+	for _, instr := range b.Instrs {
+		switch instr := instr.(type) {
+		case *ir.Panic, *ir.Unreachable:
+			return true
+		case *ir.Call:
+			if instr.Common().NoReturn() != ir.Returns {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package irutil
 
 import (
+	"go/ast"
+	"go/token"
 	"go/types"
 	"strings"
 
@@ -176,3 +178,86 @@ func Flatten(v ir.Value) ir.Value {
 	}
 	return out
 }
+
+// ReturnPos returns the position of the return statement at which control leaves fn when going from b, a predecessor
+// of fn.Exit, to the exit block. Blocks that consist only of a bare return get eliminated, in which case we find the
+// return statement in the branch of the if statement that b ends in. If control falls off the end of the function,
+// the position of the closing brace is returned.
+func ReturnPos(fn *ir.Function, b *ir.BasicBlock) token.Pos {
+	ctrl := b.Control()
+	if ret, ok := ctrl.Source().(*ast.ReturnStmt); ok {
+		return ret.Pos()
+	}
+	var body *ast.BlockStmt
+	switch node := fn.Source().(type) {
+	case *ast.FuncDecl:
+		body = node.Body
+	case *ast.FuncLit:
+		body = node.Body
+	default:
+		return fn.Pos()
+	}
+	if iff, ok := ctrl.(*ir.If); ok && iff.Source() != nil {
+		switch loop := iff.Source().(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			// The loop exits directly to the return statement following it.
+			if ret, ok := nextStmt(body, loop.(ast.Stmt)).(*ast.ReturnStmt); ok {
+				return ret.Pos()
+			}
+			return body.Rbrace
+		}
+		// The source of the If is either the if statement, or part of its condition if the condition uses && or ||.
+		stmt, _ := iff.Source().(*ast.IfStmt)
+		if stmt == nil {
+			cond := iff.Source()
+			ast.Inspect(body, func(node ast.Node) bool {
+				if stmt != nil {
+					return false
+				}
+				if s, ok := node.(*ast.IfStmt); ok && cond.Pos() >= s.Cond.Pos() && cond.End() <= s.Cond.End() {
+					stmt = s
+					return false
+				}
+				return true
+			})
+		}
+		if stmt != nil {
+			var branch ast.Stmt = stmt.Body
+			if b.Succs[0] != fn.Exit {
+				branch = stmt.Else
+			}
+			if block, ok := branch.(*ast.BlockStmt); ok && len(block.List) > 0 {
+				if ret, ok := block.List[len(block.List)-1].(*ast.ReturnStmt); ok {
+					return ret.Pos()
+				}
+			}
+		}
+	}
+	return body.Rbrace
+}
+
+// nextStmt returns the statement following stmt in the statement list containing it, or nil.
+func nextStmt(root ast.Node, stmt ast.Stmt) ast.Stmt {
+	var out ast.Stmt
+	ast.Inspect(root, func(node ast.Node) bool {
+		if out != nil {
+			return false
+		}
+		var list []ast.Stmt
+		switch node := node.(type) {
+		case *ast.BlockStmt:
+			list = node.List
+		case *ast.CaseClause:
+			list = node.Body
+		case *ast.CommClause:
+			list = node.Body
+		}
+		for i, s := range list {
+			if s == stmt && i+1 < len(list) {
+				out = list[i+1]
+			}
+		}
+		return true
+	})
+	return out
+}
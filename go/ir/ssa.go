@@ -379,14 +379,15 @@ type Function struct {
 
 	// These fields are populated only when the function body is built:
 
-	Params    []*Parameter  // function parameters; for methods, includes receiver
-	FreeVars  []*FreeVar    // free variables whose values must be supplied by closure
-	Locals    []*Alloc      // frame-allocated variables of this function
-	Blocks    []*BasicBlock // basic blocks of the function; nil => external
-	Exit      *BasicBlock   // The function's exit block
-	AnonFuncs []*Function   // anonymous functions (from FuncLit, RangeStmt) directly beneath this one
-	referrers []Instruction // referring instructions (iff Parent() != nil)
-	NoReturn  NoReturn      // Calling this function will always terminate control flow.
+	Params    []*Parameter      // function parameters; for methods, includes receiver
+	FreeVars  []*FreeVar        // free variables whose values must be supplied by closure
+	Locals    []*Alloc          // frame-allocated variables of this function
+	Blocks    []*BasicBlock     // basic blocks of the function; nil => external
+	Exit      *BasicBlock       // The function's exit block
+	AnonFuncs []*Function       // anonymous functions (from FuncLit, RangeStmt) directly beneath this one
+	referrers []Instruction     // referring instructions (iff Parent() != nil)
+	NoReturn  NoReturn          // Calling this function will always terminate control flow.
+	ExitsIf   []ConditionalExit // Calling this function will terminate control flow if any of these conditions hold.
 
 	goversion string // Go version of syntax (NB: init is special)
 
@@ -482,6 +483,16 @@ const (
 	NeverReturns
 )
 
+// A ConditionalExit describes a function that terminates control
+// flow, in the manner described by Kind, when its argument Arg is equal
+// to the constant Value. Arg is an index into the function's
+// parameters, which include the receiver.
+type ConditionalExit struct {
+	Arg   int
+	Value constant.Value
+	Kind  NoReturn
+}
+
 type constValue struct {
 	c   Constant
 	idx int
@@ -1778,6 +1789,43 @@ func (c *CallCommon) StaticCallee() *Function {
 	return nil
 }
 
+// NoReturn reports whether this call terminates control flow. Besides
+// the callee's NoReturn, this takes into account conditions under which
+// the callee doesn't return that are met by constant arguments, as well
+// as some interface methods that are known not to return.
+func (c *CallCommon) NoReturn() NoReturn {
+	if c.IsInvoke() {
+		switch c.Method.FullName() {
+		case "(testing.TB).FailNow",
+			"(testing.TB).Fatal",
+			"(testing.TB).Fatalf",
+			"(testing.TB).SkipNow",
+			"(testing.TB).Skip",
+			"(testing.TB).Skipf":
+			// All implementations of testing.TB are provided by the
+			// testing package, and these methods call runtime.Goexit.
+			return AlwaysUnwinds
+		}
+		return Returns
+	}
+	callee := c.StaticCallee()
+	if callee == nil {
+		return Returns
+	}
+	if callee.NoReturn != Returns {
+		return callee.NoReturn
+	}
+	for _, cond := range callee.ExitsIf {
+		if cond.Arg >= len(c.Args) {
+			continue
+		}
+		if k, ok := c.Args[cond.Arg].(*Const); ok && constEqual(k.Value, cond.Value) {
+			return cond.Kind
+		}
+	}
+	return Returns
+}
+
 // Description returns a description of the mode of this call suitable
 // for a user interface, e.g., "static method call".
 func (c *CallCommon) Description() string {
@@ -104,15 +104,11 @@ func Graph(c *cache.Cache, cfg *packages.Config, patterns ...string) ([]*Package
 		for path, imp := range pkg.Imports {
 			spec.Imports[path] = m[imp]
 		}
-		if cdir := config.Dir(pkg.GoFiles); cdir != "" {
-			cfg, err := config.Load(cdir)
-			if err != nil {
-				spec.Errors = append(spec.Errors, convertError(err)...)
-			}
-			spec.Config = cfg
-		} else {
-			spec.Config = config.DefaultConfig
+		cfg, err := config.Load(config.Dir(pkg.GoFiles))
+		if err != nil {
+			spec.Errors = append(spec.Errors, convertError(err)...)
 		}
+		spec.Config = cfg
 		spec.Hash, err = computeHash(c, spec)
 		if err != nil {
 			spec.Errors = append(spec.Errors, convertError(err)...)
@@ -0,0 +1,44 @@
+// Package buildcallgraph defines an Analyzer that computes the call
+// graph of a package, resolving calls through interfaces and function
+// values with class hierarchy analysis, refined by variable type
+// analysis. It does not report any diagnostics itself but may be used
+// as an input to other analyzers.
+//
+// The graph only contains the functions of the current package and the
+// method sets of its types. Dynamic calls that may reach other
+// functions are recorded in the graph's Unresolved set.
+package buildcallgraph
+
+import (
+	"reflect"
+
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/callgraph"
+	"honnef.co/go/tools/go/ir/callgraph/cha"
+	"honnef.co/go/tools/go/ir/callgraph/vta"
+	"honnef.co/go/tools/go/ir/irutil"
+	"honnef.co/go/tools/internal/passes/buildir"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name:       "buildcallgraph",
+	Doc:        "build call graph for later passes",
+	Run:        run,
+	Requires:   []*analysis.Analyzer{buildir.Analyzer},
+	ResultType: reflect.TypeOf(new(callgraph.Graph)),
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	irpkg := pass.ResultOf[buildir.Analyzer].(*buildir.IR).Pkg
+	funcs := map[*ir.Function]bool{}
+	for fn := range irutil.AllFunctions(irpkg.Prog) {
+		// Functions of other packages have no bodies and are of no use
+		// to the analyses, other than as static callees.
+		if fn.Pkg == irpkg || fn.Blocks != nil {
+			funcs[fn] = true
+		}
+	}
+	return vta.CallGraph(funcs, cha.FromFunctions(funcs)), nil
+}
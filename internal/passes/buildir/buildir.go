@@ -11,11 +11,17 @@
 package buildir
 
 import (
+	"fmt"
 	"go/ast"
+	"go/constant"
+	"go/token"
 	"go/types"
 	"reflect"
+	"strings"
 
+	"honnef.co/go/tools/config"
 	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/types/typeutil"
 
 	"golang.org/x/tools/go/analysis"
 )
@@ -26,12 +32,89 @@ type noReturn struct {
 
 func (*noReturn) AFact() {}
 
+func (f *noReturn) String() string {
+	return fmt.Sprintf("noReturn(%s)", kindString(f.Kind))
+}
+
+// exitsIf records the conditions under which a function terminates
+// control flow. Constants are stored in their exact string
+// representation, as constant.Value cannot be serialized.
+type exitsIf struct {
+	Conds []exitCond
+}
+
+type exitCond struct {
+	Arg   int
+	Kind  constant.Kind
+	Value string
+	Exit  ir.NoReturn
+}
+
+func (*exitsIf) AFact() {}
+
+func (f *exitsIf) String() string {
+	parts := make([]string, len(f.Conds))
+	for i, cond := range f.Conds {
+		parts[i] = fmt.Sprintf("$%d == %s: %s", cond.Arg, cond.Value, kindString(cond.Exit))
+	}
+	return fmt.Sprintf("exitsIf(%s)", strings.Join(parts, ", "))
+}
+
+func kindString(kind ir.NoReturn) string {
+	switch kind {
+	case ir.Returns:
+		return "returns"
+	case ir.AlwaysExits:
+		return "exits"
+	case ir.AlwaysUnwinds:
+		return "unwinds"
+	case ir.NeverReturns:
+		return "never returns"
+	default:
+		return fmt.Sprintf("NoReturn(%d)", kind)
+	}
+}
+
+func encodeExits(conds []ir.ConditionalExit) *exitsIf {
+	out := &exitsIf{Conds: make([]exitCond, len(conds))}
+	for i, cond := range conds {
+		out.Conds[i] = exitCond{
+			Arg:   cond.Arg,
+			Kind:  cond.Value.Kind(),
+			Value: cond.Value.ExactString(),
+			Exit:  cond.Kind,
+		}
+	}
+	return out
+}
+
+func decodeExits(fact *exitsIf) []ir.ConditionalExit {
+	var out []ir.ConditionalExit
+	for _, cond := range fact.Conds {
+		var v constant.Value
+		switch cond.Kind {
+		case constant.Bool:
+			v = constant.MakeBool(cond.Value == "true")
+		case constant.String:
+			v = constant.MakeFromLiteral(cond.Value, token.STRING, 0)
+		case constant.Int:
+			v = constant.MakeFromLiteral(cond.Value, token.INT, 0)
+		}
+		if v == nil || v.Kind() == constant.Unknown {
+			continue
+		}
+		out = append(out, ir.ConditionalExit{Arg: cond.Arg, Value: v, Kind: cond.Exit})
+	}
+	return out
+}
+
 var Analyzer = &analysis.Analyzer{
 	Name:       "buildir",
 	Doc:        "build IR for later passes",
 	Run:        run,
+	Requires:   []*analysis.Analyzer{config.Analyzer},
 	ResultType: reflect.TypeOf(new(IR)),
-	FactTypes:  []analysis.Fact{new(noReturn)},
+	FactTypes:  []analysis.Fact{new(noReturn), new(exitsIf)},
 }
 
 // IR provides intermediate representation for all the
@@ -56,6 +139,18 @@ func run(pass *analysis.Pass) (interface{}, error) {
 
 	mode := ir.GlobalDebug
 
+	cfg := config.For(pass)
+	// declare applies the semantics the user declared for fn.
+	declare := func(fn *ir.Function) {
+		obj, ok := fn.Object().(*types.Func)
+		if !ok {
+			return
+		}
+		if sem, ok := cfg.Function(typeutil.FuncName(obj)); ok && sem.NoReturn {
+			fn.NoReturn = ir.NeverReturns
+		}
+	}
+
 	prog := ir.NewProgram(pass.Fset, mode)
 
 	// Create IR packages for all imports.
@@ -73,6 +168,11 @@ func run(pass *analysis.Pass) (interface{}, error) {
 						if pass.ImportObjectFact(fn.Object(), &noRet) {
 							fn.NoReturn = noRet.Kind
 						}
+						var exits exitsIf
+						if pass.ImportObjectFact(fn.Object(), &exits) {
+							fn.ExitsIf = decodeExits(&exits)
+						}
+						declare(fn)
 					}
 				}
 				createAll(p.Imports())
@@ -83,6 +183,9 @@ func run(pass *analysis.Pass) (interface{}, error) {
 
 	// Create and build the primary package.
 	irpkg := prog.CreatePackage(pass.Pkg, pass.Files, pass.TypesInfo, false)
+	for _, fn := range irpkg.Functions {
+		declare(fn)
+	}
 	irpkg.Build()
 
 	// Compute list of source functions, including literals,
@@ -101,6 +204,9 @@ func run(pass *analysis.Pass) (interface{}, error) {
 		if fn.NoReturn > 0 {
 			pass.ExportObjectFact(fn.Object(), &noReturn{fn.NoReturn})
 		}
+		if len(fn.ExitsIf) > 0 {
+			pass.ExportObjectFact(fn.Object(), encodeExits(fn.ExitsIf))
+		}
 	}
 
 	return &IR{Pkg: irpkg, SrcFuncs: funcs}, nil
@@ -26,3 +26,7 @@ func Test(t *testing.T) {
 		}
 	}
 }
+
+func TestExitFacts(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), buildir.Analyzer, "exits/a", "exits/b", "github.com/sirupsen/logrus")
+}
@@ -0,0 +1,42 @@
+package buildir
+
+import (
+	"bytes"
+	"encoding/gob"
+	"go/constant"
+	"go/token"
+	"testing"
+
+	"honnef.co/go/tools/go/ir"
+)
+
+func TestExitsIfEncoding(t *testing.T) {
+	conds := []ir.ConditionalExit{
+		{Arg: 0, Value: constant.MakeBool(true), Kind: ir.AlwaysExits},
+		{Arg: 1, Value: constant.MakeBool(false), Kind: ir.AlwaysUnwinds},
+		{Arg: 2, Value: constant.MakeInt64(42), Kind: ir.NeverReturns},
+		{Arg: 3, Value: constant.MakeInt64(-1), Kind: ir.AlwaysExits},
+		{Arg: 4, Value: constant.MakeString("fatal \"error\"\n"), Kind: ir.AlwaysUnwinds},
+	}
+
+	// Facts are serialized with gob when they cross process boundaries.
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(encodeExits(conds)); err != nil {
+		t.Fatal(err)
+	}
+	var fact exitsIf
+	if err := gob.NewDecoder(buf).Decode(&fact); err != nil {
+		t.Fatal(err)
+	}
+
+	got := decodeExits(&fact)
+	if len(got) != len(conds) {
+		t.Fatalf("got %d conditions, want %d", len(got), len(conds))
+	}
+	for i, want := range conds {
+		g := got[i]
+		if g.Arg != want.Arg || g.Kind != want.Kind || g.Value.Kind() != want.Value.Kind() || !constant.Compare(g.Value, token.EQL, want.Value) {
+			t.Errorf("condition %d: got {%d %s %d}, want {%d %s %d}", i, g.Arg, g.Value, g.Kind, want.Arg, want.Value, want.Kind)
+		}
+	}
+}
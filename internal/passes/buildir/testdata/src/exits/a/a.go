@@ -0,0 +1,22 @@
+package a
+
+import "os"
+
+func ExitIf(b bool) { // want ExitIf:`exitsIf\(\$0 == true: never returns\)`
+	if b {
+		os.Exit(1)
+	}
+}
+
+func ExitCode(code int) { // want ExitCode:`exitsIf\(\$0 == 2: never returns\)`
+	switch code {
+	case 2:
+		os.Exit(code)
+	}
+}
+
+func PanicOn(s string) { // want PanicOn:`exitsIf\(\$0 == "fatal": unwinds\)`
+	if s == "fatal" {
+		panic(s)
+	}
+}
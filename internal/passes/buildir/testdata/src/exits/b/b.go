@@ -0,0 +1,39 @@
+package b
+
+import (
+	"os"
+
+	"exits/a"
+)
+
+// The conditions under which functions in a exit have to survive
+// being encoded as facts.
+
+func exitTrue() { // want exitTrue:`noReturn\(never returns\)`
+	a.ExitIf(true)
+}
+
+func exitFalse() {
+	a.ExitIf(false)
+}
+
+func exitCode() { // want exitCode:`noReturn\(never returns\)`
+	a.ExitCode(2)
+}
+
+func panicFatal() { // want panicFatal:`noReturn\(unwinds\)`
+	a.PanicOn("fatal")
+}
+
+func panicOther() {
+	a.PanicOn("other")
+}
+
+func Forward(b bool) { // want Forward:`exitsIf\(\$0 == true: never returns\)`
+	a.ExitIf(b)
+}
+
+func deferredExit() { // want deferredExit:`noReturn\(never returns\)`
+	defer os.Exit(1)
+	println()
+}
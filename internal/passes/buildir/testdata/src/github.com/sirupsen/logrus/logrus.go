@@ -0,0 +1,68 @@
+// Package logrus mirrors the parts of github.com/sirupsen/logrus that
+// buildExits knows about.
+package logrus
+
+import "os"
+
+type Level uint32
+
+const (
+	PanicLevel Level = iota
+	FatalLevel
+)
+
+type Hook interface {
+	Fire(*Entry) error
+}
+
+type Logger struct {
+	Hooks    []Hook
+	ExitFunc func(int)
+	level    Level
+}
+
+type Entry struct {
+	Logger *Logger
+}
+
+func (logger *Logger) IsLevelEnabled(level Level) bool {
+	return logger.level >= level
+}
+
+func (logger *Logger) Exit(code int) { // want Exit:`noReturn\(exits\)`
+	if logger.ExitFunc == nil {
+		logger.ExitFunc = os.Exit
+	}
+	logger.ExitFunc(code)
+}
+
+func (logger *Logger) Log(level Level, args ...interface{}) { // want Log:`exitsIf\(\$1 == 0: unwinds\)`
+	if logger.IsLevelEnabled(level) {
+		entry := &Entry{Logger: logger}
+		entry.Log(level, args...)
+	}
+}
+
+func (logger *Logger) Fatal(args ...interface{}) { // want Fatal:`noReturn\(exits\)`
+	logger.Log(FatalLevel, args...)
+	logger.Exit(1)
+}
+
+func (logger *Logger) Panic(args ...interface{}) { // want Panic:`noReturn\(unwinds\)`
+	logger.Log(PanicLevel, args...)
+}
+
+func (entry *Entry) Log(level Level, args ...interface{}) { // want Log:`exitsIf\(\$1 == 0: unwinds\)`
+	if entry.Logger.IsLevelEnabled(level) {
+		entry.log(level)
+	}
+}
+
+func (entry *Entry) log(level Level) {
+	for _, hook := range entry.Logger.Hooks {
+		hook.Fire(entry)
+	}
+	if level <= PanicLevel {
+		panic(entry)
+	}
+}
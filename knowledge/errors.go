@@ -0,0 +1,23 @@
+package knowledge
+
+// IgnoredErrors lists functions in the standard library whose error
+// results are conventionally discarded, because they can only fail in
+// ways that callers cannot reasonably handle, or because they are
+// documented to never fail. Functions whose error results are
+// provably always nil, such as (*bytes.Buffer).Write, don't need to
+// be listed.
+var IgnoredErrors = []string{
+	"fmt.Print",
+	"fmt.Printf",
+	"fmt.Println",
+	"fmt.Fprint",
+	"fmt.Fprintf",
+	"fmt.Fprintln",
+
+	// Documented to always return a nil error.
+	"math/rand.Read",
+	"(*math/rand.Rand).Read",
+	"(*hash/maphash.Hash).Write",
+	"(*hash/maphash.Hash).WriteByte",
+	"(*hash/maphash.Hash).WriteString",
+}
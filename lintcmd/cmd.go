@@ -7,6 +7,7 @@ import (
 	"encoding/gob"
 	"flag"
 	"fmt"
+	"go/build"
 	"go/token"
 	stdversion "go/version"
 	"io"
@@ -18,17 +19,20 @@ import (
 	"runtime/pprof"
 	"runtime/trace"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/rules"
 	"honnef.co/go/tools/config"
 	"honnef.co/go/tools/go/loader"
 	"honnef.co/go/tools/lintcmd/version"
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/packages"
 )
 
 type buildConfig struct {
@@ -54,12 +58,16 @@ type Command struct {
 
 		// mutually exclusive mode flags
 		explain      string
+		explainUse   string
 		printVersion bool
 		listChecks   bool
 		merge        bool
 
 		matrix bool
 
+		config string
+		unused config.UnusedConfig
+
 		debugCpuprofile       string
 		debugMemprofile       string
 		debugVersion          bool
@@ -145,9 +153,11 @@ func (cmd *Command) initFlagSet(name string) {
 	flags.BoolVar(&cmd.flags.showIgnored, "show-ignored", false, "Don't filter ignored diagnostics")
 	flags.StringVar(&cmd.flags.formatter, "f", "text", "Output `format` (valid choices are 'stylish', 'text' and 'json')")
 	flags.StringVar(&cmd.flags.explain, "explain", "", "Print description of `check`")
+	flags.StringVar(&cmd.flags.explainUse, "explain-use", "", "Print why `pkg.Object` is considered used by U1000")
 	flags.BoolVar(&cmd.flags.listChecks, "list-checks", false, "List all available checks")
 	flags.BoolVar(&cmd.flags.merge, "merge", false, "Merge results of multiple Staticcheck runs")
 	flags.BoolVar(&cmd.flags.matrix, "matrix", false, "Read a build config matrix from stdin")
+	flags.StringVar(&cmd.flags.config, "config", "", "Load configuration from `file`, on top of discovered configuration files")
 
 	flags.StringVar(&cmd.flags.debugCpuprofile, "debug.cpuprofile", "", "Write CPU profile to `file`")
 	flags.StringVar(&cmd.flags.debugMemprofile, "debug.memprofile", "", "Write memory profile to `file`")
@@ -162,8 +172,50 @@ func (cmd *Command) initFlagSet(name string) {
 	flags.Var(&cmd.flags.checks, "checks", "Comma-separated list of `checks` to enable.")
 	flags.Var(&cmd.flags.fail, "fail", "Comma-separated list of `checks` that can cause a non-zero exit status.")
 	flags.Var(&cmd.flags.goVersion, "go", "Target Go `version` in the format '1.x', or the literal 'module' to use the module's Go version")
+
+	unusedFlags := []struct {
+		name  string
+		value **bool
+		usage string
+	}{
+		{"field-writes-are-uses", &cmd.flags.unused.FieldWritesAreUses, "Treat writes to fields as uses"},
+		{"post-statements-are-reads", &cmd.flags.unused.PostStatementsAreReads, "Treat x++ and x += 1 as reads of x"},
+		{"exported-is-used", &cmd.flags.unused.ExportedIsUsed, "Treat exported identifiers as used"},
+		{"exported-fields-are-used", &cmd.flags.unused.ExportedFieldsAreUsed, "Treat exported fields as used"},
+		{"parameters-are-used", &cmd.flags.unused.ParametersAreUsed, "Treat function parameters as used"},
+		{"local-variables-are-used", &cmd.flags.unused.LocalVariablesAreUsed, "Treat local variables as used"},
+		{"generated-is-used", &cmd.flags.unused.GeneratedIsUsed, "Treat identifiers in generated files as used"},
+		{"whole-program", &cmd.flags.unused.WholeProgram, "Flag exported identifiers that aren't used by any of the analyzed packages"},
+	}
+	for _, f := range unusedFlags {
+		flags.Var(optionalBool{f.value}, "unused."+f.name, f.usage+" (U1000). Overrides the corresponding configuration option.")
+	}
+}
+
+// optionalBool is a boolean flag that remembers whether it has been
+// set. It stores its value in the pointer that ptr points to.
+type optionalBool struct {
+	ptr **bool
 }
 
+func (b optionalBool) String() string {
+	if b.ptr == nil || *b.ptr == nil {
+		return ""
+	}
+	return strconv.FormatBool(**b.ptr)
+}
+
+func (b optionalBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*b.ptr = &v
+	return nil
+}
+
+func (b optionalBool) IsBoolFlag() bool { return true }
+
 type list []string
 
 func (list *list) String() string {
@@ -310,6 +362,8 @@ func (cmd *Command) Execute() int {
 		exit = cmd.printVersion()
 	case cmd.flags.explain != "":
 		exit = cmd.explain()
+	case cmd.flags.explainUse != "":
+		exit = cmd.explainUse()
 	case cmd.flags.merge:
 		exit = cmd.merge()
 	default:
@@ -423,6 +477,162 @@ func (cmd *Command) merge() int {
 	return cmd.printDiagnostics(cs, relevantDiagnostics)
 }
 
+// applyConfigFlags applies the -config and -unused.* flags to the configuration.
+func (cmd *Command) applyConfigFlags() int {
+	if path := cmd.flags.config; path != "" {
+		cfg, err := config.LoadFile(path)
+		if err != nil {
+			if perr, ok := err.(config.ParseError); ok {
+				fmt.Fprintf(os.Stderr, "%s:%d:%d couldn't parse configuration: %s\n", perr.Filename, perr.Position.Line, perr.Position.Col, perr.Message)
+			} else {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("couldn't load configuration: %s", err))
+			}
+			return 2
+		}
+		config.Explicit = &cfg
+	}
+	if !reflect.DeepEqual(cmd.flags.unused, config.UnusedConfig{}) {
+		// Flags take precedence over all configuration files.
+		if config.Explicit == nil {
+			config.Explicit = &config.Config{}
+		}
+		config.Explicit.Unused = config.Explicit.Unused.Merge(cmd.flags.unused)
+	}
+	return 0
+}
+
+// addRules adds analyzers for the rules declared by the configurations
+// of the packages we lint, as well as by the configuration that applies
+// to the working directory. Each rule only runs on packages whose
+// configuration declares it.
+func (cmd *Command) addRules(bconfs []buildConfig) int {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = ""
+	}
+
+	// Finding the packages' configurations requires loading the
+	// packages, which we avoid if no configuration file sets rules.
+	declared := false
+	for _, root := range patternRoots(cwd, cmd.flags.fs.Args()) {
+		ok, err := config.DeclaresRules(root)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, fmt.Errorf("couldn't load configuration: %s", err))
+			return 2
+		}
+		if ok {
+			declared = true
+			break
+		}
+	}
+	if !declared {
+		return 0
+	}
+
+	dirs, err := packageDirs(cmd.flags.fs.Args(), cmd.flags.tests, bconfs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	dirs = append([]string{cwd}, dirs...)
+
+	var all []config.Rule
+	byID := map[string]config.Rule{}
+	for _, dir := range dirs {
+		cfg, err := config.Load(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, fmt.Errorf("couldn't load configuration: %s", err))
+			return 2
+		}
+		for _, r := range cfg.Rules {
+			if other, ok := byID[r.ID]; ok {
+				if other.File != r.File {
+					fmt.Fprintf(os.Stderr, "rule %s is declared in both %s and %s\n", r.ID, other.File, r.File)
+					return 2
+				}
+				continue
+			}
+			byID[r.ID] = r
+			all = append(all, r)
+		}
+	}
+
+	as, err := rules.Analyzers(all)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("couldn't load rules: %s", err))
+		return 2
+	}
+	for _, a := range as {
+		if _, ok := cmd.analyzers[a.Analyzer.Name]; ok {
+			fmt.Fprintf(os.Stderr, "rule %s has the same name as an existing check\n", a.Analyzer.Name)
+			return 2
+		}
+	}
+	cmd.AddAnalyzers(as...)
+	return 0
+}
+
+// patternRoots returns the directories that contain the packages that
+// patterns may match. Patterns that aren't file system paths, such as
+// import paths, are assumed to match packages in the module that
+// contains cwd.
+func patternRoots(cwd string, patterns []string) []string {
+	var out []string
+	if cwd != "" {
+		out = append(out, cwd)
+	}
+	for _, p := range patterns {
+		if !build.IsLocalImport(p) && !filepath.IsAbs(p) {
+			continue
+		}
+		if i := strings.Index(p, "..."); i >= 0 {
+			p = p[:i]
+			if !strings.HasSuffix(p, "/") {
+				// ./foo... matches ./foo and ./foobar
+				p = filepath.Dir(p)
+			}
+		}
+		if strings.HasSuffix(p, ".go") {
+			p = filepath.Dir(p)
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(cwd, p)
+		}
+		out = append(out, filepath.Clean(p))
+	}
+	return out
+}
+
+// packageDirs returns the directories of the packages that patterns
+// match, in any of the build configurations.
+func packageDirs(patterns []string, tests bool, bconfs []buildConfig) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, bconf := range bconfs {
+		cfg := &packages.Config{
+			Mode:       packages.NeedName | packages.NeedFiles,
+			Tests:      tests,
+			BuildFlags: bconf.Flags,
+			Env:        append(os.Environ(), bconf.Envs...),
+		}
+		pkgs, err := packages.Load(cfg, patterns...)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load packages: %s", err)
+		}
+		for _, pkg := range pkgs {
+			if len(pkg.GoFiles) == 0 {
+				continue
+			}
+			dir := filepath.Dir(pkg.GoFiles[0])
+			if !seen[dir] {
+				seen[dir] = true
+				out = append(out, dir)
+			}
+		}
+	}
+	return out, nil
+}
+
 func (cmd *Command) lint() int {
 	switch cmd.flags.formatter {
 	case "text", "stylish", "json", "sarif", "binary", "null":
@@ -465,6 +675,13 @@ func (cmd *Command) lint() int {
 		bconfs = append(bconfs, bc)
 	}
 
+	if exit := cmd.applyConfigFlags(); exit != 0 {
+		return exit
+	}
+	if exit := cmd.addRules(bconfs); exit != 0 {
+		return exit
+	}
+
 	var measureAnalyzers func(analysis *analysis.Analyzer, pkg *loader.PackageSpec, d time.Duration)
 	if path := cmd.flags.debugMeasureAnalyzers; path != "" {
 		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
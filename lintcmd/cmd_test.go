@@ -1,7 +1,12 @@
 package lintcmd
 
 import (
+	"encoding/json"
 	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -43,3 +48,81 @@ func TestParsePos(t *testing.T) {
 		}
 	}
 }
+
+func TestPatternRoots(t *testing.T) {
+	cwd := filepath.FromSlash("/home/user/mod")
+	got := patternRoots(cwd, []string{"./...", "./pkg/...", "./cmd...", "../other", "example.com/mod/pkg", "/abs/dir/...", "file.go"})
+	var want []string
+	for _, dir := range []string{"/home/user/mod", "/home/user/mod", "/home/user/mod/pkg", "/home/user/mod", "/home/user/other", "/abs/dir"} {
+		want = append(want, filepath.FromSlash(dir))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRulesInPackageConfig(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":  "module example.com/mod\n\ngo 1.18\n",
+		"main.go": "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n",
+		// Only the configuration of the sub package declares the rule.
+		"sub/staticcheck.conf": `rules = ["acme.rules"]`,
+		"sub/acme.rules": `[[rule]]
+id = "ACME1000"
+message = "don't print"
+match = '(CallExpr (Symbol "fmt.Println") _)'
+`,
+		"sub/sub.go": "package sub\n\nimport \"fmt\"\n\nfunc Fn() { fmt.Println() }\n",
+	})
+	t.Setenv("STATICCHECK_CACHE", filepath.Join(root, "cache"))
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	out, err := os.Create(filepath.Join(root, "out.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	cmd := NewCommand("staticcheck")
+	cmd.ParseFlags([]string{"-f", "json", "./..."})
+	exit := cmd.Execute()
+	os.Stdout = stdout
+	if exit == 2 {
+		t.Fatal("linting failed")
+	}
+
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	dec := json.NewDecoder(out)
+	for {
+		var diag struct {
+			Code     string `json:"code"`
+			Location struct {
+				File string `json:"file"`
+			} `json:"location"`
+		}
+		if err := dec.Decode(&diag); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if diag.Code == "ACME1000" {
+			files = append(files, filepath.Base(diag.Location.File))
+		}
+	}
+	if want := []string{"sub.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("got ACME1000 in %q, want %q", files, want)
+	}
+}
@@ -4,7 +4,14 @@
 package lintcmd
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"honnef.co/go/tools/config"
+	"honnef.co/go/tools/knowledge"
 )
 
 var buildConfigTests = []struct {
@@ -93,3 +100,307 @@ func FuzzParseBuildConfig(f *testing.F) {
 		parseBuildConfig(in)
 	})
 }
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConfigDiscovery(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		dir      string
+		explicit string
+		checks   []string
+	}{
+		{
+			name: "stops at module root",
+			files: map[string]string{
+				"staticcheck.conf":           `checks = ["inherit", "-SA1000"]`,
+				"mod/go.mod":                 "module example.com/mod\n",
+				"mod/staticcheck.conf":       `checks = ["inherit", "-SA2000"]`,
+				"mod/pkg/staticcheck.conf":   `checks = ["inherit", "-SA3000"]`,
+				"mod/pkg/sub/placeholder.go": "package sub\n",
+			},
+			dir:    "mod/pkg/sub",
+			checks: []string{"all", "-SA2000", "-SA3000"},
+		},
+		{
+			name: "stops at workspace root",
+			files: map[string]string{
+				"staticcheck.conf":      `checks = ["inherit", "-SA1000"]`,
+				"ws/go.work":            "go 1.22\n",
+				"ws/staticcheck.conf":   `checks = ["inherit", "-SA2000"]`,
+				"ws/mod/go.mod":         "module example.com/mod\n",
+				"ws/mod/placeholder.go": "package mod\n",
+			},
+			dir:    "ws/mod",
+			checks: []string{"all"},
+		},
+		{
+			name: "root key",
+			files: map[string]string{
+				"go.mod":                   "module example.com/mod\n",
+				"staticcheck.conf":         `checks = ["inherit", "-SA1000"]`,
+				"pkg/staticcheck.conf":     "root = true\n" + `checks = ["inherit", "-SA2000"]`,
+				"pkg/sub/staticcheck.conf": `checks = ["inherit", "-SA3000"]`,
+			},
+			dir:    "pkg/sub",
+			checks: []string{"all", "-SA2000", "-SA3000"},
+		},
+		{
+			name: "explicit on top of discovery",
+			files: map[string]string{
+				"go.mod":           "module example.com/mod\n",
+				"staticcheck.conf": `checks = ["inherit", "-SA1000"]`,
+				"explicit.conf":    `checks = ["inherit", "-SA2000"]`,
+			},
+			dir:      ".",
+			explicit: "explicit.conf",
+			checks:   []string{"all", "-SA1000", "-SA2000"},
+		},
+		{
+			name: "explicit root replaces discovery",
+			files: map[string]string{
+				"go.mod":           "module example.com/mod\n",
+				"staticcheck.conf": `checks = ["inherit", "-SA1000"]`,
+				"explicit.conf":    "root = true\n" + `checks = ["inherit", "-SA2000"]`,
+			},
+			dir:      ".",
+			explicit: "explicit.conf",
+			checks:   []string{"all", "-SA2000"},
+		},
+		{
+			name: "json",
+			files: map[string]string{
+				"go.mod":               "module example.com/mod\n",
+				"staticcheck.conf":     `checks = ["inherit", "-SA1000"]`,
+				"pkg/staticcheck.json": `{"$schema": "https://staticcheck.dev/staticcheck.schema.json", "checks": ["inherit", "-SA2000"]}`,
+			},
+			dir:    "pkg",
+			checks: []string{"all", "-SA1000", "-SA2000"},
+		},
+		{
+			name: "explicit json",
+			files: map[string]string{
+				"go.mod":        "module example.com/mod\n",
+				"explicit.json": `{"root": true, "checks": ["inherit", "-SA2000"]}`,
+			},
+			dir:      ".",
+			explicit: "explicit.json",
+			checks:   []string{"all", "-SA2000"},
+		},
+	}
+
+	defer func(checks []string) { config.DefaultConfig.Checks = checks }(config.DefaultConfig.Checks)
+	config.DefaultConfig.Checks = []string{"all"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFiles(t, root, tt.files)
+
+			if tt.explicit != "" {
+				cfg, err := config.LoadFile(filepath.Join(root, tt.explicit))
+				if err != nil {
+					t.Fatal(err)
+				}
+				config.Explicit = &cfg
+				defer func() { config.Explicit = nil }()
+			}
+
+			cfg, err := config.Load(filepath.Join(root, filepath.FromSlash(tt.dir)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg.Checks, tt.checks) {
+				t.Errorf("got checks %q, want %q", cfg.Checks, tt.checks)
+			}
+			if cfg.Root {
+				t.Errorf("merged configuration shouldn't have Root set")
+			}
+		})
+	}
+}
+
+func TestConfigDiscoveryAmbiguous(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":           "module example.com/mod\n",
+		"staticcheck.conf": `checks = ["all"]`,
+		"staticcheck.json": `{"checks": ["all"]}`,
+	})
+	if _, err := config.Load(root); err == nil {
+		t.Fatal("expected error for directory with both staticcheck.conf and staticcheck.json")
+	}
+}
+
+func TestConfigJSONSyntaxError(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":           "module example.com/mod\n",
+		"staticcheck.json": "{\n\t\"checks\": [\"all\",]\n}",
+	})
+	_, err := config.Load(root)
+	if err == nil {
+		t.Fatal("expected syntax error")
+	}
+	want := filepath.Join(root, "staticcheck.json") + ":2:19: "
+	if got := err.Error(); len(got) < len(want) || got[:len(want)] != want {
+		t.Errorf("got error %q, want prefix %q", got, want)
+	}
+}
+
+func TestConfigFunctions(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod": "module example.com/mod\n",
+		"staticcheck.conf": `[functions]
+"example.com/log.Fatal" = ["noreturn"]
+"example.com/log.Infof" = ["printf:0"]`,
+		"pkg/staticcheck.conf": `[functions]
+"example.com/log.Fatal" = ["inherit", "printf:0"]
+"example.com/log.Infof" = ["pure"]`,
+	})
+	cfg, err := config.Load(filepath.Join(root, "pkg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := cfg.Function("example.com/log.Fatal")
+	if want := (config.FunctionSemantics{NoReturn: true, Printf: 0}); !ok || got != want {
+		t.Errorf("got %+v for Fatal, want %+v", got, want)
+	}
+	got, ok = cfg.Function("example.com/log.Infof")
+	if want := (config.FunctionSemantics{Pure: true, Printf: -1}); !ok || got != want {
+		t.Errorf("got %+v for Infof, want %+v", got, want)
+	}
+	if _, ok := cfg.Function("example.com/log.Debugf"); ok {
+		t.Errorf("got semantics for undeclared function")
+	}
+
+	writeFiles(t, root, map[string]string{
+		"bad/staticcheck.conf": `[functions]
+"example.com/log.Fatal" = ["exits"]`,
+	})
+	if _, err := config.Load(filepath.Join(root, "bad")); err == nil {
+		t.Fatal("expected error for unknown function semantics")
+	}
+}
+
+func TestConfigIgnoredErrors(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":               "module example.com/mod\n",
+		"staticcheck.conf":     `ignored_errors = ["inherit", "example.com/log.Flush"]`,
+		"pkg/staticcheck.conf": `ignored_errors = ["example.com/log.Close"]`,
+	})
+	cfg, err := config.Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(append([]string(nil), knowledge.IgnoredErrors...), "example.com/log.Flush")
+	if !reflect.DeepEqual(cfg.IgnoredErrors, want) {
+		t.Errorf("got %q, want %q", cfg.IgnoredErrors, want)
+	}
+
+	cfg, err = config.Load(filepath.Join(root, "pkg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/log.Close"}; !reflect.DeepEqual(cfg.IgnoredErrors, want) {
+		t.Errorf("got %q, want %q", cfg.IgnoredErrors, want)
+	}
+}
+
+func TestConfigRules(t *testing.T) {
+	const rule = `[[rule]]
+id = "ACME1000"
+message = "use $buf.WriteString"
+match = '(CallExpr (Symbol "fmt.Fprint") [buf s])'
+replace = '(CallExpr (SelectorExpr buf (Ident "WriteString")) [s])'
+`
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":               "module example.com/mod\n",
+		"staticcheck.conf":     `rules = ["lint/acme.rules"]`,
+		"lint/acme.rules":      rule,
+		"pkg/staticcheck.conf": `rules = ["inherit", "../lint/more.rules"]`,
+		"lint/more.rules":      strings.Replace(rule, "ACME1000", "ACME1001", 1),
+		"off/staticcheck.conf": `rules = []`,
+	})
+	cfg, err := config.Load(filepath.Join(root, "pkg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range cfg.Rules {
+		ids = append(ids, r.ID)
+	}
+	if want := []string{"ACME1000", "ACME1001"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got rules %q, want %q", ids, want)
+	}
+	if want := filepath.Join(root, "lint", "acme.rules"); cfg.Rules[0].File != want {
+		t.Errorf("got file %q, want %q", cfg.Rules[0].File, want)
+	}
+
+	cfg, err = config.Load(filepath.Join(root, "off"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Rules) != 0 {
+		t.Errorf("got %d rules, want none", len(cfg.Rules))
+	}
+
+	writeFiles(t, root, map[string]string{
+		"dup/staticcheck.conf":  `rules = ["inherit", "dup.rules"]`,
+		"dup/dup.rules":         rule,
+		"bad/staticcheck.conf":  `rules = ["bad.rules"]`,
+		"bad/bad.rules":         strings.Replace(rule, "[buf s]", "[buf]", 1),
+		"pred/staticcheck.conf": `rules = ["pred.rules"]`,
+		"pred/pred.rules":       strings.Replace(rule, "[buf s])'", "[buf (Predicate \"acme.unknown\" s)])'", 1),
+	})
+	if _, err := config.Load(filepath.Join(root, "dup")); err == nil {
+		t.Error("expected error for duplicate rule ID")
+	}
+	if _, err := config.Load(filepath.Join(root, "bad")); err == nil {
+		t.Error("expected error for replacement using unbound binding")
+	}
+	if _, err := config.Load(filepath.Join(root, "pred")); err == nil {
+		t.Error("expected error for unknown predicate")
+	}
+}
+
+func TestConfigDeclaresRules(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                    "module example.com/mod\n",
+		"staticcheck.conf":          `checks = ["all"]`,
+		"a/staticcheck.conf":        `checks = ["inherit"]`,
+		"testdata/staticcheck.conf": `rules = ["acme.rules"]`,
+	})
+	for _, dir := range []string{root, filepath.Join(root, "a")} {
+		if ok, err := config.DeclaresRules(dir); err != nil || ok {
+			t.Errorf("DeclaresRules(%q) = %t, %v, want false", dir, ok, err)
+		}
+	}
+
+	// Rules declared anywhere in the module may apply to the packages
+	// in the module's root.
+	writeFiles(t, root, map[string]string{
+		"a/b/staticcheck.conf": `rules = ["acme.rules"]`,
+	})
+	for _, dir := range []string{root, filepath.Join(root, "a")} {
+		if ok, err := config.DeclaresRules(dir); err != nil || !ok {
+			t.Errorf("DeclaresRules(%q) = %t, %v, want true", dir, ok, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package lintcmd
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"strings"
+
+	"honnef.co/go/tools/analysis/facts/directives"
+	"honnef.co/go/tools/analysis/facts/generated"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/config"
+	"honnef.co/go/tools/go/loader"
+	"honnef.co/go/tools/lintcmd/cache"
+	"honnef.co/go/tools/unused"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// splitObjectQuery splits a query of the form "import/path.Object" or "import/path.Type.Method" into the package path
+// and the names of the object and, optionally, of the field or method.
+func splitObjectQuery(query string) (pkgPath string, names []string, ok bool) {
+	slash := strings.LastIndex(query, "/")
+	dot := strings.Index(query[slash+1:], ".")
+	if dot == -1 {
+		return "", nil, false
+	}
+	dot += slash + 1
+	names = strings.Split(query[dot+1:], ".")
+	if len(names) > 2 || names[0] == "" || (len(names) == 2 && names[1] == "") {
+		return "", nil, false
+	}
+	return query[:dot], names, true
+}
+
+func lookupObject(pkg *types.Package, names []string) types.Object {
+	obj := pkg.Scope().Lookup(names[0])
+	if obj == nil || len(names) == 1 {
+		return obj
+	}
+	if _, ok := obj.(*types.TypeName); !ok {
+		return nil
+	}
+	sel, _, _ := types.LookupFieldOrMethod(obj.Type(), true, pkg, names[1])
+	return sel
+}
+
+// explainUse prints the reason for why an object isn't flagged by U1000.
+func (cmd *Command) explainUse() int {
+	query := cmd.flags.explainUse
+	pkgPath, names, ok := splitObjectQuery(query)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "invalid value %q for flag -explain-use, expected import/path.Object or import/path.Type.Member\n", query)
+		return 2
+	}
+	if exit := cmd.applyConfigFlags(); exit != 0 {
+		return exit
+	}
+
+	c, err := cache.Default()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	cfg := &packages.Config{Tests: cmd.flags.tests}
+	if cmd.flags.tags != "" {
+		cfg.BuildFlags = []string{"-tags", cmd.flags.tags}
+	}
+	specs, err := loader.Graph(c, cfg, pkgPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	found := false
+	for _, spec := range specs {
+		if spec.PkgPath != pkgPath {
+			continue
+		}
+		if len(spec.Errors) != 0 {
+			fmt.Fprintf(os.Stderr, "couldn't load %s: %s\n", spec, spec.Errors[0])
+			return 1
+		}
+		lpkg, _, err := loader.Load(spec, &loader.Options{GoVersion: string(cmd.flags.goVersion)})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(lpkg.Errors) != 0 {
+			fmt.Fprintf(os.Stderr, "couldn't load %s: %s\n", spec, lpkg.Errors[0])
+			return 1
+		}
+		obj := lookupObject(lpkg.Types, names)
+		if obj == nil {
+			continue
+		}
+		found = true
+
+		pass := &analysis.Pass{
+			Fset:      lpkg.Fset,
+			Files:     lpkg.Syntax,
+			Pkg:       lpkg.Types,
+			TypesInfo: lpkg.TypesInfo,
+			ResultOf: map[*analysis.Analyzer]interface{}{
+				config.Analyzer:     &spec.Config,
+				directives.Analyzer: lint.ParseDirectives(lpkg.Syntax, lpkg.Fset),
+			},
+		}
+		gen, err := generated.Analyzer.Run(pass)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		pass.ResultOf[generated.Analyzer] = gen
+
+		chain := unused.Explain(pass, obj)
+		if chain == nil {
+			continue
+		}
+		fmt.Printf("%s is used (in %s):\n", query, spec)
+		for _, u := range chain {
+			fmt.Printf("\t%s\n", u)
+		}
+		return 0
+	}
+
+	if !found {
+		fmt.Fprintf(os.Stderr, "couldn't find %s\n", query)
+		return 1
+	}
+	fmt.Printf("%s is unused\n", query)
+	return 0
+}
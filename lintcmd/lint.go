@@ -180,6 +180,12 @@ func (l *linter) lint(r *runner.Runner, cfg *packages.Config, patterns []string)
 	}
 	used := map[unusedKey]bool{}
 	var unuseds []unusedPair
+	// In whole-program mode, U1000 returns graphs instead of results. They get merged across all packages, and
+	// results are computed at the end.
+	var wholeProgram unused.SerializedGraph
+	var haveWholeProgram bool
+	// The import paths of files that U1000 may flag objects in, in whole-program mode.
+	wholeProgramFiles := map[string]string{}
 	for _, res := range results {
 		if len(res.Errors) > 0 && !res.Failed {
 			panic("package has errors but isn't marked as failed")
@@ -217,6 +223,16 @@ func (l *linter) lint(r *runner.Runner, cfg *packages.Config, patterns []string)
 			}
 			out.Diagnostics = append(out.Diagnostics, filtered...)
 
+			if len(resd.Unused.Graph) > 0 {
+				wholeProgram.Merge(resd.Unused.Graph)
+				haveWholeProgram = true
+				if allowedAnalyzers["U1000"] {
+					for _, f := range res.Package.GoFiles {
+						wholeProgramFiles[f] = res.Package.PkgPath
+					}
+				}
+			}
+
 			for _, obj := range resd.Unused.Used {
 				// Note: a side-effect of this code is that fields in instantiated structs are handled correctly. Even
 				// if only an instantiated field is marked as used, we will not flag the generic field, because it has
@@ -250,15 +266,63 @@ func (l *linter) lint(r *runner.Runner, cfg *packages.Config, patterns []string)
 		}
 	}
 
+	if haveWholeProgram {
+		ures := wholeProgram.Results()
+		for _, obj := range ures.Used {
+			if pkgPath, ok := wholeProgramFiles[obj.Position.Filename]; ok {
+				key := unusedKey{
+					pkgPath: pkgPath,
+					base:    filepath.Base(obj.Position.Filename),
+					line:    obj.Position.Line,
+					name:    obj.Name,
+				}
+				used[key] = true
+			}
+		}
+		for _, obj := range ures.Unused {
+			pkgPath, ok := wholeProgramFiles[obj.Position.Filename]
+			if !ok {
+				// Objects in packages that weren't analyzed, or for which U1000 has been disabled.
+				continue
+			}
+			key := unusedKey{
+				pkgPath: pkgPath,
+				base:    filepath.Base(obj.Position.Filename),
+				line:    obj.Position.Line,
+				name:    obj.Name,
+			}
+			unuseds = append(unuseds, unusedPair{key, obj})
+			if _, ok := used[key]; !ok {
+				used[key] = false
+			}
+		}
+	}
+
 	for _, uo := range unuseds {
 		if used[uo.key] {
 			continue
 		}
+		var fixes []runner.SuggestedFix
+		if len(uo.obj.Fix) > 0 {
+			fix := runner.SuggestedFix{
+				Message:   fmt.Sprintf("remove unused %s %s", uo.obj.Kind, uo.obj.Name),
+				TextEdits: make([]runner.TextEdit, len(uo.obj.Fix)),
+			}
+			for i, e := range uo.obj.Fix {
+				fix.TextEdits[i] = runner.TextEdit{
+					Position: e.Position,
+					End:      e.End,
+					NewText:  []byte(e.NewText),
+				}
+			}
+			fixes = append(fixes, fix)
+		}
 		out.Diagnostics = append(out.Diagnostics, diagnostic{
 			Diagnostic: runner.Diagnostic{
-				Position: uo.obj.DisplayPosition,
-				Message:  fmt.Sprintf("%s %s is unused", uo.obj.Kind, uo.obj.Name),
-				Category: "U1000",
+				Position:       uo.obj.DisplayPosition,
+				Message:        fmt.Sprintf("%s %s is unused", uo.obj.Kind, uo.obj.Name),
+				Category:       "U1000",
+				SuggestedFixes: fixes,
 			},
 			MergeIf: lint.MergeIfAll,
 		})
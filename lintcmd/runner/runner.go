@@ -541,7 +541,10 @@ func (r *subrunner) do(act action) error {
 	hashCfg.Checks = nil
 	// note that we don't hash staticcheck's version; it is set as the
 	// salt by a package main.
-	fmt.Fprintf(h, "cfg %#v\n", hashCfg)
+	//
+	// We use the config's String method instead of %#v because the
+	// latter would include the addresses of pointers.
+	fmt.Fprintf(h, "cfg %s\n", hashCfg)
 	fmt.Fprintf(h, "pkg %x\n", a.Package.Hash)
 	fmt.Fprintf(h, "analyzers %s\n", r.analyzerNames)
 	fmt.Fprintf(h, "go %s\n", r.GoVersion)
@@ -252,6 +252,12 @@ func (o *sarifFormatter) Format(checks []*lint.Analyzer, diagnostics []diagnosti
 	}
 	for _, c := range checks {
 		doc := c.Doc.Compile()
+		var helpURI string
+		if c.Analyzer.URL != "" {
+			// Rules declared in rule files aren't documented on our
+			// website.
+			helpURI = "https://staticcheck.dev/docs/checks#" + c.Analyzer.Name
+		}
 		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules,
 			sarif.ReportingDescriptor{
 				// We don't set Name, as Name and ID mustn't be identical.
@@ -260,7 +266,7 @@ func (o *sarifFormatter) Format(checks []*lint.Analyzer, diagnostics []diagnosti
 					Text:     doc.Title,
 					Markdown: doc.TitleMarkdown,
 				},
-				HelpURI: "https://staticcheck.dev/docs/checks#" + c.Analyzer.Name,
+				HelpURI: helpURI,
 				// We use our markdown as the plain text version, too. We
 				// use very little markdown, primarily quotations,
 				// indented code blocks and backticks. All of these are
@@ -132,6 +132,55 @@ func ASTToNode(node interface{}) Node {
 	panic(fmt.Sprintf("internal error: unhandled type %T", node))
 }
 
+// CheckReplacement verifies that replace can be used to replace nodes
+// matched by match. Replacements get turned into code and thus may only
+// refer to bindings of match and mustn't use nodes that only make sense
+// when matching.
+func CheckReplacement(match, replace Pattern) error {
+	bound := map[string]bool{}
+	for _, name := range match.Bindings {
+		bound[name] = true
+	}
+	return checkReplacement(replace.Root, bound)
+}
+
+func checkReplacement(node Node, bound map[string]bool) error {
+	switch node := node.(type) {
+	case Binding:
+		if !bound[node.Name] {
+			return fmt.Errorf("%s isn't bound by the match pattern", node.Name)
+		}
+		if node.Node != nil {
+			return fmt.Errorf("cannot create binding %s", node.Name)
+		}
+		return nil
+	case Any, Or, Not, Many:
+		return fmt.Errorf("cannot use %s in replacement", node)
+	case List:
+		if node.Head == nil {
+			return nil
+		}
+		if err := checkReplacement(node.Head, bound); err != nil {
+			return err
+		}
+		return checkReplacement(node.Tail, bound)
+	case String, Token, Nil, nil:
+		return nil
+	}
+	v := reflect.ValueOf(node)
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Type().Field(i).IsExported() {
+			continue
+		}
+		if child, ok := v.Field(i).Interface().(Node); ok {
+			if err := checkReplacement(child, bound); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func NodeToAST(node Node, state State) interface{} {
 	switch node := node.(type) {
 	case Binding:
@@ -146,15 +195,21 @@ func NodeToAST(node Node, state State) interface{} {
 		default:
 			return v
 		}
-	case Builtin, Any, Object, Symbol, Not, Or:
+	case Builtin, Any, Object, Symbol, Not, Or, Many:
 		panic("XXX")
 	case List:
 		if (node == List{}) {
 			return []ast.Node{}
 		}
-		x := []ast.Node{NodeToAST(node.Head, state).(ast.Node)}
-		x = append(x, NodeToAST(node.Tail, state).([]ast.Node)...)
+		x := toNodes(NodeToAST(node.Head, state))
+		x = append(x, toNodes(NodeToAST(node.Tail, state))...)
 		return x
+	case Seq:
+		var stmts []ast.Stmt
+		for _, n := range toNodes(NodeToAST(node.Stmts, state)) {
+			stmts = append(stmts, toStmt(n))
+		}
+		return stmts
 	case Token:
 		return token.Token(node)
 	case String:
@@ -178,44 +233,15 @@ func NodeToAST(node Node, state State) interface{} {
 		fAST := out.Elem().FieldByName(T.Field(i).Name)
 		switch fAST.Type().Kind() {
 		case reflect.Slice:
-			c := reflect.ValueOf(NodeToAST(fNode.Interface().(Node), state))
-			if c.Kind() != reflect.Slice {
-				// it's a single node in the pattern, we have to wrap
-				// it in a slice
-				slice := reflect.MakeSlice(fAST.Type(), 1, 1)
-				slice.Index(0).Set(c)
-				c = slice
-			}
-			switch fAST.Interface().(type) {
-			case []ast.Node:
-				switch cc := c.Interface().(type) {
-				case []ast.Node:
-					fAST.Set(c)
-				case []ast.Expr:
-					var slice []ast.Node
-					for _, el := range cc {
-						slice = append(slice, el)
-					}
-					fAST.Set(reflect.ValueOf(slice))
-				default:
-					panic("XXX")
+			nodes := toNodes(NodeToAST(fNode.Interface().(Node), state))
+			slice := reflect.MakeSlice(fAST.Type(), 0, len(nodes))
+			for _, n := range nodes {
+				if fAST.Type().Elem() == stmtType {
+					n = toStmt(n)
 				}
-			case []ast.Expr:
-				switch cc := c.Interface().(type) {
-				case []ast.Node:
-					var slice []ast.Expr
-					for _, el := range cc {
-						slice = append(slice, el.(ast.Expr))
-					}
-					fAST.Set(reflect.ValueOf(slice))
-				case []ast.Expr:
-					fAST.Set(c)
-				default:
-					panic("XXX")
-				}
-			default:
-				panic("XXX")
+				slice = reflect.Append(slice, reflect.ValueOf(n))
 			}
+			fAST.Set(slice)
 		case reflect.Int:
 			c := reflect.ValueOf(NodeToAST(fNode.Interface().(Node), state))
 			switch c.Kind() {
@@ -233,6 +259,15 @@ func NodeToAST(node Node, state State) interface{} {
 			}
 		default:
 			r := NodeToAST(fNode.Interface().(Node), state)
+			if _, ok := r.(*ast.BlockStmt); !ok && r != nil && fAST.Type() == blockStmtType {
+				// Matching treats blocks as lists of statements, which
+				// is also what bindings of blocks contain.
+				block := &ast.BlockStmt{}
+				for _, n := range toNodes(r) {
+					block.List = append(block.List, toStmt(n))
+				}
+				r = block
+			}
 			if r != nil {
 				fAST.Set(reflect.ValueOf(r))
 			}
@@ -241,3 +276,40 @@ func NodeToAST(node Node, state State) interface{} {
 
 	return out.Interface().(ast.Node)
 }
+
+var (
+	stmtType      = reflect.TypeOf((*ast.Stmt)(nil)).Elem()
+	blockStmtType = reflect.TypeOf((*ast.BlockStmt)(nil))
+)
+
+// toNodes turns the result of NodeToAST into a list of nodes. Slices,
+// such as ranges of statements bound by Many nodes, are spliced into
+// the list, while single nodes become lists of one element.
+func toNodes(v interface{}) []ast.Node {
+	if n, ok := v.(ast.Node); ok {
+		return []ast.Node{n}
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		panic(fmt.Sprintf("internal error: unexpected type %T", v))
+	}
+	out := make([]ast.Node, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface().(ast.Node)
+	}
+	return out
+}
+
+// toStmt turns a node into a statement. Patterns don't distinguish
+// between expressions and expression statements, or declarations and
+// declaration statements.
+func toStmt(n ast.Node) ast.Stmt {
+	switch n := n.(type) {
+	case ast.Expr:
+		return &ast.ExprStmt{X: n}
+	case *ast.GenDecl:
+		return &ast.DeclStmt{Decl: n}
+	default:
+		return n.(ast.Stmt)
+	}
+}
@@ -144,8 +144,7 @@ would match any list with at least two elements, where the first two elements ar
 
 	(List "foo" (List "bar" _))
 
-Note that it is not possible to match from the end of the list.
-That is, there is no way to express a query such as "a list of any length where the last element is foo".
+Matching from the end of the list, or in its middle, requires the Many node, which is described below.
 
 Note that unlike in LISP, nil and empty lists are distinct from one another.
 In patterns, with respect to lists, nil is akin to Go's untyped nil.
@@ -244,6 +243,46 @@ and 'name' will either be a String if the first option matched, or an Ident or S
 
 The Not node negates a match. For example, (Not (Ident _)) will match all nodes that aren't identifiers.
 
+(TypeOf node type) matches an expression that matches node and whose type,
+printed with fully qualified package paths, matches type.
+Because type is matched against a string, it can be a String, a binding or an Or node.
+For example, the following pattern matches calls of Write on pointers to bytes.Buffer and strings.Builder:
+
+	(CallExpr (SelectorExpr (TypeOf _ (Or "*bytes.Buffer" "*strings.Builder")) (Ident "Write")) _)
+
+(Implements node type) and (AssignableTo node type) match an expression that matches node and whose type
+implements the interface named by type or is assignable to the type named by type, respectively.
+Here, type must be a String, such as "io.Reader", "*net/url.URL" or "[]byte".
+Named types are looked up in the transitive imports of the package being matched;
+if the type cannot be found, the node doesn't match.
+
+(Predicate name node) matches a node that matches node and that satisfies a predicate written in Go.
+Predicates are registered with RegisterPredicate, and they receive the Matcher and the matched node.
+This allows patterns to express conditions that the language cannot, while keeping the rest of the query declarative.
+Using a predicate that hasn't been registered is a parse error.
+
+(Many node) matches any number of consecutive elements of a list, each of which has to match node.
+Used as an element of a list, it matches as many elements as possible while still allowing the rest of the list to match.
+For example, the following pattern matches function bodies whose last statement is a bare return:
+
+	(FuncDecl _ _ _ [(Many _) (ReturnStmt [])])
+
+Bindings of Many nodes capture ranges of elements, such as the slice of statements between two other statements:
+
+	(FuncDecl _ _ _ [first body@(Many _) last])
+
+(Seq stmts) matches a list of statements, such as the body of a block, a case clause or a select clause,
+that contains stmts as a contiguous sequence of statements. Only the first such sequence in a list is matched.
+For example, the following pattern matches a variable declaration that is immediately followed by an assignment to the variable:
+
+	(Seq [(GenDecl "VAR" [(ValueSpec [name] typ [])]) (AssignStmt name "=" value)])
+
+The statements matched by a pattern whose root is Seq are available via Matcher.Stmts,
+and edits such as those created by code.MatchAndEdit replace only those statements, not the entire block.
+In replacements, Seq produces a sequence of statements, into which ranges bound by Many nodes are spliced:
+
+	(Seq [(GenDecl "VAR" [(ValueSpec [name] typ [value])])])
+
 ChanDir(0)
 
 # Automatic unnesting of AST nodes
@@ -6,6 +6,8 @@ import (
 	"go/token"
 	"go/types"
 	"reflect"
+	"strings"
+	"sync"
 
 	"golang.org/x/tools/go/ast/astutil"
 )
@@ -91,11 +93,26 @@ type State = map[string]any
 
 type Matcher struct {
 	TypesInfo *types.Info
-	State     State
+	// Pkg is the package whose syntax is being matched. Nodes such as
+	// Implements resolve the names of types, written like
+	// "*net/http.Request", by searching the transitive imports of
+	// Pkg. Names of types in packages that Pkg doesn't depend on
+	// don't resolve, and nodes using them don't match.
+	Pkg   *types.Package
+	State State
+	// Stmts are the statements matched by a pattern whose root is a
+	// Seq node.
+	Stmts []ast.Stmt
 
 	bindingsMapping []string
 
 	setBindings []uint64
+
+	// typeCache caches the types that names resolve to in typeCachePkg.
+	// Matchers can be reused for many matches in the same package,
+	// which avoids searching the import graph repeatedly.
+	typeCache    map[string]types.Type
+	typeCachePkg *types.Package
 }
 
 func (m *Matcher) set(b Binding, value interface{}) {
@@ -127,12 +144,16 @@ func (m *Matcher) merge() {
 func (m *Matcher) Match(a Pattern, b ast.Node) bool {
 	m.bindingsMapping = a.Bindings
 	m.State = State{}
+	m.Stmts = nil
 	m.push()
-	_, ok := match(m, a.Root, b)
+	ret, ok := match(m, a.Root, b)
 	m.merge()
 	if len(m.setBindings) != 0 {
 		panic(fmt.Sprintf("%d entries left on the stack, expected none", len(m.setBindings)))
 	}
+	if _, isSeq := a.Root.(Seq); isSeq && ok {
+		m.Stmts = ret.([]ast.Stmt)
+	}
 	return ok
 }
 
@@ -466,19 +487,103 @@ func (Any) Match(m *Matcher, node interface{}) (interface{}, bool) {
 func (l List) Match(m *Matcher, node interface{}) (interface{}, bool) {
 	v := reflect.ValueOf(node)
 	if v.Kind() == reflect.Slice {
-		if isNil(l.Head) {
-			return node, v.Len() == 0
+		_, ok := matchList(m, l, v, false)
+		return node, ok
+	}
+	// Our empty list does not equal an untyped Go nil. This way, we can
+	// tell apart an if with no else and an if with an empty else.
+	return nil, false
+}
+
+// matchList matches the list pattern l against the elements of v. If
+// prefix is true, l only has to match a prefix of v. It returns the
+// number of elements that were matched.
+func matchList(m *Matcher, l Node, v reflect.Value, prefix bool) (int, bool) {
+	list, ok := l.(List)
+	if !ok {
+		// The tail of a list, such as rest in [x:rest], matches all
+		// remaining elements.
+		_, ok := match(m, l, v.Interface())
+		return v.Len(), ok
+	}
+	if isNil(list.Head) {
+		return 0, prefix || v.Len() == 0
+	}
+	if isMany(list.Head) {
+		// Try the longest run of elements first, backtracking until
+		// the rest of the list matches.
+		for n := v.Len(); n >= 0; n-- {
+			m.push()
+			if _, ok := match(m, list.Head, v.Slice(0, n).Interface()); ok {
+				if k, ok := matchList(m, list.Tail, v.Slice(n, v.Len()), prefix); ok {
+					m.merge()
+					return n + k, true
+				}
+			}
+			m.pop()
 		}
-		if v.Len() == 0 {
+		return 0, false
+	}
+	if v.Len() == 0 {
+		return 0, false
+	}
+	if _, ok := match(m, list.Head, v.Index(0).Interface()); !ok {
+		return 0, false
+	}
+	k, ok := matchList(m, list.Tail, v.Slice(1, v.Len()), prefix)
+	return k + 1, ok
+}
+
+func isMany(node Node) bool {
+	switch node := node.(type) {
+	case Many:
+		return true
+	case Binding:
+		_, ok := node.Node.(Many)
+		return ok
+	default:
+		return false
+	}
+}
+
+func (many Many) Match(m *Matcher, node interface{}) (interface{}, bool) {
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Slice {
+		return nil, false
+	}
+	for i := 0; i < v.Len(); i++ {
+		if _, ok := match(m, many.Node, v.Index(i).Interface()); !ok {
 			return nil, false
 		}
-		// OPT(dh): don't check the entire tail if head didn't match
-		_, ok1 := match(m, l.Head, v.Index(0).Interface())
-		_, ok2 := match(m, l.Tail, v.Slice(1, v.Len()).Interface())
-		return node, ok1 && ok2
 	}
-	// Our empty list does not equal an untyped Go nil. This way, we can
-	// tell apart an if with no else and an if with an empty else.
+	return node, true
+}
+
+func (seq Seq) Match(m *Matcher, node interface{}) (interface{}, bool) {
+	var stmts []ast.Stmt
+	switch node := node.(type) {
+	case []ast.Stmt:
+		stmts = node
+	case *ast.CaseClause:
+		stmts = node.Body
+	case *ast.CommClause:
+		stmts = node.Body
+	default:
+		return nil, false
+	}
+	l, ok := seq.Stmts.(List)
+	if !ok {
+		l = List{Head: seq.Stmts, Tail: List{}}
+	}
+	v := reflect.ValueOf(stmts)
+	for i := range stmts {
+		m.push()
+		if n, ok := matchList(m, l, v.Slice(i, len(stmts)), true); ok && n > 0 {
+			m.merge()
+			return stmts[i : i+n], true
+		}
+		m.pop()
+	}
 	return nil, false
 }
 
@@ -690,6 +795,178 @@ func (texpr TrulyConstantExpression) Match(m *Matcher, node interface{}) (interf
 	return expr, ok
 }
 
+// A PredicateFunc reports whether a node that has been matched by a
+// Predicate's Node satisfies the predicate.
+type PredicateFunc func(m *Matcher, node interface{}) bool
+
+var (
+	predicatesMu sync.RWMutex
+	predicates   = map[string]PredicateFunc{}
+)
+
+// RegisterPredicate makes a Go function available to patterns as
+// (Predicate name node). It panics if name is already registered.
+//
+// The parser rejects patterns that use unknown predicates, so
+// predicates have to be registered before the patterns that use them
+// are parsed.
+func RegisterPredicate(name string, fn PredicateFunc) {
+	predicatesMu.Lock()
+	defer predicatesMu.Unlock()
+	if _, ok := predicates[name]; ok {
+		panic(fmt.Sprintf("predicate %q registered twice", name))
+	}
+	predicates[name] = fn
+}
+
+func lookupPredicate(name string) (PredicateFunc, bool) {
+	predicatesMu.RLock()
+	defer predicatesMu.RUnlock()
+	fn, ok := predicates[name]
+	return fn, ok
+}
+
+func (pred Predicate) Match(m *Matcher, node interface{}) (interface{}, bool) {
+	fn, ok := lookupPredicate(pred.Name)
+	if !ok {
+		// The parser rejects unknown predicates, but patterns can be
+		// constructed without it.
+		return nil, false
+	}
+	r, ok := match(m, pred.Node, node)
+	if !ok {
+		return nil, false
+	}
+	return r, fn(m, r)
+}
+
+// typeOf matches x against node and returns the matched expression
+// and its type.
+func typeOf(m *Matcher, x Node, node interface{}) (ast.Expr, types.Type, bool) {
+	r, ok := match(m, x, node)
+	if !ok {
+		return nil, nil, false
+	}
+	expr, ok := r.(ast.Expr)
+	if !ok {
+		return nil, nil, false
+	}
+	T := m.TypesInfo.TypeOf(expr)
+	if T == nil {
+		return nil, nil, false
+	}
+	return expr, T, true
+}
+
+func (typ TypeOf) Match(m *Matcher, node interface{}) (interface{}, bool) {
+	expr, T, ok := typeOf(m, typ.X, node)
+	if !ok {
+		return nil, false
+	}
+	_, ok = match(m, typ.Type, types.TypeString(T, nil))
+	return expr, ok
+}
+
+func (impl Implements) Match(m *Matcher, node interface{}) (interface{}, bool) {
+	expr, T, ok := typeOf(m, impl.X, node)
+	if !ok {
+		return nil, false
+	}
+	name, ok := impl.Type.(String)
+	if !ok {
+		return nil, false
+	}
+	iface, ok := m.lookupType(string(name)).Underlying().(*types.Interface)
+	if !ok {
+		return nil, false
+	}
+	return expr, types.Implements(T, iface)
+}
+
+func (assign AssignableTo) Match(m *Matcher, node interface{}) (interface{}, bool) {
+	expr, T, ok := typeOf(m, assign.X, node)
+	if !ok {
+		return nil, false
+	}
+	name, ok := assign.Type.(String)
+	if !ok {
+		return nil, false
+	}
+	V := m.lookupType(string(name))
+	if V == types.Typ[types.Invalid] {
+		return nil, false
+	}
+	return expr, types.AssignableTo(T, V)
+}
+
+// lookupType resolves the name of a type, such as "error",
+// "*bytes.Buffer" or "[]net/url.URL". It returns the invalid type if
+// the name cannot be resolved.
+func (m *Matcher) lookupType(name string) types.Type {
+	if m.typeCache == nil || m.typeCachePkg != m.Pkg {
+		m.typeCache = map[string]types.Type{}
+		m.typeCachePkg = m.Pkg
+	}
+	if T, ok := m.typeCache[name]; ok {
+		return T
+	}
+	T := m.resolveType(name)
+	m.typeCache[name] = T
+	return T
+}
+
+// resolveType implements lookupType, without caching.
+func (m *Matcher) resolveType(name string) types.Type {
+	invalid := types.Typ[types.Invalid]
+	switch {
+	case strings.HasPrefix(name, "*"):
+		T := m.lookupType(name[1:])
+		if T == invalid {
+			return invalid
+		}
+		return types.NewPointer(T)
+	case strings.HasPrefix(name, "[]"):
+		T := m.lookupType(name[2:])
+		if T == invalid {
+			return invalid
+		}
+		return types.NewSlice(T)
+	}
+
+	dot := strings.LastIndex(name, ".")
+	if dot == -1 {
+		if obj, ok := types.Universe.Lookup(name).(*types.TypeName); ok {
+			return obj.Type()
+		}
+		return invalid
+	}
+	if m.Pkg == nil {
+		return invalid
+	}
+	path, typName := name[:dot], name[dot+1:]
+
+	// Search the import graph breadth-first, as most types of interest
+	// are defined in direct imports.
+	seen := map[*types.Package]struct{}{}
+	q := []*types.Package{m.Pkg}
+	for len(q) > 0 {
+		pkg := q[0]
+		q = q[1:]
+		if _, ok := seen[pkg]; ok {
+			continue
+		}
+		seen[pkg] = struct{}{}
+		if pkg.Path() == path {
+			if obj, ok := pkg.Scope().Lookup(typName).(*types.TypeName); ok {
+				return obj.Type()
+			}
+			return invalid
+		}
+		q = append(q, pkg.Imports()...)
+	}
+	return invalid
+}
+
 var (
 	// Types of fields in go/ast structs that we want to skip
 	rtTokPos = reflect.TypeOf(token.Pos(0))
@@ -709,7 +986,13 @@ var (
 	_ matcher = Object{}
 	_ matcher = Symbol{}
 	_ matcher = Or{}
+	_ matcher = Many{}
+	_ matcher = Seq{}
 	_ matcher = Not{}
 	_ matcher = IntegerLiteral{}
 	_ matcher = TrulyConstantExpression{}
+	_ matcher = TypeOf{}
+	_ matcher = Implements{}
+	_ matcher = AssignableTo{}
+	_ matcher = Predicate{}
 )
@@ -37,6 +37,14 @@ func roots(node Node, m map[reflect.Type]struct{}) {
 		roots(node.Node, m)
 	case Binding:
 		roots(node.Node, m)
+	case TypeOf:
+		roots(node.X, m)
+	case Implements:
+		roots(node.X, m)
+	case AssignableTo:
+		roots(node.X, m)
+	case Predicate:
+		roots(node.Node, m)
 	case Nil, nil:
 		// this branch is reached via bindings
 		for _, T := range allTypes {
@@ -101,6 +109,7 @@ var nodeToASTTypes = map[reflect.Type][]reflect.Type{
 	reflect.TypeOf(String("")):                nil,
 	reflect.TypeOf(Token(0)):                  nil,
 	reflect.TypeOf(List{}):                    {reflect.TypeOf((*ast.BlockStmt)(nil)), reflect.TypeOf((*ast.FieldList)(nil))},
+	reflect.TypeOf(Many{}):                    {reflect.TypeOf((*ast.BlockStmt)(nil)), reflect.TypeOf((*ast.FieldList)(nil))},
 	reflect.TypeOf(Builtin{}):                 {reflect.TypeOf((*ast.Ident)(nil))},
 	reflect.TypeOf(Object{}):                  {reflect.TypeOf((*ast.Ident)(nil))},
 	reflect.TypeOf(Symbol{}):                  {reflect.TypeOf((*ast.Ident)(nil)), reflect.TypeOf((*ast.SelectorExpr)(nil))},
@@ -146,6 +155,7 @@ var nodeToASTTypes = map[reflect.Type][]reflect.Type{
 	reflect.TypeOf(BranchStmt{}):              {reflect.TypeOf((*ast.BranchStmt)(nil))},
 	reflect.TypeOf(IncDecStmt{}):              {reflect.TypeOf((*ast.IncDecStmt)(nil))},
 	reflect.TypeOf(BasicLit{}):                {reflect.TypeOf((*ast.BasicLit)(nil))},
+	reflect.TypeOf(Seq{}):                     {reflect.TypeOf((*ast.BlockStmt)(nil)), reflect.TypeOf((*ast.CaseClause)(nil)), reflect.TypeOf((*ast.CommClause)(nil))},
 	reflect.TypeOf(IntegerLiteral{}):          {reflect.TypeOf((*ast.BasicLit)(nil)), reflect.TypeOf((*ast.UnaryExpr)(nil))},
 	reflect.TypeOf(TrulyConstantExpression{}): allTypes, // this is an over-approximation, which is fine
 }
@@ -156,6 +166,10 @@ var requiresTypeInfo = map[string]bool{
 	"Object":                  true,
 	"IntegerLiteral":          true,
 	"TrulyConstantExpression": true,
+	"TypeOf":                  true,
+	"Implements":              true,
+	"AssignableTo":            true,
+	"Predicate":               true,
 }
 
 type Parser struct {
@@ -349,12 +363,17 @@ func populateNode(typ string, objs []Node, allowTypeInfo bool) (Node, error) {
 			if obj, ok := objs[i].(String); ok {
 				f.Set(reflect.ValueOf(string(obj)))
 			} else {
-				return nil, fmt.Errorf("first argument of (Binding name node) must be string, but got %s", objs[i])
+				return nil, fmt.Errorf("first argument of (%s name node) must be string, but got %s", typ, objs[i])
 			}
 		} else {
 			f.Set(reflect.ValueOf(objs[i]))
 		}
 	}
+	if pred, ok := v.Interface().(Predicate); ok {
+		if _, ok := lookupPredicate(pred.Name); !ok {
+			return nil, fmt.Errorf("unknown predicate %q", pred.Name)
+		}
+	}
 	return v.Interface().(Node), nil
 }
 
@@ -415,6 +434,12 @@ var structNodes = map[string]reflect.Type{
 	"Not":                     reflect.TypeOf(Not{}),
 	"IntegerLiteral":          reflect.TypeOf(IntegerLiteral{}),
 	"TrulyConstantExpression": reflect.TypeOf(TrulyConstantExpression{}),
+	"TypeOf":                  reflect.TypeOf(TypeOf{}),
+	"Implements":              reflect.TypeOf(Implements{}),
+	"AssignableTo":            reflect.TypeOf(AssignableTo{}),
+	"Predicate":               reflect.TypeOf(Predicate{}),
+	"Many":                    reflect.TypeOf(Many{}),
+	"Seq":                     reflect.TypeOf(Seq{}),
 }
 
 func (p *Parser) object() (Node, error) {
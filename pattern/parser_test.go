@@ -3,6 +3,7 @@ package pattern
 import (
 	"fmt"
 	"go/ast"
+	"go/format"
 	goparser "go/parser"
 	"go/token"
 	"os"
@@ -145,3 +146,176 @@ func _() { _ = Alias(0) }
 		t.Errorf("%s did not match", p2.Root)
 	}
 }
+
+func TestMatchTypes(t *testing.T) {
+	const src = `package pkg
+
+import (
+	"bytes"
+	"io"
+)
+
+type writer struct{}
+
+func (writer) Write(b []byte) (int, error) { return len(b), nil }
+
+func use(interface{}) {}
+
+func fn(buf *bytes.Buffer, r io.Reader, w writer, n int) {
+	use(buf)
+	use(r)
+	use(w)
+	use(n)
+}
+`
+	f, pkg, info, err := debug.TypeCheck(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := map[string]ast.Expr{}
+	ast.Inspect(f, func(node ast.Node) bool {
+		if call, ok := node.(*ast.CallExpr); ok && len(call.Args) == 1 {
+			if ident, ok := call.Args[0].(*ast.Ident); ok {
+				args[ident.Name] = ident
+			}
+		}
+		return true
+	})
+
+	RegisterPredicate("test.isN", func(m *Matcher, node interface{}) bool {
+		ident, ok := node.(*ast.Ident)
+		return ok && ident.Name == "n"
+	})
+
+	tests := []struct {
+		pattern string
+		arg     string
+		want    bool
+	}{
+		{`(TypeOf _ "*bytes.Buffer")`, "buf", true},
+		{`(TypeOf _ "*bytes.Buffer")`, "r", false},
+		{`(TypeOf _ (Or "io.Reader" "io.Writer"))`, "r", true},
+		{`(TypeOf _ "foo.writer")`, "w", true},
+		{`(TypeOf (Ident "r") _)`, "buf", false},
+		{`(Implements _ "io.Reader")`, "buf", true},
+		{`(Implements _ "io.Reader")`, "w", false},
+		{`(Implements _ "io.Writer")`, "w", true},
+		{`(Implements _ "io.Closer")`, "buf", false},
+		{`(Implements _ "error")`, "n", false},
+		{`(Implements _ "net/http.Handler")`, "buf", false},
+		{`(AssignableTo _ "io.Writer")`, "buf", true},
+		{`(AssignableTo _ "*bytes.Buffer")`, "r", false},
+		{`(AssignableTo _ "int")`, "n", true},
+		{`(AssignableTo _ "[]byte")`, "n", false},
+		{`(Predicate "test.isN" _)`, "n", true},
+		{`(Predicate "test.isN" _)`, "r", false},
+		{`(Predicate "test.isN" (TypeOf _ "int"))`, "n", true},
+	}
+	// Reuse the matcher, so that we exercise its cache of resolved types.
+	m := &Matcher{TypesInfo: info, Pkg: pkg}
+	for _, tt := range tests {
+		q := MustParse(tt.pattern)
+		if got := m.Match(q, args[tt.arg]); got != tt.want {
+			t.Errorf("%s on %s: got %t, want %t", tt.pattern, tt.arg, got, tt.want)
+		}
+	}
+
+	p := &Parser{AllowTypeInfo: true}
+	if _, err := p.Parse(`(Predicate "test.unknown" _)`); err == nil {
+		t.Error("expected error for unknown predicate")
+	}
+	// Patterns constructed without the parser don't panic on unknown
+	// predicates.
+	q := Pattern{Root: Predicate{Name: "test.unknown", Node: Any{}}}
+	if m.Match(q, args["n"]) {
+		t.Error("unknown predicate matched")
+	}
+}
+
+func TestMatchSeq(t *testing.T) {
+	const src = `package pkg
+
+func fn() {
+	var x int
+	x = 1
+	println(x)
+	println(x)
+	return
+}
+`
+	fset := token.NewFileSet()
+	f, err := goparser.ParseFile(fset, "pkg.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := f.Decls[0].(*ast.FuncDecl).Body
+
+	render := func(v interface{}) string {
+		var b strings.Builder
+		if err := format.Node(&b, fset, v); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		pattern string
+		replace string
+		// want is the rendered range of matched statements, or the
+		// rendered replacement, if any.
+		want string
+	}{
+		{`(Seq [(GenDecl "VAR" _) (AssignStmt _ "=" _)])`, "", "var x int\nx = 1"},
+		{`(Seq (ReturnStmt []))`, "", "return"},
+		{`(Seq [(CallExpr _ _) (CallExpr _ _)])`, "", "println(x)\nprintln(x)"},
+		{`(Seq [(AssignStmt _ _ _) (Many (CallExpr (Ident "println") _)) (ReturnStmt [])])`, "", "x = 1\nprintln(x)\nprintln(x)\nreturn"},
+		{`(Seq [(ReturnStmt _) (CallExpr _ _)])`, "", ""},
+		{`(Seq [(AssignStmt _ _ _) (Many (GenDecl "VAR" _)) (ReturnStmt [])])`, "", ""},
+		{`(Seq [first@(GenDecl "VAR" _) second@(AssignStmt _ _ _)])`, `(Seq [second first])`, "x = 1\nvar x int"},
+		{`(Seq [a@(AssignStmt _ _ _) calls@(Many (CallExpr (Ident "println") _))])`, `(Seq [calls (CallExpr (Ident "flush") []) a])`, "println(x)\nprintln(x)\nflush()\nx = 1"},
+		{`(Seq [_ rest@(Many _) (ReturnStmt [])])`, `(Seq rest)`, "x = 1\nprintln(x)\nprintln(x)"},
+	}
+	for _, tt := range tests {
+		q := MustParse(tt.pattern)
+		m := &Matcher{}
+		if !m.Match(q, body) {
+			if tt.want != "" {
+				t.Errorf("%s didn't match", tt.pattern)
+			}
+			continue
+		}
+		if tt.want == "" {
+			t.Errorf("%s unexpectedly matched %q", tt.pattern, render(m.Stmts))
+			continue
+		}
+		got := render(m.Stmts)
+		if tt.replace != "" {
+			r, err := (&Parser{}).Parse(tt.replace)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := CheckReplacement(q, r); err != nil {
+				t.Fatal(err)
+			}
+			got = render(NodeToAST(r.Root, m.State))
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+
+	// Many also matches runs of elements in ordinary lists.
+	q := MustParse(`(FuncDecl _ _ _ [_ _ mid@(Many _) (ReturnStmt [])])`)
+	m := &Matcher{}
+	if !m.Match(q, f.Decls[0]) {
+		t.Fatal("list with Many didn't match")
+	}
+	if got, want := render(m.State["mid"]), "println(x)\nprintln(x)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := CheckReplacement(q, MustParse(`(Seq (Many _))`)); err == nil {
+		t.Error("expected error for Many in replacement")
+	}
+}
@@ -64,6 +64,12 @@ var (
 	_ Node = Or{}
 	_ Node = IntegerLiteral{}
 	_ Node = TrulyConstantExpression{}
+	_ Node = TypeOf{}
+	_ Node = Implements{}
+	_ Node = AssignableTo{}
+	_ Node = Predicate{}
+	_ Node = Many{}
+	_ Node = Seq{}
 )
 
 type Symbol struct {
@@ -338,10 +344,61 @@ type Or struct {
 	Nodes []Node
 }
 
+// A TypeOf matches an expression whose type, as printed by
+// types.TypeString without a qualifier, matches Type.
+// For example, the type of a pointer to a bytes.Buffer is written as "*bytes.Buffer".
+type TypeOf struct {
+	X    Node
+	Type Node
+}
+
+// An Implements matches an expression whose type implements the
+// interface named by Type, which must be a string. See [Matcher.Pkg]
+// for how names are resolved.
+type Implements struct {
+	X    Node
+	Type Node
+}
+
+// An AssignableTo matches an expression whose value is assignable to
+// variables of the type named by Type, which must be a string. See
+// [Matcher.Pkg] for how names are resolved.
+type AssignableTo struct {
+	X    Node
+	Type Node
+}
+
+// A Predicate matches a node that matches Node and that satisfies the
+// Go predicate registered under Name. See [RegisterPredicate].
+type Predicate struct {
+	Name string
+	Node Node
+}
+
 type Not struct {
 	Node Node
 }
 
+// A Many matches any number of consecutive elements of a list, each of
+// which has to match Node. Used as the head of a list, as in
+// [(Many _) x], it matches as many elements as possible while still
+// allowing the rest of the list to match. Bindings of Many nodes
+// capture ranges of elements, such as []ast.Stmt.
+type Many struct {
+	Node Node
+}
+
+// A Seq matches a list of statements, such as the body of a block or
+// of a case clause, that contains Stmts as a contiguous sequence of
+// statements. Only the first such sequence is matched. The matched
+// statements are available via [Matcher.Stmts].
+//
+// In replacements, Seq produces a list of statements, which allows
+// replacing a sequence of statements with another.
+type Seq struct {
+	Stmts Node
+}
+
 // A TrulyConstantExpression is a constant expression that does not make use of any identifiers.
 // It is constant even under varying build tags.
 type TrulyConstantExpression struct {
@@ -408,6 +465,15 @@ func (el Ellipsis) String() string                  { return stringify(el) }
 func (not Not) String() string                      { return stringify(not) }
 func (lit IntegerLiteral) String() string           { return stringify(lit) }
 func (expr TrulyConstantExpression) String() string { return stringify(expr) }
+func (expr TypeOf) String() string                  { return stringify(expr) }
+func (expr Implements) String() string              { return stringify(expr) }
+func (expr AssignableTo) String() string            { return stringify(expr) }
+func (many Many) String() string                    { return stringify(many) }
+func (seq Seq) String() string                      { return stringify(seq) }
+
+func (pred Predicate) String() string {
+	return fmt.Sprintf("(Predicate %q %s)", pred.Name, pred.Node)
+}
 
 func (or Or) String() string {
 	s := "(Or"
@@ -522,3 +588,9 @@ func (Binding) isNode()                 {}
 func (Not) isNode()                     {}
 func (IntegerLiteral) isNode()          {}
 func (TrulyConstantExpression) isNode() {}
+func (TypeOf) isNode()                  {}
+func (Implements) isNode()              {}
+func (AssignableTo) isNode()            {}
+func (Predicate) isNode()               {}
+func (Many) isNode()                    {}
+func (Seq) isNode()                     {}
@@ -10,7 +10,6 @@ import (
 	"honnef.co/go/tools/analysis/edit"
 	"honnef.co/go/tools/analysis/lint"
 	"honnef.co/go/tools/analysis/report"
-	"honnef.co/go/tools/knowledge"
 	"honnef.co/go/tools/pattern"
 
 	"golang.org/x/tools/go/analysis"
@@ -35,7 +34,7 @@ var Analyzer = SCAnalyzer.Analyzer
 var (
 	checkWriteBytesSprintfQ = pattern.MustParse(`
 	(CallExpr
-		(SelectorExpr recv (Ident "Write"))
+		(SelectorExpr recv@(Implements _ "io.Writer") (Ident "Write"))
 		(CallExpr (ArrayType nil (Ident "byte"))
 			(CallExpr
 				fn@(Or
@@ -45,9 +44,13 @@ var (
 				args)
 	))`)
 
+	// The type needs to implement both StringWriter and Writer.
+	// If it doesn't implement Writer, then we cannot pass it to fmt.Fprint.
 	checkWriteStringSprintfQ = pattern.MustParse(`
 	(CallExpr
-		(SelectorExpr recv (Ident "WriteString"))
+		(SelectorExpr
+			recv@(Implements (Implements _ "io.StringWriter") "io.Writer")
+			(Ident "WriteString"))
 		(CallExpr
 			fn@(Or
 				(Symbol "fmt.Sprint")
@@ -60,10 +63,6 @@ func run(pass *analysis.Pass) (interface{}, error) {
 	fn := func(node ast.Node) {
 		if m, ok := code.Match(pass, checkWriteBytesSprintfQ, node); ok {
 			recv := m.State["recv"].(ast.Expr)
-			recvT := pass.TypesInfo.TypeOf(recv)
-			if !types.Implements(recvT, knowledge.Interfaces["io.Writer"]) {
-				return
-			}
 
 			name := m.State["fn"].(*types.Func).Name()
 			newName := "F" + strings.TrimPrefix(name, "S")
@@ -80,15 +79,6 @@ func run(pass *analysis.Pass) (interface{}, error) {
 			report.Report(pass, node, msg, report.Fixes(fix))
 		} else if m, ok := code.Match(pass, checkWriteStringSprintfQ, node); ok {
 			recv := m.State["recv"].(ast.Expr)
-			recvT := pass.TypesInfo.TypeOf(recv)
-			if !types.Implements(recvT, knowledge.Interfaces["io.StringWriter"]) {
-				return
-			}
-			// The type needs to implement both StringWriter and Writer.
-			// If it doesn't implement Writer, then we cannot pass it to fmt.Fprint.
-			if !types.Implements(recvT, knowledge.Interfaces["io.Writer"]) {
-				return
-			}
 
 			name := m.State["fn"].(*types.Func).Name()
 			newName := "F" + strings.TrimPrefix(name, "S")
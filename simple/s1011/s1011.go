@@ -75,7 +75,7 @@ var checkLoopAppendQ = pattern.MustParse(`
 		(AssignStmt [lhs] "=" [(CallExpr (Builtin "append") [lhs val])])]))`)
 
 func run(pass *analysis.Pass) (interface{}, error) {
-	pure := pass.ResultOf[purity.Analyzer].(purity.Result)
+	pure := pass.ResultOf[purity.Analyzer].(*purity.Result)
 
 	fn := func(node ast.Node) {
 		m, ok := code.Match(pass, checkLoopAppendQ, node)
@@ -87,7 +87,7 @@ func run(pass *analysis.Pass) (interface{}, error) {
 			return
 		}
 
-		if m.State["idx"] != nil && code.MayHaveSideEffects(pass, m.State["x"].(ast.Expr), pure) {
+		if m.State["idx"] != nil && code.MayBeImpure(pass, m.State["x"].(ast.Expr), pure) {
 			// When using an index-based loop, x gets evaluated repeatedly and thus should be pure.
 			// This doesn't matter for value-based loops, because x only gets evaluated once.
 			return
@@ -98,7 +98,7 @@ func run(pass *analysis.Pass) (interface{}, error) {
 			return
 		}
 
-		if code.MayHaveSideEffects(pass, m.State["lhs"].(ast.Expr), pure) {
+		if code.MayBeImpure(pass, m.State["lhs"].(ast.Expr), pure) {
 			// The lhs may be dynamic and return different values on each iteration. For example:
 			//
 			// 	func bar() map[int][]int { /* return one of several maps */ }
@@ -35,10 +35,18 @@ import (
 	"honnef.co/go/tools/staticcheck/sa1030"
 	"honnef.co/go/tools/staticcheck/sa1031"
 	"honnef.co/go/tools/staticcheck/sa1032"
+	"honnef.co/go/tools/staticcheck/sa1033"
+	"honnef.co/go/tools/staticcheck/sa1034"
+	"honnef.co/go/tools/staticcheck/sa1035"
+	"honnef.co/go/tools/staticcheck/sa1036"
 	"honnef.co/go/tools/staticcheck/sa2000"
 	"honnef.co/go/tools/staticcheck/sa2001"
 	"honnef.co/go/tools/staticcheck/sa2002"
 	"honnef.co/go/tools/staticcheck/sa2003"
+	"honnef.co/go/tools/staticcheck/sa2004"
+	"honnef.co/go/tools/staticcheck/sa2005"
+	"honnef.co/go/tools/staticcheck/sa2006"
+	"honnef.co/go/tools/staticcheck/sa2007"
 	"honnef.co/go/tools/staticcheck/sa3000"
 	"honnef.co/go/tools/staticcheck/sa3001"
 	"honnef.co/go/tools/staticcheck/sa4000"
@@ -72,6 +80,8 @@ import (
 	"honnef.co/go/tools/staticcheck/sa4030"
 	"honnef.co/go/tools/staticcheck/sa4031"
 	"honnef.co/go/tools/staticcheck/sa4032"
+	"honnef.co/go/tools/staticcheck/sa4033"
+	"honnef.co/go/tools/staticcheck/sa4034"
 	"honnef.co/go/tools/staticcheck/sa5000"
 	"honnef.co/go/tools/staticcheck/sa5001"
 	"honnef.co/go/tools/staticcheck/sa5002"
@@ -84,6 +94,11 @@ import (
 	"honnef.co/go/tools/staticcheck/sa5010"
 	"honnef.co/go/tools/staticcheck/sa5011"
 	"honnef.co/go/tools/staticcheck/sa5012"
+	"honnef.co/go/tools/staticcheck/sa5013"
+	"honnef.co/go/tools/staticcheck/sa5014"
+	"honnef.co/go/tools/staticcheck/sa5015"
+	"honnef.co/go/tools/staticcheck/sa5016"
+	"honnef.co/go/tools/staticcheck/sa5017"
 	"honnef.co/go/tools/staticcheck/sa6000"
 	"honnef.co/go/tools/staticcheck/sa6001"
 	"honnef.co/go/tools/staticcheck/sa6002"
@@ -99,6 +114,8 @@ import (
 	"honnef.co/go/tools/staticcheck/sa9007"
 	"honnef.co/go/tools/staticcheck/sa9008"
 	"honnef.co/go/tools/staticcheck/sa9009"
+	"honnef.co/go/tools/staticcheck/sa9010"
+	"honnef.co/go/tools/staticcheck/sa9011"
 )
 
 var Analyzers = []*lint.Analyzer{
@@ -133,10 +150,18 @@ var Analyzers = []*lint.Analyzer{
 	sa1030.SCAnalyzer,
 	sa1031.SCAnalyzer,
 	sa1032.SCAnalyzer,
+	sa1033.SCAnalyzer,
+	sa1034.SCAnalyzer,
+	sa1035.SCAnalyzer,
+	sa1036.SCAnalyzer,
 	sa2000.SCAnalyzer,
 	sa2001.SCAnalyzer,
 	sa2002.SCAnalyzer,
 	sa2003.SCAnalyzer,
+	sa2004.SCAnalyzer,
+	sa2005.SCAnalyzer,
+	sa2006.SCAnalyzer,
+	sa2007.SCAnalyzer,
 	sa3000.SCAnalyzer,
 	sa3001.SCAnalyzer,
 	sa4000.SCAnalyzer,
@@ -170,6 +195,8 @@ var Analyzers = []*lint.Analyzer{
 	sa4030.SCAnalyzer,
 	sa4031.SCAnalyzer,
 	sa4032.SCAnalyzer,
+	sa4033.SCAnalyzer,
+	sa4034.SCAnalyzer,
 	sa5000.SCAnalyzer,
 	sa5001.SCAnalyzer,
 	sa5002.SCAnalyzer,
@@ -182,6 +209,11 @@ var Analyzers = []*lint.Analyzer{
 	sa5010.SCAnalyzer,
 	sa5011.SCAnalyzer,
 	sa5012.SCAnalyzer,
+	sa5013.SCAnalyzer,
+	sa5014.SCAnalyzer,
+	sa5015.SCAnalyzer,
+	sa5016.SCAnalyzer,
+	sa5017.SCAnalyzer,
 	sa6000.SCAnalyzer,
 	sa6001.SCAnalyzer,
 	sa6002.SCAnalyzer,
@@ -197,4 +229,6 @@ var Analyzers = []*lint.Analyzer{
 	sa9007.SCAnalyzer,
 	sa9008.SCAnalyzer,
 	sa9009.SCAnalyzer,
+	sa9010.SCAnalyzer,
+	sa9011.SCAnalyzer,
 }
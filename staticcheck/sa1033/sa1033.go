@@ -0,0 +1,54 @@
+package sa1033
+
+import (
+	"fmt"
+
+	"honnef.co/go/tools/analysis/facts/taint"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA1033",
+		Run:      run,
+		Requires: []*analysis.Analyzer{taint.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Untrusted data in SQL query`,
+		Text: `Data that an attacker controls, such as the parameters of an HTTP
+request, is used to build an SQL query. This allows the attacker to
+change the meaning of the query, for example to read or delete
+arbitrary data. Instead of embedding data in queries, use placeholders
+and pass the data as arguments:
+
+    db.Query("SELECT * FROM users WHERE name = '" + r.FormValue("name") + "'")
+
+should be written as
+
+    db.Query("SELECT * FROM users WHERE name = $1", r.FormValue("name"))
+
+The check tracks untrusted data across function calls and packages.
+Sources, sinks and sanitizers can be configured with the taint option.`,
+		Since:      "Unreleased",
+		NonDefault: true,
+		Severity:   lint.SeverityWarning,
+		MergeIf:    lint.MergeIfAny,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	res := pass.ResultOf[taint.Analyzer].(*taint.Result)
+	for _, flow := range res.FlowsOf(taint.SQL) {
+		var opts []report.Option
+		for _, step := range flow.Path {
+			opts = append(opts, report.Related(step, step.Description))
+		}
+		report.Report(pass, flow.Sink, fmt.Sprintf("possible SQL injection: untrusted data flows into %s", flow.Target), opts...)
+	}
+	return nil, nil
+}
@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type server struct {
+	db *sql.DB
+}
+
+func (s *server) fn1(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("name")
+	s.db.Query("SELECT * FROM users WHERE name = '" + name + "'") //@ diag(`possible SQL injection: untrusted data flows into (*database/sql.DB).Query`)
+	s.db.Query("SELECT * FROM users WHERE name = $1", name)
+	s.db.Exec(fmt.Sprintf("DELETE FROM users WHERE name = '%s'", name)) //@ diag(`possible SQL injection`)
+}
+
+func (s *server) fn2(w http.ResponseWriter, r *http.Request) {
+	var sb strings.Builder
+	sb.WriteString("SELECT * FROM users WHERE id IN (")
+	sb.WriteString(strings.Join(r.URL.Query()["id"], ","))
+	sb.WriteString(")")
+	s.db.QueryRow(sb.String()) //@ diag(`possible SQL injection`)
+}
+
+func lookup(db *sql.DB, table string) {
+	db.Query("SELECT * FROM " + table) // flows are reported in the callers of lookup
+}
+
+func (s *server) fn3(w http.ResponseWriter, r *http.Request) {
+	lookup(s.db, "users")
+	lookup(s.db, r.Header.Get("X-Table")) //@ diag(`untrusted data flows into call to lookup`)
+}
+
+func handler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tx, _ := db.Begin()
+		tx.Query(r.PostFormValue("query")) //@ diag(`untrusted data flows into (*database/sql.Tx).Query`)
+		tx.Prepare("SELECT " + r.Method)   //@ diag(`untrusted data flows into (*database/sql.Tx).Prepare`)
+	}
+}
+
+func fn4(db *sql.DB, query string) {
+	// Nothing here is untrusted
+	db.Query(query)
+	db.Query("SELECT 1")
+}
@@ -0,0 +1,50 @@
+package sa1034
+
+import (
+	"fmt"
+
+	"honnef.co/go/tools/analysis/facts/taint"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA1034",
+		Run:      run,
+		Requires: []*analysis.Analyzer{taint.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Untrusted data in command name`,
+		Text: `Data that an attacker controls, such as the parameters of an HTTP
+request, is used as the name of a command that is executed, or as the
+script of a shell started with \'sh -c\'. This may allow the attacker
+to run arbitrary commands.
+
+Other arguments of commands aren't flagged, as they are commonly and
+safely derived from user input.
+
+The check tracks untrusted data across function calls and packages.
+Sources, sinks and sanitizers can be configured with the taint option.`,
+		Since:      "Unreleased",
+		NonDefault: true,
+		Severity:   lint.SeverityWarning,
+		MergeIf:    lint.MergeIfAny,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	res := pass.ResultOf[taint.Analyzer].(*taint.Result)
+	for _, flow := range res.FlowsOf(taint.Exec) {
+		var opts []report.Option
+		for _, step := range flow.Path {
+			opts = append(opts, report.Related(step, step.Description))
+		}
+		report.Report(pass, flow.Sink, fmt.Sprintf("possible command injection: untrusted data flows into %s", flow.Target), opts...)
+	}
+	return nil, nil
+}
@@ -0,0 +1,25 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"os/exec"
+)
+
+func fn1(w http.ResponseWriter, r *http.Request) {
+	exec.Command(r.FormValue("cmd")) //@ diag(`possible command injection: untrusted data flows into os/exec.Command`)
+	exec.Command("git", "log", r.FormValue("rev"))
+	exec.Command("ls", r.FormValue("dir"))
+	exec.Command("git", "log", "HEAD")
+	exec.CommandContext(context.Background(), "sh", "-c", "echo "+r.URL.Path) //@ diag(`untrusted data flows into os/exec.CommandContext`)
+	exec.Command("bash", "-c", `echo "$1"`, "bash", r.URL.Path)
+}
+
+func run(name string, args ...string) error {
+	return exec.Command(name, args...).Run()
+}
+
+func fn2(w http.ResponseWriter, r *http.Request) {
+	run(r.URL.Query().Get("tool"), "-v") //@ diag(`untrusted data flows into call to run`)
+	run("tool", "-v", r.URL.Query().Get("arg"))
+}
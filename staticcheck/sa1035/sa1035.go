@@ -0,0 +1,50 @@
+package sa1035
+
+import (
+	"fmt"
+
+	"honnef.co/go/tools/analysis/facts/taint"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA1035",
+		Run:      run,
+		Requires: []*analysis.Analyzer{taint.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Untrusted data marked as safe HTML`,
+		Text: `Data that an attacker controls, such as the parameters of an HTTP
+request, is converted to one of html/template's types, such as
+template.HTML, that mark content as safe. The template engine won't
+escape such content, which allows the attacker to inject arbitrary
+HTML and scripts into the page. Either don't use these types for
+untrusted data, or escape the data first, for example with
+html.EscapeString.
+
+The check tracks untrusted data across function calls and packages.
+Sources, sinks and sanitizers can be configured with the taint option.`,
+		Since:      "Unreleased",
+		NonDefault: true,
+		Severity:   lint.SeverityWarning,
+		MergeIf:    lint.MergeIfAny,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	res := pass.ResultOf[taint.Analyzer].(*taint.Result)
+	for _, flow := range res.FlowsOf(taint.HTML) {
+		var opts []report.Option
+		for _, step := range flow.Path {
+			opts = append(opts, report.Related(step, step.Description))
+		}
+		report.Report(pass, flow.Sink, fmt.Sprintf("possible cross-site scripting: untrusted data flows into %s", flow.Target), opts...)
+	}
+	return nil, nil
+}
@@ -0,0 +1,30 @@
+package pkg
+
+import (
+	"html"
+	"html/template"
+	"net/http"
+)
+
+type page struct {
+	Title template.HTML
+	Body  template.HTML
+}
+
+func fn1(w http.ResponseWriter, r *http.Request) {
+	var p page
+	p.Title = template.HTML(r.FormValue("title")) //@ diag(`possible cross-site scripting: untrusted data flows into conversion to html/template.HTML`)
+	p.Body = template.HTML(html.EscapeString(r.FormValue("body")))
+	_ = template.JS(r.Referer()) //@ diag(`conversion to html/template.JS`)
+	_ = template.HTML("<b>static</b>")
+	_ = p
+}
+
+func render(s string) template.HTML {
+	return template.HTML("<p>" + s + "</p>")
+}
+
+func fn2(w http.ResponseWriter, r *http.Request) {
+	_ = render(r.UserAgent()) //@ diag(`untrusted data flows into call to render`)
+	_ = render(template.HTMLEscapeString(r.UserAgent()))
+}
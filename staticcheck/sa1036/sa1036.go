@@ -0,0 +1,49 @@
+package sa1036
+
+import (
+	"fmt"
+
+	"honnef.co/go/tools/analysis/facts/taint"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA1036",
+		Run:      run,
+		Requires: []*analysis.Analyzer{taint.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Untrusted data in file path`,
+		Text: `Data that an attacker controls, such as the parameters of an HTTP
+request, is used as a file path. By using absolute paths or
+sequences of "..", the attacker may be able to access files outside
+of the intended directory. Use filepath.Base to strip directories
+from file names, or verify that cleaned paths are contained in the
+intended directory.
+
+The check tracks untrusted data across function calls and packages.
+Sources, sinks and sanitizers can be configured with the taint option.`,
+		Since:      "Unreleased",
+		NonDefault: true,
+		Severity:   lint.SeverityWarning,
+		MergeIf:    lint.MergeIfAny,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	res := pass.ResultOf[taint.Analyzer].(*taint.Result)
+	for _, flow := range res.FlowsOf(taint.Path) {
+		var opts []report.Option
+		for _, step := range flow.Path {
+			opts = append(opts, report.Related(step, step.Description))
+		}
+		report.Report(pass, flow.Sink, fmt.Sprintf("possible path traversal: untrusted data flows into %s", flow.Target), opts...)
+	}
+	return nil, nil
+}
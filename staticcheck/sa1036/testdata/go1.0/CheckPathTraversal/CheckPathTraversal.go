@@ -0,0 +1,28 @@
+package pkg
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+func fn1(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("file")
+	os.Open(name)                              //@ diag(`possible path traversal: untrusted data flows into os.Open`)
+	os.Open(filepath.Join("/srv/files", name)) //@ diag(`untrusted data flows into os.Open`)
+	os.Open(filepath.Join("/srv", filepath.Base(name)))
+	os.Remove("/tmp/" + r.FormValue("tmp")) //@ diag(`untrusted data flows into os.Remove`)
+	http.ServeFile(w, r, r.URL.Path)        //@ diag(`untrusted data flows into net/http.ServeFile`)
+	http.ServeFile(w, r, "/srv/index.html")
+	ioutil.ReadFile(name) //@ diag(`untrusted data flows into call to ReadFile`)
+}
+
+func save(dir, name string, data []byte) error {
+	return os.WriteFile(filepath.Join(dir, name), data, 0644)
+}
+
+func fn2(w http.ResponseWriter, r *http.Request) {
+	save("/srv/uploads", r.FormValue("name"), nil) //@ diag(`untrusted data flows into call to save`)
+	save("/srv/uploads", "fixed", nil)
+}
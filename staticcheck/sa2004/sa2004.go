@@ -0,0 +1,65 @@
+package sa2004
+
+import (
+	"fmt"
+
+	"honnef.co/go/tools/analysis/facts/locks"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA2004",
+		Run:      run,
+		Requires: []*analysis.Analyzer{locks.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Returning with a mutex locked on some paths`,
+		Text: `A function locks a mutex and returns without unlocking it, while
+unlocking it on other paths. This usually happens when an early
+return was added to a function that unlocks the mutex explicitly
+instead of deferring the call to \'Unlock\':
+
+    mu.Lock()
+    if cond {
+        return // mu is still locked
+    }
+    mu.Unlock()
+
+Functions that return with a mutex locked on all paths, functions
+that return a function that unlocks the mutex, such as
+
+    func (c *Conn) grab() (release func(), err error)
+
+as well as functions whose names start with \'lock\', such as
+\'lockState\', are assumed to do so intentionally.`,
+		Since:      "Unreleased",
+		NonDefault: true,
+		Severity:   lint.SeverityWarning,
+		MergeIf:    lint.MergeIfAny,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	res := pass.ResultOf[locks.Analyzer].(*locks.Result)
+	for _, iss := range res.Issues {
+		if iss.Kind != locks.HeldAtReturn {
+			continue
+		}
+		var opts []report.Option
+		for _, step := range iss.Related {
+			opts = append(opts, report.Related(step, step.Description))
+		}
+		state := "locked"
+		if iss.State == locks.RLocked {
+			state = "read-locked"
+		}
+		report.Report(pass, iss, fmt.Sprintf("returning while %s is still %s", iss.Mutex, state), opts...)
+	}
+	return nil, nil
+}
@@ -0,0 +1,151 @@
+package pkg
+
+import (
+	"errors"
+	"sync"
+)
+
+type T struct {
+	mu sync.Mutex
+	rw sync.RWMutex
+	x  int
+}
+
+func (t *T) fn1(b bool) error {
+	t.mu.Lock()
+	if b {
+		return errors.New("b") //@ diag(`returning while t.mu is still locked`)
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *T) fn2(b bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if b {
+		return errors.New("b")
+	}
+	return nil
+}
+
+func (t *T) fn3() int {
+	t.rw.RLock()
+	if t.x > 0 {
+		return t.x //@ diag(`returning while t.rw is still read-locked`)
+	}
+	t.rw.RUnlock()
+	return 0
+}
+
+// acquire returns with the lock held on all paths, which we assume to be intentional.
+func (t *T) acquire() {
+	t.mu.Lock()
+	t.x++
+}
+
+func (t *T) lockIfOpen() error {
+	t.mu.Lock()
+	if t.x < 0 {
+		t.mu.Unlock()
+		return errors.New("closed")
+	}
+	return nil
+}
+
+func (t *T) fn4() {
+	if err := t.lockIfOpen(); err != nil {
+		return
+	}
+	t.x++
+	t.mu.Unlock()
+}
+
+func (t *T) fn5(b bool) {
+	t.acquire()
+	if b {
+		return //@ diag(`still locked`)
+	}
+	t.mu.Unlock()
+}
+
+func (t *T) fn6(b bool) {
+	t.mu.Lock()
+	if b {
+		panic("b")
+	}
+	t.mu.Unlock()
+}
+
+func (t *T) fn7(b bool) {
+	if b {
+		t.mu.Lock()
+	}
+	t.x++
+	if b {
+		t.mu.Unlock()
+	}
+}
+
+func fn8(b bool) {
+	var mu sync.Mutex
+	mu.Lock()
+	if b {
+		return //@ diag(`returning while mu is still locked`)
+	}
+	mu.Unlock()
+}
+
+func (t *T) fn9(b bool) {
+	t.mu.Lock()
+	defer func() {
+		t.mu.Unlock()
+	}()
+	if b {
+		return
+	}
+}
+
+func (t *T) fn10(b bool) {
+	t.mu.Lock()
+	if b {
+		t.mu.Unlock()
+		return
+	}
+	t.x++
+} //@ diag(`still locked`)
+
+func (t *T) runlock() {
+	t.rw.RUnlock()
+}
+
+// grab hands the read lock off to its caller, together with a function that releases it.
+func (t *T) grab() (func(), error) {
+	t.rw.RLock()
+	if t.x < 0 {
+		t.rw.RUnlock()
+		return nil, errors.New("closed")
+	}
+	return t.runlock, nil
+}
+
+func (t *T) grabClosure(b bool) func() {
+	t.mu.Lock()
+	if b {
+		t.mu.Unlock()
+		return nil
+	}
+	return func() { t.mu.Unlock() }
+}
+
+func (t *T) grabWrong(b, c bool) func() {
+	t.mu.Lock()
+	if b {
+		t.mu.Unlock()
+		return nil
+	}
+	if c {
+		return nil //@ diag(`still locked`)
+	}
+	return func() { t.mu.Unlock() }
+}
@@ -0,0 +1,56 @@
+package sa2005
+
+import (
+	"fmt"
+
+	"honnef.co/go/tools/analysis/facts/locks"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA2005",
+		Run:      run,
+		Requires: []*analysis.Analyzer{locks.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Locking a mutex that is already locked`,
+		Text: `Mutexes in the \'sync\' package aren't reentrant. Locking a mutex
+that the goroutine has already locked, either directly or by calling
+a function that locks it, deadlocks. The same is true for acquiring
+a write lock on a \'sync.RWMutex\' that is read-locked, or a read
+lock on one that is write-locked.`,
+		Since:      "Unreleased",
+		NonDefault: true,
+		Severity:   lint.SeverityWarning,
+		MergeIf:    lint.MergeIfAny,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	res := pass.ResultOf[locks.Analyzer].(*locks.Result)
+	for _, iss := range res.Issues {
+		if iss.Kind != locks.DoubleLock {
+			continue
+		}
+		var opts []report.Option
+		for _, step := range iss.Related {
+			opts = append(opts, report.Related(step, step.Description))
+		}
+		state := "locked"
+		if iss.State == locks.RLocked {
+			state = "read-locked"
+		}
+		if iss.Callee != "" {
+			report.Report(pass, iss, fmt.Sprintf("call to %s locks %s, which is already %s", iss.Callee, iss.Mutex, state), opts...)
+		} else {
+			report.Report(pass, iss, fmt.Sprintf("%s is already %s", iss.Mutex, state), opts...)
+		}
+	}
+	return nil, nil
+}
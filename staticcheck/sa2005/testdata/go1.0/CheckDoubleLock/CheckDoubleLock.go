@@ -0,0 +1,92 @@
+package pkg
+
+import "sync"
+
+type T struct {
+	mu sync.Mutex
+	rw sync.RWMutex
+	x  int
+}
+
+func (t *T) incLocked() {
+	t.x++
+}
+
+func (t *T) inc() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.x++
+}
+
+func (t *T) fn1() {
+	t.mu.Lock()
+	t.x++
+	t.mu.Lock() //@ diag(`t.mu is already locked`)
+}
+
+func (t *T) fn2() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inc() //@ diag(`call to (*example.com/CheckDoubleLock.T).inc locks t.mu, which is already locked`)
+}
+
+func (t *T) fn3() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.incLocked()
+}
+
+func (t *T) fn4() {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	t.rw.Lock() //@ diag(`t.rw is already read-locked`)
+}
+
+func (t *T) fn5() {
+	t.rw.RLock()
+	t.rw.RLock()
+	t.rw.RUnlock()
+	t.rw.RUnlock()
+}
+
+func (t *T) fn6() {
+	t.mu.Lock()
+	t.mu.Unlock()
+	t.mu.Lock()
+	t.mu.Unlock()
+}
+
+func (t *T) fn7() {
+	for i := 0; i < 10; i++ {
+		t.mu.Lock()
+		t.x++
+		t.mu.Unlock()
+	}
+}
+
+func fn8(t1, t2 *T) {
+	t1.mu.Lock()
+	t2.mu.Lock()
+	t2.mu.Unlock()
+	t1.mu.Unlock()
+}
+
+// waitLocked temporarily releases the lock held by its caller.
+func (t *T) waitLocked() {
+	t.mu.Unlock()
+	defer t.mu.Lock()
+	t.x++
+}
+
+func (t *T) read(b bool) {
+	if b {
+		t.waitLocked()
+	}
+}
+
+func (t *T) fn9(b bool) {
+	// read only requires t.mu to be held if b is true.
+	t.read(!b)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+}
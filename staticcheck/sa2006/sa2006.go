@@ -0,0 +1,73 @@
+package sa2006
+
+import (
+	"fmt"
+
+	"honnef.co/go/tools/analysis/facts/locks"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA2006",
+		Run:      run,
+		Requires: []*analysis.Analyzer{locks.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Unlocking a mutex that isn't locked, or with the wrong method`,
+		Text: `Unlocking a mutex that isn't locked is a run-time error. So is
+releasing a read lock on a \'sync.RWMutex\' with \'Unlock\', or a
+write lock with \'RUnlock\'. This often happens when a deferred call
+to \'Unlock\' is combined with an explicit one:
+
+    mu.Lock()
+    defer mu.Unlock()
+    if cond {
+        mu.Unlock() // mu will be unlocked again when returning
+        return
+    }`,
+		Since:      "Unreleased",
+		NonDefault: true,
+		Severity:   lint.SeverityWarning,
+		MergeIf:    lint.MergeIfAny,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	res := pass.ResultOf[locks.Analyzer].(*locks.Result)
+	for _, iss := range res.Issues {
+		var msg string
+		switch iss.Kind {
+		case locks.NotLocked:
+			if iss.Callee != "" {
+				msg = fmt.Sprintf("call to %s unlocks %s, which is not locked", iss.Callee, iss.Mutex)
+			} else {
+				msg = fmt.Sprintf("%s is not locked", iss.Mutex)
+			}
+		case locks.WrongUnlock:
+			switch {
+			case iss.Callee != "" && iss.State == locks.RLocked:
+				msg = fmt.Sprintf("call to %s write-unlocks %s, which is read-locked", iss.Callee, iss.Mutex)
+			case iss.Callee != "":
+				msg = fmt.Sprintf("call to %s read-unlocks %s, which is write-locked", iss.Callee, iss.Mutex)
+			case iss.State == locks.RLocked:
+				msg = fmt.Sprintf("%s is read-locked, use RUnlock instead of Unlock", iss.Mutex)
+			default:
+				msg = fmt.Sprintf("%s is write-locked, use Unlock instead of RUnlock", iss.Mutex)
+			}
+		default:
+			continue
+		}
+		var opts []report.Option
+		for _, step := range iss.Related {
+			opts = append(opts, report.Related(step, step.Description))
+		}
+		report.Report(pass, iss, msg, opts...)
+	}
+	return nil, nil
+}
@@ -0,0 +1,84 @@
+package pkg
+
+import "sync"
+
+type T struct {
+	mu sync.Mutex
+	rw sync.RWMutex
+	x  int
+}
+
+func (t *T) fn1(b bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock() //@ diag(`t.mu is not locked`)
+	if b {
+		t.mu.Unlock()
+		return
+	}
+}
+
+func (t *T) fn2() {
+	t.mu.Lock()
+	t.mu.Unlock()
+	t.mu.Unlock() //@ diag(`t.mu is not locked`)
+}
+
+func (t *T) fn3() {
+	t.rw.RLock()
+	defer t.rw.Unlock() //@ diag(`t.rw is read-locked, use RUnlock instead of Unlock`)
+}
+
+func (t *T) fn4() {
+	t.rw.Lock()
+	t.rw.RUnlock() //@ diag(`t.rw is write-locked, use Unlock instead of RUnlock`)
+}
+
+func (t *T) unlock() {
+	t.mu.Unlock()
+}
+
+func (t *T) fn5() {
+	// Releasing a lock held by our caller is fine.
+	t.mu.Unlock()
+}
+
+func (t *T) fn6() {
+	t.mu.Lock()
+	t.unlock()
+	t.unlock() //@ diag(`call to (*example.com/CheckUnlockUnlocked.T).unlock unlocks t.mu, which is not locked`)
+}
+
+func (t *T) fn7() {
+	t.mu.Lock()
+	t.unlock()
+}
+
+type rows struct {
+	mu   sync.RWMutex
+	held bool
+}
+
+func (r *rows) runlockIfHeld() {
+	if r.held {
+		r.held = false
+		r.mu.RUnlock()
+	}
+}
+
+func (r *rows) close() {
+	r.runlockIfHeld()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+}
+
+func (r *rows) next() {
+	r.runlockIfHeld()
+	r.mu.RLock()
+	r.mu.RUnlock()
+}
+
+func fn8(r *rows) {
+	// Neither next nor close read-unlock r.mu unless it is held.
+	defer r.close()
+	r.next()
+}
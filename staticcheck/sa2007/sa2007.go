@@ -0,0 +1,406 @@
+package sa2007
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/irutil"
+	"honnef.co/go/tools/internal/passes/buildir"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA2007",
+		Run:      run,
+		Requires: []*analysis.Analyzer{buildir.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Goroutine may block forever on a channel operation`,
+		Text: `A goroutine sends on or receives from a channel that was created by
+the function that started it, but the function can return without
+performing the matching operation. Once the function has returned,
+nothing else can receive from or send on the channel, and the
+goroutine blocks forever.
+
+This commonly happens when the function stops waiting for a result
+because of a timeout:
+
+    ch := make(chan result)
+    go func() { ch <- compute() }()
+    select {
+    case r := <-ch:
+        return r, nil
+    case <-ctx.Done():
+        return result{}, ctx.Err() // the goroutine is stuck sending on ch
+    }
+
+Giving the channel a buffer of one element lets the goroutine complete
+its send even if nobody is receiving.
+
+Only channels that don't escape the function are considered, and
+goroutines that are started in loops are ignored.`,
+		Since:    "Unreleased",
+		Severity: lint.SeverityWarning,
+		MergeIf:  lint.MergeIfAny,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	for _, fn := range pass.ResultOf[buildir.Analyzer].(*buildir.IR).SrcFuncs {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if g, ok := instr.(*ir.Go); ok {
+					checkGo(pass, fn, g)
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// op is a channel operation in the goroutine.
+type op struct {
+	instr ir.Instruction
+	ch    *ir.MakeChan
+	send  bool
+}
+
+func checkGo(pass *analysis.Pass, fn *ir.Function, g *ir.Go) {
+	if fn.Exit == nil {
+		return
+	}
+	b := g.Block()
+	for _, succ := range b.Succs {
+		if irutil.Reachable(succ, b) {
+			// The goroutine is started in a loop; the function may have to perform the matching operation once per
+			// iteration, which we cannot reason about.
+			return
+		}
+	}
+
+	for _, o := range goroutineOps(g) {
+		if o.send && !unbuffered(o.ch) {
+			continue
+		}
+		matches, ok := parentOps(g, o.ch, !o.send)
+		if !ok {
+			continue
+		}
+		exit := leakingExit(fn, g, matches)
+		if exit == token.NoPos {
+			continue
+		}
+
+		var expr ast.Expr
+		switch src := o.instr.Source().(type) {
+		case *ast.SendStmt:
+			expr = src.Chan
+		case *ast.UnaryExpr:
+			expr = src.X
+		default:
+			continue
+		}
+		name := report.Render(pass, expr)
+		var msg, related string
+		if o.send {
+			msg = fmt.Sprintf("goroutine may block forever sending on %s: the function can return without receiving from it", name)
+			related = fmt.Sprintf("returns without receiving from %s", name)
+		} else {
+			msg = fmt.Sprintf("goroutine may block forever receiving from %s: the function can return without sending on or closing it", name)
+			related = fmt.Sprintf("returns without sending on or closing %s", name)
+		}
+		report.Report(pass, g, msg,
+			report.Related(o.instr, "the goroutine blocks here"),
+			report.Related(position(exit), related))
+	}
+}
+
+// goroutineOps returns the plain sends and receives in the goroutine started by g that operate on channels created by
+// the function starting it. Operations in select statements are ignored, as they usually have alternatives that let
+// the goroutine make progress.
+func goroutineOps(g *ir.Go) []op {
+	var callee *ir.Function
+	var bindings []ir.Value
+	switch v := g.Call.Value.(type) {
+	case *ir.MakeClosure:
+		callee = v.Fn.(*ir.Function)
+		bindings = v.Bindings
+	case *ir.Function:
+		callee = v
+	default:
+		return nil
+	}
+	if callee.Blocks == nil || g.Call.IsInvoke() {
+		return nil
+	}
+
+	var ops []op
+	for _, b := range callee.Blocks {
+		for _, instr := range b.Instrs {
+			var v ir.Value
+			var send bool
+			switch instr := instr.(type) {
+			case *ir.Send:
+				v, send = instr.Chan, true
+			case *ir.Recv:
+				v = instr.Chan
+			default:
+				continue
+			}
+			if ch := calleeChan(g, callee, bindings, v); ch != nil {
+				ops = append(ops, op{instr, ch, send})
+			}
+		}
+	}
+	return ops
+}
+
+// calleeChan maps the channel v in the goroutine's function to the channel created by the parent function.
+func calleeChan(g *ir.Go, callee *ir.Function, bindings []ir.Value, v ir.Value) *ir.MakeChan {
+	for {
+		v = irutil.Flatten(v)
+		switch w := v.(type) {
+		case *ir.ChangeType:
+			v = w.X
+			continue
+		case *ir.Load:
+			if fv, ok := w.X.(*ir.FreeVar); ok {
+				v = fv
+				continue
+			}
+			return nil
+		case *ir.FreeVar:
+			for i, fv := range callee.FreeVars {
+				if fv == w && i < len(bindings) {
+					return parentChan(bindings[i])
+				}
+			}
+			return nil
+		case *ir.Parameter:
+			for i, p := range callee.Params {
+				if p == w && i < len(g.Call.Args) {
+					return parentChan(g.Call.Args[i])
+				}
+			}
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
+// parentChan returns the channel that v refers to in the parent function. v may be the address of a captured variable.
+func parentChan(v ir.Value) *ir.MakeChan {
+	seen := map[ir.Value]struct{}{}
+	var resolve func(v ir.Value) *ir.MakeChan
+	resolve = func(v ir.Value) *ir.MakeChan {
+		v = irutil.Flatten(v)
+		if _, ok := seen[v]; ok || v == nil {
+			return nil
+		}
+		seen[v] = struct{}{}
+		switch v := v.(type) {
+		case *ir.MakeChan:
+			return v
+		case *ir.ChangeType:
+			return resolve(v.X)
+		case *ir.Load:
+			return resolve(v.X)
+		case *ir.Alloc:
+			var out *ir.MakeChan
+			for _, ref := range *v.Referrers() {
+				store, ok := ref.(*ir.Store)
+				if !ok || store.Addr != v {
+					continue
+				}
+				ch := resolve(store.Val)
+				if ch == nil || (out != nil && out != ch) {
+					return nil
+				}
+				out = ch
+			}
+			return out
+		default:
+			return nil
+		}
+	}
+	return resolve(v)
+}
+
+func unbuffered(ch *ir.MakeChan) bool {
+	k, ok := ch.Size.(*ir.Const)
+	if !ok || k.Value == nil {
+		return false
+	}
+	n, exact := constant.Int64Val(constant.ToInt(k.Value))
+	return exact && n == 0
+}
+
+// parentOps returns the instructions in the parent function that perform the operation matching the
+// goroutine's, which is a send if send is true and a receive otherwise. Closing the channel matches either operation.
+// It reports false if the channel escapes in ways we don't understand.
+func parentOps(g *ir.Go, ch *ir.MakeChan, send bool) (map[ir.Instruction]struct{}, bool) {
+	matches := map[ir.Instruction]struct{}{}
+	aliases := map[ir.Value]struct{}{}
+	var q []ir.Value
+	add := func(v ir.Value) {
+		if _, ok := aliases[v]; !ok {
+			aliases[v] = struct{}{}
+			q = append(q, v)
+		}
+	}
+	add(ch)
+
+	for len(q) > 0 {
+		v := q[len(q)-1]
+		q = q[:len(q)-1]
+		for _, ref := range *v.Referrers() {
+			switch ref := ref.(type) {
+			case *ir.DebugRef, *ir.BinOp:
+			case *ir.Sigma, *ir.Phi, *ir.ChangeType, *ir.Load:
+				add(ref.(ir.Value))
+			case *ir.Store:
+				if ref.Addr == v {
+					continue
+				}
+				alloc, ok := ref.Addr.(*ir.Alloc)
+				if !ok {
+					return nil, false
+				}
+				add(alloc)
+			case *ir.Send:
+				if ref.X == v {
+					return nil, false
+				}
+				if send {
+					matches[ref] = struct{}{}
+				}
+			case *ir.Recv:
+				if !send {
+					matches[ref] = struct{}{}
+				}
+			case *ir.Select:
+				for i, st := range ref.States {
+					if st.Send == v {
+						return nil, false
+					}
+					if st.Chan == v && (st.Dir == types.SendOnly) == send {
+						matches[selectCase(ref, i)] = struct{}{}
+					}
+				}
+			case *ir.MakeClosure:
+				if ref != g.Call.Value {
+					return nil, false
+				}
+			case *ir.Go:
+				if ref != g {
+					return nil, false
+				}
+			case *ir.Call:
+				builtin, ok := ref.Call.Value.(*ir.Builtin)
+				if !ok {
+					return nil, false
+				}
+				switch builtin.Name() {
+				case "close":
+					matches[ref] = struct{}{}
+				case "len", "cap":
+				default:
+					return nil, false
+				}
+			case *ir.Defer:
+				// Deferred calls, including deferred closes, run on every return.
+				return nil, false
+			default:
+				return nil, false
+			}
+		}
+	}
+	return matches, true
+}
+
+// selectCase returns the first instruction of the block that is executed when state i of sel is chosen, or sel itself
+// if that block cannot be determined.
+func selectCase(sel *ir.Select, i int) ir.Instruction {
+	swtch, ok := sel.Block().Control().(*ir.ConstantSwitch)
+	if !ok {
+		return sel
+	}
+	if ex, ok := swtch.Tag.(*ir.Extract); !ok || ex.Tuple != sel {
+		return sel
+	}
+	for j, cond := range swtch.Conds {
+		k, ok := cond.(*ir.Const)
+		if !ok || k.Value == nil || j >= len(sel.Block().Succs) {
+			continue
+		}
+		if n, exact := constant.Int64Val(k.Value); exact && n == int64(i) {
+			succ := sel.Block().Succs[j]
+			if len(succ.Preds) != 1 || len(succ.Instrs) == 0 {
+				return sel
+			}
+			return succ.Instrs[0]
+		}
+	}
+	return sel
+}
+
+// leakingExit returns the position of a return statement that can be reached from g without executing any of the
+// matching operations, or token.NoPos if there is no such return.
+func leakingExit(fn *ir.Function, g *ir.Go, matches map[ir.Instruction]struct{}) token.Pos {
+	start := g.Block()
+	after := false
+	for _, instr := range start.Instrs {
+		if instr == g {
+			after = true
+			continue
+		}
+		if _, ok := matches[instr]; ok && after {
+			return token.NoPos
+		}
+	}
+
+	seen := map[*ir.BasicBlock]struct{}{start: {}}
+	q := []*ir.BasicBlock{start}
+	for len(q) > 0 {
+		b := q[0]
+		q = q[1:]
+		for _, succ := range b.Succs {
+			if succ == fn.Exit {
+				return irutil.ReturnPos(fn, b)
+			}
+			if _, ok := seen[succ]; ok {
+				continue
+			}
+			seen[succ] = struct{}{}
+			if performs(succ, matches) || irutil.Aborts(succ) {
+				continue
+			}
+			q = append(q, succ)
+		}
+	}
+	return token.NoPos
+}
+
+type position token.Pos
+
+func (pos position) Pos() token.Pos { return token.Pos(pos) }
+
+func performs(b *ir.BasicBlock, matches map[ir.Instruction]struct{}) bool {
+	for _, instr := range b.Instrs {
+		if _, ok := matches[instr]; ok {
+			return true
+		}
+	}
+	return false
+}
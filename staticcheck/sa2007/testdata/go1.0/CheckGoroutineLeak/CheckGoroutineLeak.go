@@ -0,0 +1,165 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+func compute() int { return 0 }
+
+func fn1(ctx context.Context) (int, error) {
+	ch := make(chan int)
+	go func() { ch <- compute() }() //@ diag(`goroutine may block forever sending on ch`)
+	select {
+	case r := <-ch:
+		return r, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func fn2(ctx context.Context) (int, error) {
+	// buffered, the goroutine can always complete its send
+	ch := make(chan int, 1)
+	go func() { ch <- compute() }()
+	select {
+	case r := <-ch:
+		return r, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func fn3(b bool) error {
+	errc := make(chan error)
+	go func() { errc <- nil }() //@ diag(`goroutine may block forever sending on errc`)
+	if b {
+		return errors.New("early")
+	}
+	return <-errc
+}
+
+func fn4() int {
+	ch := make(chan int)
+	go func() { ch <- compute() }()
+	return <-ch
+}
+
+func fn5(b bool) {
+	done := make(chan struct{})
+	go func() { //@ diag(`goroutine may block forever receiving from done`)
+		<-done
+	}()
+	if b {
+		return
+	}
+	close(done)
+}
+
+func fn6(b bool) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		<-done
+	}()
+	if b {
+		return
+	}
+}
+
+func worker(ch chan<- int) {
+	ch <- compute()
+}
+
+func fn7() int {
+	ch := make(chan int)
+	go worker(ch) //@ diag(`goroutine may block forever sending on ch`)
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		return -1
+	}
+}
+
+func fn8(n int) int {
+	// goroutines started in a loop aren't checked
+	ch := make(chan int)
+	for i := 0; i < n; i++ {
+		go func() { ch <- compute() }()
+	}
+	sum := 0
+	for i := 0; i < n; i++ {
+		sum += <-ch
+	}
+	return sum
+}
+
+var sink chan int
+
+func fn9(b bool) {
+	// the channel escapes
+	ch := make(chan int)
+	sink = ch
+	go func() { ch <- compute() }()
+	if b {
+		return
+	}
+	<-ch
+}
+
+func fn10(ctx context.Context) {
+	// the goroutine has an alternative to blocking
+	ch := make(chan int)
+	go func() {
+		select {
+		case ch <- compute():
+		case <-ctx.Done():
+		}
+	}()
+	if ctx.Err() != nil {
+		return
+	}
+	<-ch
+}
+
+func fn11(b bool) int {
+	ch := make(chan int)
+	go func() { ch <- compute() }()
+	if b {
+		panic("unreachable")
+	}
+	return <-ch
+}
+
+func fn12() {
+	ch := make(chan int)
+	go func() {
+		for range ch {
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+	close(ch)
+}
+
+func fn13(ctx context.Context, xs []int) {
+	in := make(chan int)
+	go func() { //@ diag(`goroutine may block forever sending on in`)
+		for {
+			in <- compute()
+		}
+	}()
+	for {
+		select {
+		case x := <-in:
+			_ = x
+		case <-ctx.Done():
+			for range xs {
+			}
+			return
+		}
+	}
+}
@@ -33,7 +33,7 @@ var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
 var Analyzer = SCAnalyzer.Analyzer
 
 func run(pass *analysis.Pass) (interface{}, error) {
-	pure := pass.ResultOf[purity.Analyzer].(purity.Result)
+	pure := pass.ResultOf[purity.Analyzer].(*purity.Result)
 
 fnLoop:
 	for _, fn := range pass.ResultOf[buildir.Analyzer].(*buildir.IR).SrcFuncs {
@@ -73,7 +73,7 @@ fnLoop:
 					// TODO(dh): support anonymous functions
 					continue
 				}
-				if _, ok := pure[callee.Object().(*types.Func)]; ok {
+				if _, ok := pure.SideEffectFree[callee.Object().(*types.Func)]; ok {
 					if pass.Pkg.Path() == "fmt_test" && callee.Object().(*types.Func).FullName() == "fmt.Sprintf" {
 						// special case for benchmarks in the fmt package
 						continue
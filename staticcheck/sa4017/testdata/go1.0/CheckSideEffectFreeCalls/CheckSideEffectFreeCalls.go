@@ -46,3 +46,24 @@ func fn4() error {
 	for {
 	}
 }
+
+type T struct{ n int }
+
+func newT(n int) *T                 { return &T{n: n} }
+func describe(t *T) string          { return fmt.Sprintf("T(%d)", t.n) }
+func (t *T) get() int               { return t.n }
+func (t *T) inc() int               { t.n++; return t.n }
+func appendTo(s []int, x int) []int { return append(s, x) }
+
+var global int
+
+func setGlobal(x int) int { global = x; return x }
+
+func fn5(t *T, s []int) {
+	newT(1)     //@ diag(`doesn't have side effects`)
+	describe(t) //@ diag(`doesn't have side effects`)
+	t.get()     //@ diag(`doesn't have side effects`)
+	t.inc()
+	appendTo(s, 1)
+	setGlobal(1)
+}
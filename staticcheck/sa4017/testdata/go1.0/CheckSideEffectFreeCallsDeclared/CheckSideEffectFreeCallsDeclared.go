@@ -0,0 +1,18 @@
+package pkg
+
+import "strconv"
+
+type opts struct{ width int }
+
+func pad(s string, o *opts) string {
+	for len(s) < o.width {
+		s += " "
+	}
+	return s
+}
+
+func fn() {
+	strconv.Quote("") //@ diag(`doesn't have side effects`)
+	pad("", &opts{})  //@ diag(`doesn't have side effects`)
+	strconv.QuoteRune(0)
+}
@@ -33,7 +33,7 @@ var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
 var Analyzer = SCAnalyzer.Analyzer
 
 func run(pass *analysis.Pass) (interface{}, error) {
-	pure := pass.ResultOf[purity.Analyzer].(purity.Result)
+	pure := pass.ResultOf[purity.Analyzer].(*purity.Result)
 
 	fn := func(node ast.Node) {
 		assign := node.(*ast.AssignStmt)
@@ -45,7 +45,7 @@ func run(pass *analysis.Pass) (interface{}, error) {
 			if reflect.TypeOf(lhs) != reflect.TypeOf(rhs) {
 				continue
 			}
-			if code.MayHaveSideEffects(pass, lhs, pure) || code.MayHaveSideEffects(pass, rhs, pure) {
+			if code.MayBeImpure(pass, lhs, pure) || code.MayBeImpure(pass, rhs, pure) {
 				continue
 			}
 
@@ -35,7 +35,12 @@ As a consequence, the following code pattern is an expensive no-op:
 
 var Analyzer = SCAnalyzer.Analyzer
 
-var ineffectiveURLQueryAddQ = pattern.MustParse(`(CallExpr (SelectorExpr (CallExpr (SelectorExpr recv (Ident "Query")) []) (Ident meth)) _)`)
+var ineffectiveURLQueryAddQ = pattern.MustParse(`
+	(CallExpr
+		(SelectorExpr
+			(CallExpr (SelectorExpr (TypeOf _ "*net/url.URL") (Ident "Query")) [])
+			(Ident (Or "Add" "Del" "Set")))
+		_)`)
 
 func run(pass *analysis.Pass) (interface{}, error) {
 	// TODO(dh): We could make this check more complex and detect
@@ -44,16 +49,7 @@ func run(pass *analysis.Pass) (interface{}, error) {
 	// false positives.
 
 	fn := func(node ast.Node) {
-		m, ok := code.Match(pass, ineffectiveURLQueryAddQ, node)
-		if !ok {
-			return
-		}
-		if !code.IsOfPointerToTypeWithName(pass, m.State["recv"].(ast.Expr), "net/url.URL") {
-			return
-		}
-		switch m.State["meth"].(string) {
-		case "Add", "Del", "Set":
-		default:
+		if _, ok := code.Match(pass, ineffectiveURLQueryAddQ, node); !ok {
 			return
 		}
 		report.Report(pass, node, "(*net/url.URL).Query returns a copy, modifying it doesn't change the URL")
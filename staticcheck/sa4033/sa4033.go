@@ -0,0 +1,445 @@
+package sa4033
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"honnef.co/go/tools/analysis/code"
+	"honnef.co/go/tools/analysis/edit"
+	"honnef.co/go/tools/analysis/facts/generated"
+	"honnef.co/go/tools/analysis/facts/purity"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+	"honnef.co/go/tools/go/ast/astutil"
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/irutil"
+	"honnef.co/go/tools/internal/passes/buildir"
+	"honnef.co/go/tools/unused"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA4033",
+		Run:      run,
+		Requires: []*analysis.Analyzer{buildir.Analyzer, generated.Analyzer, purity.Analyzer, unused.Analyzer.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Unused function parameter or result that is always discarded`,
+		Text: `An unexported function has a parameter that is never read, or
+a result that is discarded by every caller. Such parameters and
+results can be removed, simplifying both the function and its
+callers.
+
+Only functions that are exclusively called directly are considered.
+Functions that are used as values, for example by being assigned to
+variables or passed to other functions, as well as methods that may
+be used to implement interfaces, have to adhere to a fixed signature
+and are never flagged.
+
+Functions in files with build constraints are never flagged, as
+variants for other platforms may use their parameters and results.
+
+Functions that are themselves unused are left to U1000.`,
+		Since:      "Unreleased",
+		NonDefault: true,
+		Severity:   lint.SeverityWarning,
+		MergeIf:    lint.MergeIfAll,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+type candidate struct {
+	fn    *ir.Function
+	decl  *ast.FuncDecl
+	calls []*ast.CallExpr
+	// sites are the IR instructions calling the function.
+	sites []ir.CallInstruction
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	irpkg := pass.ResultOf[buildir.Analyzer].(*buildir.IR)
+
+	deadFns := map[token.Position]struct{}{}
+	for _, obj := range pass.ResultOf[unused.Analyzer.Analyzer].(unused.Result).Unused {
+		deadFns[obj.Position] = struct{}{}
+	}
+
+	// Functions in files with build constraints may have variants
+	// for other platforms that do use their parameters and results.
+	// Such variants are constrained, too, so skipping constrained
+	// files skips all of them.
+	constrained := map[*token.File]struct{}{}
+	for _, f := range pass.Files {
+		if _, ok := code.BuildConstraints(pass, f); ok {
+			constrained[pass.Fset.File(f.Pos())] = struct{}{}
+		}
+	}
+
+	candidates := map[*types.Func]*candidate{}
+	for _, fn := range irpkg.SrcFuncs {
+		obj, ok := fn.Object().(*types.Func)
+		if !ok || obj.Exported() || obj.Name() == "_" || obj.Name() == "init" || obj.Name() == "main" {
+			continue
+		}
+		decl, ok := fn.Source().(*ast.FuncDecl)
+		if !ok || decl.Body == nil || len(decl.Body.List) == 0 {
+			continue
+		}
+		if code.IsGenerated(pass, decl.Pos()) || hasLinkDirective(decl) {
+			continue
+		}
+		if _, ok := constrained[pass.Fset.File(decl.Pos())]; ok {
+			continue
+		}
+		if _, ok := deadFns[pass.Fset.Position(obj.Pos())]; ok {
+			// U1000 already reports the entire function.
+			continue
+		}
+		candidates[obj] = &candidate{fn: fn, decl: decl}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// Methods that implement an interface have to keep their
+	// signatures. Because candidates are unexported, only
+	// interfaces declared in this package can be implemented by
+	// them, either by the receiver's type or by types that embed it.
+	var ifaces []*types.Interface
+	for _, tv := range pass.TypesInfo.Types {
+		if iface, ok := tv.Type.Underlying().(*types.Interface); ok {
+			ifaces = append(ifaces, iface)
+		}
+	}
+	var named []*types.Named
+	for _, obj := range pass.TypesInfo.Defs {
+		if tn, ok := obj.(*types.TypeName); ok && !tn.IsAlias() {
+			if T, ok := tn.Type().(*types.Named); ok && T.TypeParams().Len() == 0 {
+				named = append(named, T)
+			}
+		}
+	}
+	for obj := range candidates {
+		recv := obj.Type().(*types.Signature).Recv()
+		if recv == nil {
+			continue
+		}
+		T := recv.Type()
+		if ptr, ok := T.(*types.Pointer); ok {
+			T = ptr.Elem()
+		}
+		if named, ok := T.(*types.Named); !ok || named.TypeParams().Len() != 0 {
+			delete(candidates, obj)
+			continue
+		}
+	ifaceLoop:
+		for _, iface := range ifaces {
+			if m, _, _ := types.LookupFieldOrMethod(iface, false, obj.Pkg(), obj.Name()); m == nil {
+				continue
+			}
+			for _, N := range named {
+				// N has the method either because it is the
+				// receiver's type or because it embeds it.
+				if m, _, _ := types.LookupFieldOrMethod(types.NewPointer(N), false, obj.Pkg(), obj.Name()); m != obj {
+					continue
+				}
+				if types.Implements(types.NewPointer(N), iface) {
+					delete(candidates, obj)
+					break ifaceLoop
+				}
+			}
+		}
+	}
+
+	// Find all direct calls. Any other reference to a function means
+	// that its value escapes and that its signature must not change.
+	called := map[*ast.Ident]*ast.CallExpr{}
+	for _, f := range pass.Files {
+		ast.Inspect(f, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			switch fun := astutil.Unparen(call.Fun).(type) {
+			case *ast.Ident:
+				called[fun] = call
+			case *ast.SelectorExpr:
+				// Method expressions, such as T.fn(x), shift the
+				// arguments by one. Treat them as escaping.
+				if sel, ok := pass.TypesInfo.Selections[fun]; !ok || sel.Kind() == types.MethodVal {
+					called[fun.Sel] = call
+				}
+			case *ast.IndexExpr:
+				if id, ok := fun.X.(*ast.Ident); ok {
+					called[id] = call
+				}
+			case *ast.IndexListExpr:
+				if id, ok := fun.X.(*ast.Ident); ok {
+					called[id] = call
+				}
+			}
+			return true
+		})
+	}
+	for id, obj := range pass.TypesInfo.Uses {
+		fn, ok := obj.(*types.Func)
+		if !ok {
+			continue
+		}
+		c, ok := candidates[fn.Origin()]
+		if !ok {
+			continue
+		}
+		if call, ok := called[id]; ok {
+			c.calls = append(c.calls, call)
+		} else {
+			delete(candidates, fn.Origin())
+		}
+	}
+
+	for _, fn := range irpkg.SrcFuncs {
+		for _, b := range fn.Blocks {
+			for _, ins := range b.Instrs {
+				site, ok := ins.(ir.CallInstruction)
+				if !ok {
+					continue
+				}
+				callee := site.Common().StaticCallee()
+				if callee == nil {
+					continue
+				}
+				obj, ok := callee.Object().(*types.Func)
+				if !ok {
+					continue
+				}
+				if c, ok := candidates[obj.Origin()]; ok {
+					c.sites = append(c.sites, site)
+				}
+			}
+		}
+	}
+
+	pure := pass.ResultOf[purity.Analyzer].(*purity.Result)
+	for obj, c := range candidates {
+		if len(c.calls) == 0 {
+			continue
+		}
+		checkParams(pass, obj, c, pure)
+		checkResults(pass, obj, c)
+	}
+	return nil, nil
+}
+
+// hasLinkDirective reports whether decl is exported to cgo or linked
+// via go:linkname, in which case its signature is fixed.
+func hasLinkDirective(decl *ast.FuncDecl) bool {
+	if decl.Doc == nil {
+		return false
+	}
+	for _, cmt := range decl.Doc.List {
+		for _, prefix := range []string{"//export ", "//go:linkname ", "//go:cgo_export_"} {
+			if strings.HasPrefix(cmt.Text, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func checkParams(pass *analysis.Pass, obj *types.Func, c *candidate, pure *purity.Result) {
+	sig := obj.Type().(*types.Signature)
+	params := c.fn.Params
+	if sig.Recv() != nil {
+		params = params[1:]
+	}
+
+	// Parameters that are referred to in the source but not in the IR,
+	// such as parameters that are only ever assigned to, are the
+	// domain of SA4009 and SA4006.
+	mentioned := map[types.Object]struct{}{}
+	ast.Inspect(c.decl.Body, func(node ast.Node) bool {
+		if id, ok := node.(*ast.Ident); ok {
+			if obj, ok := pass.TypesInfo.Uses[id]; ok {
+				mentioned[obj] = struct{}{}
+			}
+		}
+		return true
+	})
+
+	for i, param := range params {
+		v := sig.Params().At(i)
+		if v.Name() == "" || v.Name() == "_" {
+			continue
+		}
+		if _, ok := mentioned[v]; ok {
+			continue
+		}
+		if isUsed(param) {
+			continue
+		}
+
+		var opts []report.Option
+		if fix, ok := removeParam(pass, sig, i, c, pure); ok {
+			opts = append(opts, report.Fixes(fix))
+		}
+		report.Report(pass, paramIdent(c.decl, v), fmt.Sprintf("parameter %s of %s is never used", v.Name(), obj.Name()), opts...)
+	}
+}
+
+func checkResults(pass *analysis.Pass, obj *types.Func, c *candidate) {
+	sig := obj.Type().(*types.Signature)
+	if sig.Results().Len() == 0 || len(c.sites) == 0 || len(c.sites) != len(c.calls) {
+		// If the IR and the AST disagree on the number of call
+		// sites, some of the calls may be going through wrappers
+		// that we cannot see.
+		return
+	}
+
+	discarded := make([]bool, sig.Results().Len())
+	for i := range discarded {
+		discarded[i] = true
+	}
+	for _, site := range c.sites {
+		call, ok := site.(*ir.Call)
+		if !ok {
+			// go and defer statements always discard all results.
+			continue
+		}
+		refs := call.Referrers()
+		if refs == nil {
+			continue
+		}
+		for _, ref := range irutil.FilterDebug(*refs) {
+			if _, ok := ref.(*ir.BlankStore); ok {
+				continue
+			}
+			if sig.Results().Len() == 1 {
+				discarded[0] = false
+				continue
+			}
+			ex, ok := ref.(*ir.Extract)
+			if !ok {
+				// The tuple is used as a whole, e.g. in f(g()).
+				for i := range discarded {
+					discarded[i] = false
+				}
+				continue
+			}
+			if isUsed(ex) {
+				discarded[ex.Index] = false
+			}
+		}
+	}
+
+	fields := c.decl.Type.Results.List
+	for i, ok := range discarded {
+		if !ok {
+			continue
+		}
+		res := sig.Results().At(i)
+		report.Report(pass, resultField(fields, i),
+			fmt.Sprintf("result %d (%s) of %s is discarded by every caller", i, types.TypeString(res.Type(), types.RelativeTo(pass.Pkg)), obj.Name()))
+	}
+}
+
+// isUsed reports whether v is used by anything other than debug
+// information and assignments to the blank identifier.
+func isUsed(v ir.Value) bool {
+	refs := v.Referrers()
+	if refs == nil {
+		return false
+	}
+	for _, ref := range irutil.FilterDebug(*refs) {
+		if _, ok := ref.(*ir.BlankStore); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// paramIdent returns the identifier declaring v in decl's parameter list.
+func paramIdent(decl *ast.FuncDecl, v *types.Var) ast.Node {
+	for _, field := range decl.Type.Params.List {
+		for _, name := range field.Names {
+			if name.Pos() == v.Pos() {
+				return name
+			}
+		}
+	}
+	return decl.Name
+}
+
+// resultField returns the field that declares the i-th result.
+func resultField(fields []*ast.Field, i int) *ast.Field {
+	for _, field := range fields {
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		if i < n {
+			return field
+		}
+		i -= n
+	}
+	return nil
+}
+
+// removeParam returns a fix that removes the i-th parameter from the
+// function's declaration and the corresponding argument from all
+// calls.
+func removeParam(pass *analysis.Pass, sig *types.Signature, i int, c *candidate, pure *purity.Result) (analysis.SuggestedFix, bool) {
+	if sig.Variadic() && i == sig.Params().Len()-1 {
+		return analysis.SuggestedFix{}, false
+	}
+
+	var edits []analysis.TextEdit
+	for _, call := range c.calls {
+		if len(call.Args) != sig.Params().Len() || call.Ellipsis.IsValid() {
+			// Calls like f(g()) pass multiple values as one
+			// argument.
+			return analysis.SuggestedFix{}, false
+		}
+		if code.MayHaveSideEffects(pass, call.Args[i], pure) {
+			return analysis.SuggestedFix{}, false
+		}
+		edits = append(edits, edit.Delete(listElement(call.Args, i)))
+	}
+
+	// Find the field and name that declare the parameter.
+	v := sig.Params().At(i)
+	fields := c.decl.Type.Params.List
+	for fi, field := range fields {
+		for ni, name := range field.Names {
+			if name.Pos() != v.Pos() {
+				continue
+			}
+			if len(field.Names) > 1 {
+				edits = append(edits, edit.Delete(listElement(field.Names, ni)))
+			} else {
+				edits = append(edits, edit.Delete(listElement(fields, fi)))
+			}
+			return edit.Fix(fmt.Sprintf("remove parameter %s", v.Name()), edits...), true
+		}
+	}
+	return analysis.SuggestedFix{}, false
+}
+
+// listElement returns the range covering the i-th element of a
+// comma-separated list, including the comma that separates it from
+// its neighbour.
+func listElement[T ast.Node](list []T, i int) edit.Range {
+	switch {
+	case len(list) == 1:
+		return edit.Range{list[i].Pos(), list[i].End()}
+	case i < len(list)-1:
+		return edit.Range{list[i].Pos(), list[i+1].Pos()}
+	default:
+		return edit.Range{list[i-1].End(), list[i].End()}
+	}
+}
@@ -0,0 +1,111 @@
+package pkg
+
+import "fmt"
+
+func fn1(a int, b string) int { //@ diag(`parameter b of fn1 is never used`)
+	return a * 2
+}
+
+func fn2(a, b, c int) { //@ diag(`parameter b of fn2 is never used`)
+	fmt.Println(a, c)
+}
+
+func fn3(a int) (int, error) { //@ diag(`result 1 (error) of fn3 is discarded by every caller`)
+	return a, nil
+}
+
+func fn4(a int) int { //@ diag(`result 0 (int) of fn4 is discarded by every caller`)
+	fmt.Println(a)
+	return a
+}
+
+func fn5(a int) (int, error) {
+	return a, nil
+}
+
+// fn6 is used as a value and has to keep its signature.
+func fn6(a int) int {
+	return 0
+}
+
+// fn7 has a parameter that is only assigned to, which is SA4009's business.
+func fn7(a int) {
+	a = 1
+	fmt.Println("")
+}
+
+func fn8(a int) {}
+
+func sideEffect() int {
+	fmt.Println("")
+	return 0
+}
+
+func fn9(ctx interface{}, a int) { //@ diag(`parameter ctx of fn9 is never used`)
+	fmt.Println(a)
+}
+
+type T struct{}
+
+func (T) m1(a int) { //@ diag(`parameter a of m1 is never used`)
+	fmt.Println("")
+}
+
+// m2 implements iface.
+func (T) m2(a int) {
+	fmt.Println("")
+}
+
+// m3 is used as a method value.
+func (T) m3(a int) {
+	fmt.Println("")
+}
+
+type iface interface{ m2(int) }
+
+func fn10(_ int, _ int) {
+	fmt.Println("")
+}
+
+func Caller() {
+	x := fn1(1, "")
+	fn2(1, 2, 3)
+	fn2(1, sideEffect(), 3)
+	y, _ := fn3(0)
+	fn3(x)
+	fn4(y)
+	go fn4(y)
+	a, b := fn5(0)
+	fmt.Println(a, b)
+	_ = fn6
+	fn7(1)
+	fn8(1)
+	fn9(nil, 1)
+	T{}.m1(1)
+	var i iface = T{}
+	i.m2(1)
+	_ = T{}.m3
+	fn10(1, 2)
+}
+
+type inner struct{}
+
+// m4 implements iface2 via outer, which embeds inner.
+func (inner) m4(a int) {
+	fmt.Println("")
+}
+
+type outer struct{ inner }
+
+func (outer) m5() {}
+
+type iface2 interface {
+	m4(int)
+	m5()
+}
+
+func Caller2() {
+	inner{}.m4(1)
+	var i iface2 = outer{}
+	i.m4(1)
+}
@@ -0,0 +1,11 @@
+package pkg
+
+func Retry(f func() error) error {
+	for {
+		err := f()
+		if err == nil || !isEphemeralError(err) {
+			return err
+		}
+		sleep(10)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !windows && !darwin
+
+package pkg
+
+func isEphemeralError(err error) bool {
+	return false
+}
@@ -0,0 +1,7 @@
+package pkg
+
+import "syscall"
+
+func isEphemeralError(err error) bool {
+	return err == syscall.ERROR_ACCESS_DENIED
+}
@@ -0,0 +1,311 @@
+package sa4034
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"honnef.co/go/tools/analysis/code"
+	"honnef.co/go/tools/analysis/intervals"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/irutil"
+	"honnef.co/go/tools/go/types/typeutil"
+	"honnef.co/go/tools/internal/passes/buildir"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA4034",
+		Run:      run,
+		Requires: []*analysis.Analyzer{buildir.Analyzer, inspect.Analyzer, intervals.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Integer comparison always has the same outcome`,
+		Text: `The ranges of the values being compared never overlap in a way
+that would allow the comparison to have a different outcome. This
+often points to a mistake in an earlier check, or to code that can no
+longer run. For example:
+
+    if x > 10 {
+        if x < 5 {
+            // never reached
+        }
+    }
+
+    if flags&mask|flagDebug != 0 {
+        // always reached, because of a missing pair of parentheses
+    }
+
+Comparisons that are always true are only flagged if one of the
+operands is the result of arithmetic. Redundant checks such as the
+last case in
+
+    switch {
+    case x < 0:
+    case x == 0:
+    case x > 0:
+    }
+
+are common and deliberate.
+
+The ranges of values are derived from constants, arithmetic and the
+conditions of branches leading to the comparison. Comparisons that
+are trivially true or false because of the types of their operands
+are flagged by SA4003 instead.
+
+The sizes of int, uint and uintptr depend on the platform, and
+comparisons are only flagged if they have the same outcome with 32-bit
+and 64-bit words. Comparisons involving constants whose values depend
+on the platform, such as math.MaxInt, are never flagged.`,
+		Since:    "Unreleased",
+		Severity: lint.SeverityWarning,
+		MergeIf:  lint.MergeIfAll,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	// The IR contains comparisons that don't exist in the source, such as those of range loops.
+	explicit := map[token.Pos]struct{}{}
+	consts := map[*types.Const]ast.Expr{}
+	code.Preorder(pass, func(node ast.Node) {
+		switch node := node.(type) {
+		case *ast.BinaryExpr:
+			explicit[node.Pos()] = struct{}{}
+		case *ast.GenDecl:
+			if node.Tok != token.CONST {
+				return
+			}
+			// Constants without values repeat the previous specification's expressions.
+			var values []ast.Expr
+			for _, spec := range node.Specs {
+				spec := spec.(*ast.ValueSpec)
+				if len(spec.Values) > 0 {
+					values = spec.Values
+				}
+				for i, name := range spec.Names {
+					if obj, ok := pass.TypesInfo.Defs[name].(*types.Const); ok && i < len(values) {
+						consts[obj] = values[i]
+					}
+				}
+			}
+		}
+	}, (*ast.BinaryExpr)(nil), (*ast.GenDecl)(nil))
+
+	res := pass.ResultOf[intervals.Analyzer].(intervals.Result)
+	for _, fn := range pass.ResultOf[buildir.Analyzer].(*buildir.IR).SrcFuncs {
+		ranges := res[fn]
+		for _, b := range fn.Blocks {
+			if ranges.Dead(b) {
+				continue
+			}
+			for _, instr := range b.Instrs {
+				binop, ok := instr.(*ir.BinOp)
+				if !ok {
+					continue
+				}
+				if _, ok := explicit[binop.Pos()]; !ok {
+					continue
+				}
+				switch binop.Op {
+				case token.LSS, token.LEQ, token.GTR, token.GEQ, token.EQL, token.NEQ:
+				default:
+					continue
+				}
+				if !interesting(ranges, binop) {
+					continue
+				}
+				if expr, ok := binop.Source().(*ast.BinaryExpr); ok &&
+					(platformDependent(pass, consts, expr.X, nil) || platformDependent(pass, consts, expr.Y, nil)) {
+					// The constants are those of the platform we're checking for, but the analysis assumes that
+					// int may be 32 or 64 bits wide.
+					continue
+				}
+				result, ok := ranges.Compare(binop.Op, binop.X, binop.Y)
+				if !ok {
+					continue
+				}
+				if result && !computed(binop.X) && !computed(binop.Y) {
+					// Conditions that are always true because of earlier branches are usually written
+					// deliberately, for clarity or out of caution, as in
+					//
+					//   switch {
+					//   case x < 0:
+					//   case x == 0:
+					//   case x > 0:
+					//   }
+					//
+					// Conditions that are always true because of arithmetic are more likely to be mistakes,
+					// such as x&mask|flag != 0.
+					continue
+				}
+				full := intervals.Range(binop.X.Type())
+				x, y := ranges.Value(binop.X), ranges.Value(binop.Y)
+				report.Report(pass, binop, fmt.Sprintf("comparison is always %t: left operand is %s and right operand is %s", result, describe(x, full), describe(y, full)))
+			}
+		}
+	}
+	return nil, nil
+}
+
+// interesting reports whether the comparison's outcome depends on more than constants and the types of its
+// operands.
+func interesting(ranges *intervals.Function, binop *ir.BinOp) bool {
+	if basic, ok := binop.X.Type().Underlying().(*types.Basic); !ok || basic.Info()&types.IsInteger == 0 {
+		return false
+	}
+	_, xk := binop.X.(*ir.Const)
+	_, yk := binop.Y.(*ir.Const)
+	if xk && yk {
+		// Comparing constants, which may differ between build configurations
+		return false
+	}
+	for _, v := range [2]ir.Value{binop.X, binop.Y} {
+		iv := ranges.Value(v)
+		if !iv.Known() {
+			return false
+		}
+		if _, ok := v.(*ir.Const); ok {
+			continue
+		}
+		if _, ok := iv.Singleton(); ok {
+			// Variables that only ever hold a single value, such as the lengths of arrays or configuration
+			// that is set by constants
+			return false
+		}
+		if iv == intervals.Range(v.Type()) {
+			// The comparison is trivially decided by the operand's type. This is SA4003's job.
+			return false
+		}
+	}
+	// Comparing against the smallest or largest value of a type, as in x > math.MaxInt32, is SA4003's job.
+	if extreme(ranges, binop.Op, binop.X, binop.Y) || extreme(ranges, flip(binop.Op), binop.Y, binop.X) {
+		return false
+	}
+	// len(x) < 0 is SA4024's job.
+	isLen := func(v ir.Value) bool {
+		call, ok := v.(*ir.Call)
+		return ok && irutil.IsCallToAny(call.Common(), "len", "cap")
+	}
+	isZero := func(v ir.Value) bool {
+		k, ok := v.(*ir.Const)
+		return ok && k.Value != nil && k.Value.String() == "0"
+	}
+	if binop.Op == token.LSS && isLen(binop.X) && isZero(binop.Y) ||
+		binop.Op == token.GTR && isZero(binop.X) && isLen(binop.Y) {
+		return false
+	}
+	return true
+}
+
+// extreme reports whether the comparison v op k compares v against a constant k that is at or beyond the bounds of
+// v's type, in one of the ways flagged by SA4003.
+func extreme(ranges *intervals.Function, op token.Token, v, k ir.Value) bool {
+	if _, ok := k.(*ir.Const); !ok {
+		return false
+	}
+	c, ok := ranges.Value(k).Singleton()
+	if !ok {
+		return false
+	}
+	full := intervals.Range(v.Type())
+	switch {
+	case c >= full.Hi:
+		return op == token.GTR || op == token.GEQ || op == token.LEQ
+	case c <= full.Lo:
+		return op == token.LSS || op == token.LEQ || op == token.GEQ
+	default:
+		return false
+	}
+}
+
+// flip returns the operator op with its operands swapped, so that x op y equals y flip(op) x.
+func flip(op token.Token) token.Token {
+	switch op {
+	case token.LSS:
+		return token.GTR
+	case token.GTR:
+		return token.LSS
+	case token.LEQ:
+		return token.GEQ
+	case token.GEQ:
+		return token.LEQ
+	default:
+		return op
+	}
+}
+
+// platformDependent reports whether the constant expression expr may have different values on platforms with
+// different sizes of int, uint and uintptr. It looks through the declarations of the package's own constants, which
+// are stored in consts.
+func platformDependent(pass *analysis.Pass, consts map[*types.Const]ast.Expr, expr ast.Expr, seen map[*types.Const]bool) bool {
+	found := false
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if found {
+			return false
+		}
+		switch node := node.(type) {
+		case *ast.UnaryExpr:
+			// ^uint(0)
+			if T := pass.TypesInfo.TypeOf(node); node.Op == token.XOR && T != nil && intervals.WordSized(T) &&
+				T.Underlying().(*types.Basic).Info()&types.IsUnsigned != 0 {
+				found = true
+			}
+		case *ast.Ident:
+			switch obj := pass.TypesInfo.ObjectOf(node).(type) {
+			case *types.Builtin:
+				// unsafe.Sizeof and friends
+				if types.Unsafe.Scope().Lookup(obj.Name()) == obj {
+					found = true
+				}
+			case *types.Const:
+				for _, name := range [...]string{"math.MaxInt", "math.MinInt", "math.MaxUint", "math/bits.UintSize", "strconv.IntSize"} {
+					if typeutil.IsObject(obj, name) {
+						found = true
+					}
+				}
+				if e, ok := consts[obj]; ok && !found && !seen[obj] {
+					if seen == nil {
+						seen = map[*types.Const]bool{}
+					}
+					seen[obj] = true
+					found = platformDependent(pass, consts, e, seen)
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// computed reports whether v is the result of arithmetic.
+func computed(v ir.Value) bool {
+	switch v.(type) {
+	case *ir.BinOp, *ir.UnOp:
+		return true
+	default:
+		return false
+	}
+}
+
+// describe describes the interval iv of values of a type whose range is full.
+func describe(iv, full intervals.Interval) string {
+	if c, ok := iv.Singleton(); ok {
+		return fmt.Sprint(c)
+	}
+	switch {
+	case iv.Hi >= full.Hi:
+		return fmt.Sprintf("at least %d", iv.Lo)
+	case iv.Lo <= full.Lo:
+		return fmt.Sprintf("at most %d", iv.Hi)
+	default:
+		return fmt.Sprintf("in [%d, %d]", iv.Lo, iv.Hi)
+	}
+}
@@ -0,0 +1,117 @@
+package pkg
+
+import "math"
+
+func fn1(x int, y uint8, s []int) {
+	if x > 10 {
+		if x < 5 { //@ diag(`comparison is always false: left operand is at least 11 and right operand is 5`)
+		}
+		if x > 5 {
+			// Redundant, but deliberate
+		}
+		if x == 10 { //@ diag(`comparison is always false`)
+		}
+		if x != 3 {
+		}
+		if x > 20 {
+		}
+	}
+
+	if y < 10 {
+		z := y * 2
+		if z >= 20 { //@ diag(`comparison is always false: left operand is at most 18 and right operand is 20`)
+		}
+		if z > 10 {
+		}
+	}
+
+	// Types decide these comparisons, SA4003 flags them.
+	if y > 255 {
+	}
+	if y <= 255 {
+	}
+
+	if len(s) < 0 {
+		// SA4024
+	}
+	n := len(s)
+	if n >= 0 {
+	}
+	if len(s) > 3 {
+		if len(s) == 2 { //@ diag(`comparison is always false`)
+		}
+	}
+
+	var arr [4]int
+	if len(arr) > 3 {
+		// Array lengths are constant
+	}
+	for i := 0; i < 10; i++ {
+		if i >= 0 {
+		}
+		if i < 10 {
+		}
+	}
+
+	const debug = 0
+	if debug > 0 {
+	}
+	k := 3
+	if k > 2 {
+		// Singleton values are usually intentional
+	}
+}
+
+const (
+	flagA = 1 << iota
+	flagB
+	flagC
+)
+
+func fn3(flags uint) {
+	if flags&flagA|flagB != 0 { //@ diag(`comparison is always true: left operand is in [2, 3] and right operand is 0`)
+	}
+	if flags&(flagA|flagB) != 0 {
+	}
+	if flags&flagC > 4 { //@ diag(`comparison is always false`)
+	}
+}
+
+func fn2(x int) {
+	if x < 0 {
+		return
+	}
+	if x < 0 { //@ diag(`comparison is always false: left operand is at least 0 and right operand is 0`)
+		// The whole block is dead, which is reported on the condition leading to it.
+		if x > 5 {
+		}
+	}
+}
+
+// The sizes of int, uint and uintptr depend on the platform.
+
+func fn4(length uint32, j uint64) {
+	if int(length) < 0 {
+		// Overflows on 32-bit platforms
+	}
+	if int(j) < 0 {
+	}
+	if uint64(length) > math.MaxInt-5 {
+		// math.MaxInt is 2147483647 on 32-bit platforms
+	}
+	const maxLen = maxUint - 5
+	if uint(length) > maxLen {
+	}
+	if uint64(length) > 1<<33 { //@ diag(`comparison is always false`)
+	}
+	if int64(length) > 1<<33 { //@ diag(`comparison is always false`)
+	}
+}
+
+const maxUint = ^uint(0)
+
+func fn5(v int32) {
+	if v < 0 || v > 2147483647 {
+		// SA4003
+	}
+}
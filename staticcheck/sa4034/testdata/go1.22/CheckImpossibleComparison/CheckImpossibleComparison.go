@@ -0,0 +1,11 @@
+package pkg
+
+func fn(n int) {
+	if n < 1 {
+		return
+	}
+	// The loop's implicit comparison of 0 < n isn't flagged.
+	for i := range n {
+		_ = i
+	}
+}
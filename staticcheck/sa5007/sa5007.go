@@ -4,6 +4,8 @@ import (
 	"honnef.co/go/tools/analysis/lint"
 	"honnef.co/go/tools/analysis/report"
 	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/ir/callgraph"
+	"honnef.co/go/tools/internal/passes/buildcallgraph"
 	"honnef.co/go/tools/internal/passes/buildir"
 
 	"golang.org/x/tools/go/analysis"
@@ -13,7 +15,7 @@ var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
 	Analyzer: &analysis.Analyzer{
 		Name:     "SA5007",
 		Run:      run,
-		Requires: []*analysis.Analyzer{buildir.Analyzer},
+		Requires: []*analysis.Analyzer{buildir.Analyzer, buildcallgraph.Analyzer},
 	},
 	Doc: &lint.RawDocumentation{
 		Title: `Infinite recursive call`,
@@ -35,18 +37,29 @@ should be used instead.`,
 var Analyzer = SCAnalyzer.Analyzer
 
 func run(pass *analysis.Pass) (interface{}, error) {
+	cg := pass.ResultOf[buildcallgraph.Analyzer].(*callgraph.Graph)
 	for _, fn := range pass.ResultOf[buildir.Analyzer].(*buildir.IR).SrcFuncs {
-		eachCall(fn, func(caller *ir.Function, site ir.CallInstruction, callee *ir.Function) {
-			if callee != fn {
-				return
+		node := cg.Nodes[fn]
+		if node == nil {
+			continue
+		}
+		for _, edge := range node.Out {
+			site := edge.Site
+			if edge.Callee.Func != fn {
+				continue
+			}
+			if cg.Unresolved[site] || len(cg.Callees(site)) != 1 {
+				// A dynamic call that may call other functions
+				continue
 			}
 			if _, ok := site.(*ir.Go); ok {
 				// Recursively spawning goroutines doesn't consume
 				// stack space infinitely, so don't flag it.
-				return
+				continue
 			}
 
 			block := site.Block()
+			recursive := true
 			for _, b := range fn.Blocks {
 				if block.Dominates(b) {
 					continue
@@ -55,23 +68,14 @@ func run(pass *analysis.Pass) (interface{}, error) {
 					continue
 				}
 				if _, ok := b.Control().(*ir.Return); ok {
-					return
+					recursive = false
+					break
 				}
 			}
-			report.Report(pass, site, "infinite recursive call")
-		})
-	}
-	return nil, nil
-}
-
-func eachCall(fn *ir.Function, cb func(caller *ir.Function, site ir.CallInstruction, callee *ir.Function)) {
-	for _, b := range fn.Blocks {
-		for _, instr := range b.Instrs {
-			if site, ok := instr.(ir.CallInstruction); ok {
-				if g := site.Common().StaticCallee(); g != nil {
-					cb(fn, site, g)
-				}
+			if recursive {
+				report.Report(pass, site, "infinite recursive call")
 			}
 		}
 	}
+	return nil, nil
 }
@@ -67,3 +67,35 @@ func (t T) Fn3() {
 	}
 	t.Fn1()
 }
+
+type Stringer interface{ String() string }
+
+type S1 struct{}
+
+func (s *S1) String() string {
+	var x Stringer = s
+	return x.String() //@ diag(`infinite recursive call`)
+}
+
+type S2 struct{}
+
+func (s *S2) String() string {
+	var x Stringer = s
+	if s == nil {
+		x = S3{}
+	}
+	return x.String()
+}
+
+type S3 struct{}
+
+func (S3) String() string { return "" }
+
+func fn7() {
+	f := fn7
+	f() //@ diag(`infinite recursive call`)
+}
+
+func fn8(x Stringer) string {
+	return x.String()
+}
@@ -7,6 +7,7 @@ import (
 
 	"honnef.co/go/tools/analysis/callcheck"
 	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/config"
 	"honnef.co/go/tools/go/ir"
 	"honnef.co/go/tools/go/ir/irutil"
 	"honnef.co/go/tools/go/types/typeutil"
@@ -20,8 +21,8 @@ import (
 var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
 	Analyzer: &analysis.Analyzer{
 		Name:     "SA5009",
-		Requires: []*analysis.Analyzer{buildir.Analyzer},
-		Run:      callcheck.Analyzer(rules),
+		Requires: []*analysis.Analyzer{buildir.Analyzer, config.Analyzer},
+		Run:      run,
 	},
 	Doc: &lint.RawDocumentation{
 		Title:    `Invalid Printf call`,
@@ -42,6 +43,36 @@ var rules = map[string]callcheck.Check{
 	"golang.org/x/xerrors.Errorf": func(call *callcheck.Call) { check(call, 0, 1) },
 }
 
+func run(pass *analysis.Pass) (interface{}, error) {
+	cfg := config.For(pass)
+	if len(cfg.Functions) == 0 {
+		return callcheck.Analyzer(rules)(pass)
+	}
+	merged := make(map[string]callcheck.Check, len(rules)+len(cfg.Functions))
+	for name, r := range rules {
+		merged[name] = r
+	}
+	for name := range cfg.Functions {
+		sem, _ := cfg.Function(name)
+		if sem.Printf == -1 {
+			continue
+		}
+		fIdx := sem.Printf
+		merged[name] = func(call *callcheck.Call) {
+			// The arguments to format have to be the variadic arguments following the format string.
+			if fIdx+2 != len(call.Args) {
+				return
+			}
+			sig := call.Instr.Common().Signature()
+			if !sig.Variadic() {
+				return
+			}
+			check(call, fIdx, fIdx+1)
+		}
+	}
+	return callcheck.Analyzer(merged)(pass)
+}
+
 type verbFlag int
 
 const (
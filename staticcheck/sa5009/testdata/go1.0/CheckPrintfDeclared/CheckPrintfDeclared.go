@@ -0,0 +1,22 @@
+package pkg
+
+import "fmt"
+
+type level int
+
+func logf(l level, format string, args ...interface{}) {
+	fmt.Println(l, fmt.Sprint(args...))
+}
+
+type logger struct{}
+
+func (*logger) infof(format string, args ...interface{}) {
+	fmt.Println(fmt.Sprint(args...))
+}
+
+func fn(l *logger) {
+	logf(0, "%d", "foo") //@ diag(`Printf format %d has arg #1 of wrong type string`)
+	logf(0, "%s", "foo")
+	l.infof("%s %s", "foo") //@ diag(`Printf format %s reads arg #2, but call has only 1 args`)
+	l.infof("%s", "foo")
+}
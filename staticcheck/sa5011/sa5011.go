@@ -1,8 +1,10 @@
 package sa5011
 
 import (
+	"fmt"
 	"go/types"
 
+	"honnef.co/go/tools/analysis/facts/nilness"
 	"honnef.co/go/tools/analysis/lint"
 	"honnef.co/go/tools/analysis/report"
 	"honnef.co/go/tools/go/ir"
@@ -16,7 +18,7 @@ var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
 	Analyzer: &analysis.Analyzer{
 		Name:     "SA5011",
 		Run:      run,
-		Requires: []*analysis.Analyzer{buildir.Analyzer},
+		Requires: []*analysis.Analyzer{buildir.Analyzer, nilness.Analysis},
 	},
 	Doc: &lint.RawDocumentation{
 		Title: `Possible nil pointer dereference`,
@@ -48,9 +50,9 @@ statements. Consider the following examples:
 
 Staticcheck tries to deduce which functions abort control flow.
 For example, it is aware that a function will not continue
-execution after a call to \'panic\' or \'log.Fatal\'. However, sometimes
-this detection fails, in particular in the presence of
-conditionals. Consider the following example:
+execution after a call to \'panic\' or \'log.Fatal\'. This includes
+functions that only exit for certain constant arguments, such as
+the following:
 
     func Log(msg string, level int) {
         fmt.Println(msg)
@@ -70,10 +72,11 @@ conditionals. Consider the following example:
         fmt.Println(*x)
     }
 
-Staticcheck will flag the dereference of \'x\', even though it is perfectly
-safe. Staticcheck is not able to deduce that a call to
-Fatal will exit the program. For the time being, the easiest
-workaround is to modify the definition of Fatal like so:
+However, sometimes this detection fails, for example when the
+decision to exit is made by calling a function stored in a variable.
+In that case, Staticcheck will flag dereferences that are perfectly
+safe. The easiest workaround is to add an explicit panic after the
+call to the function that exits:
 
     func Fatal(msg string) {
         Log(msg, levelFatal)
@@ -82,7 +85,23 @@ workaround is to modify the definition of Fatal like so:
 
 We also hard-code functions from common logging packages such as
 logrus. Please file an issue if we're missing support for a
-popular package.`,
+popular package.
+
+The same applies to passing a possibly nil pointer to a function
+that dereferences it unconditionally, such as the result of a type
+assertion whose failure isn't handled:
+
+    func get(x *T) int {
+        return x.field
+    }
+
+    func fn(v any) {
+        x, ok := v.(*T)
+        if !ok {
+            log.Println("unexpected type")
+        }
+        get(x)
+    }`,
 		Since:    "2020.1",
 		Severity: lint.SeverityWarning,
 		MergeIf:  lint.MergeIfAny,
@@ -213,9 +232,82 @@ func run(pass *analysis.Pass) (interface{}, error) {
 							report.Related(r, "this check suggests that the pointer can be nil"))
 					}
 				}
+				if call, ok := instr.(*ir.Call); ok {
+					checkCall(pass, call, maybeNil)
+				}
 			}
 		}
 	}
 
 	return nil, nil
 }
+
+// checkCall flags possibly nil arguments to functions that
+// unconditionally dereference them.
+func checkCall(pass *analysis.Pass, call *ir.Call, maybeNil map[ir.Value]ir.Instruction) {
+	callee := call.Common().StaticCallee()
+	if callee == nil {
+		return
+	}
+	obj, ok := callee.Object().(*types.Func)
+	if !ok {
+		return
+	}
+	nilRes := pass.ResultOf[nilness.Analysis].(*nilness.Result)
+	for i, arg := range call.Common().Args {
+		deref, ok := nilRes.Dereferences(obj, i)
+		if !ok {
+			continue
+		}
+		var opts []report.Option
+		if r, ok := maybeNil[arg]; ok {
+			opts = append(opts, report.Related(r, "this check suggests that the pointer can be nil"))
+		} else if assert := unguardedAssertion(arg, call); assert != nil {
+			opts = append(opts, report.Related(assert, "this type assertion may fail, leaving the value nil"))
+		} else {
+			continue
+		}
+		if deref != nil {
+			opts = append(opts, report.Related(deref, fmt.Sprintf("%s dereferences its argument here", obj.Name())))
+		}
+		report.Report(pass, call, fmt.Sprintf("possible nil pointer dereference: argument may be nil, but %s dereferences it", obj.Name()), opts...)
+	}
+}
+
+// unguardedAssertion returns the type assertion that produced v if v
+// is the value of a comma-ok type assertion whose success is checked
+// somewhere, but not before instr executes.
+func unguardedAssertion(v ir.Value, instr ir.Instruction) *ir.TypeAssert {
+	ex, ok := v.(*ir.Extract)
+	if !ok || ex.Index != 0 {
+		return nil
+	}
+	assert, ok := ex.Tuple.(*ir.TypeAssert)
+	if !ok || !assert.CommaOk {
+		return nil
+	}
+	checked := false
+	for _, ref := range *assert.Referrers() {
+		okv, isExtract := ref.(*ir.Extract)
+		if !isExtract || okv.Index != 1 {
+			continue
+		}
+		for _, use := range *okv.Referrers() {
+			iff, isIf := use.(*ir.If)
+			if !isIf {
+				continue
+			}
+			checked = true
+			// Negated conditions swap the successors, so the first
+			// successor is always the one taken if the assertion succeeded.
+			succ := iff.Block().Succs[0]
+			if len(succ.Preds) == 1 && succ.Dominates(instr.Block()) {
+				return nil
+			}
+		}
+	}
+	if !checked {
+		return nil
+	}
+	return assert
+}
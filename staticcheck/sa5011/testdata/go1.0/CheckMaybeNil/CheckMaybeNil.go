@@ -114,11 +114,19 @@ func fn10(x *int) {
 
 func fn11(x *int) {
 	if x == nil {
-		die2(true)
+		die2(false)
 	}
 	_ = *x //@ diag(`possible nil pointer dereference`)
 }
 
+func fn11_1(x *int) {
+	if x == nil {
+		// die2 exits when its argument is true
+		die2(true)
+	}
+	_ = *x
+}
+
 func doPanic() { panic("") }
 func doExit()  { syscall.Exit(1) }
 
@@ -185,3 +193,63 @@ func fn16() {
 	}
 	_ = xs4[0]
 }
+
+const (
+	levelInfo = iota
+	levelFatal
+)
+
+func Log(msg string, level int) {
+	println(msg)
+	if level == levelFatal {
+		os.Exit(1)
+	}
+}
+
+func Fatal(msg string) {
+	Log(msg, levelFatal)
+}
+
+func Logf(level int, msg string) {
+	Log(msg, level)
+}
+
+type Logger struct{}
+
+func (Logger) Log(level int, msg string) {
+	switch level {
+	case levelInfo:
+		println(msg)
+	case levelFatal:
+		println(msg)
+		os.Exit(1)
+	}
+}
+
+func fn17(x *int) {
+	if x == nil {
+		Fatal("unexpected nil pointer")
+	}
+	_ = *x
+}
+
+func fn18(x *int) {
+	if x == nil {
+		Logf(levelFatal, "unexpected nil pointer")
+	}
+	_ = *x
+}
+
+func fn19(x *int, l Logger) {
+	if x == nil {
+		l.Log(levelFatal, "unexpected nil pointer")
+	}
+	_ = *x
+}
+
+func fn20(x *int) {
+	if x == nil {
+		Log("unexpected nil pointer", levelInfo)
+	}
+	_ = *x //@ diag(`possible nil pointer dereference`)
+}
@@ -0,0 +1,80 @@
+package pkg
+
+import "log"
+
+type S struct{ field int }
+
+func (s *S) get() int { return s.field }
+
+func get(s *S) int      { return s.field }
+func indirect(s *S) int { return get(s) }
+
+func maybe(s *S) int {
+	if s == nil {
+		return 0
+	}
+	return s.field
+}
+
+func args1(s *S) {
+	get(s) //@ diag(`argument may be nil, but get dereferences it`)
+	if s == nil {
+		log.Println("s is nil")
+	}
+}
+
+func args2(s *S) {
+	if s == nil {
+		log.Println("s is nil")
+	}
+	indirect(s) //@ diag(`argument may be nil, but indirect dereferences it`)
+	s.get()     //@ diag(`argument may be nil, but get dereferences it`)
+}
+
+func args3(s *S) {
+	if s == nil {
+		log.Println("s is nil")
+	}
+	maybe(s)
+}
+
+func args4(s *S) {
+	if s != nil {
+		get(s)
+	}
+}
+
+func args5(v interface{}) {
+	s, ok := v.(*S)
+	if !ok {
+		log.Println("unexpected type")
+	}
+	get(s) //@ diag(`argument may be nil, but get dereferences it`)
+}
+
+func args6(v interface{}) {
+	s, ok := v.(*S)
+	if !ok {
+		return
+	}
+	get(s)
+}
+
+func args7(v interface{}) {
+	if s, ok := v.(*S); ok {
+		get(s)
+	}
+}
+
+func args8(v interface{}) {
+	s, ok := v.(*S)
+	if !ok {
+		log.Fatal("unexpected type")
+	}
+	get(s)
+}
+
+func args9(v interface{}) {
+	s, _ := v.(*S)
+	get(s)
+}
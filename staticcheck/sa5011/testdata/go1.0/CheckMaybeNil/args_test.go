@@ -0,0 +1,17 @@
+package pkg
+
+import "testing"
+
+func helper(t testing.TB, s *S) {
+	if s == nil {
+		t.Fatalf("s is nil")
+	}
+	get(s)
+}
+
+func helperError(t testing.TB, s *S) {
+	if s == nil {
+		t.Errorf("s is nil")
+	}
+	get(s) //@ diag(`argument may be nil, but get dereferences it`)
+}
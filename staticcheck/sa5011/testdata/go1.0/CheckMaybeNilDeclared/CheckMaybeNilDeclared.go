@@ -0,0 +1,27 @@
+package pkg
+
+var exit func(int)
+
+func fatal(msg string) {
+	println(msg)
+	exit(1)
+}
+
+func warn(msg string) {
+	println(msg)
+	exit(1)
+}
+
+func fn1(x *int) {
+	if x == nil {
+		fatal("x is nil")
+	}
+	_ = *x
+}
+
+func fn2(x *int) {
+	if x == nil {
+		warn("x is nil")
+	}
+	_ = *x //@ diag(`possible nil pointer dereference`)
+}
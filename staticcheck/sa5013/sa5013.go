@@ -0,0 +1,81 @@
+package sa5013
+
+import (
+	"fmt"
+
+	"honnef.co/go/tools/analysis/intervals"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/internal/passes/buildir"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA5013",
+		Run:      run,
+		Requires: []*analysis.Analyzer{buildir.Analyzer, intervals.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Index is always out of bounds`,
+		Text: `The index of an indexing operation is out of bounds for every
+length that the indexed slice, string or array can have at that point,
+and the operation will always panic. For example:
+
+    s := make([]int, 0, 10)
+    s[0] = 1
+
+    if len(s) < 3 {
+        fmt.Println(s[3])
+    }
+
+The ranges of indices and lengths are derived from constants,
+arithmetic and the conditions of branches leading to the indexing
+operation.`,
+		Since:    "Unreleased",
+		Severity: lint.SeverityError,
+		MergeIf:  lint.MergeIfAll,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	res := pass.ResultOf[intervals.Analyzer].(intervals.Result)
+	for _, fn := range pass.ResultOf[buildir.Analyzer].(*buildir.IR).SrcFuncs {
+		ranges := res[fn]
+		for _, b := range fn.Blocks {
+			if ranges.Dead(b) {
+				continue
+			}
+			for _, instr := range b.Instrs {
+				var x, index ir.Value
+				switch instr := instr.(type) {
+				case *ir.Index:
+					x, index = instr.X, instr.Index
+				case *ir.IndexAddr:
+					x, index = instr.X, instr.Index
+				case *ir.StringLookup:
+					x, index = instr.X, instr.Index
+				default:
+					continue
+				}
+				if !instr.Pos().IsValid() {
+					continue
+				}
+				idx, n := ranges.Value(index), ranges.Value(x)
+				if !idx.Known() || !n.Known() {
+					continue
+				}
+				if idx.Hi < 0 {
+					report.Report(pass, instr, fmt.Sprintf("index out of bounds: index is always negative, at most %d", idx.Hi))
+				} else if !n.Unbounded() && idx.Lo >= n.Hi {
+					report.Report(pass, instr, fmt.Sprintf("index out of bounds: index is at least %d, but length is at most %d", idx.Lo, n.Hi))
+				}
+			}
+		}
+	}
+	return nil, nil
+}
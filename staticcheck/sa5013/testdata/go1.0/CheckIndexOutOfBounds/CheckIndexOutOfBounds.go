@@ -0,0 +1,65 @@
+package pkg
+
+func fn1(s []int, str string, i int) {
+	_ = s[0]
+	if len(s) < 3 {
+		_ = s[1]
+		_ = s[2] //@ diag(`index is at least 2, but length is at most 2`)
+	}
+	if len(s) == 0 {
+		_ = s[0] //@ diag(`index is at least 0, but length is at most 0`)
+	}
+	if len(s) > 0 {
+		_ = s[0]
+		_ = s[len(s)-1]
+	}
+
+	if len(str) <= 2 {
+		_ = str[5] //@ diag(`index is at least 5, but length is at most 2`)
+	}
+
+	s2 := make([]int, 0, 10)
+	s2[0] = 1 //@ diag(`index is at least 0, but length is at most 0`)
+	s3 := make([]int, 4)
+	s3[3] = 1
+	s3[i] = 1
+	if i > 3 {
+		s3[i] = 1 //@ diag(`index is at least 4, but length is at most 4`)
+	}
+	if i < 0 {
+		s3[i] = 1 //@ diag(`index is always negative`)
+	}
+
+	var arr [4]int
+	for j := 0; j < 4; j++ {
+		arr[j] = j
+	}
+	for j := 0; j <= 4; j++ {
+		_ = arr[j]
+	}
+	if i >= 4 {
+		_ = arr[i] //@ diag(`index is at least 4, but length is at most 4`)
+	}
+}
+
+func fn2(s []int) {
+	if len(s) < 3 {
+		return
+	}
+	_ = s[2]
+	if len(s) < 3 {
+		// dead code
+		_ = s[3]
+	}
+}
+
+func fn3(s []int) {
+	for i := range s {
+		_ = s[i]
+	}
+	for i := 0; i < len(s); i++ {
+		_ = s[i]
+	}
+	n := len(s)
+	_ = s[n/2]
+}
@@ -0,0 +1,77 @@
+package sa5014
+
+import (
+	"fmt"
+	"go/types"
+
+	"honnef.co/go/tools/analysis/facts/resources"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+	"honnef.co/go/tools/go/types/typeutil"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA5014",
+		Run:      run,
+		Requires: []*analysis.Analyzer{resources.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Resource is not closed on all paths`,
+		Text: `A resource, such as a file, a network connection, an HTTP response
+body, or the result set of an SQL query, is opened but not closed
+on at least one path through the function. This leaks file
+descriptors, connections or memory. For example:
+
+    f, err := os.Open(name)
+    if err != nil {
+        return err
+    }
+    if !valid(name) {
+        return errInvalid // f is never closed
+    }
+    defer f.Close()
+
+Resources are also considered closed when they are returned, stored
+in memory that outlives the function, or passed to functions that
+close them or take ownership of them.
+
+The cancel functions of contexts are checked separately, by SA5015.`,
+		Since:      "Unreleased",
+		NonDefault: true,
+		Severity:   lint.SeverityWarning,
+		MergeIf:    lint.MergeIfAny,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	res := pass.ResultOf[resources.Analyzer].(*resources.Result)
+	for _, leak := range res.Leaks {
+		if leak.Cancel {
+			// Checked by SA5015.
+			continue
+		}
+		name := "function"
+		if callee := leak.Source.Common().StaticCallee(); callee != nil {
+			if obj, ok := callee.Object().(*types.Func); ok {
+				name = typeutil.FuncName(obj)
+			}
+		}
+		T := types.TypeString(leak.Type, types.RelativeTo(pass.Pkg))
+		var opts []report.Option
+		for _, step := range leak.Path {
+			opts = append(opts, report.Related(step, step.Description))
+		}
+		if leak.Never {
+			report.Report(pass, leak.Source, fmt.Sprintf("%s returned by %s is never closed", T, name), opts...)
+		} else {
+			line := pass.Fset.PositionFor(leak.Return, false).Line
+			report.Report(pass, leak.Source, fmt.Sprintf("%s returned by %s is not closed when returning on line %d", T, name, line), opts...)
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,278 @@
+package pkg
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+)
+
+func fn1(name string) error {
+	f, err := os.Open(name) //@ diag(`*os.File returned by os.Open is not closed when returning on line 20`)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return errors.New("empty name")
+	}
+	defer f.Close()
+	return nil
+}
+
+func fn2(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return nil
+}
+
+func fn3(name string) {
+	os.Open(name) //@ diag(`*os.File returned by os.Open is never closed`)
+}
+
+func fn4(name string) ([]byte, error) {
+	f, err := os.Open(name) //@ diag(`never closed`)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(f)
+}
+
+func fn5(name string) (*os.File, error) {
+	// Returning the file makes the caller responsible for closing it.
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func fn6(name string) (*os.File, error) {
+	f, err := os.Open(name) //@ diag(`not closed when returning on line 62`)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, errors.New("empty name")
+	}
+	return f, nil
+}
+
+func fn7(name string) error {
+	f, err := fn5(name) //@ diag(`*os.File returned by example.com/CheckResourceLeak.fn5 is never closed`)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(os.Stdout, f)
+	return err
+}
+
+func fn8(url string) ([]byte, error) {
+	resp, err := http.Get(url) //@ diag(`*net/http.Response returned by net/http.Get is not closed when returning on line 82`)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, errors.New("bad status")
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+func fn9(url string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		resp.Body.Close()
+	}()
+	return nil
+}
+
+func fn10(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT 1") //@ diag(`*database/sql.Rows returned by (*database/sql.DB).Query is never closed`)
+	if err != nil {
+		return nil, err
+	}
+	return rows.Columns()
+}
+
+func fn11(db *sql.DB) error {
+	rows, err := db.Query("SELECT 1")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+type wrapper struct {
+	r io.ReadCloser
+}
+
+func (w *wrapper) Close() error { return w.r.Close() }
+
+func fn12(name string) (*wrapper, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &wrapper{r: f}, nil
+}
+
+func fn13(name string) {
+	w, err := fn12(name) //@ diag(`is never closed`)
+	if err != nil {
+		return
+	}
+	_ = w
+}
+
+func fn14(name string) {
+	w, err := fn12(name)
+	if err != nil {
+		return
+	}
+	defer w.Close()
+}
+
+func fn15(name string) {
+	f, err := os.Open(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if name == "" {
+		log.Fatal("empty name")
+	}
+	f.Close()
+}
+
+func fn16(name string) {
+	f, err := os.Create(name) //@ diag(`never closed`)
+	if err != nil {
+		return
+	}
+	w := bufio.NewWriter(f)
+	w.WriteString("hello")
+	w.Flush()
+}
+
+func fn17(name string) {
+	f, _ := os.Open(name)
+	if f == nil {
+		return
+	}
+	f.Close()
+}
+
+var files []*os.File
+
+func fn18(name string) {
+	f, _ := os.Open(name)
+	files = append(files, f)
+}
+
+func fn19(names []string) {
+	for _, name := range names {
+		f, err := os.Open(name) //@ diag(`not closed`)
+		if err != nil {
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		f.Close()
+	}
+}
+
+func fn20(names []string) {
+	var all []io.Closer
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+		all = append(all, f)
+	}
+	for _, c := range all {
+		c.Close()
+	}
+}
+
+func fn21(name string) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err2 := f.Close(); err == nil {
+			err = err2
+		}
+	}()
+	_, err = f.WriteString("hello")
+	return err
+}
+
+func fn22(name string, open func(string) (io.ReadCloser, error)) {
+	var rc io.ReadCloser
+	var err error
+	if open != nil {
+		rc, err = open(name)
+	} else {
+		rc, err = os.Open(name)
+	}
+	if err != nil {
+		return
+	}
+	rc.Close()
+}
+
+func fn23(names []string) {
+	for _, name := range names {
+		f, err := os.Open(name) //@ diag(`never closed`)
+		if err != nil {
+			return
+		}
+		_ = f
+	}
+}
+
+func fn24(db *sql.DB) (int, error) {
+	// *sql.Row closes its rows when scanned.
+	var n int
+	row := db.QueryRow("SELECT 1")
+	return n, row.Scan(&n)
+}
+
+type row struct {
+	rows *sql.Rows
+	err  error
+}
+
+func (r *row) scan() error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	return nil
+}
+
+func queryRow(db *sql.DB) *row {
+	rows, err := db.Query("SELECT 1")
+	return &row{rows: rows, err: err}
+}
+
+func fn25(db *sql.DB) {
+	// row has no Close method, it closes its rows itself.
+	r := queryRow(db)
+	_ = r
+}
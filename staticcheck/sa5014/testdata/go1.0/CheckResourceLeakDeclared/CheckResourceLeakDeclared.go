@@ -0,0 +1,30 @@
+package pkg
+
+type conn struct{ pool *pool }
+
+func (c *conn) Close() error { return nil }
+
+type pool struct{ conns []*conn }
+
+func connect(p *pool) (*conn, error) {
+	c := &conn{pool: p}
+	p.conns = append(p.conns, c)
+	return c, nil
+}
+
+func fn1(p *pool) {
+	c, err := connect(p) //@ diag(`*conn returned by example.com/CheckResourceLeakDeclared.connect is never closed`)
+	if err != nil {
+		return
+	}
+	_ = c
+}
+
+func fn2(p *pool) error {
+	c, err := connect(p)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return nil
+}
@@ -0,0 +1,70 @@
+package sa5015
+
+import (
+	"fmt"
+	"go/types"
+
+	"honnef.co/go/tools/analysis/facts/resources"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+	"honnef.co/go/tools/go/types/typeutil"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA5015",
+		Run:      run,
+		Requires: []*analysis.Analyzer{resources.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Cancel function of context isn't called on all paths`,
+		Text: `The cancel function returned by \'context.WithCancel\',
+\'context.WithTimeout\', \'context.WithDeadline\' and their variants
+has to be called once the context is no longer needed. Until then,
+the context and its children, as well as any timers and goroutines
+associated with them, are kept alive. For example:
+
+    ctx, cancel := context.WithTimeout(ctx, time.Second)
+    if err := prepare(ctx); err != nil {
+        return err // cancel is never called
+    }
+    defer cancel()
+
+Passing the cancel function to a function that calls it, returning
+it, or storing it in memory that outlives the function counts as
+calling it.`,
+		Since:    "Unreleased",
+		Severity: lint.SeverityWarning,
+		MergeIf:  lint.MergeIfAny,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	res := pass.ResultOf[resources.Analyzer].(*resources.Result)
+	for _, leak := range res.Leaks {
+		if !leak.Cancel {
+			continue
+		}
+		name := "function"
+		if callee := leak.Source.Common().StaticCallee(); callee != nil {
+			if obj, ok := callee.Object().(*types.Func); ok {
+				name = typeutil.FuncName(obj)
+			}
+		}
+		var opts []report.Option
+		for _, step := range leak.Path {
+			opts = append(opts, report.Related(step, step.Description))
+		}
+		if leak.Never {
+			report.Report(pass, leak.Source, fmt.Sprintf("the cancel function returned by %s is never called", name), opts...)
+		} else {
+			line := pass.Fset.PositionFor(leak.Return, false).Line
+			report.Report(pass, leak.Source, fmt.Sprintf("the cancel function returned by %s is not called when returning on line %d", name, line), opts...)
+		}
+	}
+	return nil, nil
+}
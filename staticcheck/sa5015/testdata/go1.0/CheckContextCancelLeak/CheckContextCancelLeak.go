@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+func prepare(ctx context.Context) error { return nil }
+
+func fn1(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second) //@ diag(`the cancel function returned by context.WithTimeout is not called when returning on line 14`)
+	if err := prepare(ctx); err != nil {
+		return err
+	}
+	defer cancel()
+	return nil
+}
+
+func fn2(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	return prepare(ctx)
+}
+
+func fn3(ctx context.Context) {
+	ctx, _ = context.WithCancel(ctx) //@ diag(`the cancel function returned by context.WithCancel is never called`)
+	prepare(ctx)
+}
+
+func fn4(ctx context.Context) (context.Context, context.CancelFunc) {
+	// Returning the cancel function hands responsibility to the caller.
+	return context.WithDeadline(ctx, time.Now())
+}
+
+func fn5(ctx context.Context) {
+	ctx, cancel := fn4(ctx) //@ diag(`the cancel function returned by example.com/CheckContextCancelLeak.fn4 is never called`)
+	_ = cancel
+	prepare(ctx)
+}
+
+func stop(cancel context.CancelFunc) {
+	cancel()
+}
+
+func fn6(ctx context.Context) {
+	// Passing the cancel function to a function that calls it counts as calling it.
+	ctx, cancel := context.WithCancel(ctx)
+	prepare(ctx)
+	stop(cancel)
+}
+
+type T struct {
+	cancel context.CancelFunc
+}
+
+func (t *T) start(ctx context.Context) {
+	// Storing the cancel function hands it to the owner of t.
+	ctx, t.cancel = context.WithCancel(ctx)
+	prepare(ctx)
+}
+
+func fn7(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		defer cancel()
+		prepare(ctx)
+	}()
+}
+
+func fn8(ctx context.Context, ok bool) error {
+	ctx, cancel := context.WithCancelCause(ctx) //@ diag(`not called when returning on line 74`)
+	if !ok {
+		return errors.New("not ok")
+	}
+	cancel(nil)
+	return nil
+}
+
+func fn9(ctx context.Context, ok bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	if !ok {
+		panic("not ok")
+	}
+	cancel()
+	return nil
+}
@@ -0,0 +1,303 @@
+package sa5016
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"honnef.co/go/tools/analysis/facts/nilness"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/go/types/typeutil"
+	"honnef.co/go/tools/internal/passes/buildir"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA5016",
+		Run:      run,
+		Requires: []*analysis.Analyzer{buildir.Analyzer, nilness.Analysis},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Dereferencing a result that is nil when the function returned an error`,
+		Text: `Many functions return a nil result together with a non-nil
+error. Using such a result before checking the error, or after
+finding that the error isn't nil, dereferences a nil pointer:
+
+    resp, err := http.Get(url)
+    defer resp.Body.Close() // resp is nil if err != nil
+    if err != nil {
+        return err
+    }
+
+Staticcheck knows about such functions in the standard library and
+infers the behavior of other functions from their return statements.`,
+		Since:    "Unreleased",
+		Severity: lint.SeverityWarning,
+		MergeIf:  lint.MergeIfAny,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	for _, fn := range pass.ResultOf[buildir.Analyzer].(*buildir.IR).SrcFuncs {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if call, ok := instr.(*ir.Call); ok {
+					checkCall(pass, fn, call)
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// errorCheck is a comparison of an error against nil.
+type errorCheck struct {
+	iff *ir.If
+	// nilSucc and errSucc are the blocks that are only executed if the error is nil or non-nil, respectively. Either
+	// may be nil if there is no such block.
+	nilSucc, errSucc *ir.BasicBlock
+}
+
+func checkCall(pass *analysis.Pass, fn *ir.Function, call *ir.Call) {
+	callee := call.Common().StaticCallee()
+	if callee == nil {
+		return
+	}
+	obj, ok := callee.Object().(*types.Func)
+	if !ok {
+		return
+	}
+	results := callee.Signature.Results()
+	if results.Len() < 2 {
+		return
+	}
+	extracts := make([]*ir.Extract, results.Len())
+	for _, ref := range *call.Referrers() {
+		if ex, ok := ref.(*ir.Extract); ok {
+			extracts[ex.Index] = ex
+		}
+	}
+	errEx := extracts[results.Len()-1]
+	if errEx == nil {
+		// The error is ignored altogether.
+		return
+	}
+	checks := errorChecks(errEx)
+	if len(checks) == 0 {
+		// The error is never compared against nil; we don't know how it is being handled.
+		return
+	}
+
+	nilRes := pass.ResultOf[nilness.Analysis].(*nilness.Result)
+	for i, ex := range extracts[:results.Len()-1] {
+		if ex == nil || !nilRes.NilOnError(obj, i) {
+			continue
+		}
+		use := firstUnsafeUse(fn, ex, checks, nilRes)
+		if use.instr == nil {
+			continue
+		}
+		name := resultName(ex)
+		if use.check.errSucc != nil {
+			report.Report(pass, use.instr, fmt.Sprintf("%s is nil because %s returned an error", name, obj.Name()),
+				report.Related(use.check.iff, "the error is checked here"))
+		} else {
+			report.Report(pass, use.instr, fmt.Sprintf("%s may be nil here because %s may have returned an error", name, obj.Name()),
+				report.Related(checks[0].iff, "the error is checked here"))
+		}
+	}
+}
+
+// errorChecks returns the places where err, or a value derived from it, is compared against nil.
+func errorChecks(err ir.Value) []errorCheck {
+	var checks []errorCheck
+	for v := range derived(err, func(*ir.Sigma) bool { return true }) {
+		for _, ref := range *v.Referrers() {
+			binop, ok := ref.(*ir.BinOp)
+			if !ok || (binop.Op != token.EQL && binop.Op != token.NEQ) {
+				continue
+			}
+			if !isNil(binop.X) && !isNil(binop.Y) {
+				continue
+			}
+			for _, use := range *binop.Referrers() {
+				iff, ok := use.(*ir.If)
+				if !ok {
+					continue
+				}
+				check := errorCheck{iff: iff}
+				// The first successor is taken if the condition holds.
+				taken, notTaken := only(iff.Block(), 0), only(iff.Block(), 1)
+				if binop.Op == token.EQL {
+					check.nilSucc, check.errSucc = taken, notTaken
+				} else {
+					check.nilSucc, check.errSucc = notTaken, taken
+				}
+				checks = append(checks, check)
+			}
+		}
+	}
+	return checks
+}
+
+// derived returns v and the values that are copies of it: sigmas accepted by follow, and phis all of whose edges are
+// copies of v.
+func derived(v ir.Value, follow func(*ir.Sigma) bool) map[ir.Value]struct{} {
+	values := map[ir.Value]struct{}{}
+	var visit func(v ir.Value)
+	visit = func(v ir.Value) {
+		if _, ok := values[v]; ok {
+			return
+		}
+		values[v] = struct{}{}
+		for _, ref := range *v.Referrers() {
+			switch ref := ref.(type) {
+			case *ir.Sigma:
+				if follow(ref) {
+					visit(ref)
+				}
+			case *ir.Phi:
+				// Phis are visited once all of their edges have
+				// been, which happens when visiting the last edge.
+				all := true
+				for _, edge := range ref.Edges {
+					if _, ok := values[edge]; !ok && edge != ref {
+						all = false
+						break
+					}
+				}
+				if all {
+					visit(ref)
+				}
+			}
+		}
+	}
+	visit(v)
+	return values
+}
+
+// only returns b's i'th successor if b is its only predecessor.
+func only(b *ir.BasicBlock, i int) *ir.BasicBlock {
+	succ := b.Succs[i]
+	if len(succ.Preds) != 1 {
+		return nil
+	}
+	return succ
+}
+
+func isNil(v ir.Value) bool {
+	k, ok := v.(*ir.Const)
+	return ok && k.IsNil()
+}
+
+type unsafeUse struct {
+	instr ir.Instruction
+	// check is the error check that guarantees that the error is non-nil, if any.
+	check errorCheck
+}
+
+// firstUnsafeUse returns the first dereference of res, or of values derived from it, that isn't guarded by a check
+// finding the error to be nil.
+func firstUnsafeUse(fn *ir.Function, res ir.Value, checks []errorCheck, nilRes *nilness.Result) unsafeUse {
+	values := derived(res, func(sigma *ir.Sigma) bool { return !comparesToNil(sigma) })
+
+	for _, b := range fn.DomPreorder() {
+		guarded := false
+		var errCheck errorCheck
+		for _, check := range checks {
+			if check.nilSucc != nil && check.nilSucc.Dominates(b) {
+				guarded = true
+				break
+			}
+			if check.errSucc != nil && check.errSucc.Dominates(b) {
+				errCheck = check
+			}
+		}
+		if guarded {
+			continue
+		}
+		for _, instr := range b.Instrs {
+			for _, v := range dereferenced(instr, nilRes) {
+				if _, ok := values[v]; ok {
+					return unsafeUse{instr, errCheck}
+				}
+			}
+		}
+	}
+	return unsafeUse{}
+}
+
+// comparesToNil reports whether sigma's value was compared against nil by the branch that introduced sigma.
+func comparesToNil(sigma *ir.Sigma) bool {
+	iff, ok := sigma.From.Control().(*ir.If)
+	if !ok {
+		return false
+	}
+	binop, ok := iff.Cond.(*ir.BinOp)
+	if !ok {
+		return false
+	}
+	return binop.X == sigma.X && isNil(binop.Y) || binop.Y == sigma.X && isNil(binop.X)
+}
+
+// dereferenced returns the values that instr dereferences.
+func dereferenced(instr ir.Instruction, nilRes *nilness.Result) []ir.Value {
+	switch instr := instr.(type) {
+	case *ir.Load:
+		return []ir.Value{instr.X}
+	case *ir.Store:
+		return []ir.Value{instr.Addr}
+	case *ir.FieldAddr:
+		return []ir.Value{instr.X}
+	case *ir.IndexAddr:
+		if _, ok := typeutil.CoreType(instr.X.Type()).(*types.Pointer); ok {
+			return []ir.Value{instr.X}
+		}
+	case *ir.MapUpdate:
+		return []ir.Value{instr.Map}
+	case *ir.TypeAssert:
+		if !instr.CommaOk {
+			return []ir.Value{instr.X}
+		}
+	case ir.CallInstruction:
+		common := instr.Common()
+		if common.IsInvoke() {
+			return []ir.Value{common.Value}
+		}
+		callee := common.StaticCallee()
+		if callee == nil {
+			return nil
+		}
+		obj, ok := callee.Object().(*types.Func)
+		if !ok {
+			return nil
+		}
+		var out []ir.Value
+		for i, arg := range common.Args {
+			if _, ok := nilRes.Dereferences(obj, i); ok {
+				out = append(out, arg)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// resultName returns the name of the variable that res is assigned to.
+func resultName(res *ir.Extract) string {
+	for _, ref := range *res.Referrers() {
+		if ref, ok := ref.(*ir.DebugRef); ok {
+			if ident, ok := ref.Expr.(*ast.Ident); ok {
+				return ident.Name
+			}
+		}
+	}
+	return "the result"
+}
@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+)
+
+type R struct{ x int }
+
+func (r *R) Close() error { r.x = 0; return nil }
+
+func open(fail bool) (*R, error) {
+	if fail {
+		return nil, errors.New("failed")
+	}
+	return &R{}, nil
+}
+
+func wrap(fail bool) (*R, error) {
+	r, err := open(fail)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func get(r *R) int { return r.x }
+
+func fn1(url string) error {
+	resp, err := http.Get(url)
+	defer resp.Body.Close() //@ diag(`resp may be nil here because Get may have returned an error`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func fn2(url string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return nil
+}
+
+func fn3() {
+	r, err := open(true)
+	if err != nil {
+		log.Println(r.x) //@ diag(`r is nil because open returned an error`)
+		return
+	}
+	_ = r.x
+}
+
+func fn4() {
+	r, err := wrap(true)
+	println(get(r)) //@ diag(`r may be nil here because wrap may have returned an error`)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func fn5() {
+	r, err := open(true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	r.Close()
+}
+
+func fn6() {
+	r, err := open(true)
+	if r != nil {
+		defer r.Close()
+	}
+	if err != nil {
+		return
+	}
+}
+
+func fn7() {
+	r, _ := open(true)
+	_ = r.x
+}
+
+func fn8() {
+	r, err := open(true)
+	if err == nil {
+		_ = r.x
+	}
+}
+
+func fn9(name string) {
+	f, err := os.Open(name)
+	if err != nil {
+		log.Println("couldn't open file:", f.Name()) //@ diag(`f is nil because Open returned an error`)
+		return
+	}
+	f.Close()
+}
+
+func fn10() {
+	r, err := open(true)
+	if err != nil {
+		r = &R{}
+	}
+	_ = r.x
+}
+
+func fn11(want bool) {
+	r, err := open(want)
+	bad := false
+	if want {
+		if err != nil {
+			bad = true
+		}
+	} else {
+		if err == nil {
+			bad = true
+		}
+	}
+	if err == nil {
+		if bad {
+			println(r.x)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package sa5017
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"honnef.co/go/tools/analysis/code"
+	"honnef.co/go/tools/analysis/facts/generated"
+	"honnef.co/go/tools/analysis/facts/nilness"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+	"honnef.co/go/tools/config"
+	"honnef.co/go/tools/go/ast/astutil"
+	"honnef.co/go/tools/go/types/typeutil"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA5017",
+		Run:      run,
+		Requires: []*analysis.Analyzer{inspect.Analyzer, generated.Analyzer, config.Analyzer, nilness.Analysis},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Error result is not checked`,
+		Text: `A function call is used as a statement, discarding the error that
+the function returns. Errors should be handled, or explicitly
+discarded by assigning them to the blank identifier:
+
+    f.Close()     // flagged
+    _ = f.Close() // not flagged
+
+Functions whose error results are always nil, such as
+\'(*bytes.Buffer).Write\' and functions that only return the errors
+of such functions, are not flagged. Other functions whose errors are
+conventionally ignored, such as \'fmt.Println\', can be listed in
+the \'ignored_errors\' option.`,
+		Since:      "Unreleased",
+		NonDefault: true,
+		Options:    []string{"ignored_errors"},
+		Severity:   lint.SeverityWarning,
+		MergeIf:    lint.MergeIfAll,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	ignored := map[string]struct{}{}
+	for _, name := range config.For(pass).IgnoredErrors {
+		ignored[name] = struct{}{}
+	}
+	nilRes := pass.ResultOf[nilness.Analysis].(*nilness.Result)
+
+	fn := func(node ast.Node) {
+		call, ok := astutil.Unparen(node.(*ast.ExprStmt).X).(*ast.CallExpr)
+		if !ok {
+			return
+		}
+		callee, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if !ok {
+			// Builtins, conversions and calls of function values.
+			return
+		}
+		sig, ok := pass.TypesInfo.TypeOf(call.Fun).Underlying().(*types.Signature)
+		if !ok {
+			return
+		}
+		results := sig.Results()
+		if results.Len() == 0 || !types.Identical(results.At(results.Len()-1).Type(), types.Universe.Lookup("error").Type()) {
+			return
+		}
+		callee = callee.Origin()
+		if nilRes.Infallible(callee) {
+			return
+		}
+		name := typeutil.FuncName(callee)
+		if _, ok := ignored[name]; ok {
+			return
+		}
+		report.Report(pass, call, fmt.Sprintf("error returned by %s is not checked", name), report.FilterGenerated())
+	}
+	code.Preorder(pass, fn, (*ast.ExprStmt)(nil))
+	return nil, nil
+}
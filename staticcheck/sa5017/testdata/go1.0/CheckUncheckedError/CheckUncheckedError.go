@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type T struct{}
+
+func (T) Close() error         { return errors.New("closed") }
+func (*T) Flush() (int, error) { return 0, nil }
+func (*T) Sync() error         { return os.ErrClosed }
+
+func mayFail(x bool) error {
+	if x {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func write(buf *bytes.Buffer, s string) error {
+	_, err := buf.WriteString(s)
+	return err
+}
+
+func fn1(f *os.File, w io.Writer, t T, buf *bytes.Buffer, sb *strings.Builder) {
+	f.Close()        //@ diag(`error returned by (*os.File).Close is not checked`)
+	w.Write(nil)     //@ diag(`error returned by (io.Writer).Write is not checked`)
+	t.Close()        //@ diag(`error returned by (example.com/CheckUncheckedError.T).Close is not checked`)
+	(&t).Sync()      //@ diag(`error returned by (*example.com/CheckUncheckedError.T).Sync is not checked`)
+	mayFail(true)    //@ diag(`error returned by example.com/CheckUncheckedError.mayFail is not checked`)
+	(mayFail(false)) //@ diag(`error returned by example.com/CheckUncheckedError.mayFail is not checked`)
+	os.Remove("foo") //@ diag(`error returned by os.Remove is not checked`)
+
+	(&t).Flush()
+	buf.Write(nil)
+	buf.WriteString("")
+	sb.WriteString("")
+	write(buf, "")
+	fmt.Println("")
+	fmt.Fprintf(w, "")
+
+	_ = f.Close()
+	defer f.Close()
+	go mayFail(true)
+	if err := mayFail(true); err != nil {
+		return
+	}
+	fn := func() error { return nil }
+	fn()
+	func() error { return nil }()
+}
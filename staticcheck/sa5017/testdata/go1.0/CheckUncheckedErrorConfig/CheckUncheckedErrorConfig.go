@@ -0,0 +1,13 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+)
+
+func fn(f *os.File) {
+	f.Close()
+	os.Remove("foo")
+	fmt.Println("") //@ diag(`error returned by fmt.Println is not checked`)
+	f.Sync()        //@ diag(`error returned by (*os.File).Sync is not checked`)
+}
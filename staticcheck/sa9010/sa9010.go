@@ -0,0 +1,99 @@
+package sa9010
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+
+	"honnef.co/go/tools/analysis/intervals"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/internal/passes/buildir"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA9010",
+		Run:      run,
+		Requires: []*analysis.Analyzer{buildir.Analyzer, intervals.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Shift amount is always at least the size of the shifted value`,
+		Text: `Shifting a value by at least its size in bits always results in
+0, or in -1 when shifting negative values to the right. For example:
+
+    func f(x uint32, n uint) uint32 {
+        if n < 32 {
+            return x
+        }
+        return x >> n
+    }
+
+Unlike SA9006, this check flags shift amounts that aren't constant,
+but whose possible values are all too large, as derived from
+arithmetic and the conditions of branches leading to the shift.
+The sizes of int, uint and uintptr depend on the platform, and shifts
+of these types are only flagged if the shift amount is at least 64.`,
+		Since:    "Unreleased",
+		Severity: lint.SeverityWarning,
+		MergeIf:  lint.MergeIfAll,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	res := pass.ResultOf[intervals.Analyzer].(intervals.Result)
+	for _, fn := range pass.ResultOf[buildir.Analyzer].(*buildir.IR).SrcFuncs {
+		ranges := res[fn]
+		for _, b := range fn.Blocks {
+			if ranges.Dead(b) {
+				continue
+			}
+			for _, instr := range b.Instrs {
+				binop, ok := instr.(*ir.BinOp)
+				if !ok || (binop.Op != token.SHL && binop.Op != token.SHR) || !binop.Pos().IsValid() {
+					continue
+				}
+				if _, ok := binop.Y.(*ir.Const); ok {
+					// Constant shifts are SA9006's job.
+					continue
+				}
+				basic, ok := binop.X.Type().Underlying().(*types.Basic)
+				if !ok {
+					continue
+				}
+				var bits int64
+				var value string
+				if intervals.WordSized(basic) {
+					// The shift has to clear the value on 64-bit platforms, too.
+					bits = 64
+					value = basic.Name() + " value"
+				} else {
+					bits = pass.TypesSizes.Sizeof(basic) * 8
+					value = fmt.Sprintf("%d-bit value", bits)
+				}
+				n := ranges.Value(binop.Y)
+				if !n.Known() || n.Lo < bits {
+					continue
+				}
+				result := "clear it"
+				if binop.Op == token.SHR && basic.Info()&types.IsUnsigned == 0 {
+					// Shifting signed values to the right preserves their sign.
+					switch x := ranges.Value(binop.X); {
+					case x.Known() && x.Hi < 0:
+						result = "result in -1"
+					case x.Known() && x.Lo >= 0:
+					default:
+						result = "result in 0 or -1"
+					}
+				}
+				report.Report(pass, binop, fmt.Sprintf("shift amount is always at least %d, shifting %s will always %s", n.Lo, value, result))
+			}
+		}
+	}
+	return nil, nil
+}
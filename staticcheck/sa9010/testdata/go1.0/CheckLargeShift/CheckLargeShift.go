@@ -0,0 +1,35 @@
+package pkg
+
+func fn1(x uint32, y int8, z int, n uint) {
+	_ = x >> n
+	if n < 32 {
+		_ = x >> n
+	} else {
+		_ = x >> n //@ diag(`shift amount is always at least 32, shifting 32-bit value will always clear it`)
+	}
+	if n >= 8 {
+		_ = y << n //@ diag(`shifting 8-bit value`)
+		_ = z << n
+	}
+	if n >= 32 {
+		// int may be 64 bits wide
+		_ = z << n
+		_ = y >> n //@ diag(`shifting 8-bit value will always result in 0 or -1`)
+		if y < 0 {
+			_ = y >> n //@ diag(`shifting 8-bit value will always result in -1`)
+		}
+		if y >= 0 {
+			_ = y >> n //@ diag(`shifting 8-bit value will always clear it`)
+		}
+	}
+	if n > 64 {
+		_ = z << n //@ diag(`shift amount is always at least 65, shifting int value will always clear it`)
+	}
+	if n < 100 {
+		m := n + 40
+		_ = x << m //@ diag(`shift amount is always at least 40`)
+	}
+	// n+40 may overflow
+	_ = x << (n + 40)
+	_ = x << 40
+}
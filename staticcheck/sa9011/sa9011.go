@@ -0,0 +1,111 @@
+package sa9011
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+
+	"honnef.co/go/tools/analysis/intervals"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/analysis/report"
+	"honnef.co/go/tools/go/ir"
+	"honnef.co/go/tools/internal/passes/buildir"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var SCAnalyzer = lint.InitializeAnalyzer(&lint.Analyzer{
+	Analyzer: &analysis.Analyzer{
+		Name:     "SA9011",
+		Run:      run,
+		Requires: []*analysis.Analyzer{buildir.Analyzer, intervals.Analyzer},
+	},
+	Doc: &lint.RawDocumentation{
+		Title: `Integer conversion always changes the value`,
+		Text: `None of the values that are being converted fit in the target
+type, which means that the conversion always truncates the value or
+changes its sign. For example:
+
+    if x > 255 {
+        b := byte(x)
+    }
+
+The ranges of values are derived from constants, arithmetic and the
+conditions of branches leading to the conversion.`,
+		Since:    "Unreleased",
+		Severity: lint.SeverityWarning,
+		MergeIf:  lint.MergeIfAll,
+	},
+})
+
+var Analyzer = SCAnalyzer.Analyzer
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	res := pass.ResultOf[intervals.Analyzer].(intervals.Result)
+	for _, fn := range pass.ResultOf[buildir.Analyzer].(*buildir.IR).SrcFuncs {
+		ranges := res[fn]
+		for _, b := range fn.Blocks {
+			if ranges.Dead(b) {
+				continue
+			}
+			for _, instr := range b.Instrs {
+				conv, ok := instr.(*ir.Convert)
+				if !ok || !conv.Pos().IsValid() || !isInteger(conv.Type()) || !isInteger(conv.X.Type()) {
+					continue
+				}
+				if _, ok := conv.X.(*ir.Const); ok {
+					continue
+				}
+				x, to := ranges.Value(conv.X), ranges.Value(conv)
+				if !x.Known() || !to.Known() {
+					continue
+				}
+				if _, ok := x.Singleton(); ok {
+					// Converting a known value is usually deliberate, as in uint8(-1 & mask).
+					continue
+				}
+				if bitwise(conv.X) {
+					// Truncating the result of bit manipulation is usually deliberate, as in uint16(^n).
+					continue
+				}
+				target := intervals.Range(conv.Type())
+				if x.Lo > target.Hi || x.Hi < target.Lo {
+					source := intervals.Range(conv.X.Type())
+					report.Report(pass, conv, fmt.Sprintf("converting value that is %s to %s always changes it", describe(x, source), conv.Type()))
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+func isInteger(T types.Type) bool {
+	basic, ok := T.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsInteger != 0
+}
+
+// bitwise reports whether v is the complement of a value, or the result of masking it.
+func bitwise(v ir.Value) bool {
+	switch v := v.(type) {
+	case *ir.UnOp:
+		return v.Op == token.XOR
+	case *ir.BinOp:
+		switch v.Op {
+		case token.AND, token.AND_NOT, token.OR, token.XOR:
+			return true
+		}
+	}
+	return false
+}
+
+// describe describes the interval iv of values of a type whose range is full.
+func describe(iv, full intervals.Interval) string {
+	switch {
+	case iv.Hi >= full.Hi:
+		return fmt.Sprintf("at least %d", iv.Lo)
+	case iv.Lo <= full.Lo:
+		return fmt.Sprintf("at most %d", iv.Hi)
+	default:
+		return fmt.Sprintf("in [%d, %d]", iv.Lo, iv.Hi)
+	}
+}
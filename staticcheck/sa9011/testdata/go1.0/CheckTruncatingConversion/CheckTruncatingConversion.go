@@ -0,0 +1,33 @@
+package pkg
+
+func fn1(x int, y uint32, z int64) {
+	_ = uint8(x)
+	if x > 255 {
+		_ = uint8(x) //@ diag(`converting value that is at least 256 to uint8 always changes it`)
+		_ = uint16(x)
+		_ = int8(x) //@ diag(`to int8 always changes it`)
+	}
+	if x < 0 {
+		_ = uint(x) //@ diag(`converting value that is at most -1 to uint always changes it`)
+		_ = int32(x)
+	}
+	if y >= 1<<16 && y < 1<<20 {
+		_ = uint16(y) //@ diag(`converting value that is in [65536, 1048575] to uint16 always changes it`)
+		_ = uint64(y)
+	}
+	if z > 1<<40 {
+		_ = uint32(z) //@ diag(`to uint32 always changes it`)
+		_ = uint64(z)
+	}
+	_ = uint8(300 + x*0)
+	v := 1000
+	_ = uint8(v)
+}
+
+func fn2(n int, mask int) {
+	if n >= 0 && n < 1<<16 {
+		_ = uint16(^n)
+		_ = uint8((n | 256) &^ mask)
+		_ = uint8(n + 1<<16) //@ diag(`to uint8 always changes it`)
+	}
+}
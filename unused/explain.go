@@ -0,0 +1,162 @@
+package unused
+
+import (
+	"fmt"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A Use describes why an object is used.
+type Use struct {
+	// Object is the used object.
+	Object Object
+	// By is the object that uses Object. It is the zero value if Object is used by the package itself.
+	By Object
+	// Rule is the number of the rule in the overview of this package that causes the use, such as "4.5". It is empty
+	// if no more specific rule applies than Object being referred to by By.
+	Rule string
+}
+
+// ruleDescriptions describe the rules from the overview of this package, as format strings that take the used object
+// and the object using it as arguments.
+var ruleDescriptions = map[string]string{
+	"1.1":  "%[1]s is an exported named type",
+	"1.2":  "%[1]s is an exported function",
+	"1.3":  "%[1]s is an exported variable",
+	"1.4":  "%[1]s is an exported constant",
+	"1.5":  "%[1]s is an init function",
+	"1.6":  "%[1]s is exported to cgo",
+	"1.7":  "%[1]s is the main function",
+	"1.8":  "%[1]s is linked via go:linkname",
+	"1.9":  "%[1]s is declared in a generated file",
+	"1.10": "%[1]s is run by the testing package",
+	"1.11": "%[1]s is ignored via //lint:ignore U1000",
+	"2.1":  "%[1]s is an exported method of %[2]s",
+	"2.5":  "%[1]s is a type parameter of %[2]s",
+	"4.1":  "%[1]s is a parameter of %[2]s",
+	"4.5":  "%[1]s is called by %[2]s",
+	"4.6":  "%[1]s is used by %[2]s",
+	"4.9":  "%[1]s is assigned to in a test by %[2]s",
+	"4.10": "%[1]s is a type parameter of %[2]s",
+	"4.11": "%[1]s is a local variable of %[2]s",
+	"5.1":  "%[1]s is converted to or from %[2]s",
+	"5.2":  "%[1]s is converted to or from unsafe.Pointer by %[2]s",
+	"6.1":  "%[1]s is a NoCopy sentinel in %[2]s",
+	"6.2":  "%[1]s is an exported field of %[2]s",
+	"6.5":  "%[1]s is an embedded struct with exported fields in %[2]s",
+	"6.6":  "%[1]s is a field of %[2]s, which has a structs.HostLayout field",
+	"7.1":  "%[1]s is accessed by %[2]s",
+	"7.2":  "%[1]s is the type of %[2]s",
+	"8.2":  "%[1]s helps %[2]s implement an interface",
+	"8.3":  "%[1]s is an interface method of %[2]s",
+	"9.2":  "%[1]s is the type of %[2]s",
+	"9.3":  "%[1]s is used in the definition of %[2]s",
+	"9.7":  "%[1]s is read by %[2]s",
+	"9.8":  "%[1]s may be called by the compiler",
+	"9.9":  "%[1]s is named the blank identifier",
+	"10.1": "%[1]s is in the same constant group as %[2]s",
+	"11.1": "%[1]s is a field of an anonymous struct in %[2]s",
+	"12.1": "%[1]s is the constraint of %[2]s",
+}
+
+// inferRule returns the rule that most likely caused a use that is merely the result of used being referred to by by.
+func inferRule(used, by Object) string {
+	switch {
+	case by.Kind == "type param":
+		return "12.1"
+	case used.Kind == "type param" && by.Kind == "type":
+		return "2.5"
+	case used.Kind == "type param":
+		return "4.10"
+	case used.Kind == "field":
+		return "7.1"
+	case used.Kind == "func" && by.Kind == "func":
+		return "4.5"
+	case used.Kind == "type" && by.Kind == "field":
+		return "7.2"
+	case used.Kind == "type" && (by.Kind == "var" || by.Kind == "const"):
+		return "9.2"
+	case used.Kind == "type" && by.Kind == "type":
+		return "9.3"
+	case used.Kind == "type" && by.Kind == "func":
+		return "4.6"
+	case used.Kind == "var" || used.Kind == "const":
+		return "9.7"
+	default:
+		return ""
+	}
+}
+
+func (u Use) String() string {
+	used := fmt.Sprintf("%s %s", u.Object.Kind, u.Object.Name)
+	by := fmt.Sprintf("%s %s", u.By.Kind, u.By.Name)
+	if u.By.Kind == "" {
+		by = "the package"
+	}
+	rule := u.Rule
+	if rule == "" && u.By.Kind != "" {
+		rule = inferRule(u.Object, u.By)
+	}
+	desc, ok := ruleDescriptions[rule]
+	if !ok {
+		desc = "%[1]s is used by %[2]s"
+	}
+	s := fmt.Sprintf(desc, used, by)
+	if rule != "" {
+		s = fmt.Sprintf("(%s) %s", rule, s)
+	}
+	return fmt.Sprintf("%s: %s", u.Object.DisplayPosition, s)
+}
+
+// Explain returns the shortest chain of uses that leads from one of the roots of the package analyzed by pass, such as
+// its exported API or its init functions, to obj. It returns nil if obj is unused. The pass must provide the results
+// of the analyzers required by Analyzer.
+func Explain(pass *analysis.Pass, obj types.Object) []Use {
+	g := newPassGraph(pass, passOptions(pass))
+	g.rules = map[edge]string{}
+	g.entry()
+
+	target, ok := g.objects[origin(obj)]
+	if !ok {
+		return nil
+	}
+
+	// Breadth-first search from the root, which has ID 0.
+	parents := make([]NodeID, len(g.nodes))
+	seen := make([]bool, len(g.nodes))
+	seen[0] = true
+	queue := []NodeID{0}
+	for len(queue) > 0 && !seen[target] {
+		id := queue[0]
+		queue = queue[1:]
+		for _, used := range g.nodes[id].uses {
+			if seen[used] {
+				continue
+			}
+			seen[used] = true
+			parents[used] = id
+			queue = append(queue, used)
+		}
+	}
+	if !seen[target] {
+		return nil
+	}
+
+	var chain []Use
+	for id := target; id != 0; id = parents[id] {
+		by := parents[id]
+		u := Use{
+			Object: g.nodes[id].obj,
+			Rule:   g.rules[edge{by, id, edgeKindUse}],
+		}
+		if by != 0 {
+			u.By = g.nodes[by].obj
+		}
+		chain = append(chain, u)
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
@@ -0,0 +1,615 @@
+package unused
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"honnef.co/go/tools/analysis/code"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Edit describes the replacement of a range of source code. Positions are not adjusted by //line directives.
+type Edit struct {
+	Position token.Position
+	End      token.Position
+	NewText  string
+}
+
+// edit is the unserialized form of Edit.
+type edit struct {
+	pos, end token.Pos
+	text     string
+}
+
+// listItem is a node that is an element of a list of nodes, such as a declaration in a file, a spec in a group, or a
+// field in a struct. The bounds of its neighbours determine how much of the surrounding source code gets deleted
+// together with the node.
+type listItem struct {
+	file *ast.File
+	// The start of the node, including its doc comment, and its end.
+	start, end token.Pos
+	// The end of the previous element, or of the list's opening token, and the start of the next element, or of the
+	// list's closing token. Either may be token.NoPos if the list has no such delimiter.
+	prevEnd, nextStart token.Pos
+}
+
+type declKind uint8
+
+const (
+	declFunc declKind = iota + 1
+	declSpec
+	declField
+)
+
+// declInfo describes where an object is declared.
+type declInfo struct {
+	kind declKind
+	item listItem
+
+	// For declSpec, the declaration, the spec, and the index of the name in the spec.
+	gen  *ast.GenDecl
+	spec ast.Spec
+	name int
+
+	// For declField, the field and the index of the name in the field. name is -1 for embedded fields.
+	field    *ast.Field
+	inStruct *types.Struct
+}
+
+// fixer computes the edits needed for deleting the declarations of unused objects.
+//
+// Removing a declaration is straightforward in most cases, but care has to be taken not to change the meaning of the
+// remaining code. In particular:
+//
+//   - constants in groups that rely on implicit repetition or iota would change values if an earlier constant were
+//     deleted, so they get renamed to the blank identifier instead;
+//   - variables whose initializers may have side effects get renamed to the blank identifier so that the side effects
+//     remain;
+//   - fields of structs that may be subject to low-level layout considerations, such as in packages using unsafe or
+//     cgo, or in structs with explicit padding, get renamed to the blank identifier, which doesn't change the struct's
+//     size, alignment or offsets;
+//   - fields of structs that are used in unkeyed composite literals or in conversions between struct types cannot be
+//     removed without also updating the literals and the other types, so we don't offer fixes for them;
+//   - fields that are referred to, such as by writes when field writes don't count as uses, or as keys in composite
+//     literals, cannot be removed without also removing the references, so we don't offer fixes for them, either;
+//   - deleting a type also deletes its methods;
+//   - imports that are no longer used after a deletion are deleted, too.
+type fixer struct {
+	pass *analysis.Pass
+
+	decls    map[types.Object]declInfo
+	specs    map[ast.Spec]listItem
+	genDecls map[*ast.GenDecl]listItem
+	methods  map[*types.TypeName][]listItem
+	imports  map[*ast.File][]*ast.ImportSpec
+	pkgNames map[*ast.ImportSpec]*types.PkgName
+	// The positions at which imported packages are referred to.
+	pkgNameUses map[*types.PkgName][]token.Pos
+	// Fields of struct types that are used in unkeyed composite literals or conversions, or that are referred to.
+	pinnedFields map[*types.Var]struct{}
+	// Whether the package cares about the memory layout of its types.
+	layoutSensitive bool
+}
+
+func newFixer(pass *analysis.Pass) *fixer {
+	fx := &fixer{
+		pass:         pass,
+		decls:        map[types.Object]declInfo{},
+		specs:        map[ast.Spec]listItem{},
+		genDecls:     map[*ast.GenDecl]listItem{},
+		methods:      map[*types.TypeName][]listItem{},
+		imports:      map[*ast.File][]*ast.ImportSpec{},
+		pkgNames:     map[*ast.ImportSpec]*types.PkgName{},
+		pkgNameUses:  map[*types.PkgName][]token.Pos{},
+		pinnedFields: map[*types.Var]struct{}{},
+	}
+
+	for id, obj := range pass.TypesInfo.Uses {
+		switch obj := obj.(type) {
+		case *types.PkgName:
+			fx.pkgNameUses[obj] = append(fx.pkgNameUses[obj], id.Pos())
+		case *types.Var:
+			if obj.IsField() {
+				fx.pinnedFields[obj.Origin()] = struct{}{}
+			}
+		}
+	}
+	// Selecting promoted fields and methods refers to the embedded fields they're promoted through.
+	for _, sel := range pass.TypesInfo.Selections {
+		T := sel.Recv()
+		for _, idx := range sel.Index()[:len(sel.Index())-1] {
+			s := structOf(T)
+			if s == nil {
+				break
+			}
+			field := s.Field(idx)
+			fx.pinnedFields[field.Origin()] = struct{}{}
+			T = field.Type()
+		}
+	}
+
+	for _, f := range pass.Files {
+		for _, spec := range f.Imports {
+			fx.imports[f] = append(fx.imports[f], spec)
+			var pn types.Object
+			if spec.Name != nil {
+				pn = pass.TypesInfo.Defs[spec.Name]
+			} else {
+				pn = pass.TypesInfo.Implicits[spec]
+			}
+			if pn, ok := pn.(*types.PkgName); ok {
+				fx.pkgNames[spec] = pn
+				if path := pn.Imported().Path(); path == "unsafe" || path == "C" {
+					fx.layoutSensitive = true
+				}
+			}
+		}
+
+		decls := make([]ast.Node, len(f.Decls))
+		for i, decl := range f.Decls {
+			decls[i] = decl
+		}
+		fx.list(f, decls, f.Name.End(), token.NoPos)
+
+		ast.Inspect(f, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.BlockStmt:
+				fx.list(f, stmts(node.List), node.Lbrace+1, node.Rbrace)
+			case *ast.CaseClause:
+				fx.list(f, stmts(node.Body), node.Colon+1, token.NoPos)
+			case *ast.CommClause:
+				fx.list(f, stmts(node.Body), node.Colon+1, token.NoPos)
+			case *ast.StructType:
+				fx.fields(f, node.Fields, structOf(pass.TypesInfo.TypeOf(node)))
+			case *ast.InterfaceType:
+				fx.fields(f, node.Methods, nil)
+			case *ast.CompositeLit:
+				if len(node.Elts) != 0 {
+					if _, ok := node.Elts[0].(*ast.KeyValueExpr); !ok {
+						fx.pin(pass.TypesInfo.TypeOf(node))
+					}
+				}
+			case *ast.CallExpr:
+				if len(node.Args) == 1 {
+					if tv, ok := pass.TypesInfo.Types[node.Fun]; ok && tv.IsType() {
+						if structOf(tv.Type) != nil && structOf(pass.TypesInfo.TypeOf(node.Args[0])) != nil {
+							fx.pin(tv.Type)
+							fx.pin(pass.TypesInfo.TypeOf(node.Args[0]))
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	return fx
+}
+
+func stmts(list []ast.Stmt) []ast.Node {
+	out := make([]ast.Node, len(list))
+	for i, stmt := range list {
+		out[i] = stmt
+	}
+	return out
+}
+
+// structOf returns the struct type underlying T, or a pointer to such a type.
+func structOf(T types.Type) *types.Struct {
+	if T == nil {
+		return nil
+	}
+	if ptr, ok := T.Underlying().(*types.Pointer); ok {
+		T = ptr.Elem()
+	}
+	s, _ := T.Underlying().(*types.Struct)
+	return s
+}
+
+func (fx *fixer) pin(T types.Type) {
+	s := structOf(T)
+	if s == nil {
+		return
+	}
+	for i := 0; i < s.NumFields(); i++ {
+		fx.pinnedFields[s.Field(i).Origin()] = struct{}{}
+	}
+}
+
+func docStart(doc *ast.CommentGroup, node ast.Node) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+	return node.Pos()
+}
+
+// list records the declarations in a list of declarations or statements.
+func (fx *fixer) list(f *ast.File, nodes []ast.Node, lbound, rbound token.Pos) {
+	for i, node := range nodes {
+		item := listItem{file: f, end: node.End(), prevEnd: lbound, nextStart: rbound}
+		if i > 0 {
+			item.prevEnd = nodes[i-1].End()
+		}
+		if i < len(nodes)-1 {
+			item.nextStart = nodes[i+1].Pos()
+		}
+
+		switch node := node.(type) {
+		case *ast.FuncDecl:
+			item.start = docStart(node.Doc, node)
+			obj, ok := fx.pass.TypesInfo.Defs[node.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			fx.decls[obj] = declInfo{kind: declFunc, item: item}
+			if recv := obj.Type().(*types.Signature).Recv(); recv != nil {
+				T := recv.Type()
+				if ptr, ok := T.(*types.Pointer); ok {
+					T = ptr.Elem()
+				}
+				if named, ok := T.(*types.Named); ok {
+					tn := named.Obj()
+					fx.methods[tn] = append(fx.methods[tn], item)
+				}
+			}
+		case *ast.GenDecl:
+			item.start = docStart(node.Doc, node)
+			fx.genDecl(f, node, item)
+		case *ast.DeclStmt:
+			if gen, ok := node.Decl.(*ast.GenDecl); ok {
+				item.start = docStart(gen.Doc, gen)
+				fx.genDecl(f, gen, item)
+			}
+		}
+	}
+}
+
+func (fx *fixer) genDecl(f *ast.File, gen *ast.GenDecl, item listItem) {
+	fx.genDecls[gen] = item
+	for i, spec := range gen.Specs {
+		sitem := listItem{file: f, end: spec.End(), prevEnd: gen.Lparen + 1, nextStart: gen.Rparen}
+		if !gen.Lparen.IsValid() {
+			sitem = item
+		}
+		if i > 0 {
+			sitem.prevEnd = gen.Specs[i-1].End()
+		}
+		if i < len(gen.Specs)-1 {
+			sitem.nextStart = gen.Specs[i+1].Pos()
+		}
+
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			if gen.Lparen.IsValid() {
+				sitem.start = docStart(spec.Doc, spec)
+			}
+			fx.specs[spec] = sitem
+			if obj := fx.pass.TypesInfo.Defs[spec.Name]; obj != nil {
+				fx.decls[obj] = declInfo{kind: declSpec, item: sitem, gen: gen, spec: spec}
+			}
+		case *ast.ValueSpec:
+			if gen.Lparen.IsValid() {
+				sitem.start = docStart(spec.Doc, spec)
+			}
+			fx.specs[spec] = sitem
+			for j, name := range spec.Names {
+				if obj := fx.pass.TypesInfo.Defs[name]; obj != nil {
+					fx.decls[obj] = declInfo{kind: declSpec, item: sitem, gen: gen, spec: spec, name: j}
+				}
+			}
+		case *ast.ImportSpec:
+			if gen.Lparen.IsValid() {
+				sitem.start = docStart(spec.Doc, spec)
+			}
+			fx.specs[spec] = sitem
+		}
+	}
+}
+
+// fields records the fields of a struct, or the methods of an interface if s is nil.
+func (fx *fixer) fields(f *ast.File, fields *ast.FieldList, s *types.Struct) {
+	for i, field := range fields.List {
+		item := listItem{
+			file:      f,
+			start:     docStart(field.Doc, field),
+			end:       field.End(),
+			prevEnd:   fields.Opening + 1,
+			nextStart: fields.Closing,
+		}
+		if i > 0 {
+			item.prevEnd = fields.List[i-1].End()
+		}
+		if i < len(fields.List)-1 {
+			item.nextStart = fields.List[i+1].Pos()
+		}
+
+		info := declInfo{kind: declField, item: item, field: field, inStruct: s}
+		if len(field.Names) == 0 {
+			if s == nil {
+				// Embedded interface
+				continue
+			}
+			info.name = -1
+			if obj := fx.pass.TypesInfo.Defs[embeddedIdent(field.Type)]; obj != nil {
+				fx.decls[obj] = info
+			}
+			continue
+		}
+		for j, name := range field.Names {
+			info.name = j
+			if obj := fx.pass.TypesInfo.Defs[name]; obj != nil {
+				fx.decls[obj] = info
+			}
+		}
+	}
+}
+
+func embeddedIdent(expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			return e.Sel
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// fix returns the edits that delete obj's declaration, or nil if obj cannot be deleted automatically.
+func (fx *fixer) fix(obj types.Object) []Edit {
+	info, ok := fx.decls[obj]
+	if !ok {
+		return nil
+	}
+
+	var edits []edit
+	switch info.kind {
+	case declFunc:
+		edits = append(edits, fx.deleteItem(info.item))
+
+	case declSpec:
+		gen := info.gen
+		switch spec := info.spec.(type) {
+		case *ast.TypeSpec:
+			edits = append(edits, fx.deleteSpec(gen, spec))
+			if tn, ok := obj.(*types.TypeName); ok {
+				for _, m := range fx.methods[tn] {
+					edits = append(edits, fx.deleteItem(m))
+				}
+			}
+		case *ast.ValueSpec:
+			name := spec.Names[info.name]
+			switch {
+			case gen.Tok == token.CONST && dependsOnPosition(gen),
+				gen.Tok == token.VAR && fx.mayHaveSideEffects(spec.Values),
+				len(spec.Names) > 1 && len(spec.Values) == 1:
+				// Keep the spec, but stop declaring the object.
+				edits = append(edits, edit{name.Pos(), name.End(), "_"})
+			case len(spec.Names) > 1:
+				edits = append(edits, deleteListElement(spec.Names, info.name))
+				if len(spec.Values) == len(spec.Names) {
+					edits = append(edits, deleteListElement(spec.Values, info.name))
+				}
+			default:
+				edits = append(edits, fx.deleteSpec(gen, spec))
+			}
+		default:
+			return nil
+		}
+
+	case declField:
+		if info.inStruct == nil {
+			// Interface method
+			edits = append(edits, fx.deleteItem(info.item))
+			break
+		}
+		if v, ok := obj.(*types.Var); ok {
+			if _, ok := fx.pinnedFields[v.Origin()]; ok {
+				return nil
+			}
+		}
+		if fx.layoutSensitive || hasPadding(info.inStruct) {
+			// Renaming the field to the blank identifier preserves the struct's memory layout.
+			if info.name == -1 {
+				edits = append(edits, edit{info.field.Type.Pos(), info.field.Type.Pos(), "_ "})
+			} else {
+				name := info.field.Names[info.name]
+				edits = append(edits, edit{name.Pos(), name.End(), "_"})
+			}
+		} else if len(info.field.Names) > 1 {
+			edits = append(edits, deleteListElement(info.field.Names, info.name))
+		} else {
+			edits = append(edits, fx.deleteItem(info.item))
+		}
+	}
+
+	edits = append(edits, fx.deleteImports(edits)...)
+	return fx.serialize(edits)
+}
+
+// deleteSpec deletes a spec, or its entire declaration if it is the declaration's only spec.
+func (fx *fixer) deleteSpec(gen *ast.GenDecl, spec ast.Spec) edit {
+	if len(gen.Specs) == 1 {
+		return fx.deleteItem(fx.genDecls[gen])
+	}
+	return fx.deleteItem(fx.specs[spec])
+}
+
+// dependsOnPosition reports whether the values of the constants in gen depend on the position of their specs, due to
+// the use of iota or implicit repetition.
+func dependsOnPosition(gen *ast.GenDecl) bool {
+	if len(gen.Specs) < 2 {
+		return false
+	}
+	for _, spec := range gen.Specs {
+		spec := spec.(*ast.ValueSpec)
+		if len(spec.Values) == 0 {
+			return true
+		}
+		for _, v := range spec.Values {
+			found := false
+			ast.Inspect(v, func(node ast.Node) bool {
+				if id, ok := node.(*ast.Ident); ok && id.Name == "iota" {
+					found = true
+				}
+				return !found
+			})
+			if found {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (fx *fixer) mayHaveSideEffects(exprs []ast.Expr) bool {
+	for _, expr := range exprs {
+		if code.MayHaveSideEffects(fx.pass, expr, nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPadding reports whether s has blank fields, which are usually used to control a struct's memory layout.
+func hasPadding(s *types.Struct) bool {
+	for i := 0; i < s.NumFields(); i++ {
+		if s.Field(i).Name() == "_" {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteListElement deletes the i-th element of a comma-separated list, as well as one of the adjacent commas.
+func deleteListElement[T ast.Node](list []T, i int) edit {
+	switch {
+	case len(list) == 1:
+		return edit{list[i].Pos(), list[i].End(), ""}
+	case i < len(list)-1:
+		return edit{list[i].Pos(), list[i+1].Pos(), ""}
+	default:
+		return edit{list[i-1].End(), list[i].End(), ""}
+	}
+}
+
+// deleteItem deletes an element of a list. If the element is on its own line, the entire line gets deleted, including
+// any trailing comments.
+func (fx *fixer) deleteItem(item listItem) edit {
+	tf := fx.pass.Fset.File(item.file.Pos())
+	line := func(pos token.Pos) int { return tf.Line(pos) }
+
+	start, end := item.start, item.end
+	for _, cg := range item.file.Comments {
+		if cg.Pos() >= end && line(cg.Pos()) == line(end) && (!item.nextStart.IsValid() || cg.End() <= item.nextStart) {
+			end = cg.End()
+		}
+	}
+
+	ownStart := !item.prevEnd.IsValid() || line(item.prevEnd) < line(start)
+	ownEnd := !item.nextStart.IsValid() || line(item.nextStart) > line(end)
+	switch {
+	case ownStart && ownEnd:
+		eof := token.Pos(tf.Base() + tf.Size())
+		// blank reports whether line l consists of only a newline.
+		blank := func(l int) bool {
+			return l >= 1 && l < tf.LineCount() && tf.LineStart(l+1)-tf.LineStart(l) == 1
+		}
+		first, last := line(start), line(end)
+		start = tf.LineStart(first)
+		if last < tf.LineCount() {
+			end = tf.LineStart(last + 1)
+		} else {
+			end = eof
+		}
+		// Don't leave two consecutive blank lines, or a blank line at the end of the file, behind.
+		if blank(first - 1) {
+			if blank(last + 1) {
+				end = tf.LineStart(last + 2)
+			} else if end == eof {
+				start = tf.LineStart(first - 1)
+			}
+		}
+	case ownEnd:
+		// The element shares its line with the previous element.
+		start = item.prevEnd
+	default:
+		// The element shares its line with the next element.
+		end = item.nextStart
+	}
+	return edit{start, end, ""}
+}
+
+// deleteImports returns edits that delete the imports that are only used in code deleted by edits.
+func (fx *fixer) deleteImports(edits []edit) []edit {
+	deleted := func(pos token.Pos) bool {
+		for _, e := range edits {
+			if e.text == "" && pos >= e.pos && pos < e.end {
+				return true
+			}
+		}
+		return false
+	}
+
+	var out []edit
+	for _, f := range fx.pass.Files {
+		for _, spec := range fx.imports[f] {
+			pn, ok := fx.pkgNames[spec]
+			if !ok || pn.Imported().Path() == "C" {
+				continue
+			}
+			uses := fx.pkgNameUses[pn]
+			if len(uses) == 0 {
+				continue
+			}
+			all := true
+			for _, use := range uses {
+				if !deleted(use) {
+					all = false
+					break
+				}
+			}
+			if !all {
+				continue
+			}
+			for _, decl := range f.Decls {
+				if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+					for _, s := range gen.Specs {
+						if s == spec {
+							out = append(out, fx.deleteSpec(gen, spec))
+						}
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (fx *fixer) serialize(edits []edit) []Edit {
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].pos < edits[j].pos
+	})
+	out := make([]Edit, len(edits))
+	for i, e := range edits {
+		out[i] = Edit{
+			Position: fx.pass.Fset.PositionFor(e.pos, false),
+			End:      fx.pass.Fset.PositionFor(e.end, false),
+			NewText:  e.text,
+		}
+	}
+	return out
+}
@@ -23,7 +23,13 @@ type SerializedGraph struct {
 	nodesByPosition map[token.Position]NodeID
 }
 
+// debugMerge enables tracing of graph merging.
+const debugMerge = false
+
 func trace(f string, args ...interface{}) {
+	if !debugMerge {
+		return
+	}
 	fmt.Fprintf(os.Stderr, f, args...)
 	fmt.Fprintln(os.Stderr)
 }
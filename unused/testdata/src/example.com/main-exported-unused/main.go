@@ -0,0 +1,22 @@
+package main
+
+import "fmt"
+
+func Fn1() {} //@ used("Fn1", true)
+func Fn2() {} //@ used("Fn2", false)
+
+const X = 1 //@ used("X", false)
+
+var Y = 2 //@ used("Y", true)
+
+type Z struct{} //@ used("Z", false)
+
+type T struct{} //@ used("T", true)
+
+func (T) Exported()      {}            //@ used("Exported", false)
+func (T) String() string { return "" } //@ used("String", true)
+
+func main() { //@ used("main", true)
+	Fn1()
+	fmt.Println(Y, T{})
+}
@@ -0,0 +1,13 @@
+package pkg
+
+type T struct{ x int }
+
+func (t T) Get() int { return helper(t.x) }
+
+func helper(x int) int { return x }
+
+func init() { fn() }
+
+func fn() {}
+
+func dead() {}
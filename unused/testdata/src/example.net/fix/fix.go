@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fn1 is unused.
+func fn1() {
+	fmt.Println(strings.ToUpper("")) // the only use of strings
+}
+
+func Fn2() { fmt.Println() }
+
+// t1 is unused, and so are its methods.
+type t1 struct{}
+
+func (t1) m() {}
+
+type T2 struct {
+	// f1 is unused.
+	f1     int
+	F2     int
+	f3, f4 string
+}
+
+var Sink = T2{}.f4
+
+const c1 = 1 // unused
+
+var v1, V2 = 1, 2
+
+var v3 = fmt.Sprint()
+
+var (
+	v4 int
+	V5 int
+)
+
+// Removing f5 would change the struct's layout.
+type T3 struct {
+	_  [4]byte
+	f5 int
+	F6 int
+}
+
+type T4 struct{ f7 int; F8 int }
+
+func Fn3() {
+	type t2 int
+	fmt.Println()
+}
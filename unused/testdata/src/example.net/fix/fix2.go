@@ -0,0 +1,7 @@
+package pkg
+
+import "os"
+
+func (t1) n() { os.Exit(0) }
+
+func Fn4() {}
@@ -0,0 +1,25 @@
+package pkg
+
+type T struct {
+	f1 int
+	f2 int
+	f3 int
+	F4 int
+}
+
+type E struct {
+	e int
+}
+
+type U struct {
+	E
+	F5 int
+}
+
+func Fn(t *T) {
+	// f1 and f2 are unused because they're only written to, but deleting them would leave the writes behind.
+	t.f1 = 1
+	_ = T{f2: 2}
+	var u U
+	u.e = 3
+}
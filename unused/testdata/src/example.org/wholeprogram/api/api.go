@@ -0,0 +1,16 @@
+package api
+
+import "example.org/wholeprogram/internal/util"
+
+type Iface interface { //@ used("Iface", true)
+	M()
+}
+
+func Exported() util.T { //@ used("Exported", true)
+	util.Used()
+	var x Iface = util.T{F: 1}
+	_ = x
+	return util.T{}
+}
+
+func Unreferenced() {} //@ used("Unreferenced", true)
@@ -0,0 +1,9 @@
+package main
+
+import "example.org/wholeprogram/api"
+
+func main() { //@ used("main", true)
+	api.Exported()
+}
+
+func Helper() {} //@ used("Helper", false)
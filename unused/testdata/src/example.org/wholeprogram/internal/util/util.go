@@ -0,0 +1,18 @@
+package util
+
+import "fmt"
+
+func Used() string { return fmt.Sprint(1) } //@ used("Used", true)
+func Unused()      {}                       //@ used("Unused", false)
+func OnlyTests()   {}                       //@ used("OnlyTests", true)
+
+type T struct { //@ used("T", true)
+	F int //@ used("F", true)
+	G int //@ used("G", false)
+}
+
+func (T) M()             {}            //@ used("M", true)
+func (T) N()             {}            //@ used("N", false)
+func (T) String() string { return "" } //@ used("String", true)
+
+type unexported struct{} //@ used("unexported", false)
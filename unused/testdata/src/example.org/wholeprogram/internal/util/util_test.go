@@ -0,0 +1,9 @@
+package util
+
+import "testing"
+
+func TestOnlyTests(t *testing.T) { //@ used_test("TestOnlyTests", true)
+	OnlyTests()
+}
+
+func helper() {} //@ used_test("helper", false)
@@ -2,18 +2,24 @@
 package unused
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
 	"io"
 	"reflect"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"honnef.co/go/tools/analysis/facts/directives"
 	"honnef.co/go/tools/analysis/facts/generated"
 	"honnef.co/go/tools/analysis/lint"
 	"honnef.co/go/tools/analysis/report"
+	"honnef.co/go/tools/config"
 	"honnef.co/go/tools/go/ast/astutil"
 	"honnef.co/go/tools/go/types/typeutil"
 
@@ -56,6 +62,9 @@ This overview is true when using the default options. Different options may chan
   - (1.7) the main function iff in the main package
   - (1.8) symbols linked via go:linkname
   - (1.9) objects in generated files
+  - (1.10) test, benchmark, fuzz and example functions in test files. This only matters if exported identifiers
+    aren't considered used.
+  - (1.11) objects annotated with //lint:ignore U1000, as well as the methods and fields of such types
 
 - named types use:
   - (2.1) exported methods
@@ -121,6 +130,11 @@ This overview is true when using the default options. Different options may chan
     interfaces because in a chain C->B->A, B wouldn't be marked as
     used by 8.3 just because it contributes A's methods to C.
 
+  - (8.5) When exported identifiers aren't considered used, interfaces
+    declared in (transitively) imported packages are known interfaces,
+    too. Otherwise, exported methods implementing interfaces such as
+    fmt.Stringer would be flagged.
+
 - Inherent uses:
   - (9.2) variables use their types
   - (9.3) types use their underlying and element types
@@ -166,6 +180,12 @@ type Result struct {
 	Used   []Object
 	Unused []Object
 	Quiet  []Object
+
+	// Graph is the package's object graph. It is only populated in
+	// whole-program mode, in which case Used, Unused and Quiet are
+	// empty. The graphs of all packages have to be merged with
+	// SerializedGraph.Merge before results can be computed.
+	Graph []Node
 }
 
 var Analyzer = &lint.Analyzer{
@@ -176,7 +196,7 @@ var Analyzer = &lint.Analyzer{
 		Name:       "U1000",
 		Doc:        "Unused code",
 		Run:        run,
-		Requires:   []*analysis.Analyzer{generated.Analyzer, directives.Analyzer},
+		Requires:   []*analysis.Analyzer{generated.Analyzer, directives.Analyzer, config.Analyzer},
 		ResultType: reflect.TypeOf(Result{}),
 	},
 }
@@ -206,27 +226,90 @@ func newGraph(
 	return &g
 }
 
-func run(pass *analysis.Pass) (interface{}, error) {
-	g := newGraph(
+// passOptions returns the options to use for the package being analyzed by pass.
+func passOptions(pass *analysis.Pass) Options {
+	cfg := config.For(pass).Unused
+	opts := OptionsFromConfig(cfg)
+	if opts.WholeProgram {
+		// In whole-program mode, only the exported identifiers of public API packages are used.
+		opts.ExportedIsUsed = false
+		for _, pattern := range cfg.APIRoots {
+			if matchPackagePattern(pattern, pass.Pkg.Path()) {
+				opts.ExportedIsUsed = true
+				break
+			}
+		}
+	}
+	return opts
+}
+
+func newPassGraph(pass *analysis.Pass, opts Options) *graph {
+	return newGraph(
 		pass.Fset,
 		pass.Files,
 		pass.Pkg,
 		pass.TypesInfo,
 		pass.ResultOf[directives.Analyzer].([]lint.Directive),
 		pass.ResultOf[generated.Analyzer].(map[string]generated.Generator),
-		DefaultOptions,
+		opts,
 	)
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	opts := passOptions(pass)
+	g := newPassGraph(pass, opts)
 	g.entry()
 
 	sg := &SerializedGraph{
 		nodes: g.nodes,
 	}
 
+	// Compute fixes for all unused objects. In whole-program mode, we don't yet know which objects are unused, but
+	// merging the graphs of other packages only adds uses. Objects that this package uses remain used, and objects
+	// that are quiet because their owners are unused may become reportable.
+	states := sg.colorAndQuieten()
+	fx := newFixer(pass)
+	for obj, id := range g.objects {
+		if obj.Pkg() != pass.Pkg || states[id].seen() || !opts.WholeProgram && states[id].quiet() {
+			continue
+		}
+		g.nodes[id].obj.Fix = fx.fix(obj)
+	}
+
 	if Debug != nil {
 		Debug.Write([]byte(sg.Dot()))
 	}
 
-	return sg.Results(), nil
+	if opts.WholeProgram {
+		return Result{Graph: g.nodes}, nil
+	}
+
+	res := sg.Results()
+	if !opts.ExportedIsUsed {
+		// The graph contains objects from other packages, which we
+		// mustn't report on.
+		res = res.filter(func(obj Object) bool {
+			return obj.Path.PkgPath == "" || obj.Path.PkgPath == pass.Pkg.Path()
+		})
+	}
+	return res, nil
+}
+
+func (res Result) filter(fn func(obj Object) bool) Result {
+	filter := func(objs []Object) []Object {
+		out := objs[:0]
+		for _, obj := range objs {
+			if fn(obj) {
+				out = append(out, obj)
+			}
+		}
+		return out
+	}
+	return Result{
+		Used:   filter(res.Used),
+		Unused: filter(res.Unused),
+		Quiet:  filter(res.Quiet),
+	}
 }
 
 type Options struct {
@@ -237,6 +320,12 @@ type Options struct {
 	ParametersAreUsed      bool
 	LocalVariablesAreUsed  bool
 	GeneratedIsUsed        bool
+
+	// WholeProgram causes the graph to include objects from other
+	// packages, so that the graphs of multiple packages can be merged
+	// and exported identifiers can be flagged if no package uses
+	// them.
+	WholeProgram bool
 }
 
 var DefaultOptions = Options{
@@ -247,6 +336,40 @@ var DefaultOptions = Options{
 	ParametersAreUsed:      true,
 	LocalVariablesAreUsed:  true,
 	GeneratedIsUsed:        true,
+	WholeProgram:           false,
+}
+
+// OptionsFromConfig returns DefaultOptions, overridden by the options
+// that have been set in cfg.
+func OptionsFromConfig(cfg config.UnusedConfig) Options {
+	opts := DefaultOptions
+	set := func(dst *bool, src *bool) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	set(&opts.FieldWritesAreUses, cfg.FieldWritesAreUses)
+	set(&opts.PostStatementsAreReads, cfg.PostStatementsAreReads)
+	set(&opts.ExportedIsUsed, cfg.ExportedIsUsed)
+	set(&opts.ExportedFieldsAreUsed, cfg.ExportedFieldsAreUsed)
+	set(&opts.ParametersAreUsed, cfg.ParametersAreUsed)
+	set(&opts.LocalVariablesAreUsed, cfg.LocalVariablesAreUsed)
+	set(&opts.GeneratedIsUsed, cfg.GeneratedIsUsed)
+	set(&opts.WholeProgram, cfg.WholeProgram)
+	return opts
+}
+
+// matchPackagePattern reports whether the import path matches pattern,
+// which is either an import path, or an import path ending in /...,
+// which matches the path itself as well as all paths below it.
+func matchPackagePattern(pattern, path string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+	if pattern == "..." {
+		return true
+	}
+	return path == pattern
 }
 
 type edgeKind uint8
@@ -276,6 +399,8 @@ type graph struct {
 	edges   map[edge]struct{}
 	nodes   []Node
 	objects map[types.Object]NodeID
+	// rules maps uses to the numbers of the rules that caused them. It is only populated when explaining uses.
+	rules map[edge]string
 
 	// package-level named types
 	namedTypes     []*types.TypeName
@@ -308,6 +433,29 @@ type Node struct {
 	owns []NodeID
 }
 
+// gobNode is the representation of Node used for gob encoding, which cannot encode unexported fields.
+type gobNode struct {
+	ID   NodeID
+	Obj  Object
+	Uses []NodeID
+	Owns []NodeID
+}
+
+func (n Node) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(gobNode{n.id, n.obj, n.uses, n.owns})
+	return buf.Bytes(), err
+}
+
+func (n *Node) GobDecode(b []byte) error {
+	var gn gobNode
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&gn); err != nil {
+		return err
+	}
+	*n = Node{gn.ID, gn.Obj, gn.Uses, gn.Owns}
+	return nil
+}
+
 func (g *graph) objectToObject(obj types.Object) Object {
 	// OPT(dh): I think we only need object paths in whole-program mode. In other cases, position-based node merging
 	// should suffice.
@@ -455,7 +603,7 @@ func (g *graph) see(obj, owner types.Object) {
 		panic("saw nil object")
 	}
 
-	if g.opts.ExportedIsUsed && obj.Pkg() != g.pkg || obj.Pkg() == nil {
+	if !g.tracksForeignObjects() && obj.Pkg() != g.pkg || obj.Pkg() == nil {
 		return
 	}
 
@@ -466,6 +614,13 @@ func (g *graph) see(obj, owner types.Object) {
 	}
 }
 
+// tracksForeignObjects reports whether the graph includes objects from
+// other packages. This is necessary when exported objects aren't
+// considered used, as well as for merging graphs in whole-program mode.
+func (g *graph) tracksForeignObjects() bool {
+	return !g.opts.ExportedIsUsed || g.opts.WholeProgram
+}
+
 func isIrrelevant(obj types.Object) bool {
 	switch obj.(type) {
 	case *types.PkgName:
@@ -475,9 +630,15 @@ func isIrrelevant(obj types.Object) bool {
 	}
 }
 
-func (g *graph) use(used, by types.Object) {
-	if g.opts.ExportedIsUsed {
-		if used.Pkg() != g.pkg || used.Pkg() == nil {
+// use records that by uses used, because of the numbered rule in the overview. The rule may be empty if the use is
+// merely the result of used being referred to.
+func (g *graph) use(used, by types.Object, rule string) {
+	if used.Pkg() == nil {
+		// Objects in the universe scope can't be unused.
+		return
+	}
+	if !g.tracksForeignObjects() {
+		if used.Pkg() != g.pkg {
 			return
 		}
 		if by != nil && by.Pkg() != g.pkg {
@@ -492,6 +653,12 @@ func (g *graph) use(used, by types.Object) {
 	nUsed := g.node(used)
 	nBy := g.node(by)
 	g.addUse(nBy, nUsed)
+	if g.rules != nil && rule != "" {
+		e := edge{nBy, nUsed, edgeKindUse}
+		if _, ok := g.rules[e]; !ok {
+			g.rules[e] = rule
+		}
+	}
 }
 
 func (g *graph) entry() {
@@ -511,7 +678,7 @@ func (g *graph) entry() {
 						if obj == nil {
 							continue
 						}
-						g.use(obj, nil)
+						g.use(obj, nil, "1.8")
 					}
 				}
 			}
@@ -530,7 +697,7 @@ func (g *graph) entry() {
 		for obj := range g.objects {
 			path := g.fset.PositionFor(obj.Pos(), false).Filename
 			if _, ok := g.generated[path]; ok {
-				g.use(obj, nil)
+				g.use(obj, nil, "1.9")
 			}
 		}
 	}
@@ -548,8 +715,33 @@ func (g *graph) entry() {
 			}
 		}
 	}
+	if g.tracksForeignObjects() {
+		// (8.5) interfaces declared in imported packages are known interfaces
+		seen := map[*types.Package]struct{}{}
+		var addImports func(pkg *types.Package)
+		addImports = func(pkg *types.Package) {
+			for _, imp := range pkg.Imports() {
+				if _, ok := seen[imp]; ok {
+					continue
+				}
+				seen[imp] = struct{}{}
+				scope := imp.Scope()
+				for _, name := range scope.Names() {
+					tname, ok := scope.Lookup(name).(*types.TypeName)
+					if !ok || !tname.Exported() {
+						continue
+					}
+					if iface, ok := tname.Type().Underlying().(*types.Interface); ok && iface.NumMethods() > 0 {
+						allInterfaces[iface] = struct{}{}
+					}
+				}
+				addImports(imp)
+			}
+		}
+		addImports(g.pkg)
+	}
 	processMethodSet := func(named *types.TypeName, ms *types.MethodSet) {
-		if g.opts.ExportedIsUsed {
+		if g.opts.ExportedIsUsed && named.Pkg() == g.pkg {
 			for i := 0; i < ms.Len(); i++ {
 				m := ms.At(i)
 				if token.IsExported(m.Obj().Name()) {
@@ -557,7 +749,7 @@ func (g *graph) entry() {
 					// (6.4) structs use embedded fields that have exported methods
 					//
 					// By reading the selection, we read all embedded fields that are part of the path
-					g.readSelection(m, named)
+					g.readSelection(m, named, "2.1")
 				}
 			}
 		}
@@ -571,7 +763,7 @@ func (g *graph) entry() {
 					for _, sel := range sels {
 						// (8.2) any concrete type implements all known interfaces
 						// (6.3) structs use embedded fields that help implement interfaces
-						g.readSelection(sel, named)
+						g.readSelection(sel, named, "8.2")
 					}
 				}
 			}
@@ -585,6 +777,29 @@ func (g *graph) entry() {
 
 	}
 
+	if g.opts.WholeProgram {
+		// (8.6) in whole-program mode, named types from other packages implement our interfaces, too. Once graphs
+		// get merged, the foreign type's node is the same as the one in its own package's graph.
+		var foreign []*types.TypeName
+		for obj := range g.objects {
+			if tname, ok := obj.(*types.TypeName); ok && tname.Pkg() != nil && tname.Pkg() != g.pkg && !tname.IsAlias() {
+				if _, ok := tname.Type().(*types.Named); ok {
+					foreign = append(foreign, tname)
+				}
+			}
+		}
+		sort.Slice(foreign, func(i, j int) bool {
+			if a, b := foreign[i].Pkg().Path(), foreign[j].Pkg().Path(); a != b {
+				return a < b
+			}
+			return foreign[i].Name() < foreign[j].Name()
+		})
+		for _, named := range foreign {
+			processMethodSet(named, types.NewMethodSet(named.Type()))
+			processMethodSet(named, types.NewMethodSet(types.NewPointer(named.Type())))
+		}
+	}
+
 	type ignoredKey struct {
 		file string
 		line int
@@ -637,12 +852,12 @@ func (g *graph) entry() {
 				_, ok = ignores[key2]
 			}
 			if ok {
-				g.use(obj, nil)
+				g.use(obj, nil, "1.11")
 
 				// use methods and fields of ignored types
 				if obj, ok := obj.(*types.TypeName); ok {
 					if obj.IsAlias() {
-						if typ, ok := types.Unalias(obj.Type()).(*types.Named); ok && (g.opts.ExportedIsUsed && typ.Obj().Pkg() != obj.Pkg() || typ.Obj().Pkg() == nil) {
+						if typ, ok := types.Unalias(obj.Type()).(*types.Named); ok && (!g.tracksForeignObjects() && typ.Obj().Pkg() != obj.Pkg() || typ.Obj().Pkg() == nil) {
 							// This is an alias of a named type in another package.
 							// Don't walk its fields or methods; we don't have to.
 							//
@@ -653,12 +868,12 @@ func (g *graph) entry() {
 					}
 					if typ, ok := types.Unalias(obj.Type()).(*types.Named); ok {
 						for i := 0; i < typ.NumMethods(); i++ {
-							g.use(typ.Method(i), nil)
+							g.use(typ.Method(i), nil, "1.11")
 						}
 					}
 					if typ, ok := obj.Type().Underlying().(*types.Struct); ok {
 						for i := 0; i < typ.NumFields(); i++ {
-							g.use(typ.Field(i), nil)
+							g.use(typ.Field(i), nil, "1.11")
 						}
 					}
 				}
@@ -667,6 +882,31 @@ func (g *graph) entry() {
 	}
 }
 
+// isTestFunction reports whether the function called name, declared in
+// the named file, is run by the testing package.
+func isTestFunction(name, filename string) bool {
+	if !strings.HasSuffix(filename, "_test.go") {
+		return false
+	}
+	if name == "TestMain" {
+		return true
+	}
+	for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := name[len(prefix):]
+		if rest == "" || rest[0] == '_' {
+			return true
+		}
+		r, _ := utf8.DecodeRuneInString(rest)
+		if !unicode.IsLower(r) {
+			return true
+		}
+	}
+	return false
+}
+
 func isOfType[T any](x any) bool {
 	_, ok := x.(T)
 	return ok
@@ -683,7 +923,7 @@ func (g *graph) read(node ast.Node, by types.Object) {
 		// (7.1) field accesses use fields
 
 		obj := g.info.ObjectOf(node)
-		g.use(obj, by)
+		g.use(obj, by, "")
 
 	case *ast.BasicLit:
 		// Nothing to do
@@ -737,7 +977,7 @@ func (g *graph) read(node ast.Node, by types.Object) {
 				// Untagged struct literal that specifies all fields. We have to manually use the fields in the type,
 				// because the unkeyd literal doesn't contain any nodes referring to the fields.
 				for i := 0; i < typ.NumFields(); i++ {
-					g.use(typ.Field(i), by)
+					g.use(typ.Field(i), by, "")
 				}
 			}
 			if g.opts.FieldWritesAreUses || unkeyed {
@@ -776,7 +1016,7 @@ func (g *graph) read(node ast.Node, by types.Object) {
 		for params, i := fn.Params(), 0; i < params.Len(); i++ {
 			g.see(params.At(i), by)
 			if params.At(i).Name() == "" {
-				g.use(params.At(i), by)
+				g.use(params.At(i), by, "4.1")
 			}
 		}
 
@@ -821,7 +1061,7 @@ func (g *graph) read(node ast.Node, by types.Object) {
 					// OPT(dh): instead of by -> name -> type, we could just emit by -> type. We don't care about the
 					// (un)usedness of parameters of any kind.
 					obj := g.info.ObjectOf(name)
-					g.use(obj, by)
+					g.use(obj, by, "4.1")
 					g.read(field.Type, obj)
 				}
 			}
@@ -838,14 +1078,14 @@ func (g *graph) read(node ast.Node, by types.Object) {
 				// embedded field
 
 				f := g.embeddedField(field.Type, by)
-				g.use(f, by)
+				g.use(f, by, "11.1")
 			} else {
 				for _, name := range field.Names {
 					// (11.1) anonymous struct types use all their fields
 					// OPT(dh): instead of by -> name -> type, we could just emit by -> type. If the type is used, then the fields are used.
 					obj := g.info.ObjectOf(name)
 					g.see(obj, by)
-					g.use(obj, by)
+					g.use(obj, by, "11.1")
 					g.read(field.Type, g.info.ObjectOf(name))
 				}
 			}
@@ -872,7 +1112,7 @@ func (g *graph) read(node ast.Node, by types.Object) {
 				// (8.3) all interface methods are marked as used
 				obj := g.info.ObjectOf(meth.Names[0])
 				g.see(obj, by)
-				g.use(obj, by)
+				g.use(obj, by, "8.3")
 				g.read(meth.Type, obj)
 			default:
 				panic(fmt.Sprintf("unexpected number of names: %d", len(meth.Names)))
@@ -915,15 +1155,15 @@ func (g *graph) read(node ast.Node, by types.Object) {
 				// either struct use each other. the fields are relevant for the
 				// conversion, but only if the fields are also accessed outside the
 				// conversion.
-				g.use(stDst.Field(i), stSrc.Field(i))
-				g.use(stSrc.Field(i), stDst.Field(i))
+				g.use(stDst.Field(i), stSrc.Field(i), "5.1")
+				g.use(stSrc.Field(i), stDst.Field(i), "5.1")
 			}
 		} else if okSrc && tDst == types.Typ[types.UnsafePointer] {
 			// (5.2) when converting to or from unsafe.Pointer, mark all fields as used.
-			g.useAllFieldsRecursively(stSrc, by)
+			g.useAllFieldsRecursively(stSrc, by, "5.2")
 		} else if okDst && tSrc == types.Typ[types.UnsafePointer] {
 			// (5.2) when converting to or from unsafe.Pointer, mark all fields as used.
-			g.useAllFieldsRecursively(stDst, by)
+			g.useAllFieldsRecursively(stDst, by, "5.2")
 		}
 
 	default:
@@ -931,16 +1171,16 @@ func (g *graph) read(node ast.Node, by types.Object) {
 	}
 }
 
-func (g *graph) useAllFieldsRecursively(typ types.Type, by types.Object) {
+func (g *graph) useAllFieldsRecursively(typ types.Type, by types.Object, rule string) {
 	switch typ := typ.Underlying().(type) {
 	case *types.Struct:
 		for i := 0; i < typ.NumFields(); i++ {
 			field := typ.Field(i)
-			g.use(field, by)
-			g.useAllFieldsRecursively(field.Type(), by)
+			g.use(field, by, rule)
+			g.useAllFieldsRecursively(field.Type(), by, rule)
 		}
 	case *types.Array:
-		g.useAllFieldsRecursively(typ.Elem(), by)
+		g.useAllFieldsRecursively(typ.Elem(), by, rule)
 	default:
 		return
 	}
@@ -964,7 +1204,7 @@ func (g *graph) write(node ast.Node, by types.Object) {
 		path := g.fset.File(obj.Pos()).Name()
 		if strings.HasSuffix(path, "_test.go") {
 			if isGlobal(obj) {
-				g.use(obj, by)
+				g.use(obj, by, "4.9")
 			}
 		}
 
@@ -1007,20 +1247,20 @@ func (g *graph) readSelectorExpr(sel *ast.SelectorExpr, by types.Object) {
 	if !ok {
 		return
 	}
-	g.readSelection(tsel, by)
+	g.readSelection(tsel, by, "")
 }
 
-func (g *graph) readSelection(sel *types.Selection, by types.Object) {
+func (g *graph) readSelection(sel *types.Selection, by types.Object, rule string) {
 	indices := sel.Index()
 	base := sel.Recv()
 	for _, idx := range indices[:len(indices)-1] {
 		// XXX do we need core types here?
 		field := typeutil.Dereference(base.Underlying()).Underlying().(*types.Struct).Field(idx)
-		g.use(field, by)
+		g.use(field, by, rule)
 		base = field.Type()
 	}
 
-	g.use(sel.Obj(), by)
+	g.use(sel.Obj(), by, rule)
 }
 
 func (g *graph) block(block *ast.BlockStmt, by types.Object) {
@@ -1060,9 +1300,9 @@ func (g *graph) decl(decl ast.Decl, by types.Object) {
 
 					if name.Name == "_" {
 						// (9.9) objects named the blank identifier are used
-						g.use(obj, by)
+						g.use(obj, by, "9.9")
 					} else if token.IsExported(name.Name) && isGlobal(obj) && g.opts.ExportedIsUsed {
-						g.use(obj, nil)
+						g.use(obj, nil, "1.4")
 					}
 				}
 			}
@@ -1086,14 +1326,14 @@ func (g *graph) decl(decl ast.Decl, by types.Object) {
 						if first == nil {
 							first = obj
 						} else {
-							g.use(obj, prev)
+							g.use(obj, prev, "10.1")
 						}
 						prev = obj
 						last = obj
 					}
 				}
 				if first != nil && first != last {
-					g.use(first, last)
+					g.use(first, last, "10.1")
 				}
 			}
 
@@ -1108,7 +1348,7 @@ func (g *graph) decl(decl ast.Decl, by types.Object) {
 				}
 				if token.IsExported(tspec.Name.Name) && isGlobal(obj) && g.opts.ExportedIsUsed {
 					// (1.1) packages use exported named types
-					g.use(g.info.ObjectOf(tspec.Name), nil)
+					g.use(g.info.ObjectOf(tspec.Name), nil, "1.1")
 				}
 
 				// (2.5) named types use all their type parameters
@@ -1118,7 +1358,7 @@ func (g *graph) decl(decl ast.Decl, by types.Object) {
 
 				if tspec.Name.Name == "_" {
 					// (9.9) objects named the blank identifier are used
-					g.use(obj, by)
+					g.use(obj, by, "9.9")
 				}
 			}
 
@@ -1149,12 +1389,12 @@ func (g *graph) decl(decl ast.Decl, by types.Object) {
 
 					if token.IsExported(name.Name) && isGlobal(obj) && g.opts.ExportedIsUsed {
 						// (1.3) packages use exported variables
-						g.use(obj, nil)
+						g.use(obj, nil, "1.3")
 					}
 
 					if name.Name == "_" {
 						// (9.9) objects named the blank identifier are used
-						g.use(obj, by)
+						g.use(obj, by, "9.9")
 					}
 				}
 			}
@@ -1170,20 +1410,23 @@ func (g *graph) decl(decl ast.Decl, by types.Object) {
 		if token.IsExported(decl.Name.Name) && g.opts.ExportedIsUsed {
 			if decl.Recv == nil {
 				// (1.2) packages use exported functions
-				g.use(obj, nil)
+				g.use(obj, nil, "1.2")
 			}
 		} else if decl.Name.Name == "init" {
 			// (1.5) packages use init functions
-			g.use(obj, nil)
+			g.use(obj, nil, "1.5")
 		} else if decl.Name.Name == "main" && g.pkg.Name() == "main" {
 			// (1.7) packages use the main function iff in the main package
-			g.use(obj, nil)
+			g.use(obj, nil, "1.7")
+		} else if decl.Recv == nil && isTestFunction(decl.Name.Name, g.fset.PositionFor(decl.Pos(), false).Filename) {
+			// (1.10) packages use test functions
+			g.use(obj, nil, "1.10")
 		} else if g.pkg.Path() == "runtime" && runtimeFuncs[decl.Name.Name] {
 			// (9.8) runtime functions that may be called from user code via the compiler
-			g.use(obj, nil)
+			g.use(obj, nil, "9.8")
 		} else if g.pkg.Path() == "runtime/coverage" && runtimeCoverageFuncs[decl.Name.Name] {
 			// (9.8) runtime functions that may be called from user code via the compiler
-			g.use(obj, nil)
+			g.use(obj, nil, "9.8")
 		}
 
 		// (4.1) functions use their receivers
@@ -1204,20 +1447,20 @@ func (g *graph) decl(decl ast.Decl, by types.Object) {
 		for params, i := fn.Params(), 0; i < params.Len(); i++ {
 			g.see(params.At(i), obj)
 			if params.At(i).Name() == "" {
-				g.use(params.At(i), obj)
+				g.use(params.At(i), obj, "4.1")
 			}
 		}
 
 		if decl.Name.Name == "_" {
 			// (9.9) objects named the blank identifier are used
-			g.use(obj, nil)
+			g.use(obj, nil, "9.9")
 		}
 
 		if decl.Doc != nil {
 			for _, cmt := range decl.Doc.List {
 				if strings.HasPrefix(cmt.Text, "//go:cgo_export_") {
 					// (1.6) packages use functions exported to cgo
-					g.use(obj, nil)
+					g.use(obj, nil, "1.6")
 				}
 			}
 		}
@@ -1246,7 +1489,7 @@ func (g *graph) seeScope(node ast.Node, by types.Object, skipLvars map[*types.Va
 		if g.opts.LocalVariablesAreUsed {
 			if obj, ok := obj.(*types.Var); ok && !obj.IsField() {
 				if _, ok := skipLvars[obj]; !ok {
-					g.use(obj, by)
+					g.use(obj, by, "4.11")
 				}
 			}
 		}
@@ -1426,12 +1669,12 @@ func (g *graph) embeddedField(node ast.Node, by types.Object) *types.Var {
 
 			if tname, ok := g.info.Uses[node_].(*types.TypeName); ok && tname.IsAlias() {
 				// When embedding an alias we want to use the alias, not what the alias points to.
-				g.use(tname, obj)
+				g.use(tname, obj, "7.2")
 			} else {
 				switch typ := typeutil.Dereference(g.info.TypeOf(node_)).(type) {
 				case *types.Named:
 					// (7.2) fields use their types
-					g.use(typ.Obj(), obj)
+					g.use(typ.Obj(), obj, "7.2")
 				case *types.Basic:
 					// Nothing to do
 				default:
@@ -1532,11 +1775,11 @@ func (g *graph) namedType(typ *types.TypeName, spec ast.Expr) {
 				fieldVar := g.embeddedField(field.Type, typ)
 				if token.IsExported(fieldVar.Name()) && g.opts.ExportedIsUsed {
 					// (6.2) structs use exported fields
-					g.use(fieldVar, typ)
+					g.use(fieldVar, typ, "6.2")
 				}
 				if g.opts.ExportedIsUsed && g.opts.ExportedFieldsAreUsed && hasExportedField(fieldVar.Type()) {
 					// (6.5) structs use embedded structs that have exported fields (recursively)
-					g.use(fieldVar, typ)
+					g.use(fieldVar, typ, "6.5")
 				}
 			} else {
 				for _, name := range field.Names {
@@ -1549,15 +1792,15 @@ func (g *graph) namedType(typ *types.TypeName, spec ast.Expr) {
 					g.read(field.Type, obj)
 					if name.Name == "_" {
 						// (9.9) objects named the blank identifier are used
-						g.use(obj, typ)
+						g.use(obj, typ, "9.9")
 					} else if token.IsExported(name.Name) && g.opts.ExportedIsUsed {
 						// (6.2) structs use exported fields
-						g.use(obj, typ)
+						g.use(obj, typ, "6.2")
 					}
 
 					if isNoCopyType(obj.Type()) {
 						// (6.1) structs use fields of type NoCopy sentinel
-						g.use(obj, typ)
+						g.use(obj, typ, "6.1")
 					}
 				}
 			}
@@ -1595,7 +1838,7 @@ func (g *graph) namedType(typ *types.TypeName, spec ast.Expr) {
 
 		// For 6.6.
 		if hasHostLayout {
-			g.useAllFieldsRecursively(typ.Type(), typ)
+			g.useAllFieldsRecursively(typ.Type(), typ, "6.6")
 		}
 	} else {
 		g.read(spec, typ)
@@ -1621,6 +1864,9 @@ type Object struct {
 	Path            ObjectPath
 	Position        token.Position
 	DisplayPosition token.Position
+	// Fix holds the edits that delete the object's declaration. It is empty for objects that aren't declared in the
+	// analyzed package, as well as for objects that cannot be deleted automatically.
+	Fix []Edit
 }
 
 func (g *SerializedGraph) Results() Result {
@@ -3,8 +3,11 @@ package unused
 import (
 	"fmt"
 	"go/token"
+	"go/types"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
@@ -208,3 +211,174 @@ func TestAll(t *testing.T) {
 		check(t, res)
 	}
 }
+
+func TestWholeProgram(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), Analyzer.Analyzer,
+		"example.org/wholeprogram/api",
+		"example.org/wholeprogram/internal/util",
+		"example.org/wholeprogram/cmd/app",
+	)
+
+	// In whole-program mode, expectations apply to the merged graph, regardless of whether an object is in a test
+	// file or not.
+	want := map[key]expectation{}
+	files := map[string]struct{}{}
+	var sg SerializedGraph
+	for _, res := range results {
+		ures := res.Result.(Result)
+		if len(ures.Graph) == 0 {
+			// Generated test main packages live in the build cache and aren't subject to our configuration.
+			continue
+		}
+		if len(ures.Used) != 0 || len(ures.Unused) != 0 || len(ures.Quiet) != 0 {
+			t.Errorf("package %s returned results in whole-program mode", res.Pass.Pkg.Path())
+		}
+		sg.Merge(ures.Graph)
+
+		for _, f := range res.Pass.Files {
+			filename := res.Pass.Fset.Position(f.Pos()).Filename
+			files[filename] = struct{}{}
+			notes, err := expect.ExtractGo(res.Pass.Fset, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, note := range notes {
+				if note.Name != "used" && note.Name != "used_test" {
+					continue
+				}
+				posn := res.Pass.Fset.PositionFor(note.Pos, false)
+				e := expectation(shouldBeUnused)
+				if note.Args[1].(bool) {
+					e = shouldBeUsed
+				}
+				want[key{note.Args[0].(string), posn.Filename, posn.Line}] = e
+			}
+		}
+	}
+
+	ures := sg.Results()
+	checkObjs := func(objs []Object, state expectation) {
+		for _, obj := range objs {
+			if _, ok := files[obj.Position.Filename]; !ok {
+				continue
+			}
+			k := key{obj.ShortName, obj.Position.Filename, obj.Position.Line}
+			exp, ok := want[k]
+			if !ok {
+				continue
+			}
+			if state != exp {
+				t.Errorf("object at %s (%s) should be %s but is %s", relativePosition(obj.Position), obj.ShortName, exp, state)
+			}
+			delete(want, k)
+		}
+	}
+	checkObjs(ures.Used, shouldBeUsed)
+	checkObjs(ures.Unused, shouldBeUnused)
+	checkObjs(ures.Quiet, shouldBeQuiet)
+
+	for key, e := range want {
+		t.Errorf("object at %s:%d should be %s but wasn't seen", relativePath(key.file), key.line, e)
+	}
+}
+
+func TestFixes(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), Analyzer.Analyzer, "example.net/fix", "example.net/fixwrites")
+
+	// Apply the fixes of all unused objects at once. Identical edits may be part of multiple fixes.
+	edits := map[string]map[Edit]struct{}{}
+	for _, res := range results {
+		for _, obj := range res.Result.(Result).Unused {
+			for _, e := range obj.Fix {
+				if edits[e.Position.Filename] == nil {
+					edits[e.Position.Filename] = map[Edit]struct{}{}
+				}
+				edits[e.Position.Filename][e] = struct{}{}
+			}
+		}
+	}
+
+	for _, res := range results {
+		for _, f := range res.Pass.Files {
+			filename := res.Pass.Fset.Position(f.Pos()).Filename
+			src, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(filename + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var sorted []Edit
+			for e := range edits[filename] {
+				sorted = append(sorted, e)
+			}
+			sort.Slice(sorted, func(i, j int) bool {
+				return sorted[i].Position.Offset > sorted[j].Position.Offset
+			})
+			for _, e := range sorted {
+				src = append(src[:e.Position.Offset:e.Position.Offset], append([]byte(e.NewText), src[e.End.Offset:]...)...)
+			}
+			if string(src) != string(want) {
+				t.Errorf("%s: got\n%s\nwant\n%s", relativePath(filename), src, want)
+			}
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), Analyzer.Analyzer, "example.net/explain")
+	pass := results[0].Pass
+
+	tests := []struct {
+		names []string
+		want  []string
+	}{
+		{[]string{"helper"}, []string{"1.1 type T", "2.1 func T.Get", "4.5 func helper"}},
+		{[]string{"T", "x"}, []string{"1.1 type T", "2.1 func T.Get", "7.1 field x"}},
+		{[]string{"fn"}, []string{"1.5 func init", "4.5 func fn"}},
+		{[]string{"dead"}, nil},
+	}
+	for _, tt := range tests {
+		obj := pass.Pkg.Scope().Lookup(tt.names[0])
+		if len(tt.names) == 2 {
+			obj, _, _ = types.LookupFieldOrMethod(obj.Type(), true, pass.Pkg, tt.names[1])
+		}
+		var got []string
+		for _, u := range Explain(pass, obj) {
+			rule := u.Rule
+			if rule == "" {
+				rule = inferRule(u.Object, u.By)
+			}
+			got = append(got, fmt.Sprintf("%s %s %s", rule, u.Object.Kind, u.Object.Name))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Explain(%s) = %q, want %q", strings.Join(tt.names, "."), got, tt.want)
+		}
+	}
+}
+
+func TestIsTestFunction(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want bool
+	}{
+		{"TestFoo", "foo_test.go", true},
+		{"Test_foo", "foo_test.go", true},
+		{"Test", "foo_test.go", true},
+		{"Testify", "foo_test.go", false},
+		{"BenchmarkFoo", "foo_test.go", true},
+		{"FuzzFoo", "foo_test.go", true},
+		{"Example", "foo_test.go", true},
+		{"ExampleFoo_bar", "foo_test.go", true},
+		{"TestMain", "foo_test.go", true},
+		{"TestFoo", "foo.go", false},
+	}
+	for _, tt := range tests {
+		if got := isTestFunction(tt.name, tt.file); got != tt.want {
+			t.Errorf("isTestFunction(%q, %q) = %t, want %t", tt.name, tt.file, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ mkdir -p data
 mkdir -p content/docs/configuration/default_config
 go run ./cmd/generate_checks/generate_checks.go >data/checks.json
 go run ./cmd/generate_config/generate_config.go >content/docs/configuration/default_config/index.md
+go run ./cmd/generate_config/generate_config.go -schema >static/staticcheck.schema.json
 
 (
 	cd themes/docsy
@@ -2,9 +2,15 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"log"
+	"os"
+	"reflect"
 	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	"honnef.co/go/tools/analysis/lint"
@@ -16,15 +22,85 @@ import (
 	"honnef.co/go/tools/unused"
 )
 
+// schema returns a JSON Schema for values of type T, based on the
+// json struct tags of T's fields. If def is valid, it is used to
+// populate default values.
+func schema(T reflect.Type, def reflect.Value) map[string]any {
+	out := map[string]any{}
+	switch T.Kind() {
+	case reflect.Struct:
+		props := map[string]any{}
+		for i := 0; i < T.NumField(); i++ {
+			field := T.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			var fdef reflect.Value
+			if def.IsValid() {
+				fdef = def.Field(i)
+			}
+			props[name] = schema(field.Type, fdef)
+		}
+		out["type"] = "object"
+		out["properties"] = props
+		out["additionalProperties"] = false
+		return out
+	case reflect.Pointer:
+		var edef reflect.Value
+		if def.IsValid() && !def.IsNil() {
+			edef = def.Elem()
+		}
+		return schema(T.Elem(), edef)
+	case reflect.Slice:
+		out["type"] = "array"
+		out["items"] = schema(T.Elem(), reflect.Value{})
+	case reflect.String:
+		out["type"] = "string"
+	case reflect.Bool:
+		out["type"] = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		out["type"] = "integer"
+	case reflect.Map:
+		out["type"] = "object"
+		out["additionalProperties"] = schema(T.Elem(), reflect.Value{})
+	default:
+		panic(fmt.Sprintf("unsupported type %s", T))
+	}
+	if def.IsValid() && !((def.Kind() == reflect.Slice || def.Kind() == reflect.Map) && def.IsNil()) {
+		out["default"] = def.Interface()
+	}
+	return out
+}
+
+func printSchema(cfg config.Config) {
+	s := schema(reflect.TypeOf(cfg), reflect.ValueOf(cfg))
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s["$id"] = "https://staticcheck.dev/staticcheck.schema.json"
+	s["title"] = "Staticcheck configuration"
+	s["description"] = "Configuration for Staticcheck, as stored in staticcheck.conf and staticcheck.json files"
+	// Allow JSON files to refer to the schema.
+	s["properties"].(map[string]any)["$schema"] = map[string]any{"type": "string"}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(s); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func main() {
+	printSchemaFlag := flag.Bool("schema", false, "Print a JSON Schema of the configuration instead of the default configuration")
+	flag.Parse()
+
 	cfg := config.DefaultConfig
 
-	checks := []string{"all"}
+	var nonDefault []string
 	do := func(analyzers ...*lint.Analyzer) {
 		for _, a := range analyzers {
 			if a.Doc.NonDefault {
-				// Use backticks to quote the check name so TOML doesn't escape them
-				checks = append(checks, fmt.Sprintf("-{{< check `%s` >}}", a.Analyzer.Name))
+				nonDefault = append(nonDefault, a.Analyzer.Name)
 			}
 		}
 	}
@@ -33,18 +109,29 @@ func main() {
 	do(stylecheck.Analyzers...)
 	do(unused.Analyzer)
 	do(quickfix.Analyzers...)
+	sort.Strings(nonDefault)
 
-	sort.Slice(checks[1:], func(i, j int) bool {
-		return checks[i+1] < checks[j+1]
-	})
+	checks := []string{"all"}
+	if *printSchemaFlag {
+		for _, name := range nonDefault {
+			checks = append(checks, "-"+name)
+		}
+		cfg.Checks = checks
+		printSchema(cfg)
+		return
+	}
 
+	for _, name := range nonDefault {
+		// Use backticks to quote the check name so TOML doesn't escape them
+		checks = append(checks, fmt.Sprintf("-{{< check `%s` >}}", name))
+	}
 	cfg.Checks = checks
 
 	buf := bytes.Buffer{}
 	toml.NewEncoder(&buf).Encode(cfg)
 
-	r := regexp.MustCompile(`(?m)^[a-z_]+`)
-	out := r.ReplaceAllString(buf.String(), "{{< option `$0` >}}")
+	r := regexp.MustCompile(`(?m)^(\s*)([a-z_]+)`)
+	out := r.ReplaceAllString(buf.String(), "$1{{< option `$2` >}}")
 
 	fmt.Println("---")
 	fmt.Println("headless: true")